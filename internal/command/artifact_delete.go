@@ -0,0 +1,151 @@
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/internal/output/docker"
+	"github.com/simplesurance/baur/v5/internal/output/filecopy"
+	"github.com/simplesurance/baur/v5/internal/output/s3"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// artifactDeleter deletes uploaded artifacts via the client for their upload
+// method.
+// Clients are created when they are used the first time, this prevents that
+// e.g. the S3 configuration must be valid when only files are deleted.
+type artifactDeleter struct {
+	s3Clt       *s3.Client
+	dockerClt   *docker.Client
+	filecopyClt *filecopy.Client
+}
+
+func (d *artifactDeleter) Delete(ctx context.Context, upload *storage.UnreferencedUpload) error {
+	switch upload.Method {
+	case storage.UploadMethodS3:
+		bucket, key, err := s3.ParseURL(upload.URI)
+		if err != nil {
+			return fmt.Errorf("parsing S3 URL failed: %w", err)
+		}
+
+		if d.s3Clt == nil {
+			d.s3Clt, err = s3.NewClient(ctx, log.StdLogger)
+			if err != nil {
+				return fmt.Errorf("creating S3 client failed: %w", err)
+			}
+		}
+
+		return d.s3Clt.Delete(ctx, bucket, key)
+
+	case storage.UploadMethodDockerRegistry:
+		if d.dockerClt == nil {
+			var err error
+
+			d.dockerClt, err = docker.NewClient(log.StdLogger.Debugf)
+			if err != nil {
+				return fmt.Errorf("creating docker client failed: %w", err)
+			}
+		}
+
+		return d.dockerClt.Delete(ctx, upload.URI)
+
+	case storage.UploadMethodFileCopy:
+		if d.filecopyClt == nil {
+			d.filecopyClt = filecopy.New(log.Debugf)
+		}
+
+		return d.filecopyClt.Delete(upload.URI)
+
+	default:
+		return fmt.Errorf("unsupported upload method %q", upload.Method)
+	}
+}
+
+// formatArtifact returns the representation of upload that is printed by
+// commands and parsed by parseArtifacts.
+func formatArtifact(upload *storage.UnreferencedUpload) string {
+	return fmt.Sprintf("%-10s %s", upload.Method, upload.URI)
+}
+
+// parseArtifacts parses artifacts in the format of formatArtifact, one per
+// line. Empty lines and lines starting with # are ignored.
+func parseArtifacts(r io.Reader) ([]*storage.UnreferencedUpload, error) {
+	var result []*storage.UnreferencedUpload
+
+	sc := bufio.NewScanner(r)
+	for lineNr := 1; sc.Scan(); lineNr++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		method, uri, found := strings.Cut(line, " ")
+		uri = strings.TrimSpace(uri)
+		if !found || uri == "" {
+			return nil, fmt.Errorf("line %d: %q is not in the format METHOD URI", lineNr, line)
+		}
+
+		switch m := storage.UploadMethod(method); m {
+		case storage.UploadMethodS3, storage.UploadMethodDockerRegistry, storage.UploadMethodFileCopy:
+			result = append(result, &storage.UnreferencedUpload{Method: m, URI: uri})
+		default:
+			return nil, fmt.Errorf("line %d: unsupported upload method %q", lineNr, method)
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// deleteArtifacts deletes the uploaded artifacts.
+// When deleting artifacts fails, the remaining ones are still deleted and the
+// failed ones are printed in the format that is accepted by the
+// "cleanup artifacts" command.
+func deleteArtifacts(ctx context.Context, uploads []*storage.UnreferencedUpload) error {
+	var deleter artifactDeleter
+	var failed []*storage.UnreferencedUpload
+
+	startTime := time.Now()
+
+	stdout.Printf("\ndeleting %s artifacts...\n", term.Highlight(len(uploads)))
+
+	for _, upload := range uploads {
+		if err := deleter.Delete(ctx, upload); err != nil {
+			stderr.ErrPrintf(err, "deleting %s artifact %s failed", upload.Method, upload.URI)
+			failed = append(failed, upload)
+			continue
+		}
+
+		stdout.Printf("%s %s\n", formatArtifact(upload), term.GreenHighlight("deleted"))
+	}
+
+	if len(failed) > 0 {
+		stdout.Printf(
+			"\nthe following artifacts could not be deleted, store them in a file and retry with %s:\n",
+			term.Highlight("baur cleanup artifacts FILE"),
+		)
+		for _, upload := range failed {
+			stdout.Println(formatArtifact(upload))
+		}
+
+		return fmt.Errorf("deleting %d of %d artifacts failed", len(failed), len(uploads))
+	}
+
+	stdout.Printf(
+		"\ndeleting artifacts %s in %s, deleted artifacts: %s\n",
+		term.GreenHighlight("successful"),
+		term.FormatDuration(time.Since(startTime)),
+		term.Highlight(len(uploads)),
+	)
+
+	return nil
+}
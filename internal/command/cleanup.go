@@ -4,7 +4,7 @@ import "github.com/spf13/cobra"
 
 var cleanupCmd = &cobra.Command{
 	Use:   "cleanup",
-	Short: "delete old database records",
+	Short: "delete old database records and artifacts",
 }
 
 func init() {
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/internal/command/term"
+
+	"github.com/spf13/cobra"
+)
+
+var cleanupArtifactsLongHelp = fmt.Sprintf(`
+Delete uploaded artifacts from S3, docker registries and the filesystem.
+FILE must contain one artifact per line in the format METHOD URI, as printed by
+%s for artifacts that could not be deleted. Empty lines and lines
+starting with # are ignored.
+If FILE is %s, the artifacts are read from stdin.
+
+The database is not accessed, it is not checked if the artifacts are still
+referenced by task runs.
+`,
+	term.Highlight("baur cleanup db --delete-artifacts"),
+	term.Highlight("-"),
+)
+
+const cleanupArtifactsExample = `
+baur cleanup artifacts failed-artifacts.txt
+`
+
+type cleanupArtifactsCmd struct {
+	cobra.Command
+}
+
+func init() {
+	cleanupCmd.AddCommand(&newCleanupArtifactsCmd().Command)
+}
+
+func newCleanupArtifactsCmd() *cleanupArtifactsCmd {
+	cmd := cleanupArtifactsCmd{
+		Command: cobra.Command{
+			Use:     "artifacts FILE|-",
+			Short:   "delete uploaded artifacts",
+			Long:    strings.TrimSpace(cleanupArtifactsLongHelp),
+			Example: strings.TrimSpace(cleanupArtifactsExample),
+			Args:    cobra.ExactArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	return &cmd
+}
+
+func (*cleanupArtifactsCmd) run(cmd *cobra.Command, args []string) {
+	var r io.Reader
+
+	if args[0] == "-" {
+		r = bufio.NewReader(os.Stdin)
+	} else {
+		f, err := os.Open(args[0])
+		exitOnErrf(err, "opening file %s failed", args[0])
+		defer f.Close()
+
+		r = bufio.NewReader(f)
+	}
+
+	uploads, err := parseArtifacts(r)
+	exitOnErrf(err, "parsing %s failed", args[0])
+
+	exitOnErr(deleteArtifacts(cmd.Context(), uploads))
+}
@@ -0,0 +1,67 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func TestCleanupArtifacts(t *testing.T) {
+	initTest(t)
+
+	tmpDir := t.TempDir()
+	artifact := filepath.Join(tmpDir, "my artifact.tar")
+	require.NoError(t, os.WriteFile(artifact, []byte("artifact"), 0o600))
+
+	// a non-empty directory can not be deleted by the filecopy client
+	undeletable := filepath.Join(tmpDir, "dir")
+	require.NoError(t, os.Mkdir(undeletable, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(undeletable, "file"), []byte("x"), 0o600))
+
+	listFile := filepath.Join(tmpDir, "artifacts.txt")
+	writeList := func(uploads ...*storage.UnreferencedUpload) {
+		lines := []string{"# artifacts", ""}
+		for _, u := range uploads {
+			lines = append(lines, formatArtifact(u))
+		}
+		require.NoError(t, os.WriteFile(listFile, []byte(strings.Join(lines, "\n")), 0o600))
+	}
+
+	t.Run("failed artifacts are printed", func(t *testing.T) {
+		writeList(
+			&storage.UnreferencedUpload{Method: storage.UploadMethodFileCopy, URI: undeletable},
+		)
+
+		stdoutBuf, _ := interceptCmdOutput(t)
+		cmd := newCleanupArtifactsCmd()
+		cmd.SetArgs([]string{listFile})
+		execCheck(t, cmd, exitCodeError)
+
+		assert.Contains(t, stdoutBuf.String(), "could not be deleted")
+		assert.Contains(t, stdoutBuf.String(), "filecopy   "+undeletable+"\n")
+	})
+
+	t.Run("artifacts are deleted", func(t *testing.T) {
+		writeList(
+			&storage.UnreferencedUpload{Method: storage.UploadMethodFileCopy, URI: artifact},
+		)
+
+		cmd := newCleanupArtifactsCmd()
+		cmd.SetArgs([]string{listFile})
+		execCheck(t, cmd, exitCodeSuccess)
+		assert.NoFileExists(t, artifact)
+	})
+}
+
+func TestParseArtifactsFailsOnInvalidLines(t *testing.T) {
+	for _, line := range []string{"filecopy", "ftp /tmp/file"} {
+		_, err := parseArtifacts(strings.NewReader(line))
+		assert.Error(t, err, line)
+	}
+}
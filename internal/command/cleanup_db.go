@@ -18,6 +18,12 @@ type cleanupDbCmd struct {
 	before  flag.DateTimeFlagValue
 	force   bool
 	pretend bool
+
+	keepLast               uint
+	keepLastSuccessful     bool
+	keepLastPerInputDigest uint
+
+	deleteArtifacts bool
 }
 
 const (
@@ -38,15 +44,36 @@ It also removes records that became dangling because all task runs referencing
 them were deleted.
 Task runs that are referenced by a release are not deleted.
 
+When deleting task runs, retention parameters can be specified to keep task
+runs independent of their age. This allows to keep the history of rarely run
+tasks. The newest task runs are determined by their start time.
+
+When %s is passed, the artifacts that were uploaded by the deleted
+task runs are also deleted from S3, docker registries and the filesystem.
+Artifacts whose URI is still referenced by a remaining task run are kept.
+Docker images are deleted by their manifest digest, this also removes other
+tags in the same repository that refer to the same manifest. Images whose
+digest is also the output of a remaining task run are therefore kept. The
+registry must allow deletions.
+Artifacts are deleted after the database records. The records of artifacts
+that could not be deleted are removed nevertheless, a later run of the command
+does not find them anymore. The failed artifacts are printed in the format
+that is accepted by %s, after storing them in a file their deletion can be
+retried with it.
+
 The command can be run without access to the baur repository by specifying the
 PostgreSQL URI via the environment variable %s.
 `,
+	term.Highlight("--delete-artifacts"),
+	term.Highlight("baur cleanup artifacts"),
 	term.Highlight(envVarPSQLURL),
 )
 
 const cleanupDbCmdExample = `
 baur cleanup db --force --before=2023.06.01-13:30 releases
 baur cleanup db --pretend --before=2023.06.01-13:30 taskruns
+baur cleanup db --before=2023.06.01-13:30 --keep-last=10 --keep-last-successful taskruns
+baur cleanup db --pretend --before=2023.06.01-13:30 --delete-artifacts taskruns
 `
 
 func init() {
@@ -81,6 +108,20 @@ func newCleanupDbCmd() *cleanupDbCmd {
 		),
 	)
 
+	cmd.Flags().UintVar(&cmd.keepLast, "keep-last", 0,
+		"keep the newest N task runs of every task\n(only applies to taskruns)",
+	)
+	cmd.Flags().BoolVar(&cmd.keepLastSuccessful, "keep-last-successful", false,
+		"keep the newest successful task run of every task\n(only applies to taskruns)",
+	)
+	cmd.Flags().UintVar(&cmd.keepLastPerInputDigest, "keep-per-digest", 0,
+		"keep the newest N task runs per task and total input digest\n(only applies to taskruns)",
+	)
+
+	cmd.Flags().BoolVar(&cmd.deleteArtifacts, "delete-artifacts", false,
+		"delete uploaded artifacts of deleted task runs that are not\nreferenced anymore, artifacts that fail to be deleted are\nprinted and can be retried with 'baur cleanup artifacts'\n(only applies to taskruns)",
+	)
+
 	if err := cmd.MarkFlagRequired("before"); err != nil {
 		panic(err)
 	}
@@ -111,6 +152,22 @@ func (c *cleanupDbCmd) run(cmd *cobra.Command, args []string) {
 		fatalf("internal error: impossible cmdline arguments: %v\n", args)
 	}
 
+	if target != deleteTargetTaskRuns && c.retention() != (storage.TaskRunsRetention{}) {
+		fatalf("the %s, %s and %s parameters can only be used when deleting %s\n",
+			term.Highlight("--keep-last"),
+			term.Highlight("--keep-last-successful"),
+			term.Highlight("--keep-per-digest"),
+			term.Highlight(deleteTargetTaskRuns),
+		)
+	}
+
+	if target != deleteTargetTaskRuns && c.deleteArtifacts {
+		fatalf("the %s parameter can only be used when deleting %s\n",
+			term.Highlight("--delete-artifacts"),
+			term.Highlight(deleteTargetTaskRuns),
+		)
+	}
+
 	if c.pretend {
 		op = term.Highlight("pretending to delete")
 	} else {
@@ -124,6 +181,10 @@ func (c *cleanupDbCmd) run(cmd *cobra.Command, args []string) {
 	)
 	if target == deleteTargetTaskRuns {
 		stdout.Printf(" and dangling records,\ntasks runs referenced by releases are kept\n")
+		c.printRetention()
+		if c.deleteArtifacts {
+			stdout.Printf("uploaded artifacts that are not referenced anymore are %s\n", term.Highlight("deleted"))
+		}
 	} else {
 		stdout.Println()
 	}
@@ -146,6 +207,29 @@ func (c *cleanupDbCmd) run(cmd *cobra.Command, args []string) {
 	}
 }
 
+func (c *cleanupDbCmd) retention() storage.TaskRunsRetention {
+	return storage.TaskRunsRetention{
+		KeepLast:               c.keepLast,
+		KeepLastSuccessful:     c.keepLastSuccessful,
+		KeepLastPerInputDigest: c.keepLastPerInputDigest,
+	}
+}
+
+func (c *cleanupDbCmd) printRetention() {
+	if c.keepLast > 0 {
+		stdout.Printf("the newest %s task runs of every task are kept\n", term.Highlight(c.keepLast))
+	}
+
+	if c.keepLastSuccessful {
+		stdout.Printf("the newest %s task run of every task is kept\n", term.Highlight("successful"))
+	}
+
+	if c.keepLastPerInputDigest > 0 {
+		stdout.Printf("the newest %s task runs per task and input digest are kept\n",
+			term.Highlight(c.keepLastPerInputDigest))
+	}
+}
+
 func (c *cleanupDbCmd) deleteReleases(ctx context.Context, storageClt storage.Storer) error {
 	startTime := time.Now()
 	result, err := storageClt.ReleasesDelete(ctx, c.before.Time, c.pretend)
@@ -169,7 +253,7 @@ func (c *cleanupDbCmd) deleteTaskRuns(ctx context.Context, storageClt storage.St
 	var stateStr string
 
 	startTime := time.Now()
-	result, err := storageClt.TaskRunsDelete(ctx, c.before.Time, c.pretend)
+	result, err := storageClt.TaskRunsDelete(ctx, c.before.Time, c.retention(), c.pretend)
 	if err != nil && result == nil {
 		return err
 	}
@@ -201,5 +285,30 @@ func (c *cleanupDbCmd) deleteTaskRuns(ctx context.Context, storageClt storage.St
 		"VCSs:", term.Highlight(result.DeletedVCS),
 	)
 
-	return err
+	if err != nil {
+		if c.deleteArtifacts {
+			stderr.Println("deleting the database records failed, uploaded artifacts are not deleted")
+		}
+
+		return err
+	}
+
+	if !c.deleteArtifacts {
+		return nil
+	}
+
+	if c.pretend {
+		c.printUnreferencedUploads(result.UnreferencedUploads)
+		return nil
+	}
+
+	return deleteArtifacts(ctx, result.UnreferencedUploads)
+}
+
+func (*cleanupDbCmd) printUnreferencedUploads(uploads []*storage.UnreferencedUpload) {
+	stdout.Printf("\n%s artifacts would be deleted:\n", term.Highlight(len(uploads)))
+
+	for _, upload := range uploads {
+		stdout.Println(formatArtifact(upload))
+	}
 }
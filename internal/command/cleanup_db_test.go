@@ -0,0 +1,90 @@
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/sqlite"
+)
+
+func TestCleanupDbDeleteArtifacts(t *testing.T) {
+	initTest(t)
+
+	tmpDir := t.TempDir()
+	dbURL := "sqlite://" + filepath.Join(tmpDir, "baur.db")
+	oldArtifact := filepath.Join(tmpDir, "old.tar")
+	sharedArtifact := filepath.Join(tmpDir, "shared.tar")
+
+	for _, p := range []string{oldArtifact, sharedArtifact} {
+		require.NoError(t, os.WriteFile(p, []byte("artifact"), 0o600))
+	}
+
+	t.Setenv(envVarPSQLURL, dbURL)
+	initDb(initDbCmd, nil)
+
+	clt, err := sqlite.New(context.Background(), dbURL, log.StdLogger)
+	require.NoError(t, err)
+
+	newTaskRun := func(start time.Time, uploadPaths ...string) *storage.TaskRunFull {
+		output := storage.Output{
+			Name:      "dist",
+			Type:      storage.ArtifactTypeFile,
+			Digest:    "sha384:3",
+			SizeBytes: 8,
+		}
+		for _, p := range uploadPaths {
+			output.Uploads = append(output.Uploads, &storage.Upload{
+				URI:                  p,
+				UploadStartTimestamp: start,
+				UploadStopTimestamp:  start,
+				Method:               storage.UploadMethodFileCopy,
+			})
+		}
+
+		return &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  "app",
+				TaskName:         "build",
+				VCSRevision:      "123",
+				StartTimestamp:   start,
+				StopTimestamp:    start.Add(time.Second),
+				TotalInputDigest: "sha384:1",
+				Result:           storage.ResultSuccess,
+			},
+			Outputs: []*storage.Output{&output},
+		}
+	}
+
+	before := time.Now().Add(-time.Hour)
+	_, err = clt.SaveTaskRun(context.Background(), newTaskRun(before.Add(-time.Hour), oldArtifact, sharedArtifact))
+	require.NoError(t, err)
+	_, err = clt.SaveTaskRun(context.Background(), newTaskRun(time.Now(), sharedArtifact))
+	require.NoError(t, err)
+	require.NoError(t, clt.Close())
+
+	beforeArg := "--before=" + before.Format("2006.01.02-15:04")
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	cmd := newCleanupDbCmd()
+	cmd.SetArgs([]string{"--force", "--pretend", "--delete-artifacts", beforeArg, "taskruns"})
+	execCheck(t, cmd, 0)
+	assert.Contains(t, stdoutBuf.String(), "1 artifacts would be deleted")
+	assert.Contains(t, stdoutBuf.String(), oldArtifact)
+	assert.NotContains(t, stdoutBuf.String(), sharedArtifact)
+	assert.FileExists(t, oldArtifact, "artifact was deleted in pretend mode")
+
+	stdoutBuf.Reset()
+	cmd = newCleanupDbCmd()
+	cmd.SetArgs([]string{"--force", "--delete-artifacts", beforeArg, "taskruns"})
+	execCheck(t, cmd, 0)
+	assert.NoFileExists(t, oldArtifact)
+	assert.FileExists(t, sharedArtifact, "artifact that is referenced by a remaining task run was deleted")
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package command
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/testutils/fstest"
+	"github.com/simplesurance/baur/v5/internal/testutils/gittest"
+	"github.com/simplesurance/baur/v5/internal/testutils/repotest"
+	"github.com/simplesurance/baur/v5/pkg/cfg"
+)
+
+func TestLsAndDiffInputs_CommandOutput(t *testing.T) {
+	initTest(t)
+
+	r := repotest.CreateBaurRepository(t)
+	gittest.CreateRepository(t, r.Dir)
+	app := cfg.App{
+		Name: "app",
+		Tasks: []*cfg.Task{
+			{
+				Name:    "build",
+				Command: []string{"true"},
+				Input: cfg.Input{
+					CommandOutput: []cfg.CommandOutputInputs{
+						{Command: []string{"cat", "version"}},
+					},
+				},
+			},
+		},
+	}
+
+	appdir := filepath.Join(r.Dir, "app")
+	fstest.MkdirAll(t, appdir)
+	require.NoError(t, app.ToFile(filepath.Join(appdir, ".app.toml")))
+	fstest.WriteToFile(t, []byte("1.0"), filepath.Join(appdir, "version"))
+
+	t.Setenv(envVarPSQLURL, "sqlite://"+filepath.Join(t.TempDir(), "baur.db"))
+	initDb(initDbCmd, nil)
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	lsInputsCmd := newLsInputsCmd()
+	lsInputsCmd.SetArgs([]string{"app.build"})
+	require.NoError(t, lsInputsCmd.Execute())
+	assert.Contains(t, stdoutBuf.String(), "cmd: cat version")
+
+	runCmd := newRunCmd()
+	runCmd.SetArgs([]string{"app.build"})
+	require.NoError(t, runCmd.Execute())
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	lsInputsCmd = newLsInputsCmd()
+	lsInputsCmd.SetArgs([]string{"1"})
+	require.NoError(t, lsInputsCmd.Execute())
+	assert.Contains(t, stdoutBuf.String(), "cmd: cat version")
+
+	fstest.WriteToFile(t, []byte("2.0"), filepath.Join(appdir, "version"))
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	diffInputsCmd := newDiffInputsCmd()
+	diffInputsCmd.SetArgs([]string{"--format", "csv", "app.build", "app.build^"})
+	execCheck(t, diffInputsCmd, 2)
+	assert.Contains(t, stdoutBuf.String(), "D,cmd: cat version,")
+}
@@ -0,0 +1,12 @@
+package command
+
+import "github.com/spf13/cobra"
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "export and import database records",
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+}
@@ -0,0 +1,106 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/pkg/storage/jsonl"
+
+	"github.com/spf13/cobra"
+)
+
+var dbExportLongHelp = fmt.Sprintf(`
+Export all task runs and releases from the baur database.
+The records are written in a portable JSON Lines format, that can be imported
+into another baur database with the %s command.
+The format is independent of the database backend, it can be used to migrate
+data between PostgreSQL and SQLite databases.
+
+If FILE is omitted or is %s, the records are written to stdout.
+
+The command can be run without access to the baur repository by specifying the
+database URL via the environment variable %s.
+`,
+	term.Highlight("db import"),
+	term.Highlight("-"),
+	term.Highlight(envVarPSQLURL),
+)
+
+const dbExportExample = `
+baur db export baur-db.jsonl
+baur db export | gzip > baur-db.jsonl.gz
+`
+
+type dbExportCmd struct {
+	cobra.Command
+}
+
+func init() {
+	dbCmd.AddCommand(&newDbExportCmd().Command)
+}
+
+func newDbExportCmd() *dbExportCmd {
+	cmd := dbExportCmd{
+		Command: cobra.Command{
+			Use:     "export [FILE]",
+			Short:   "export database records in JSON Lines format",
+			Long:    strings.TrimSpace(dbExportLongHelp),
+			Example: strings.TrimSpace(dbExportExample),
+			Args:    cobra.MaximumNArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	return &cmd
+}
+
+func (c *dbExportCmd) run(cmd *cobra.Command, args []string) {
+	var w io.Writer
+	var f *os.File
+	var bufW *bufio.Writer
+
+	psqlURL, err := postgresqlURL()
+	exitOnErr(err)
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	defer storageClt.Close()
+
+	toStdout := len(args) == 0 || args[0] == "-"
+	if toStdout {
+		w = stdout
+	} else {
+		f, err = os.Create(args[0])
+		exitOnErrf(err, "creating file %s failed", args[0])
+
+		bufW = bufio.NewWriter(f)
+		w = bufW
+	}
+
+	result, err := jsonl.Export(cmd.Context(), storageClt, w)
+	exitOnErr(err, "exporting database records failed")
+
+	if !toStdout {
+		err = bufW.Flush()
+		exitOnErrf(err, "writing to file %s failed", args[0])
+
+		err = f.Close()
+		exitOnErrf(err, "writing to file %s failed", args[0])
+	}
+
+	// when the records are written to stdout, the summary must not be
+	// mixed with them
+	out := stdout
+	if toStdout {
+		out = stderr
+	}
+
+	out.Printf("exported %s task runs and %s releases\n",
+		term.Highlight(result.TaskRuns),
+		term.Highlight(result.Releases),
+	)
+}
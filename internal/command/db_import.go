@@ -0,0 +1,95 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/pkg/storage/jsonl"
+
+	"github.com/spf13/cobra"
+)
+
+var dbImportLongHelp = fmt.Sprintf(`
+Import task runs and releases into the baur database.
+FILE must contain records in the format written by the %s command.
+If FILE is %s, the records are read from stdin.
+
+The import is idempotent, task runs and releases that already exist in the
+database are skipped. Task runs are assigned new IDs, references of releases
+to them are updated accordingly.
+
+The command can be run without access to the baur repository by specifying the
+database URL via the environment variable %s.
+`,
+	term.Highlight("db export"),
+	term.Highlight("-"),
+	term.Highlight(envVarPSQLURL),
+)
+
+const dbImportExample = `
+baur db import baur-db.jsonl
+gunzip -c baur-db.jsonl.gz | baur db import -
+`
+
+type dbImportCmd struct {
+	cobra.Command
+}
+
+func init() {
+	dbCmd.AddCommand(&newDbImportCmd().Command)
+}
+
+func newDbImportCmd() *dbImportCmd {
+	cmd := dbImportCmd{
+		Command: cobra.Command{
+			Use:     "import FILE|-",
+			Short:   "import database records in JSON Lines format",
+			Long:    strings.TrimSpace(dbImportLongHelp),
+			Example: strings.TrimSpace(dbImportExample),
+			Args:    cobra.ExactArgs(1),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	return &cmd
+}
+
+func (c *dbImportCmd) run(cmd *cobra.Command, args []string) {
+	var r io.Reader
+
+	psqlURL, err := postgresqlURL()
+	exitOnErr(err)
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	defer storageClt.Close()
+
+	if args[0] == "-" {
+		r = bufio.NewReader(os.Stdin)
+	} else {
+		f, err := os.Open(args[0])
+		exitOnErrf(err, "opening file %s failed", args[0])
+		defer f.Close()
+
+		r = bufio.NewReader(f)
+	}
+
+	result, err := jsonl.Import(cmd.Context(), storageClt, r)
+	if result != nil {
+		stdout.Printf(
+			"%-20s %s\n"+
+				"%-20s %s\n"+
+				"%-20s %s\n"+
+				"%-20s %s\n",
+			"Task Runs imported:", term.Highlight(result.TaskRunsImported),
+			"Task Runs skipped:", term.Highlight(result.TaskRunsSkipped),
+			"Releases imported:", term.Highlight(result.ReleasesImported),
+			"Releases skipped:", term.Highlight(result.ReleasesSkipped),
+		)
+	}
+	exitOnErr(err, "importing database records failed")
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/sqlite"
+)
+
+func TestDbExportImport(t *testing.T) {
+	initTest(t)
+
+	tmpDir := t.TempDir()
+	srcURL := "sqlite://" + filepath.Join(tmpDir, "src.db")
+	dstURL := "sqlite://" + filepath.Join(tmpDir, "dst.db")
+	exportFile := filepath.Join(tmpDir, "export.jsonl")
+
+	t.Setenv(envVarPSQLURL, srcURL)
+	initDb(initDbCmd, nil)
+
+	clt, err := sqlite.New(context.Background(), srcURL, log.StdLogger)
+	require.NoError(t, err)
+	startTime := time.Now()
+	id, err := clt.SaveTaskRun(context.Background(), &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "app",
+			TaskName:         "build",
+			VCSRevision:      "123",
+			StartTimestamp:   startTime,
+			StopTimestamp:    startTime.Add(time.Second),
+			TotalInputDigest: "sha384:1",
+			Result:           storage.ResultSuccess,
+		},
+		Inputs: storage.Inputs{
+			Strings: []*storage.InputString{{String: "hello", Digest: "sha384:2"}},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, clt.CreateRelease(context.Background(), "v1", "", startTime, []int{id}, nil))
+	require.NoError(t, clt.Close())
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	exportCmd := newDbExportCmd()
+	exportCmd.SetArgs([]string{exportFile})
+	execCheck(t, exportCmd, 0)
+	assert.Contains(t, stdoutBuf.String(), "exported 1 task runs and 1 releases")
+
+	t.Setenv(envVarPSQLURL, dstURL)
+	initDb(initDbCmd, nil)
+
+	stdoutBuf.Reset()
+	importCmd := newDbImportCmd()
+	importCmd.SetArgs([]string{exportFile})
+	execCheck(t, importCmd, 0)
+	assert.Regexp(t, `Task Runs imported:\s+1\n`, stdoutBuf.String())
+	assert.Regexp(t, `Releases imported:\s+1\n`, stdoutBuf.String())
+
+	stdoutBuf.Reset()
+	importCmd = newDbImportCmd()
+	importCmd.SetArgs([]string{exportFile})
+	execCheck(t, importCmd, 0)
+	assert.Regexp(t, `Task Runs skipped:\s+1\n`, stdoutBuf.String())
+	assert.Regexp(t, `Releases skipped:\s+1\n`, stdoutBuf.String())
+}
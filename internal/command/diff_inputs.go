@@ -221,7 +221,7 @@ func (c *diffInputsCmd) getTaskRunInputs(repo *baur.Repository, argDetails *diff
 		if withInputStrs {
 			inputStrs = baur.AsInputStrings(c.inputStr...)
 		}
-		inputResolver := baur.NewInputResolver(c.gitRepo, repo.Path, inputStrs, true)
+		inputResolver := newInputResolver(repo, c.gitRepo, inputStrs, true)
 
 		inputs, err := inputResolver.Resolve(ctx, argDetails.task)
 		if err != nil {
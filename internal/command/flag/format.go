@@ -5,6 +5,7 @@ const (
 	FormatCSV      = "csv"
 	FormatJSON     = "json"
 	FormatPlain    = "plain"
+	FormatYAML     = "yaml"
 )
 
 type Format struct {
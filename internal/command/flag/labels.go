@@ -0,0 +1,72 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+const labelSep = "="
+
+// Labels is a commandline flag that accepts KEY=VALUE pairs.
+// It can be specified multiple times, every occurrence adds a label.
+type Labels struct {
+	Labels map[string]string
+}
+
+// String returns the default value in the usage output
+func (l *Labels) String() string {
+	return ""
+}
+
+// Set parses a KEY=VALUE pair and adds it to the labels.
+// The key must not be empty and must not be specified multiple times, the
+// value can be empty and can contain "=" characters.
+func (l *Labels) Set(val string) error {
+	key, value, found := strings.Cut(val, labelSep)
+	if !found {
+		return fmt.Errorf("format must be %s", l.Type())
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return errors.New("key is empty")
+	}
+
+	if _, exists := l.Labels[key]; exists {
+		return fmt.Errorf("label %q is specified multiple times", key)
+	}
+
+	if l.Labels == nil {
+		l.Labels = map[string]string{}
+	}
+
+	l.Labels[key] = value
+
+	return nil
+}
+
+// Type returns the format description
+func (l *Labels) Type() string {
+	return "KEY=VALUE"
+}
+
+// Filters returns storage filters that match task runs that have all labels.
+// The filters are sorted by the label keys.
+func (l *Labels) Filters() []*storage.Filter {
+	result := make([]*storage.Filter, 0, len(l.Labels))
+
+	for _, k := range slices.Sorted(maps.Keys(l.Labels)) {
+		result = append(result, &storage.Filter{
+			Field:    storage.FieldLabel,
+			Operator: storage.OpEQ,
+			Value:    storage.LabelString(k, l.Labels[k]),
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,41 @@
+package flag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func TestLabelsSet(t *testing.T) {
+	var l Labels
+
+	require.NoError(t, l.Set("ci=true"))
+	require.NoError(t, l.Set("job_url=https://ci.example.com/?id=1"))
+	require.NoError(t, l.Set("user="))
+
+	assert.Equal(t, map[string]string{
+		"ci":      "true",
+		"job_url": "https://ci.example.com/?id=1",
+		"user":    "",
+	}, l.Labels)
+
+	assert.Equal(t, []*storage.Filter{
+		{Field: storage.FieldLabel, Operator: storage.OpEQ, Value: "ci=true"},
+		{Field: storage.FieldLabel, Operator: storage.OpEQ, Value: "job_url=https://ci.example.com/?id=1"},
+		{Field: storage.FieldLabel, Operator: storage.OpEQ, Value: "user="},
+	}, l.Filters())
+
+	require.Error(t, l.Set("ci=false"), "duplicate key accepted")
+}
+
+func TestLabelsSetInvalid(t *testing.T) {
+	for _, val := range []string{"", "ci", "=true", " =true"} {
+		t.Run(val, func(t *testing.T) {
+			var l Labels
+			require.Error(t, l.Set(val))
+		})
+	}
+}
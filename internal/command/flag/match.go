@@ -0,0 +1,156 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+const (
+	matchNegationPrefix = "!"
+	matchPrefixSuffix   = "*"
+)
+
+// Match is a commandline flag that specifies how the values of a field are
+// matched.
+// It accepts the following formats:
+//   - VALUE: matches VALUE
+//   - VALUE,VALUE...: matches one of the values
+//   - PREFIX*: matches values that start with PREFIX
+//   - !VALUE, !VALUE,VALUE...: negates the match
+type Match struct {
+	Op     storage.Op
+	Values []string
+
+	validValues []string
+	isSet       bool
+}
+
+// NewMatch returns a Match flag.
+// If validValues are passed, only those values are accepted and prefix
+// matching is not supported.
+func NewMatch(validValues ...string) *Match {
+	return &Match{validValues: validValues}
+}
+
+// String returns the default value in the usage output
+func (m *Match) String() string {
+	return ""
+}
+
+// Set parses the passed string and sets the operator and values.
+func (m *Match) Set(val string) error {
+	negate := strings.HasPrefix(val, matchNegationPrefix)
+	val = strings.TrimPrefix(val, matchNegationPrefix)
+
+	values := strings.Split(val, FieldSep)
+	for i, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return fmt.Errorf("format must be %s, value is empty", m.Type())
+		}
+
+		values[i] = v
+	}
+
+	if err := m.validate(values); err != nil {
+		return err
+	}
+
+	switch {
+	case len(values) == 1 && strings.HasSuffix(values[0], matchPrefixSuffix):
+		if negate {
+			return errors.New("prefix matches can not be negated")
+		}
+
+		if len(m.validValues) > 0 {
+			return errors.New("prefix matches are not supported")
+		}
+
+		prefix := strings.TrimSuffix(values[0], matchPrefixSuffix)
+		if prefix == "" {
+			return errors.New("prefix is empty")
+		}
+
+		m.Op = storage.OpPrefix
+		values[0] = prefix
+
+	case len(values) == 1 && negate:
+		m.Op = storage.OpNE
+	case len(values) == 1:
+		m.Op = storage.OpEQ
+	case negate:
+		m.Op = storage.OpNotIN
+	default:
+		m.Op = storage.OpIN
+	}
+
+	m.Values = values
+	m.isSet = true
+
+	return nil
+}
+
+func (m *Match) validate(values []string) error {
+	if len(m.validValues) == 0 {
+		if len(values) == 1 {
+			return nil
+		}
+
+		for _, v := range values {
+			if strings.HasSuffix(v, matchPrefixSuffix) {
+				return errors.New("prefix matches can only be used with a single value")
+			}
+		}
+
+		return nil
+	}
+
+	for _, v := range values {
+		if !slices.Contains(m.validValues, v) {
+			return fmt.Errorf("%q is not a valid value, must be one of: %s",
+				v, strings.Join(m.validValues, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Type returns the format description
+func (m *Match) Type() string {
+	if len(m.validValues) > 0 {
+		return "[!]VALUE[,VALUE]..."
+	}
+
+	return "[!]VALUE[,VALUE]...|PREFIX*"
+}
+
+// IsSet returns true if a value was set.
+func (m *Match) IsSet() bool {
+	return m.isSet
+}
+
+// Filter returns a storage filter that matches field by the flag value.
+// If the flag is not set, nil is returned.
+func (m *Match) Filter(field storage.Field) *storage.Filter {
+	if !m.isSet {
+		return nil
+	}
+
+	var val any
+	switch m.Op { //nolint:exhaustive // Set only uses the ops of the cases
+	case storage.OpIN, storage.OpNotIN:
+		val = m.Values
+	default:
+		val = m.Values[0]
+	}
+
+	return &storage.Filter{
+		Field:    field,
+		Operator: m.Op,
+		Value:    val,
+	}
+}
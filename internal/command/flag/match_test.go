@@ -0,0 +1,58 @@
+package flag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func TestMatchSet(t *testing.T) {
+	tcs := []struct {
+		val            string
+		expectedOp     storage.Op
+		expectedValues []string
+	}{
+		{val: "abc", expectedOp: storage.OpEQ, expectedValues: []string{"abc"}},
+		{val: "!abc", expectedOp: storage.OpNE, expectedValues: []string{"abc"}},
+		{val: "a, b", expectedOp: storage.OpIN, expectedValues: []string{"a", "b"}},
+		{val: "!a,b", expectedOp: storage.OpNotIN, expectedValues: []string{"a", "b"}},
+		{val: "ab*", expectedOp: storage.OpPrefix, expectedValues: []string{"ab"}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.val, func(t *testing.T) {
+			m := NewMatch()
+			require.NoError(t, m.Set(tc.val))
+			assert.True(t, m.IsSet())
+			assert.Equal(t, tc.expectedOp, m.Op)
+			assert.Equal(t, tc.expectedValues, m.Values)
+		})
+	}
+
+	for _, val := range []string{"", "a,", "!ab*", "*", "a,b*"} {
+		t.Run("invalid-"+val, func(t *testing.T) {
+			require.Error(t, NewMatch().Set(val))
+		})
+	}
+}
+
+func TestMatchValidValues(t *testing.T) {
+	m := NewMatch("success", "failure")
+
+	require.NoError(t, m.Set("!failure"))
+	assert.Equal(t, &storage.Filter{
+		Field:    storage.FieldResult,
+		Operator: storage.OpNE,
+		Value:    "failure",
+	}, m.Filter(storage.FieldResult))
+
+	require.Error(t, m.Set("unknown"))
+	require.Error(t, m.Set("succ*"))
+}
+
+func TestMatchFilterUnset(t *testing.T) {
+	assert.Nil(t, NewMatch().Filter(storage.FieldVCSRevision))
+}
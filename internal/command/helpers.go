@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 
 	"github.com/simplesurance/baur/v5/internal/command/flag"
 	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/internal/digest"
+	"github.com/simplesurance/baur/v5/internal/digest/filecache"
 	"github.com/simplesurance/baur/v5/internal/format/csv"
 	"github.com/simplesurance/baur/v5/internal/format/jsonformat"
 	"github.com/simplesurance/baur/v5/internal/format/table"
@@ -17,7 +20,9 @@ import (
 	"github.com/simplesurance/baur/v5/pkg/baur"
 	"github.com/simplesurance/baur/v5/pkg/cfg"
 	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/httpclient"
 	"github.com/simplesurance/baur/v5/pkg/storage/postgres"
+	"github.com/simplesurance/baur/v5/pkg/storage/sqlite"
 )
 
 type Formatter interface {
@@ -45,6 +50,10 @@ Examples:
 // postgresql URI can be stored
 const envVarPSQLURL = "BAUR_POSTGRESQL_URL"
 
+// envVarServerToken contains the name of an environment variable in that the
+// token for authenticating requests to a baur server is stored.
+const envVarServerToken = "BAUR_SERVER_TOKEN"
+
 func findRepository() (*baur.Repository, error) {
 	if repositoryPath != "" {
 		cfgPath := filepath.Join(repositoryPath, baur.RepositoryCfgFile)
@@ -111,7 +120,10 @@ func mustArgToApp(repo *baur.Repository, arg string) *baur.App {
 	return apps[0]
 }
 
-// newStorageClient creates a new postgresql storage client.
+// newStorageClient creates a new storage client.
+// If psqlURI is a sqlite:// URL a SQLite storage client is created,
+// if it is a https:// or http:// URL a client for a baur server,
+// otherwise a postgresql one.
 // If the environment variable BAUR_PSQL_URI is set, this uri is used instead
 // of the configuration specified in the baur.Repository object
 func newStorageClient(psqlURI string) (storage.Storer, error) {
@@ -121,6 +133,29 @@ func newStorageClient(psqlURI string) (storage.Storer, error) {
 		uri = envURI
 	}
 
+	if sqlite.IsURL(uri) {
+		var logger sqlite.Logger
+		if verboseFlag {
+			logger = log.StdLogger
+		}
+
+		return sqlite.New(ctx, uri, logger)
+	}
+
+	if httpclient.IsURL(uri) {
+		var logger httpclient.Logger
+		if verboseFlag {
+			logger = log.StdLogger
+		}
+
+		token := os.Getenv(envVarServerToken)
+		if token == "" {
+			return nil, fmt.Errorf("the environment variable %s must be set to the authentication token of the baur server", envVarServerToken)
+		}
+
+		return httpclient.New(ctx, uri, token, logger)
+	}
+
 	var logger postgres.Logger
 	if verboseFlag {
 		logger = log.StdLogger
@@ -195,12 +230,12 @@ func mustNewCompatibleStorageRepo(r *baur.Repository) storage.Storer {
 
 func mustNewCompatibleStorage(uri string) storage.Storer {
 	clt, err := newStorageClient(uri)
-	exitOnErr(err, "creating postgresql storage client failed")
+	exitOnErr(err, "creating storage client failed")
 
 	if err := clt.IsCompatible(ctx); err != nil {
 		if errors.Is(err, storage.ErrNotExist) {
-			fatal("baur postgresql database not found\n" +
-				" - ensure that the postgresql URL is correct,\n" +
+			fatal("baur database not found\n" +
+				" - ensure that the database URL is correct,\n" +
 				" - run 'baur init db' to create the database and schema")
 		}
 		clt.Close()
@@ -211,7 +246,7 @@ func mustNewCompatibleStorage(uri string) storage.Storer {
 }
 
 func mustGetRepoState(dir string) *git.Repository {
-	repo, err := git.NewRepositoryWithCheck(dir)
+	repo, err := git.NewRepositoryWithCheck(dir, log.Debugf)
 	if err != nil {
 		if errors.Is(err, git.ErrRepositoryNotFound) {
 			fatalf("git repository not found, the baur repository (%s) must be part of a git repository", dir)
@@ -223,6 +258,77 @@ func mustGetRepoState(dir string) *git.Repository {
 	return repo
 }
 
+// newInputResolver returns a [baur.InputResolver] that uses the file digest
+// algorithm of the repository configuration.
+func newInputResolver(
+	repo *baur.Repository,
+	gitRepo baur.GitUntrackedFilesResolver,
+	fixedInputs []baur.Input,
+	hashGitUntrackedFiles bool,
+) *baur.InputResolver {
+	algorithm := digest.GitObjectID
+	if repo.Cfg.Digest.FileAlgorithm == cfg.FileDigestAlgorithmXXH64 {
+		algorithm = digest.XXH64
+	}
+
+	return baur.NewInputResolver(
+		gitRepo,
+		repo.Path,
+		fixedInputs,
+		hashGitUntrackedFiles,
+		baur.WithFileDigestAlgorithm(algorithm),
+	)
+}
+
+// newTaskStatusEvaluator returns a [baur.TaskStatusEvaluator] that resolves
+// the inputs of as many tasks concurrently as CPUs are available.
+func newTaskStatusEvaluator(
+	repo *baur.Repository,
+	store storage.Storer,
+	inputResolver *baur.InputResolver,
+	lookupInputStr string,
+) *baur.TaskStatusEvaluator {
+	return baur.NewTaskStatusEvaluator(
+		repo.Path,
+		store,
+		inputResolver,
+		lookupInputStr,
+		baur.WithResolveParallelism(uint(runtime.NumCPU())),
+	)
+}
+
+// useFileDigestCache configures inputResolver to use the file digest cache of
+// the repository, if it is enabled in the repository configuration.
+// The returned function persists the cache, it must be called when
+// inputResolver finished resolving inputs.
+// Errors are printed and the cache is not used, the digests are then
+// calculated on every invocation.
+func useFileDigestCache(repo *baur.Repository, inputResolver *baur.InputResolver) (save func()) {
+	if !repo.Cfg.Cache.FileDigests {
+		return func() {}
+	}
+
+	path, err := filecache.DefaultPath(repo.Path)
+	if err != nil {
+		stderr.Printf("file digest cache disabled, determining its path failed: %s\n", err)
+		return func() {}
+	}
+
+	cache, err := filecache.Open(path, log.Debugf)
+	if err != nil {
+		stderr.Printf("file digest cache disabled, loading it failed: %s\n", err)
+		return func() {}
+	}
+
+	inputResolver.UseFileDigestCache(cache)
+
+	return func() {
+		if err := cache.Save(); err != nil {
+			stderr.Printf("storing file digest cache failed: %s\n", err)
+		}
+	}
+}
+
 func mustArgToTasks(repo *baur.Repository, vcs *git.Repository, args []string) []*baur.Task {
 	appLoader, err := baur.NewLoader(repo.Cfg, vcs.CommitID, log.StdLogger)
 	exitOnErr(err)
@@ -13,19 +13,22 @@ import (
 
 const initDbExample = `
 baur init db postgres://postgres@localhost:5432/baur?sslmode=disable
+baur init db sqlite:///var/lib/baur/baur.db
 `
 
 var initDbLongHelp = fmt.Sprintf(`
-Creates the baur tables in a PostgreSQL database.
+Creates the baur tables in a PostgreSQL or SQLite database.
+SQLite databases are referenced by URLs in the format sqlite:///path/to/baur.db,
+the database file is created if it does not exist.
 
-The Postgres URL is read from the repository configuration file.
+The database URL is read from the repository configuration file.
 Alternatively the URL can be passed as argument or
 by setting the '%s' environment variable.`,
 	term.Highlight(envVarPSQLURL))
 
 var initDbCmd = &cobra.Command{
-	Use:               "db [POSTGRES-URL]",
-	Short:             "create baur tables in a PostgreSQL database",
+	Use:               "db [DATABASE-URL]",
+	Short:             "create baur tables in a PostgreSQL or SQLite database",
 	Example:           strings.TrimSpace(initDbExample),
 	Long:              strings.TrimSpace(initDbLongHelp),
 	Run:               initDb,
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/testutils/repotest"
+)
+
+func TestRunLabelsAreStoredAndFilterable(t *testing.T) {
+	initTest(t)
+
+	r := repotest.CreateBaurRepository(t)
+	app := r.CreateSimpleApp(t)
+
+	t.Setenv(envVarPSQLURL, "sqlite://"+filepath.Join(t.TempDir(), "baur.db"))
+	initDb(initDbCmd, nil)
+
+	taskID := fmt.Sprintf("%s.%s", app.Name, app.Tasks[0].Name)
+
+	runCmd := newRunCmd()
+	require.NoError(t, runCmd.Flags().Set("label", "ci=true"))
+	require.NoError(t, runCmd.Flags().Set("label", "job_url=https://ci.example.com/job?id=1"))
+	runCmd.Run(&runCmd.Command, []string{taskID})
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	lsRunsCmd := newLsRunsCmd()
+	lsRunsCmd.format.Val = "csv"
+	require.NoError(t, lsRunsCmd.Flags().Set("label", "ci=true"))
+	lsRunsCmd.Run(&lsRunsCmd.Command, []string{taskID})
+	assert.Contains(t, stdoutBuf.String(), fmt.Sprintf("1,%s,%s,success,", app.Name, app.Tasks[0].Name))
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	showCmd := newShowCmd()
+	showCmd.Run(&showCmd.Command, []string{"1"})
+	assert.Contains(t, stdoutBuf.String(), "Labels:")
+	assert.Regexp(t, `ci:\s+true`, stdoutBuf.String())
+	assert.Regexp(t, `job_url:\s+https://ci.example.com/job\?id=1`, stdoutBuf.String())
+
+	var cmdFailed bool
+	exitFunc = func(code int) {
+		cmdFailed = code != 0
+	}
+
+	lsRunsCmd = newLsRunsCmd()
+	require.NoError(t, lsRunsCmd.Flags().Set("label", "ci=false"))
+	lsRunsCmd.Run(&lsRunsCmd.Command, []string{taskID})
+	assert.True(t, cmdFailed)
+}
@@ -0,0 +1,111 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/pkg/baur"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+var logsLongHelp = fmt.Sprintf(`
+Show the recorded output of a task run.
+
+The combined stdout and stderr output of the task command is stored with the
+recorded task runs when it is enabled via the store setting in the TaskLogs
+section of the repository configuration. When the output is bigger than %s,
+only its beginning and end are stored.
+
+A task run can be specified by:
+- its run-id,
+- or by the git-like syntax APP_NAME.TASK_NAME^, the number of ^ characters
+  specify the run-id, ^ refers the last recorded run, '^^' the run before the
+  last, and so on
+`,
+	term.Highlight(term.FormatSize(baur.MaxTaskRunLogSize)),
+)
+
+const logsExample = `
+baur logs 312		show the output of the task run with ID 312
+baur logs calc.build^	show the output of the last recorded run of the
+			build task of the calc app
+`
+
+func init() {
+	rootCmd.AddCommand(&newLogsCmd().Command)
+}
+
+type logsCmd struct {
+	cobra.Command
+}
+
+func newLogsCmd() *logsCmd {
+	cmd := logsCmd{
+		Command: cobra.Command{
+			Use:     "logs <APP_NAME.TASK_NAME^|RUN_ID>",
+			Short:   "show the recorded output of a task run",
+			Long:    strings.TrimSpace(logsLongHelp),
+			Example: strings.TrimSpace(logsExample),
+			Args:    logsArgs,
+			ValidArgsFunction: newCompleteTargetFunc(completeTargetFuncOpts{
+				withoutWildcards: true,
+				withoutAppNames:  true,
+				withoutPaths:     true,
+			}),
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	return &cmd
+}
+
+func logsArgs(_ *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("accepts 1 arg, received %d", len(args))
+	}
+
+	validArgRE := regexp.MustCompile(`^[\w-]+\.[\w-]+\^+$|^[0-9]+$`)
+	if !validArgRE.MatchString(args[0]) {
+		return fmt.Errorf("invalid argument: %q", args[0])
+	}
+
+	return nil
+}
+
+func (*logsCmd) run(_ *cobra.Command, args []string) {
+	repo := mustFindRepository()
+
+	app, task, runID := parseDiffSpec(args[0])
+	taskRun := getTaskRun(repo, &diffInputArgDetails{
+		arg:      args[0],
+		appName:  app,
+		taskName: task,
+		runID:    runID,
+	})
+
+	storageClt := mustNewCompatibleStorageRepo(repo)
+	defer storageClt.Close()
+
+	compressedLog, err := storageClt.TaskRunLog(ctx, taskRun.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			stderr.Printf("no output was recorded for task run with id %d\n", taskRun.ID)
+			exitFunc(exitCodeError)
+			return
+		}
+
+		exitOnErr(err)
+	}
+
+	log, err := baur.DecompressTaskRunLog(compressedLog)
+	exitOnErr(err)
+
+	_, err = stdout.Write(log)
+	exitOnErr(err)
+}
@@ -0,0 +1,78 @@
+package command
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/testutils/repotest"
+)
+
+func TestLogsShowsRecordedOutput(t *testing.T) {
+	initTest(t)
+
+	r := repotest.CreateBaurRepository(t, repotest.WithStoreTaskLogs())
+	app := r.CreateSimpleApp(t)
+
+	t.Setenv(envVarPSQLURL, "sqlite://"+filepath.Join(t.TempDir(), "baur.db"))
+	initDb(initDbCmd, nil)
+
+	taskID := fmt.Sprintf("%s.%s", app.Name, app.Tasks[0].Name)
+
+	runCmd := newRunCmd()
+	runCmd.Run(&runCmd.Command, []string{taskID})
+
+	for _, arg := range []string{"1", taskID + "^"} {
+		t.Run(arg, func(t *testing.T) {
+			stdoutBuf, _ := interceptCmdOutput(t)
+
+			logsCmd := newLogsCmd()
+			require.NoError(t, logsCmd.Args(&logsCmd.Command, []string{arg}))
+			logsCmd.Run(&logsCmd.Command, []string{arg})
+
+			assert.Equal(t, "building app\n", stdoutBuf.String())
+		})
+	}
+}
+
+func TestLogsAreNotRecordedByDefault(t *testing.T) {
+	initTest(t)
+
+	r := repotest.CreateBaurRepository(t)
+	app := r.CreateSimpleApp(t)
+
+	t.Setenv(envVarPSQLURL, "sqlite://"+filepath.Join(t.TempDir(), "baur.db"))
+	initDb(initDbCmd, nil)
+
+	taskID := fmt.Sprintf("%s.%s", app.Name, app.Tasks[0].Name)
+
+	runCmd := newRunCmd()
+	runCmd.Run(&runCmd.Command, []string{taskID})
+
+	var cmdFailed bool
+	exitFunc = func(code int) {
+		cmdFailed = code != 0
+	}
+
+	_, stderrBuf := interceptCmdOutput(t)
+
+	logsCmd := newLogsCmd()
+	logsCmd.Run(&logsCmd.Command, []string{taskID + "^"})
+	assert.True(t, cmdFailed)
+	assert.Contains(t, stderrBuf.String(), "no output was recorded")
+}
+
+func TestLogsArgs(t *testing.T) {
+	cmd := newLogsCmd()
+
+	for _, arg := range []string{"1", "calc.build^", "calc.build^^"} {
+		assert.NoError(t, cmd.Args(&cmd.Command, []string{arg}), arg)
+	}
+
+	for _, arg := range []string{"calc.build", "calc", "1^", "-1"} {
+		assert.Error(t, cmd.Args(&cmd.Command, []string{arg}), arg)
+	}
+}
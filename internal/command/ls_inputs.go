@@ -63,6 +63,7 @@ func newLsInputsCmd() *lsInputsCmd {
 
 func (c *lsInputsCmd) run(_ *cobra.Command, args []string) {
 	var inputs *baur.Inputs
+	var totalDigest string
 
 	if taskID, err := strconv.Atoi(args[0]); err == nil {
 		if len(c.inputStr) != 0 {
@@ -70,34 +71,42 @@ func (c *lsInputsCmd) run(_ *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 
-		inputs = c.mustGetTaskRunInputs(taskID)
+		inputs, totalDigest = c.mustGetTaskRunInputs(taskID)
 	} else {
 		inputs = c.mustGetTaskInputs(args[0])
 	}
 
 	inputs.Sort()
-	c.mustPrintTaskInputs(inputs)
+	c.mustPrintTaskInputs(inputs, totalDigest)
 }
 
-func (c *lsInputsCmd) mustGetTaskRunInputs(taskRunID int) *baur.Inputs {
+// mustGetTaskRunInputs returns the inputs and the total input digest of the
+// task run.
+// The recorded total input digest is returned, because it can not be
+// recalculated from the recorded inputs when it was calculated with a file
+// digest algorithm other than the default.
+func (c *lsInputsCmd) mustGetTaskRunInputs(taskRunID int) (*baur.Inputs, string) {
 	repo := mustFindRepository()
 
 	storageClt := mustNewCompatibleStorageRepo(repo)
 	defer storageClt.Close()
 
+	taskRun, err := storageClt.TaskRun(ctx, taskRunID)
+	exitOnErr(err)
+
 	inputs, err := storageClt.Inputs(ctx, taskRunID)
 	exitOnErr(err)
 
-	return toBaurInputs(inputs)
+	return toBaurInputs(inputs), taskRun.TotalInputDigest
 }
 
 func (c *lsInputsCmd) mustGetTaskInputs(taskSpec string) *baur.Inputs {
 	repo := mustFindRepository()
 	vcsState := mustGetRepoState(repo.Path)
 	task := mustArgToTask(repo, vcsState, taskSpec)
-	inputResolver := baur.NewInputResolver(
+	inputResolver := newInputResolver(
+		repo,
 		vcsState,
-		repo.Path,
 		baur.AsInputStrings(c.inputStr...),
 		true,
 	)
@@ -108,7 +117,9 @@ func (c *lsInputsCmd) mustGetTaskInputs(taskSpec string) *baur.Inputs {
 	return inputs
 }
 
-func (c *lsInputsCmd) mustPrintTaskInputs(inputs *baur.Inputs) {
+// mustPrintTaskInputs prints inputs. If totalDigest is empty, the total
+// input digest is calculated from inputs.
+func (c *lsInputsCmd) mustPrintTaskInputs(inputs *baur.Inputs, totalDigest string) {
 	var formatter Formatter
 	var headers []string
 	writeHeaders := !c.quiet
@@ -145,9 +156,12 @@ func (c *lsInputsCmd) mustPrintTaskInputs(inputs *baur.Inputs) {
 	exitOnErr(err)
 
 	if c.showDigest && !c.quiet && !isCSV {
-		totalDigest, err := inputs.Digest()
-		exitOnErr(err, "calculating total input digest failed")
+		if totalDigest == "" {
+			d, err := inputs.Digest()
+			exitOnErr(err, "calculating total input digest failed")
+			totalDigest = d.String()
+		}
 
-		stdout.Printf("\nTotal Input Digest: %s\n", term.Highlight(totalDigest.String()))
+		stdout.Printf("\nTotal Input Digest: %s\n", term.Highlight(totalDigest))
 	}
 }
@@ -0,0 +1,154 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v5/internal/command/flag"
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+var lsReleasesLongHelp = fmt.Sprintf(`
+List releases, ordered by their creation time.
+
+The command can be run without access to the baur repository, by specifying
+the PostgreSQL URI via the environment variable %s.
+
+Filter Parameters:
+	The %s parameter accepts the following values:
+	VALUE		matches releases with the name VALUE
+	VALUE,VALUE...	matches releases with one of the names
+	PREFIX*		matches releases with a name that starts with PREFIX
+	!VALUE...	negates the match
+`,
+	term.Highlight(envVarPSQLURL),
+	term.Highlight("--name"),
+)
+
+const lsReleasesExample = `
+baur ls releases					list all releases
+baur ls releases --name='v1.*'				list releases with names
+							starting with v1.
+baur ls releases --after=2024.01.01-00:00 --format=json	list releases created after
+							2024.01.01 in JSON format`
+
+func init() {
+	lsCmd.AddCommand(&newLsReleasesCmd().Command)
+}
+
+type lsReleasesCmd struct {
+	cobra.Command
+
+	format *flag.OneOf
+	after  flag.DateTimeFlagValue
+	before flag.DateTimeFlagValue
+	name   *flag.Match
+	quiet  bool
+}
+
+func newLsReleasesCmd() *lsReleasesCmd {
+	cmd := lsReleasesCmd{
+		Command: cobra.Command{
+			Use:               "releases",
+			Short:             "list releases",
+			Long:              strings.TrimSpace(lsReleasesLongHelp),
+			Example:           strings.TrimSpace(lsReleasesExample),
+			Args:              cobra.NoArgs,
+			ValidArgsFunction: cobra.NoFileCompletions,
+		},
+		format: flag.NewFormatFlag(),
+		name:   flag.NewMatch(),
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().Var(cmd.format, flag.FormatFlagName, cmd.format.Usage(term.Highlight))
+	_ = cmd.format.RegisterFlagCompletion(&cmd.Command)
+
+	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
+		"only show the release names")
+
+	cmd.Flags().Var(cmd.name, "name",
+		"only show releases with matching names")
+
+	cmd.Flags().VarP(&cmd.after, "after", "a",
+		fmt.Sprintf("only show releases that were created after this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	cmd.Flags().VarP(&cmd.before, "before", "b",
+		fmt.Sprintf("only show releases that were created before this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	return &cmd
+}
+
+func (c *lsReleasesCmd) run(cmd *cobra.Command, _ []string) {
+	ctx := cmd.Context()
+
+	psqlURL, err := postgresqlURL()
+	exitOnErr(err)
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	defer storageClt.Close()
+
+	formatter := mustNewFormatter(c.format.Val, c.createHeader())
+
+	err = storageClt.Releases(ctx, c.getFilters(), func(release *storage.Release) error {
+		if c.quiet && c.format.Val != flag.FormatJSON {
+			mustWriteRow(formatter, release.Name)
+			return nil
+		}
+
+		mustWriteRow(formatter, release.Name, release.CreatedAt.Format(flag.DateTimeFormatTz), release.BaseRelease)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			stderr.Println("no matching releases exist")
+			exitFunc(exitCodeError)
+		}
+		exitOnErr(err)
+	}
+
+	exitOnErr(formatter.Flush())
+}
+
+func (c *lsReleasesCmd) createHeader() []string {
+	if c.format.Val == flag.FormatJSON {
+		return []string{"Name", "CreatedAt", "BaseRelease"}
+	}
+
+	if c.quiet {
+		return nil
+	}
+
+	return []string{"Name", "Created At", "Base Release"}
+}
+
+func (c *lsReleasesCmd) getFilters() []*storage.Filter {
+	var filters []*storage.Filter
+
+	if f := c.name.Filter(storage.FieldReleaseName); f != nil {
+		filters = append(filters, f)
+	}
+
+	if c.before != (flag.DateTimeFlagValue{}) {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldReleaseCreatedAt,
+			Operator: storage.OpLT,
+			Value:    c.before.Time,
+		})
+	}
+
+	if c.after != (flag.DateTimeFlagValue{}) {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldReleaseCreatedAt,
+			Operator: storage.OpGT,
+			Value:    c.after.Time,
+		})
+	}
+
+	return filters
+}
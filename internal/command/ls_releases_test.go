@@ -0,0 +1,68 @@
+package command
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/testutils/repotest"
+)
+
+// initReleasesTest creates a baur repository with an sqlite database, runs
+// all tasks and creates releases with the given names.
+func initReleasesTest(t *testing.T, releaseNames ...string) *repotest.Repo {
+	t.Helper()
+
+	initTest(t)
+
+	r := repotest.CreateBaurRepository(t)
+	r.CreateSimpleApp(t)
+
+	t.Setenv(envVarPSQLURL, "sqlite://"+filepath.Join(t.TempDir(), "baur.db"))
+	initDb(initDbCmd, nil)
+
+	runCmd := newRunCmd()
+	runCmd.Run(&runCmd.Command, nil)
+
+	for _, name := range releaseNames {
+		releaseCreateCmd := newReleaseCreateCmd()
+		releaseCreateCmd.SetArgs([]string{name})
+		require.NoError(t, releaseCreateCmd.Execute())
+	}
+
+	return r
+}
+
+func TestLsReleases(t *testing.T) {
+	initReleasesTest(t, "v1.0", "v1.1", "v2.0")
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	lsReleasesCmd := newLsReleasesCmd()
+	lsReleasesCmd.SetArgs([]string{"--quiet"})
+	require.NoError(t, lsReleasesCmd.Execute())
+	assert.Equal(t, "v1.0\nv1.1\nv2.0\n", stdoutBuf.String())
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	lsReleasesCmd = newLsReleasesCmd()
+	lsReleasesCmd.SetArgs([]string{"--quiet", "--name", "v1.*"})
+	require.NoError(t, lsReleasesCmd.Execute())
+	assert.Equal(t, "v1.0\nv1.1\n", stdoutBuf.String())
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	lsReleasesCmd = newLsReleasesCmd()
+	lsReleasesCmd.SetArgs([]string{"--format", "csv", "--name", "!v1.0,v1.1"})
+	require.NoError(t, lsReleasesCmd.Execute())
+	assert.Regexp(t, `^Name,Created At,Base Release\nv2\.0,\d{4}`, stdoutBuf.String())
+
+	var cmdFailed bool
+	exitFunc = func(code int) {
+		cmdFailed = code != 0
+	}
+
+	lsReleasesCmd = newLsReleasesCmd()
+	lsReleasesCmd.SetArgs([]string{"--before", "2000.01.01-00:00"})
+	require.NoError(t, lsReleasesCmd.Execute())
+	assert.True(t, cmdFailed)
+}
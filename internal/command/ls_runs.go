@@ -15,13 +15,33 @@ import (
 	"github.com/simplesurance/baur/v5/pkg/storage"
 )
 
-const lsRunsLongHelp = `
+var lsRunsLongHelp = fmt.Sprintf(`
 List recorded task runs.
 
 Arguments:
 	'*' can be passed as APP_NAME or TASK_NAME argument to match
 	all Apps or Tasks.
-`
+
+Filter Parameters:
+	The %s, %s, %s, %s and %s
+	parameters accept the following values:
+	VALUE		matches runs with the value VALUE
+	VALUE,VALUE...	matches runs with one of the values
+	PREFIX*		matches runs with a value that starts with PREFIX
+	!VALUE...	negates the match
+	A run has multiple output digests and URIs, a run matches if one of
+	them matches, negated filters match if none of them matches.
+
+	%s can be specified multiple times, only runs that have all passed
+	labels are listed.
+`,
+	term.Highlight("--result"),
+	term.Highlight("--revision"),
+	term.Highlight("--input-digest"),
+	term.Highlight("--output-digest"),
+	term.Highlight("--output-uri"),
+	term.Highlight("--label"),
+)
 
 const lsRunsExample = `
 baur ls runs -s duration-desc calc	list task runs of the calc
@@ -33,7 +53,16 @@ baur ls runs --limit=1 calc		list a single task run of the calc
 					application
 baur ls runs --has-input=string:master calc
 					list task runs of the calc application
-					that have a 'string:master' input`
+					that have a 'string:master' input
+baur ls runs --result=failure --revision='4fe1*' '*'
+					list failed task runs of commits
+					starting with 4fe1
+baur ls runs --output-digest=sha384:5fa2... '*'
+					list task runs that produced an output
+					with the given digest
+baur ls runs --label ci=true --label branch=main '*'
+					list task runs that have the labels ci=true
+					and branch=main`
 
 func init() {
 	lsCmd.AddCommand(&newLsRunsCmd().Command)
@@ -50,6 +79,14 @@ type lsRunsCmd struct {
 	limit  uint
 	quiet  bool
 
+	result       *flag.Match
+	revision     *flag.Match
+	dirty        bool
+	inputDigest  *flag.Match
+	outputDigest *flag.Match
+	outputURI    *flag.Match
+	labels       flag.Labels
+
 	app  string
 	task string
 }
@@ -79,6 +116,12 @@ func newLsRunsCmd() *lsRunsCmd {
 			"output format",
 			flag.FormatCSV, flag.FormatPlain,
 		),
+
+		result:       flag.NewMatch(string(storage.ResultSuccess), string(storage.ResultFailure)),
+		revision:     flag.NewMatch(),
+		inputDigest:  flag.NewMatch(),
+		outputDigest: flag.NewMatch(),
+		outputURI:    flag.NewMatch(),
 	}
 
 	cmd.Run = cmd.run
@@ -109,6 +152,29 @@ func newLsRunsCmd() *lsRunsCmd {
 			term.Highlight("string:")),
 	)
 
+	cmd.Flags().Var(cmd.result, "result",
+		fmt.Sprintf("only show runs with the given result (%s, %s)",
+			term.Highlight(storage.ResultSuccess), term.Highlight(storage.ResultFailure)))
+
+	cmd.Flags().Var(cmd.revision, "revision",
+		"only show runs of matching VCS revisions")
+
+	cmd.Flags().BoolVar(&cmd.dirty, "dirty", false,
+		fmt.Sprintf("only show runs of dirty (%s) or clean (%s) VCS worktrees",
+			term.Highlight("true"), term.Highlight("false")))
+
+	cmd.Flags().Var(cmd.inputDigest, "input-digest",
+		"only show runs with matching total input digests")
+
+	cmd.Flags().Var(cmd.outputDigest, "output-digest",
+		"only show runs with matching output digests")
+
+	cmd.Flags().Var(cmd.outputURI, "output-uri",
+		"only show runs with matching upload URIs")
+
+	cmd.Flags().Var(&cmd.labels, "label",
+		"only show runs that have the label, can be specified multiple times")
+
 	return &cmd
 }
 
@@ -267,5 +333,27 @@ func (c *lsRunsCmd) getFilters() []*storage.Filter {
 		}
 	}
 
+	for _, f := range []*storage.Filter{
+		c.result.Filter(storage.FieldResult),
+		c.revision.Filter(storage.FieldVCSRevision),
+		c.inputDigest.Filter(storage.FieldTotalInputDigest),
+		c.outputDigest.Filter(storage.FieldOutputDigest),
+		c.outputURI.Filter(storage.FieldOutputURI),
+	} {
+		if f != nil {
+			filters = append(filters, f)
+		}
+	}
+
+	if c.Flags().Changed("dirty") {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldVCSIsDirty,
+			Operator: storage.OpEQ,
+			Value:    c.dirty,
+		})
+	}
+
+	filters = append(filters, c.labels.Filters()...)
+
 	return filters
 }
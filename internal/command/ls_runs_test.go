@@ -51,3 +51,34 @@ func TestLsRunsHasInput(t *testing.T) {
 	lsRunsCmd.Run(&lsRunsCmd.Command, []string{taskID})
 	assert.True(t, cmdFailed)
 }
+
+func TestLsRunsResultAndOutputFilters(t *testing.T) {
+	initTest(t)
+
+	r := repotest.CreateBaurRepository(t, repotest.WithNewDB())
+	app := r.CreateSimpleApp(t)
+	runInitDb(t)
+
+	taskID := fmt.Sprintf("%s.%s", app.Name, app.Tasks[0].Name)
+
+	runCmd := newRunCmd()
+	runCmd.Run(&runCmd.Command, []string{taskID})
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	lsRunsCmd := newLsRunsCmd()
+	lsRunsCmd.format.Val = "csv"
+	require.NoError(t, lsRunsCmd.Flags().Set("result", "success"))
+	require.NoError(t, lsRunsCmd.Flags().Set("output-uri", "!does-not-exist"))
+	lsRunsCmd.Run(&lsRunsCmd.Command, []string{taskID})
+	assert.Contains(t, stdoutBuf.String(), fmt.Sprintf("1,%s,%s,success,", app.Name, app.Tasks[0].Name))
+
+	var cmdFailed bool
+	exitFunc = func(code int) {
+		cmdFailed = code != 0
+	}
+
+	lsRunsCmd = newLsRunsCmd()
+	require.NoError(t, lsRunsCmd.Flags().Set("result", "failure"))
+	lsRunsCmd.Run(&lsRunsCmd.Command, []string{taskID})
+	assert.True(t, cmdFailed)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/simplesurance/baur/v5/internal/command/term"
 	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/internal/set"
 	"github.com/simplesurance/baur/v5/internal/validation"
 	"github.com/simplesurance/baur/v5/pkg/baur"
 	"github.com/simplesurance/baur/v5/pkg/storage"
@@ -23,7 +24,11 @@ baur release create stable		      create a release named 'stable',
 					      including all tasks
 baur release create --include '*.build' v3    create a release named 'v3',
 					      including only tasks called
-					      'build'`
+					      'build'
+baur release create --base v1.2 \
+	--include app.build v1.2.1	      create a release named 'v1.2.1'
+					      from release 'v1.2', replacing
+					      the task run of 'app.build'`
 
 var releaseCreateLongHelp = fmt.Sprintf(`
 Creates a named snapshot of the status of tasks.
@@ -39,17 +44,29 @@ By default, all tasks are included in the release.
 Pass the --include flag to include only specific tasks.
 See %s for more information about the TARGET syntax.
 
+With %s, the release is derived from an existing release. It contains the
+task runs of the base release, only the task runs of tasks matching
+%s are replaced by their current runs. Tasks that are not part of the
+base release are added. The name of the base release is stored with the
+new release. %s requires that %s is specified.
+
 Exit Codes:
   %d - Success
   %d - Error
   %d - Release with the same name already exist
   %d - One or more tasks are in pending state
+  %d - Base release does not exist
 `,
 	term.Highlight("baur run --help"),
+	term.Highlight("--base"),
+	term.Highlight("--include"),
+	term.Highlight("--base"),
+	term.Highlight("--include"),
 	exitCodeSuccess,
 	exitCodeError,
 	exitCodeAlreadyExist,
 	exitCodeTaskRunIsPending,
+	exitCodeNotExist,
 )
 
 type releaseCreateCmd struct {
@@ -57,6 +74,7 @@ type releaseCreateCmd struct {
 	requireCleanGitWorktree bool
 	metadataFile            string
 	includes                []string
+	base                    string
 }
 
 func init() {
@@ -93,6 +111,12 @@ func newReleaseCreateCmd() *releaseCreateCmd {
 		newCompleteTargetFunc(completeTargetFuncOpts{withoutPaths: true}),
 	)
 
+	cmd.Flags().StringVar(&cmd.base, "base", "",
+		"name of a release the release is derived from,\n"+
+			"only the task runs of tasks matching --include are replaced,\n"+
+			"requires --include",
+	)
+
 	cmd.Run = cmd.run
 
 	return &cmd
@@ -105,6 +129,13 @@ func (c *releaseCreateCmd) run(cmd *cobra.Command, args []string) {
 	err := validation.StrID(releaseName)
 	exitOnErrf(err, "invalid release name: %q", releaseName)
 
+	if c.base != "" && len(c.includes) == 0 {
+		fatalf("the %s parameter can only be used together with %s\n",
+			term.Highlight("--base"),
+			term.Highlight("--include"),
+		)
+	}
+
 	stdout.Printf("creating release: %s\n", term.Highlight(releaseName))
 
 	repo := mustFindRepository()
@@ -131,8 +162,29 @@ func (c *releaseCreateCmd) run(cmd *cobra.Command, args []string) {
 	}
 
 	storageClt := mustNewCompatibleStorageRepo(repo)
+
+	var baseRunIDs map[string]int
+	if c.base != "" {
+		rm := baur.NewReleaseManager(&storageClt, nil, log.StdLogger)
+		baseRunIDs, err = rm.TaskRunIDs(ctx, c.base)
+		if errors.Is(err, storage.ErrNotExist) {
+			stderr.Printf("base release %s does not exist\n", term.Highlight(c.base))
+			exitFunc(exitCodeNotExist)
+		}
+		exitOnErr(err)
+	}
+
 	runIDs := c.mustFetchTaskIDs(ctx, repo, storageClt, tasks)
 
+	if c.base != "" {
+		var baseCnt int
+		runIDs, baseCnt = withBaseTaskRuns(runIDs, tasks, baseRunIDs)
+		stdout.Printf(
+			"using the task runs of %s tasks from base release %s\n",
+			term.Highlight(baseCnt), term.Highlight(c.base),
+		)
+	}
+
 	var metadataReader io.Reader
 	if c.metadataFile != "" {
 		fd, err := os.Open(c.metadataFile)
@@ -142,7 +194,7 @@ func (c *releaseCreateCmd) run(cmd *cobra.Command, args []string) {
 		metadataReader = fd
 	}
 
-	err = storageClt.CreateRelease(ctx, releaseName, time.Now(), runIDs, metadataReader)
+	err = storageClt.CreateRelease(ctx, releaseName, c.base, time.Now(), runIDs, metadataReader)
 	if errors.Is(err, storage.ErrExists) {
 		stderr.PrintErrf("release with name %q already exists, release names must be unique\n", releaseName)
 		exitFunc(exitCodeAlreadyExist)
@@ -162,38 +214,64 @@ func (c *releaseCreateCmd) mustFetchTaskIDs(
 	storageClt storage.Storer,
 	tasks []*baur.Task,
 ) []int {
-	statusMgr := baur.NewTaskStatusEvaluator(
-		repo.Path,
+	inputResolver := newInputResolver(
+		repo,
+		mustGetRepoState(repo.Path),
+		nil,
+		!c.requireCleanGitWorktree,
+	)
+	saveFileDigestCache := useFileDigestCache(repo, inputResolver)
+
+	statusMgr := newTaskStatusEvaluator(
+		repo,
 		storageClt,
-		baur.NewInputResolver(
-			mustGetRepoState(repo.Path),
-			repo.Path,
-			nil,
-			!c.requireCleanGitWorktree,
-		),
+		inputResolver,
 		"",
 	)
 
-	runIDs := make([]int, 0, len(tasks))
 	stdout.Printf("evaluating task statuses")
-	for _, task := range tasks {
-		status, _, taskRun, err := statusMgr.Status(ctx, task)
-		if err != nil {
-			stdout.Println()
-			exitOnErrf(err, "%s: evaluating task status failed", task)
-		}
-		if status != baur.TaskStatusRunExist {
-			stdout.Println()
+	statuses, err := statusMgr.Statuses(ctx, tasks, func(*baur.Task) {
+		stdout.Printf(".")
+	})
+	stdout.Println()
+	// the cache is saved before exiting because of pending tasks, the
+	// digests are reused when the release is created again after running
+	// them
+	saveFileDigestCache()
+	exitOnErr(err, "evaluating task status failed")
+
+	runIDs := make([]int, 0, len(statuses))
+	for _, st := range statuses {
+		if st.Status != baur.TaskStatusRunExist {
 			stderr.PrintErrf("%s: task status is %s, expecting %s\n",
-				task.ID, status, baur.TaskStatusRunExist)
+				st.Task.ID, st.Status, baur.TaskStatusRunExist)
 			exitFunc(exitCodeTaskRunIsPending)
 		}
 
-		stdout.Printf(".")
-		runIDs = append(runIDs, taskRun.ID)
-
+		runIDs = append(runIDs, st.TaskRun.ID)
 	}
-	stdout.Println()
 
 	return runIDs
 }
+
+// withBaseTaskRuns returns runIDs with the task run IDs of baseRunIDs
+// appended, that belong to tasks that are not in tasks.
+// The number of appended task runs is returned additionally.
+func withBaseTaskRuns(runIDs []int, tasks []*baur.Task, baseRunIDs map[string]int) ([]int, int) {
+	included := make(set.Set[string], len(tasks))
+	for _, task := range tasks {
+		included.Add(task.ID)
+	}
+
+	var cnt int
+	for taskID, runID := range baseRunIDs {
+		if included.Contains(taskID) {
+			continue
+		}
+
+		runIDs = append(runIDs, runID)
+		cnt++
+	}
+
+	return runIDs, cnt
+}
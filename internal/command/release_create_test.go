@@ -0,0 +1,104 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/digest/filecache"
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/pkg/baur"
+	"github.com/simplesurance/baur/v5/pkg/cfg"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func newTestReleaseManager(t *testing.T) *baur.ReleaseManager {
+	t.Helper()
+
+	psqlURL, err := postgresqlURL()
+	require.NoError(t, err)
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	t.Cleanup(func() { storageClt.Close() })
+
+	return baur.NewReleaseManager(&storageClt, nil, log.StdLogger)
+}
+
+func TestReleaseCreateWithBase(t *testing.T) {
+	r := initReleasesTest(t, "v1")
+	app := r.AppCfgs[0]
+	buildTaskID := fmt.Sprintf("%s.build", app.Name)
+	checkTaskID := fmt.Sprintf("%s.check", app.Name)
+
+	r.WriteAdditionalFileContents(t, app.Name, "output_content.txt", "2")
+
+	runCmd := newRunCmd()
+	runCmd.Run(&runCmd.Command, nil)
+
+	releaseCreateCmd := newReleaseCreateCmd()
+	releaseCreateCmd.SetArgs([]string{"--base", "v1", "--include", buildTaskID, "v1.1"})
+	require.NoError(t, releaseCreateCmd.Execute())
+
+	rm := newTestReleaseManager(t)
+
+	v1, err := rm.TaskRunIDs(t.Context(), "v1")
+	require.NoError(t, err)
+	v11, err := rm.TaskRunIDs(t.Context(), "v1.1")
+	require.NoError(t, err)
+	require.Len(t, v11, 2)
+	assert.NotEqual(t, v1[buildTaskID], v11[buildTaskID])
+	assert.Equal(t, v1[checkTaskID], v11[checkTaskID])
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	lsReleasesCmd := newLsReleasesCmd()
+	lsReleasesCmd.SetArgs([]string{"--format", "csv", "--name", "v1.1"})
+	require.NoError(t, lsReleasesCmd.Execute())
+	assert.Regexp(t, `\nv1\.1,\d{4}[^,]*,v1\n$`, stdoutBuf.String())
+
+	releaseCreateCmd = newReleaseCreateCmd()
+	releaseCreateCmd.SetArgs([]string{"--base", "v0", "--include", buildTaskID, "v1.2"})
+	execCheck(t, releaseCreateCmd, exitCodeNotExist)
+
+	_, err = rm.TaskRunIDs(t.Context(), "v1.2")
+	require.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+func TestReleaseCreateWithBaseRequiresInclude(t *testing.T) {
+	initReleasesTest(t, "v1")
+
+	releaseCreateCmd := newReleaseCreateCmd()
+	releaseCreateCmd.SetArgs([]string{"--base", "v1", "v1.1"})
+	execCheck(t, releaseCreateCmd, exitCodeError)
+
+	_, err := newTestReleaseManager(t).TaskRunIDs(t.Context(), "v1.1")
+	require.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+func TestReleaseCreateWithPendingTasksStoresFileDigestCache(t *testing.T) {
+	r := initReleasesTest(t)
+	app := r.AppCfgs[0]
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	r.Cfg.Cache.FileDigests = true
+	r.Cfg.Digest.FileAlgorithm = "xxh64"
+	require.NoError(t, r.Cfg.ToFile(filepath.Join(r.Dir, baur.RepositoryCfgFile), cfg.ToFileOptOverwrite()))
+
+	// digests of recently modified files are not cached
+	r.WriteAdditionalFileContents(t, app.Name, "output_content.txt", "2")
+	mtime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(r.Dir, app.Name, "output_content.txt"), mtime, mtime))
+
+	releaseCreateCmd := newReleaseCreateCmd()
+	releaseCreateCmd.SetArgs([]string{"v1"})
+	execCheck(t, releaseCreateCmd, exitCodeTaskRunIsPending)
+
+	cachePath, err := filecache.DefaultPath(r.Dir)
+	require.NoError(t, err)
+	assert.FileExists(t, cachePath)
+}
@@ -0,0 +1,76 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+var releaseDeleteLongHelp = fmt.Sprintf(`
+Delete a release.
+The task runs that are part of the release are not deleted, they are removed
+by %s when they become old enough.
+
+The command can be run without access to the baur repository, by specifying
+the PostgreSQL URI via the environment variable %s.
+
+Exit Codes:
+  %d - Success
+  %d - Error
+  %d - Release does not exist
+`,
+	term.Highlight("baur cleanup db"),
+	term.Highlight(envVarPSQLURL),
+	exitCodeSuccess,
+	exitCodeError,
+	exitCodeNotExist,
+)
+
+type releaseDeleteCmd struct {
+	cobra.Command
+}
+
+func init() {
+	releaseCmd.AddCommand(&newReleaseDeleteCmd().Command)
+}
+
+func newReleaseDeleteCmd() *releaseDeleteCmd {
+	cmd := releaseDeleteCmd{
+		Command: cobra.Command{
+			Use:               "delete NAME",
+			Short:             "delete a release",
+			Long:              strings.TrimSpace(releaseDeleteLongHelp),
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: cobra.NoFileCompletions,
+		},
+	}
+
+	cmd.Run = cmd.run
+
+	return &cmd
+}
+
+func (c *releaseDeleteCmd) run(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	releaseName := args[0]
+
+	psqlURL, err := postgresqlURL()
+	exitOnErr(err)
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	defer storageClt.Close()
+
+	err = storageClt.ReleaseDelete(ctx, releaseName)
+	if errors.Is(err, storage.ErrNotExist) {
+		stderr.Printf("release %s does not exist\n", term.Highlight(releaseName))
+		exitFunc(exitCodeNotExist)
+	}
+	exitOnErr(err, "deleting release failed")
+
+	stdout.Printf("release %s deleted\n", term.Highlight(releaseName))
+}
@@ -0,0 +1,26 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseDelete(t *testing.T) {
+	initReleasesTest(t, "v1", "v2")
+
+	releaseDeleteCmd := newReleaseDeleteCmd()
+	releaseDeleteCmd.SetArgs([]string{"v1"})
+	require.NoError(t, releaseDeleteCmd.Execute())
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	lsReleasesCmd := newLsReleasesCmd()
+	lsReleasesCmd.SetArgs([]string{"--quiet"})
+	require.NoError(t, lsReleasesCmd.Execute())
+	assert.Equal(t, "v2\n", stdoutBuf.String())
+
+	releaseDeleteCmd = newReleaseDeleteCmd()
+	releaseDeleteCmd.SetArgs([]string{"v1"})
+	execCheck(t, releaseDeleteCmd, exitCodeNotExist)
+}
@@ -0,0 +1,246 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v5/internal/command/flag"
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/pkg/baur"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+var releaseDiffLongHelp = fmt.Sprintf(`
+List the tasks that differ between two releases.
+
+A task differs if it only exists in one of the releases, if the releases
+contain different runs of it or if the output URIs of its runs differ.
+With %s, the inputs that differ between the task runs with different
+total input digests are listed instead.
+
+The command can be run without access to the baur repository, by specifying
+the PostgreSQL URI via the environment variable %s.
+
+States:
+	D - total input digests differ
+	C - total input digests are the same but the task runs or output URIs differ
+	+ - the task is missing in the OLD release
+	- - the task is missing in the NEW release
+
+Exit Codes:
+	0 - Releases are the same
+	1 - Error
+	2 - Releases differ
+	%d - Release does not exist
+`,
+	term.Highlight("--inputs"),
+	term.Highlight(envVarPSQLURL),
+	exitCodeNotExist,
+)
+
+const releaseDiffExample = `
+baur release diff v1.1 v1.2		list tasks that differ between the
+					releases v1.1 and v1.2
+baur release diff --inputs v1.1 v1.2	list the inputs of tasks that differ
+					between the releases v1.1 and v1.2`
+
+type releaseDiffCmd struct {
+	cobra.Command
+
+	format *flag.OneOf
+	quiet  bool
+	inputs bool
+}
+
+func init() {
+	releaseCmd.AddCommand(&newReleaseDiffCmd().Command)
+}
+
+func newReleaseDiffCmd() *releaseDiffCmd {
+	cmd := releaseDiffCmd{
+		Command: cobra.Command{
+			Use:               "diff OLD NEW",
+			Short:             "list tasks that differ between two releases",
+			Long:              strings.TrimSpace(releaseDiffLongHelp),
+			Example:           strings.TrimSpace(releaseDiffExample),
+			Args:              cobra.ExactArgs(2),
+			ValidArgsFunction: cobra.NoFileCompletions,
+		},
+		format: flag.NewFormatFlag(),
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().Var(cmd.format, flag.FormatFlagName, cmd.format.Usage(term.Highlight))
+	_ = cmd.format.RegisterFlagCompletion(&cmd.Command)
+
+	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
+		"with --format=plain: listing the differences is suppressed.\n"+
+			"with --format=csv: headers are not printed.")
+
+	cmd.Flags().BoolVar(&cmd.inputs, "inputs", false,
+		"list the inputs that differ between task runs with different total input digests")
+
+	return &cmd
+}
+
+func (c *releaseDiffCmd) run(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	oldName, newName := args[0], args[1]
+
+	psqlURL, err := postgresqlURL()
+	exitOnErr(err)
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	defer storageClt.Close()
+
+	rm := baur.NewReleaseManager(&storageClt, nil, log.StdLogger)
+	diffs, err := rm.DiffReleases(ctx, oldName, newName)
+	if errors.Is(err, storage.ErrNotExist) {
+		stderr.Println(err)
+		exitFunc(exitCodeNotExist)
+	}
+	exitOnErr(err)
+
+	var formatter Formatter
+	if c.inputs {
+		formatter = mustNewFormatter(c.format.Val, c.header(
+			[]string{"App", "Task", "State", "Path", "Digest1", "Digest2"},
+			[]string{"AppName", "TaskName", "State", "Path", "Digest1", "Digest2"},
+		))
+	} else {
+		formatter = mustNewFormatter(c.format.Val, c.header(
+			[]string{"State", "App", "Task", "Run ID 1", "Run ID 2", "Input Digest 1", "Input Digest 2", "Removed URIs", "Added URIs"},
+			[]string{"State", "AppName", "TaskName", "RunID1", "RunID2", "TotalInputDigest1", "TotalInputDigest2", "RemovedURIs", "AddedURIs"},
+		))
+	}
+
+	if c.format.Val != flag.FormatPlain || !c.quiet {
+		if c.inputs {
+			c.writeInputDiffs(ctx, formatter, storageClt, diffs)
+		} else {
+			c.writeTaskDiffs(formatter, diffs)
+		}
+	}
+
+	exitOnErr(formatter.Flush())
+
+	if c.format.Val == flag.FormatPlain {
+		if !c.quiet {
+			stdout.Println()
+		}
+
+		if len(diffs) > 0 {
+			stdout.Printf("the releases differ\n")
+		} else {
+			stdout.Printf("the releases are the same\n")
+		}
+	}
+
+	if len(diffs) > 0 {
+		exitFunc(2)
+	}
+}
+
+func (c *releaseDiffCmd) header(plainHeaders, jsonHeaders []string) []string {
+	if c.format.Val == flag.FormatJSON {
+		return jsonHeaders
+	}
+
+	if c.quiet {
+		return nil
+	}
+
+	return plainHeaders
+}
+
+func (c *releaseDiffCmd) writeTaskDiffs(formatter Formatter, diffs []*baur.ReleaseTaskDiff) {
+	for _, d := range diffs {
+		if c.format.Val == flag.FormatJSON {
+			mustWriteRow(formatter,
+				d.State.String(),
+				d.AppName,
+				d.TaskName,
+				optionalRunID(d.RunID1),
+				optionalRunID(d.RunID2),
+				d.TotalInputDigest1,
+				d.TotalInputDigest2,
+				nonNilSlice(d.RemovedURIs),
+				nonNilSlice(d.AddedURIs),
+			)
+			continue
+		}
+
+		mustWriteRow(formatter,
+			d.State,
+			d.AppName,
+			d.TaskName,
+			runIDStr(d.RunID1),
+			runIDStr(d.RunID2),
+			d.TotalInputDigest1,
+			d.TotalInputDigest2,
+			strings.Join(d.RemovedURIs, " "),
+			strings.Join(d.AddedURIs, " "),
+		)
+	}
+}
+
+// writeInputDiffs writes the input differences of the task runs of diffs
+// with different total input digests.
+func (c *releaseDiffCmd) writeInputDiffs(ctx context.Context, formatter Formatter, storageClt storage.Storer, diffs []*baur.ReleaseTaskDiff) {
+	for _, d := range diffs {
+		if d.State != baur.DigestMismatch {
+			continue
+		}
+
+		inputDiffs, err := baur.DiffInputs(
+			mustTaskRunInputs(ctx, storageClt, d.TaskID(), d.RunID1),
+			mustTaskRunInputs(ctx, storageClt, d.TaskID(), d.RunID2),
+		)
+		exitOnErr(err)
+
+		for _, id := range inputDiffs {
+			mustWriteRow(formatter, d.AppName, d.TaskName, id.State.String(), id.Path, id.Digest1, id.Digest2)
+		}
+	}
+}
+
+func mustTaskRunInputs(ctx context.Context, storageClt storage.Storer, taskID string, taskRunID int) *baur.Inputs {
+	inputs, err := storageClt.Inputs(ctx, taskRunID)
+	if errors.Is(err, storage.ErrNotExist) {
+		return baur.NewInputs(nil)
+	}
+	exitOnErrf(err, "%s: retrieving inputs of task run %d failed", taskID, taskRunID)
+
+	return toBaurInputs(inputs)
+}
+
+func runIDStr(id int) string {
+	if id == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(id)
+}
+
+func optionalRunID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
+
+func nonNilSlice(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseDiff(t *testing.T) {
+	r := initReleasesTest(t, "v1", "v1-copy")
+	app := r.AppCfgs[0]
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	releaseDiffCmd := newReleaseDiffCmd()
+	releaseDiffCmd.SetArgs([]string{"v1", "v1-copy"})
+	require.NoError(t, releaseDiffCmd.Execute())
+	assert.Contains(t, stdoutBuf.String(), "the releases are the same")
+
+	r.WriteAdditionalFileContents(t, app.Name, "output_content.txt", "2")
+
+	runCmd := newRunCmd()
+	runCmd.Run(&runCmd.Command, nil)
+
+	releaseCreateCmd := newReleaseCreateCmd()
+	releaseCreateCmd.SetArgs([]string{"v2"})
+	require.NoError(t, releaseCreateCmd.Execute())
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	releaseDiffCmd = newReleaseDiffCmd()
+	releaseDiffCmd.SetArgs([]string{"--format", "json", "v1", "v2"})
+	execCheck(t, releaseDiffCmd, 2)
+
+	var result []map[string]any
+	require.NoError(t, json.Unmarshal(stdoutBuf.Bytes(), &result), stdoutBuf.String())
+	require.Len(t, result, 1)
+	assert.Equal(t, "D", result[0]["State"])
+	assert.Equal(t, app.Name, result[0]["AppName"])
+	assert.Equal(t, "build", result[0]["TaskName"])
+	assert.NotEqual(t, result[0]["RunID1"], result[0]["RunID2"])
+	assert.NotEqual(t, result[0]["TotalInputDigest1"], result[0]["TotalInputDigest2"])
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	releaseDiffCmd = newReleaseDiffCmd()
+	releaseDiffCmd.SetArgs([]string{"--format", "csv", "--quiet", "--inputs", "v1", "v2"})
+	execCheck(t, releaseDiffCmd, 2)
+	assert.Contains(t, stdoutBuf.String(), app.Name+",build,D,"+app.Name+"/output_content.txt,")
+
+	releaseDiffCmd = newReleaseDiffCmd()
+	releaseDiffCmd.SetArgs([]string{"v1", "v3"})
+	execCheck(t, releaseDiffCmd, exitCodeNotExist)
+}
@@ -1,14 +1,18 @@
 package command
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 
 	"github.com/simplesurance/baur/v5/internal/command/term"
 	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/internal/output/docker"
+	"github.com/simplesurance/baur/v5/internal/output/filecopy"
 	"github.com/simplesurance/baur/v5/internal/output/s3"
 	"github.com/simplesurance/baur/v5/pkg/baur"
 	"github.com/simplesurance/baur/v5/pkg/storage"
@@ -19,14 +23,23 @@ Download outputs belonging to a release.
 
 The command downloads one instance of each uploaded output of task runs that
 belong to the release.
-Only outputs that have been uploaded to S3 are downloaded, others are ignored.
-The downloaded outputs are stored at the path: %s.
+Outputs that have been uploaded to S3 or copied to a directory are stored at
+the path: %s.
+Docker images are pulled from their registry. With %s, they are
+additionally stored as tar archive at: %s.
+
+The digests of downloaded S3 and copied outputs are verified against the
+digests recorded when they were uploaded. Files with a mismatching digest are
+deleted and reported, the command exits with an error.
+Outputs that already exist in DEST-DIR with the recorded digest are not
+downloaded again, an interrupted download can be resumed by rerunning the
+command.
 
 The command can be run without access to the baur repository by specifying the
 PostgreSQL URI via the environment variable %s.
 
 When task IDS are specified via %s, the command fails if any of them is not
-part of the release or does not have >1 output uploaded to S3.
+part of the release or does not have >1 uploaded output.
 
 
 Exit Codes:
@@ -35,6 +48,8 @@ Exit Codes:
   %d - Release does not exist
 `,
 	term.Highlight("DEST-DIR/TASK-ID/OUTPUT-NAME"),
+	term.Highlight("--docker-save"),
+	term.Highlight("DEST-DIR/TASK-ID/OUTPUT-NAME.tar"),
 	term.Highlight(envVarPSQLURL),
 	term.Highlight("--tasks"),
 	exitCodeSuccess,
@@ -42,9 +57,13 @@ Exit Codes:
 	exitCodeNotExist,
 )
 
+const defReleaseDownloadParallel = 4
+
 type releaseDownloadCmd struct {
 	cobra.Command
-	taskIDs []string
+	taskIDs    []string
+	dockerSave bool
+	parallel   uint
 }
 
 func init() {
@@ -72,6 +91,14 @@ func newReleaseDownloadCmd() *releaseDownloadCmd {
 			"(default: all)",
 	)
 
+	cmd.Flags().BoolVar(&cmd.dockerSave, "docker-save", false,
+		"store docker images as tar archives in DEST-DIR instead of only pulling them",
+	)
+
+	cmd.Flags().UintVarP(&cmd.parallel, "parallel", "p", defReleaseDownloadParallel,
+		"max. number of outputs that are downloaded in parallel",
+	)
+
 	cmd.Run = cmd.run
 	return &cmd
 }
@@ -81,6 +108,11 @@ func (r *releaseDownloadCmd) run(cmd *cobra.Command, args []string) {
 	releaseName := args[0]
 	destDir := args[1]
 
+	if r.parallel == 0 {
+		stderr.Printf("--parallel must be greater than 0\n")
+		exitFunc(exitCodeError)
+	}
+
 	psqlURL, err := postgresqlURL()
 	exitOnErr(err)
 
@@ -89,18 +121,42 @@ func (r *releaseDownloadCmd) run(cmd *cobra.Command, args []string) {
 	s3clt, err := s3.NewClient(ctx, log.StdLogger)
 	exitOnErr(err)
 
-	mgr := baur.NewReleaseManager(&storageClt, s3clt, log.StdLogger)
+	mgr := baur.NewReleaseManager(
+		&storageClt, s3clt, log.StdLogger,
+		baur.WithFileCopyDownloader(filecopy.New(log.Debugf)),
+		baur.WithDockerDownloader(&lazyDockerClient{}),
+	)
 	downloadCount := 0
+	skipCount := 0
 	err = mgr.DownloadOutputs(ctx, baur.DownloadOutputsParams{
 		ReleaseName: releaseName,
 		DestDir:     destDir,
 		TaskIDs:     r.taskIDs,
+		DockerSave:  r.dockerSave,
+		Parallel:    r.parallel,
 		DownloadStartFn: func(taskID, url, destfilepath string) {
+			downloadCount++
+
+			if destfilepath == "" {
+				stdout.Printf(
+					"%s: pulling %s\n",
+					term.Highlight(taskID), url,
+				)
+				return
+			}
+
 			stdout.Printf(
 				"%s: downloading %s -> %s\n",
 				term.Highlight(taskID), url, destfilepath,
 			)
-			downloadCount++
+		},
+		SkipFn: func(taskID, _, destfilepath string) {
+			skipCount++
+
+			stdout.Printf(
+				"%s: %s already exists with the recorded digest, skipping download\n",
+				term.Highlight(taskID), destfilepath,
+			)
 		},
 	})
 
@@ -112,10 +168,66 @@ func (r *releaseDownloadCmd) run(cmd *cobra.Command, args []string) {
 		exitFunc(exitCodeNotExist)
 	}
 
+	var mismatchErr *baur.DigestMismatchError
+	if errors.As(err, &mismatchErr) {
+		stderr.Printf("\n%s outputs do not match their recorded digests, the downloaded files have been deleted:\n",
+			term.Highlight(len(mismatchErr.Mismatches)),
+		)
+		for _, m := range mismatchErr.Mismatches {
+			stderr.Printf("%s: %s -> %s\n", term.Highlight(m.TaskID), m.URI, m.DestFile)
+			stderr.Printf("\texpected digest: %s\n", m.ExpectedDigest)
+			stderr.Printf("\tactual digest:   %s\n", term.RedHighlight(m.ActualDigest))
+		}
+		exitFunc(exitCodeError)
+	}
+
 	exitOnErr(err)
 
 	stdout.Printf(
-		"\ndownloaded %s outputs %s to %s\n",
+		"\ndownloaded %s outputs %s to %s, %s already existed\n",
 		term.Highlight(downloadCount), term.GreenHighlight("successfully"), term.Highlight(destDir),
+		term.Highlight(skipCount),
 	)
 }
+
+// lazyDockerClient creates the docker client on first use, downloading
+// releases without docker outputs does not require a docker setup.
+type lazyDockerClient struct {
+	mu  sync.Mutex
+	clt *docker.Client
+}
+
+func (l *lazyDockerClient) client() (*docker.Client, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.clt != nil {
+		return l.clt, nil
+	}
+
+	clt, err := docker.NewClient(log.StdLogger.Debugf)
+	if err != nil {
+		return nil, fmt.Errorf("creating docker client failed: %w", err)
+	}
+
+	l.clt = clt
+	return clt, nil
+}
+
+func (l *lazyDockerClient) Pull(ctx context.Context, uri string) (string, error) {
+	clt, err := l.client()
+	if err != nil {
+		return "", err
+	}
+
+	return clt.Pull(ctx, uri)
+}
+
+func (l *lazyDockerClient) Save(ctx context.Context, uri, destFile string) error {
+	clt, err := l.client()
+	if err != nil {
+		return err
+	}
+
+	return clt.Save(ctx, uri, destFile)
+}
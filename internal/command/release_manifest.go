@@ -0,0 +1,113 @@
+package command
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/baur/v5/internal/command/flag"
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/pkg/baur"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+var releaseManifestLongHelp = fmt.Sprintf(`
+Print a machine-readable manifest of a release.
+
+The manifest describes every task run of the release with its run ID, VCS
+revision, total input digest and its outputs with their digests, sizes and
+upload URIs. The metadata stored with the release is included base64 encoded.
+
+The command can be run without access to the baur repository, by specifying
+the PostgreSQL URI via the environment variable %s.
+
+Exit Codes:
+  %d - Success
+  %d - Error
+  %d - Release does not exist
+`,
+	term.Highlight(envVarPSQLURL),
+	exitCodeSuccess,
+	exitCodeError,
+	exitCodeNotExist,
+)
+
+const releaseManifestExample = `
+baur release manifest v1.2			print the manifest of release v1.2
+						in JSON format
+baur release manifest --format=yaml v1.2	print the manifest of release v1.2
+						in YAML format`
+
+type releaseManifestCmd struct {
+	cobra.Command
+
+	format *flag.OneOf
+}
+
+func init() {
+	releaseCmd.AddCommand(&newReleaseManifestCmd().Command)
+}
+
+func newReleaseManifestCmd() *releaseManifestCmd {
+	cmd := releaseManifestCmd{
+		Command: cobra.Command{
+			Use:               "manifest NAME",
+			Short:             "print a machine-readable manifest of a release",
+			Long:              strings.TrimSpace(releaseManifestLongHelp),
+			Example:           strings.TrimSpace(releaseManifestExample),
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: cobra.NoFileCompletions,
+		},
+		format: flag.NewOneOfFlag(
+			flag.FormatFlagName,
+			flag.FormatJSON,
+			"output format",
+			flag.FormatJSON, flag.FormatYAML,
+		),
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().Var(cmd.format, flag.FormatFlagName, cmd.format.Usage(term.Highlight))
+	_ = cmd.format.RegisterFlagCompletion(&cmd.Command)
+
+	return &cmd
+}
+
+func (c *releaseManifestCmd) run(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	releaseName := args[0]
+
+	psqlURL, err := postgresqlURL()
+	exitOnErr(err)
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	defer storageClt.Close()
+
+	rm := baur.NewReleaseManager(&storageClt, nil, log.StdLogger)
+	manifest, err := rm.Manifest(ctx, releaseName)
+	if errors.Is(err, storage.ErrNotExist) {
+		stderr.Printf(
+			"release %s does not exist\n",
+			term.Highlight(releaseName),
+		)
+		exitFunc(exitCodeNotExist)
+	}
+	exitOnErr(err)
+
+	if c.format.Val == flag.FormatYAML {
+		enc := yaml.NewEncoder(stdout)
+		exitOnErr(enc.Encode(manifest))
+		exitOnErr(enc.Close())
+		return
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	exitOnErr(enc.Encode(manifest))
+}
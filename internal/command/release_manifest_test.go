@@ -0,0 +1,64 @@
+package command
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/simplesurance/baur/v5/pkg/baur"
+)
+
+func TestReleaseManifest(t *testing.T) {
+	r := initReleasesTest(t)
+	app := r.AppCfgs[0]
+
+	metadataFile := filepath.Join(t.TempDir(), "metadata")
+	require.NoError(t, os.WriteFile(metadataFile, []byte("build 123"), 0o600))
+
+	releaseCreateCmd := newReleaseCreateCmd()
+	releaseCreateCmd.SetArgs([]string{"--metadata", metadataFile, "v1"})
+	require.NoError(t, releaseCreateCmd.Execute())
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	releaseManifestCmd := newReleaseManifestCmd()
+	releaseManifestCmd.SetArgs([]string{"v1"})
+	require.NoError(t, releaseManifestCmd.Execute())
+
+	var jsonManifest baur.ReleaseManifest
+	require.NoError(t, json.Unmarshal(stdoutBuf.Bytes(), &jsonManifest), stdoutBuf.String())
+	assert.Equal(t, "v1", jsonManifest.Name)
+	assert.False(t, jsonManifest.CreatedAt.IsZero())
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("build 123")), jsonManifest.Metadata)
+
+	require.Len(t, jsonManifest.Tasks, 2)
+	build := jsonManifest.Tasks[0]
+	assert.Equal(t, app.Name, build.AppName)
+	assert.Equal(t, "build", build.TaskName)
+	assert.NotZero(t, build.RunID)
+	assert.NotEmpty(t, build.VCSRevision)
+	assert.NotEmpty(t, build.TotalInputDigest)
+	require.NotEmpty(t, build.Outputs)
+	assert.NotEmpty(t, build.Outputs[0].Digest)
+	assert.NotEmpty(t, build.Outputs[0].Uploads)
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	releaseManifestCmd = newReleaseManifestCmd()
+	releaseManifestCmd.SetArgs([]string{"--format", "yaml", "v1"})
+	require.NoError(t, releaseManifestCmd.Execute())
+
+	var yamlManifest baur.ReleaseManifest
+	require.NoError(t, yaml.Unmarshal(stdoutBuf.Bytes(), &yamlManifest), stdoutBuf.String())
+	assert.Equal(t, jsonManifest.Name, yamlManifest.Name)
+	assert.Equal(t, jsonManifest.Metadata, yamlManifest.Metadata)
+	assert.Equal(t, jsonManifest.Tasks, yamlManifest.Tasks)
+
+	releaseManifestCmd = newReleaseManifestCmd()
+	releaseManifestCmd.SetArgs([]string{"v2"})
+	execCheck(t, releaseManifestCmd, exitCodeNotExist)
+}
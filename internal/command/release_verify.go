@@ -0,0 +1,226 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v5/internal/command/flag"
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/pkg/baur"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+var releaseVerifyLongHelp = fmt.Sprintf(`
+Verify that a release matches the current checkout.
+
+The inputs of every task in the release are resolved in the current
+worktree. Their total input digest is compared with the one of the task run
+that is part of the release. For every task with a different total input
+digest, the inputs that differ are listed.
+Digest1 is the digest of the input of the task run in the release, Digest2
+the digest of the input in the current worktree.
+
+States:
+	D - digests do not match
+	+ - the input is missing in the task run of the release
+	- - the input is missing in the current worktree,
+	    if Path is empty the task does not exist anymore
+
+If Path is empty for state D, the total input digests differ but the
+differing inputs could not be determined.
+
+Exit Codes:
+	0 - The release matches the current checkout
+	1 - Error
+	2 - The release differs from the current checkout
+	%d - Release does not exist
+`,
+	exitCodeNotExist,
+)
+
+const releaseVerifyExample = `
+baur release verify v1.1			verify that the release v1.1 was
+						created from the inputs in the
+						current worktree
+baur release verify --input-str=v1 v1.1	verify the release v1.1, include the
+						string "v1" as input of all tasks`
+
+type releaseVerifyCmd struct {
+	cobra.Command
+
+	format   *flag.OneOf
+	quiet    bool
+	inputStr []string
+}
+
+func init() {
+	releaseCmd.AddCommand(&newReleaseVerifyCmd().Command)
+}
+
+func newReleaseVerifyCmd() *releaseVerifyCmd {
+	cmd := releaseVerifyCmd{
+		Command: cobra.Command{
+			Use:               "verify NAME",
+			Short:             "verify that a release matches the current checkout",
+			Long:              strings.TrimSpace(releaseVerifyLongHelp),
+			Example:           strings.TrimSpace(releaseVerifyExample),
+			Args:              cobra.ExactArgs(1),
+			ValidArgsFunction: cobra.NoFileCompletions,
+		},
+		format: flag.NewFormatFlag(),
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().Var(cmd.format, flag.FormatFlagName, cmd.format.Usage(term.Highlight))
+	_ = cmd.format.RegisterFlagCompletion(&cmd.Command)
+
+	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
+		"with --format=plain: listing the inputs that differ is suppressed.\n"+
+			"with --format=csv: headers are not printed.")
+
+	cmd.Flags().StringArrayVar(&cmd.inputStr, "input-str", nil,
+		"include a string as input of all tasks, can be specified multiple times")
+
+	return &cmd
+}
+
+func (c *releaseVerifyCmd) run(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	releaseName := args[0]
+
+	repo := mustFindRepository()
+	vcsState := mustGetRepoState(repo.Path)
+
+	storageClt := mustNewCompatibleStorageRepo(repo)
+	defer storageClt.Close()
+
+	rm := baur.NewReleaseManager(&storageClt, nil, log.StdLogger)
+	runIDs, err := rm.TaskRunIDs(ctx, releaseName)
+	if errors.Is(err, storage.ErrNotExist) {
+		stderr.Println(err)
+		exitFunc(exitCodeNotExist)
+	}
+	exitOnErr(err)
+
+	loader, err := baur.NewLoader(repo.Cfg, vcsState.CommitID, log.StdLogger)
+	exitOnErr(err)
+
+	tasks, err := loader.LoadTasks()
+	exitOnErr(err)
+
+	tasksByID := make(map[string]*baur.Task, len(tasks))
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+
+	inputResolver := newInputResolver(repo, vcsState, baur.AsInputStrings(c.inputStr...), true)
+	saveFileDigestCache := useFileDigestCache(repo, inputResolver)
+
+	formatter := mustNewFormatter(c.format.Val, c.header())
+
+	var differs bool
+	for _, taskID := range slices.Sorted(maps.Keys(runIDs)) {
+		diffs := c.mustDiffTask(ctx, storageClt, inputResolver, taskID, runIDs[taskID], tasksByID[taskID])
+		if len(diffs) == 0 {
+			continue
+		}
+
+		differs = true
+		if c.format.Val == flag.FormatPlain && c.quiet {
+			continue
+		}
+
+		appName, taskName, _ := strings.Cut(taskID, ".")
+		for _, d := range diffs {
+			mustWriteRow(formatter, appName, taskName, d.State.String(), d.Path, d.Digest1, d.Digest2)
+		}
+	}
+
+	saveFileDigestCache()
+
+	exitOnErr(formatter.Flush())
+
+	if c.format.Val == flag.FormatPlain {
+		if !c.quiet {
+			stdout.Println()
+		}
+
+		if differs {
+			stdout.Printf("release %s %s from the current checkout\n",
+				term.Highlight(releaseName), term.RedHighlight("differs"))
+		} else {
+			stdout.Printf("release %s %s the current checkout\n",
+				term.Highlight(releaseName), term.GreenHighlight("matches"))
+		}
+	}
+
+	if differs {
+		exitFunc(2)
+	}
+}
+
+func (c *releaseVerifyCmd) header() []string {
+	if c.format.Val == flag.FormatJSON {
+		return []string{"AppName", "TaskName", "State", "Path", "Digest1", "Digest2"}
+	}
+
+	if c.quiet {
+		return nil
+	}
+
+	return []string{"App", "Task", "State", "Path", "Digest1", "Digest2"}
+}
+
+// mustDiffTask returns the differences between the inputs of the task run
+// with the ID runID and the current inputs of task.
+// If task is nil, a single [baur.Removed] difference without a path is
+// returned.
+// If the total input digests are the same, nil is returned.
+// If they differ but no differing input can be determined, a single
+// [baur.DigestMismatch] difference without a path is returned.
+func (c *releaseVerifyCmd) mustDiffTask(
+	ctx context.Context,
+	storageClt storage.Storer,
+	inputResolver *baur.InputResolver,
+	taskID string,
+	runID int,
+	task *baur.Task,
+) []*baur.InputDiff {
+	taskRun, err := storageClt.TaskRun(ctx, runID)
+	exitOnErrf(err, "%s: retrieving task run %d failed", taskID, runID)
+
+	if task == nil {
+		return []*baur.InputDiff{{State: baur.Removed, Digest1: taskRun.TotalInputDigest}}
+	}
+
+	inputs, err := inputResolver.Resolve(ctx, task)
+	exitOnErrf(err, "%s: resolving inputs failed", taskID)
+
+	totalInputDigest, err := inputs.Digest()
+	exitOnErrf(err, "%s: calculating total input digest failed", taskID)
+
+	if totalInputDigest.String() == taskRun.TotalInputDigest {
+		return nil
+	}
+
+	diffs, err := baur.DiffInputs(mustTaskRunInputs(ctx, storageClt, taskID, runID), inputs)
+	exitOnErr(err)
+
+	if len(diffs) == 0 {
+		return []*baur.InputDiff{{
+			State:   baur.DigestMismatch,
+			Digest1: taskRun.TotalInputDigest,
+			Digest2: totalInputDigest.String(),
+		}}
+	}
+
+	return diffs
+}
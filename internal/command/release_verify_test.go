@@ -0,0 +1,40 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseVerify(t *testing.T) {
+	r := initReleasesTest(t, "v1")
+	app := r.AppCfgs[0]
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	releaseVerifyCmd := newReleaseVerifyCmd()
+	releaseVerifyCmd.SetArgs([]string{"v1"})
+	require.NoError(t, releaseVerifyCmd.Execute())
+	assert.Contains(t, stdoutBuf.String(), "matches the current checkout")
+
+	r.WriteAdditionalFileContents(t, app.Name, "output_content.txt", "2")
+
+	stdoutBuf, _ = interceptCmdOutput(t)
+	releaseVerifyCmd = newReleaseVerifyCmd()
+	releaseVerifyCmd.SetArgs([]string{"--format", "json", "v1"})
+	execCheck(t, releaseVerifyCmd, 2)
+
+	var result []map[string]any
+	require.NoError(t, json.Unmarshal(stdoutBuf.Bytes(), &result), stdoutBuf.String())
+	require.Len(t, result, 1)
+	assert.Equal(t, app.Name, result[0]["AppName"])
+	assert.Equal(t, "build", result[0]["TaskName"])
+	assert.Equal(t, "D", result[0]["State"])
+	assert.Equal(t, app.Name+"/output_content.txt", result[0]["Path"])
+	assert.NotEqual(t, result[0]["Digest1"], result[0]["Digest2"])
+
+	releaseVerifyCmd = newReleaseVerifyCmd()
+	releaseVerifyCmd.SetArgs([]string{"v2"})
+	execCheck(t, releaseVerifyCmd, exitCodeNotExist)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/simplesurance/baur/v5/internal/command/flag"
 	"github.com/simplesurance/baur/v5/internal/command/term"
 	"github.com/simplesurance/baur/v5/internal/exec"
 	"github.com/simplesurance/baur/v5/internal/log"
@@ -28,6 +29,8 @@ baur run auth				run all tasks of the auth application, upload the produced outp
 baur run calc.check			run the check task of the calc application and upload the produced outputs
 baur run *.build			run all tasks named build of the all applications and upload the produced outputs
 baur run --force			run and upload all tasks of applications, independent of their status
+baur run --label ci=true --label branch=main
+					run all pending tasks and store the labels with the task runs
 `
 
 const flagNameRequireCleanGitWorktree = "require-clean-git-worktree"
@@ -90,6 +93,7 @@ type runCmd struct {
 	taskRunnerGoRoutines    uint
 	showOutput              bool
 	requireCleanGitWorktree bool
+	labels                  flag.Labels
 
 	// other fields
 	storage      storage.Storer
@@ -142,6 +146,8 @@ func newRunCmd() *runCmd {
 	)
 	cmd.Flags().BoolVarP(&cmd.requireCleanGitWorktree, flagNameRequireCleanGitWorktree, "c", false,
 		"fail if the git repository contains modified or untracked files")
+	cmd.Flags().Var(&cmd.labels, "label",
+		"store a KEY=VALUE label with the recorded task runs,\ncan be specified multiple times")
 
 	return &cmd
 }
@@ -166,14 +172,16 @@ func (c *runCmd) run(_ *cobra.Command, args []string) {
 	c.storage = mustNewCompatibleStorageRepo(repo)
 	defer c.storage.Close()
 
-	inputResolver := baur.NewInputResolver(
+	inputResolver := newInputResolver(
+		repo,
 		mustGetRepoState(c.repoRootPath),
-		c.repoRootPath,
 		baur.AsInputStrings(c.inputStr...),
 		!c.requireCleanGitWorktree,
 	)
-	taskStatusEvaluator := baur.NewTaskStatusEvaluator(
-		c.repoRootPath,
+	saveFileDigestCache := useFileDigestCache(repo, inputResolver)
+
+	taskStatusEvaluator := newTaskStatusEvaluator(
+		repo,
 		c.storage,
 		inputResolver,
 		c.lookupInputStr,
@@ -188,6 +196,7 @@ func (c *runCmd) run(_ *cobra.Command, args []string) {
 		baur.NewTaskInfoCreator(c.storage, taskStatusEvaluator),
 		c.failFast,
 	)
+	c.taskRunner.StoreLog = repo.Cfg.TaskLogs.Store
 
 	if c.showOutput && !verboseFlag {
 		c.taskRunner.LogFn = stderr.Printf
@@ -286,6 +295,8 @@ func (c *runCmd) run(_ *cobra.Command, args []string) {
 		c.uploadRoutinePool.Wait()
 	}
 
+	saveFileDigestCache()
+
 	stdout.PrintSep()
 	stdout.Printf("finished in: %s\n",
 		term.FormatDuration(
@@ -408,7 +419,7 @@ func (c *runCmd) uploadAndRecord(
 		}
 	}
 
-	id, err := baur.StoreRun(ctx, c.storage, c.gitRepo, task, inputs, runResult, uploadResults)
+	id, err := baur.StoreRun(ctx, c.storage, c.gitRepo, task, inputs, runResult, uploadResults, c.labels.Labels)
 	if err != nil {
 		stderr.Printf("%s: recording build result in database %s, %s\n",
 			term.Highlight(task),
@@ -477,27 +488,31 @@ func (c *runCmd) filterPendingTasks(taskStatusEvaluator *baur.TaskStatusEvaluato
 
 	taskIDColLen := maxTaskIDLen(tasks) + len(sep)
 
-	stdout.Printf("Evaluating status of tasks:\n\n")
+	// resolving the inputs of all tasks can take some time, output
+	// progress dots to let the user know that something is happening
+	stdout.Printf("Evaluating status of tasks")
+	statuses, err := taskStatusEvaluator.Statuses(ctx, tasks, func(*baur.Task) {
+		stdout.Printf(".")
+	})
+	stdout.Printf("\n\n")
+	if err != nil {
+		return nil, fmt.Errorf("evaluating task status failed: %w", err)
+	}
 
 	result := make([]*pendingTask, 0, len(tasks))
-	for _, task := range tasks {
-		status, inputs, run, err := taskStatusEvaluator.Status(ctx, task)
-		if err != nil {
-			return nil, fmt.Errorf("%s: evaluating task status failed: %w", task, err)
-		}
-
-		if status == baur.TaskStatusRunExist {
+	for _, st := range statuses {
+		if st.Status == baur.TaskStatusRunExist {
 			stdout.Printf("%-*s%s%s (%s)\n",
-				taskIDColLen, task, sep, term.ColoredTaskStatus(status), term.GreenHighlight(run.ID))
+				taskIDColLen, st.Task, sep, term.ColoredTaskStatus(st.Status), term.GreenHighlight(st.TaskRun.ID))
 
 			if !c.force {
 				continue
 			}
 		} else {
-			stdout.Printf("%-*s%s%s\n", taskIDColLen, task, sep, term.ColoredTaskStatus(status))
+			stdout.Printf("%-*s%s%s\n", taskIDColLen, st.Task, sep, term.ColoredTaskStatus(st.Status))
 		}
 
-		result = append(result, &pendingTask{task: task, inputs: inputs})
+		result = append(result, &pendingTask{task: st.Task, inputs: st.Inputs})
 	}
 
 	return result, nil
@@ -0,0 +1,148 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/pkg/storage/httpclient"
+	"github.com/simplesurance/baur/v5/pkg/storage/httpserver"
+)
+
+const serveShutdownTimeout = 30 * time.Second
+
+var serveLongHelp = fmt.Sprintf(`
+Serve the baur database via an HTTP/JSON API.
+The server allows baur clients to access the database without having direct
+network access and credentials for the database.
+Clients use the server by setting the database URL in their repository
+configuration or the %s environment variable to the https:// URL of the
+server.
+
+Requests must be authenticated with a token. The token is read from the
+environment variable %s, by the server and by the clients.
+
+The database URL is read from the repository configuration file or the
+environment variable %s.
+
+Without the %s and %s parameters the server serves unencrypted HTTP, the
+token is then transferred in clear text. This should only be used when TLS is
+terminated by a reverse proxy.
+`,
+	term.Highlight(envVarPSQLURL),
+	term.Highlight(envVarServerToken),
+	term.Highlight(envVarPSQLURL),
+	term.Highlight("--tls-cert"),
+	term.Highlight("--tls-key"),
+)
+
+const serveExample = `
+BAUR_SERVER_TOKEN=secret baur serve --tls-cert server.crt --tls-key server.key
+BAUR_SERVER_TOKEN=secret BAUR_POSTGRESQL_URL=https://baur.example.com:8443 baur status
+`
+
+type serveCmd struct {
+	cobra.Command
+
+	listenAddr  string
+	tlsCertFile string
+	tlsKeyFile  string
+}
+
+func init() {
+	rootCmd.AddCommand(&newServeCmd().Command)
+}
+
+func newServeCmd() *serveCmd {
+	cmd := serveCmd{
+		Command: cobra.Command{
+			Use:     "serve",
+			Short:   "serve the baur database via an HTTP API",
+			Long:    strings.TrimSpace(serveLongHelp),
+			Example: strings.TrimSpace(serveExample),
+			Args:    cobra.NoArgs,
+		},
+	}
+
+	cmd.Flags().StringVarP(&cmd.listenAddr, "listen", "l", ":8443",
+		"address on that the server listens")
+	cmd.Flags().StringVar(&cmd.tlsCertFile, "tls-cert", "",
+		"path to a PEM encoded TLS certificate file")
+	cmd.Flags().StringVar(&cmd.tlsKeyFile, "tls-key", "",
+		"path to a PEM encoded TLS private key file")
+	cmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")
+
+	cmd.Run = cmd.run
+
+	return &cmd
+}
+
+func (c *serveCmd) run(cmd *cobra.Command, _ []string) {
+	token := os.Getenv(envVarServerToken)
+	if token == "" {
+		fatalf("the environment variable %s must be set to the token that clients use for authentication\n", envVarServerToken)
+	}
+
+	psqlURL, err := postgresqlURL()
+	exitOnErr(err)
+
+	if httpclient.IsURL(psqlURL) {
+		fatalf("the database URL must refer to a PostgreSQL or SQLite database, got an URL of a baur server\n")
+	}
+
+	storageClt := mustNewCompatibleStorage(psqlURL)
+	defer storageClt.Close()
+
+	var logger httpserver.Logger
+	if verboseFlag {
+		logger = log.StdLogger
+	}
+
+	handler, err := httpserver.New(storageClt, token, logger)
+	exitOnErr(err)
+
+	srv := http.Server{
+		Addr:              c.listenAddr,
+		Handler:           handler,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			stderr.Printf("shutting down server failed: %s\n", err)
+		}
+	}()
+
+	if c.tlsCertFile != "" {
+		stdout.Printf("serving baur database via %s on %s\n", term.Highlight("HTTPS"), term.Highlight(c.listenAddr))
+		err = srv.ListenAndServeTLS(c.tlsCertFile, c.tlsKeyFile)
+	} else {
+		stdout.Printf("serving baur database via %s on %s\n", term.Highlight("HTTP"), term.Highlight(c.listenAddr))
+		err = srv.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		stdout.Println("server terminated")
+		return
+	}
+
+	exitOnErr(err)
+}
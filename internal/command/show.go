@@ -3,6 +3,8 @@ package command
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -194,6 +196,7 @@ func (c *showCmd) printTask(formatter Formatter, task *baur.Task) {
 			mustWriteRow(formatter, "", "", "Type:", term.Highlight("File"))
 			mustWriteRow(formatter, "", "", "Optional:", term.Highlight(f.Optional))
 			mustWriteRow(formatter, "", "", "Git tracked only:", term.Highlight(f.GitTrackedOnly))
+			mustWriteRow(formatter, "", "", "Exclude Git ignored:", term.Highlight(f.ExcludeGitIgnored))
 			mustWriteStringSliceRows(formatter, "Paths:", 2, f.Paths)
 
 			if i+1 < len(task.UnresolvedInputs.Files) {
@@ -249,7 +252,7 @@ func (c *showCmd) printTask(formatter Formatter, task *baur.Task) {
 			}
 		}
 
-		if len(task.UnresolvedInputs.ExcludedFiles.Paths) > 0 &&
+		if len(task.UnresolvedInputs.CommandOutput) > 0 &&
 			(len(task.UnresolvedInputs.GolangSources) > 0 ||
 				len(task.UnresolvedInputs.EnvironmentVariables) > 0 ||
 				len(task.UnresolvedInputs.Files) > 0 ||
@@ -257,6 +260,23 @@ func (c *showCmd) printTask(formatter Formatter, task *baur.Task) {
 			mustWriteRow(formatter, "", "", "", "")
 		}
 
+		for i, co := range task.UnresolvedInputs.CommandOutput {
+			mustWriteRow(formatter, "", "", "Type:", term.Highlight("Command Output"))
+			mustWriteRow(formatter, "", "", "Command:", term.Highlight(c.strCmd(co.Command)))
+			if i+1 < len(task.UnresolvedInputs.CommandOutput) {
+				mustWriteRow(formatter, "", "", "", "")
+			}
+		}
+
+		if len(task.UnresolvedInputs.ExcludedFiles.Paths) > 0 &&
+			(len(task.UnresolvedInputs.GolangSources) > 0 ||
+				len(task.UnresolvedInputs.EnvironmentVariables) > 0 ||
+				len(task.UnresolvedInputs.Files) > 0 ||
+				len(task.UnresolvedInputs.TaskInfos) > 0 ||
+				len(task.UnresolvedInputs.CommandOutput) > 0) {
+			mustWriteRow(formatter, "", "", "", "")
+		}
+
 		if len(task.UnresolvedInputs.ExcludedFiles.Paths) > 0 {
 			mustWriteRow(formatter, "", "", "", "")
 			mustWriteRow(formatter, "", "", "Type:", term.Highlight("Excluded Files"))
@@ -392,6 +412,15 @@ func (*showCmd) showBuild(taskRunID int) {
 	mustWriteRow(formatter, "Total Input Digest:", term.Highlight(taskRun.TotalInputDigest))
 	mustWriteRow(formatter, "Output Count:", term.Highlight(len(outputs)))
 
+	if len(taskRun.Labels) > 0 {
+		mustWriteRow(formatter)
+		mustWriteRow(formatter, term.Underline("Labels:"))
+
+		for _, k := range slices.Sorted(maps.Keys(taskRun.Labels)) {
+			mustWriteRow(formatter, "", k+":", term.Highlight(taskRun.Labels[k]))
+		}
+	}
+
 	if len(outputs) > 0 {
 		mustWriteRow(formatter)
 		mustWriteRow(formatter, term.Underline("Outputs:"))
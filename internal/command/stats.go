@@ -0,0 +1,407 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/simplesurance/baur/v5/internal/command/flag"
+	"github.com/simplesurance/baur/v5/internal/command/term"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+const defStatsWindows = 4
+
+var statsLongHelp = fmt.Sprintf(`
+Show statistics about recorded task runs.
+
+For every task the following is shown:
+	Runs		number of recorded runs
+	Failure Rate	percentage of runs that failed
+	P50, P95, Max	duration percentiles and the maximum duration
+	Rebuilds	number of runs with a total input digest for which
+			a successful run had already been recorded,
+			the task was run although its result was available
+	Trend		median duration per time window, the time period
+			is split into %s equally long windows, windows
+			without runs are shown as '-'
+
+Arguments:
+	TARGET is specified as APP_NAME[.TASK_NAME], '*' can be passed as
+	APP_NAME or TASK_NAME to match all Apps or Tasks.
+	If no argument is passed, statistics for all tasks are shown.
+`,
+	term.Highlight("--windows"),
+)
+
+const statsExample = `
+baur stats					show statistics of all tasks
+baur stats --after=2024.01.01-00:00 calc	show statistics of the calc app
+						tasks for runs started after
+						2024.01.01
+baur stats --format=json '*.build'		show statistics of build tasks
+						in JSON format`
+
+func init() {
+	rootCmd.AddCommand(&newStatsCmd().Command)
+}
+
+type statsCmd struct {
+	cobra.Command
+
+	format  *flag.OneOf
+	after   flag.DateTimeFlagValue
+	before  flag.DateTimeFlagValue
+	windows uint
+	quiet   bool
+}
+
+func newStatsCmd() *statsCmd {
+	cmd := statsCmd{
+		Command: cobra.Command{
+			Use:     "stats [APP_NAME[.TASK_NAME]]",
+			Short:   "show statistics about task runs",
+			Long:    strings.TrimSpace(statsLongHelp),
+			Example: strings.TrimSpace(statsExample),
+			Args:    cobra.MaximumNArgs(1),
+			ValidArgsFunction: newCompleteTargetFunc(completeTargetFuncOpts{
+				withoutPaths:     true,
+				withoutWildcards: true,
+			}),
+		},
+		format: flag.NewFormatFlag(),
+	}
+
+	cmd.Run = cmd.run
+
+	cmd.Flags().Var(cmd.format, flag.FormatFlagName, cmd.format.Usage(term.Highlight))
+	_ = cmd.format.RegisterFlagCompletion(&cmd.Command)
+
+	cmd.Flags().BoolVarP(&cmd.quiet, "quiet", "q", false,
+		"suppress printing a header")
+
+	cmd.Flags().VarP(&cmd.after, "after", "a",
+		fmt.Sprintf("only include runs that were started after this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	cmd.Flags().VarP(&cmd.before, "before", "b",
+		fmt.Sprintf("only include runs that were started before this datetime.\nFormat: %s", term.Highlight(flag.DateTimeFormatDescr)))
+
+	cmd.Flags().UintVarP(&cmd.windows, "windows", "w", defStatsWindows,
+		"number of time windows the duration trend is split into")
+
+	return &cmd
+}
+
+func (c *statsCmd) run(_ *cobra.Command, args []string) {
+	var app, task string
+
+	if c.windows == 0 {
+		stderr.Printf("%s must be greater than 0\n", term.Highlight("--windows"))
+		exitFunc(exitCodeError)
+	}
+
+	if len(args) == 1 {
+		app, task = parseSpec(args[0])
+	}
+
+	repo := mustFindRepository()
+	storageClt := mustNewCompatibleStorageRepo(repo)
+	defer storageClt.Close()
+
+	var runs []*storage.TaskRunWithID
+	err := storageClt.TaskRuns(
+		ctx,
+		c.getFilters(app, task),
+		[]*storage.Sorter{{Field: storage.FieldStartTime, Order: storage.OrderAsc}},
+		storage.NoLimit,
+		func(taskRun *storage.TaskRunWithID) error {
+			runs = append(runs, taskRun)
+			return nil
+		},
+	)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			stderr.Println("no matching task runs exist")
+			exitFunc(exitCodeError)
+		}
+		exitOnErr(err)
+	}
+
+	stats := newTaskRunStats(runs, c.after.Time, c.before.Time, int(c.windows))
+
+	formatter := mustNewFormatter(c.format.Val, c.createHeader())
+	for _, s := range stats {
+		c.printStats(formatter, s)
+	}
+
+	exitOnErr(formatter.Flush())
+}
+
+func (c *statsCmd) getFilters(app, task string) []*storage.Filter {
+	var filters []*storage.Filter
+
+	if app != "" && app != "*" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldApplicationName,
+			Operator: storage.OpEQ,
+			Value:    app,
+		})
+	}
+
+	if task != "" && task != "*" {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldTaskName,
+			Operator: storage.OpEQ,
+			Value:    task,
+		})
+	}
+
+	if c.before != (flag.DateTimeFlagValue{}) {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpLT,
+			Value:    c.before.Time,
+		})
+	}
+
+	if c.after != (flag.DateTimeFlagValue{}) {
+		filters = append(filters, &storage.Filter{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpGT,
+			Value:    c.after.Time,
+		})
+	}
+
+	return filters
+}
+
+func (c *statsCmd) createHeader() []string {
+	if c.format.Val == flag.FormatJSON {
+		return []string{
+			"AppName",
+			"TaskName",
+			"Runs",
+			"FailureRate",
+			"P50DurationSeconds",
+			"P95DurationSeconds",
+			"MaxDurationSeconds",
+			"Rebuilds",
+			"TrendDurationSeconds",
+		}
+	}
+
+	if c.quiet {
+		return nil
+	}
+
+	return []string{
+		"App",
+		"Task",
+		"Runs",
+		"Failure Rate",
+		"P50",
+		"P95",
+		"Max",
+		"Rebuilds",
+		"Trend",
+	}
+}
+
+func (c *statsCmd) printStats(formatter Formatter, s *taskRunStats) {
+	if c.format.Val == flag.FormatJSON {
+		trend := make([]*float64, 0, len(s.Trend))
+		for _, d := range s.Trend {
+			if d < 0 {
+				trend = append(trend, nil)
+				continue
+			}
+			secs := d.Seconds()
+			trend = append(trend, &secs)
+		}
+
+		mustWriteRow(formatter,
+			s.AppName,
+			s.TaskName,
+			s.Runs,
+			s.FailureRate(),
+			s.P50.Seconds(),
+			s.P95.Seconds(),
+			s.Max.Seconds(),
+			s.Rebuilds,
+			trend,
+		)
+		return
+	}
+
+	withoutUnits := c.format.Val == flag.FormatCSV
+	fmtDuration := func(d time.Duration) string {
+		if d < 0 {
+			return "-"
+		}
+		return term.FormatDuration(d, term.FormatBaseWithoutUnitName(withoutUnits))
+	}
+
+	trend := make([]string, 0, len(s.Trend))
+	for _, d := range s.Trend {
+		trend = append(trend, fmtDuration(d))
+	}
+
+	mustWriteRow(formatter,
+		s.AppName,
+		s.TaskName,
+		strconv.Itoa(s.Runs),
+		fmt.Sprintf("%.1f%%", s.FailureRate()*100),
+		fmtDuration(s.P50),
+		fmtDuration(s.P95),
+		fmtDuration(s.Max),
+		strconv.Itoa(s.Rebuilds),
+		strings.Join(trend, " "),
+	)
+}
+
+// taskRunStats are statistics about the recorded runs of a single task.
+type taskRunStats struct {
+	AppName  string
+	TaskName string
+
+	Runs     int
+	Failures int
+	P50      time.Duration
+	P95      time.Duration
+	Max      time.Duration
+	// Rebuilds is the number of runs that had the same total input
+	// digest as an earlier successful run of the task.
+	Rebuilds int
+	// Trend contains the median duration of the runs per time window,
+	// it is -1 for windows without runs.
+	Trend []time.Duration
+}
+
+func (s *taskRunStats) FailureRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+
+	return float64(s.Failures) / float64(s.Runs)
+}
+
+// newTaskRunStats calculates the statistics per task of runs.
+// runs must be sorted ascending by their start time.
+// The period between from and to is split into windows equally long time
+// windows for the duration trend. If from or to are zero, the start time of
+// the first respectively last run is used instead.
+// The result is sorted by application and task name.
+func newTaskRunStats(runs []*storage.TaskRunWithID, from, to time.Time, windows int) []*taskRunStats {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	if from.IsZero() {
+		from = runs[0].StartTimestamp
+	}
+	if to.IsZero() {
+		to = runs[len(runs)-1].StartTimestamp
+	}
+
+	type taskRuns struct {
+		stats            *taskRunStats
+		durations        []time.Duration
+		windowDurations  [][]time.Duration
+		succeededDigests map[string]struct{}
+	}
+
+	tasks := map[string]*taskRuns{}
+	var result []*taskRunStats
+
+	for _, run := range runs {
+		key := run.ApplicationName + "." + run.TaskName
+		t, exists := tasks[key]
+		if !exists {
+			t = &taskRuns{
+				stats: &taskRunStats{
+					AppName:  run.ApplicationName,
+					TaskName: run.TaskName,
+				},
+				windowDurations:  make([][]time.Duration, windows),
+				succeededDigests: map[string]struct{}{},
+			}
+			tasks[key] = t
+			result = append(result, t.stats)
+		}
+
+		duration := run.StopTimestamp.Sub(run.StartTimestamp)
+
+		t.stats.Runs++
+		if run.Result != storage.ResultSuccess {
+			t.stats.Failures++
+		}
+
+		t.durations = append(t.durations, duration)
+
+		w := timeWindow(run.StartTimestamp, from, to, windows)
+		t.windowDurations[w] = append(t.windowDurations[w], duration)
+
+		if _, exists := t.succeededDigests[run.TotalInputDigest]; exists {
+			t.stats.Rebuilds++
+		} else if run.Result == storage.ResultSuccess {
+			t.succeededDigests[run.TotalInputDigest] = struct{}{}
+		}
+	}
+
+	for _, t := range tasks {
+		slices.Sort(t.durations)
+		t.stats.P50 = percentile(t.durations, 50)
+		t.stats.P95 = percentile(t.durations, 95)
+		t.stats.Max = t.durations[len(t.durations)-1]
+
+		t.stats.Trend = make([]time.Duration, 0, windows)
+		for _, d := range t.windowDurations {
+			if len(d) == 0 {
+				t.stats.Trend = append(t.stats.Trend, -1)
+				continue
+			}
+
+			slices.Sort(d)
+			t.stats.Trend = append(t.stats.Trend, percentile(d, 50))
+		}
+	}
+
+	slices.SortFunc(result, func(a, b *taskRunStats) int {
+		if c := strings.Compare(a.AppName, b.AppName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.TaskName, b.TaskName)
+	})
+
+	return result
+}
+
+// timeWindow returns the index of the time window t belongs to, when the
+// period between from and to is split into windows equally long windows.
+// Times outside of the period are assigned to the first or last window.
+func timeWindow(t, from, to time.Time, windows int) int {
+	period := to.Sub(from)
+	if period <= 0 {
+		return 0
+	}
+
+	w := int(float64(t.Sub(from)) / float64(period) * float64(windows))
+	return min(max(w, 0), windows-1)
+}
+
+// percentile returns the p-th percentile of the ascending sorted durations,
+// by the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/command/flag"
+	"github.com/simplesurance/baur/v5/internal/testutils/repotest"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func statsTestRun(app, task string, start time.Time, duration time.Duration, result storage.Result, digest string) *storage.TaskRunWithID {
+	return &storage.TaskRunWithID{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  app,
+			TaskName:         task,
+			StartTimestamp:   start,
+			StopTimestamp:    start.Add(duration),
+			Result:           result,
+			TotalInputDigest: digest,
+		},
+	}
+}
+
+func TestNewTaskRunStats(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	runs := []*storage.TaskRunWithID{
+		statsTestRun("calc", "check", start, time.Second, storage.ResultSuccess, "d1"),
+		statsTestRun("calc", "build", start, 10*time.Second, storage.ResultFailure, "d1"),
+		statsTestRun("calc", "build", start.Add(time.Hour), 20*time.Second, storage.ResultSuccess, "d1"),
+		statsTestRun("calc", "build", start.Add(2*time.Hour), 30*time.Second, storage.ResultSuccess, "d1"),
+		statsTestRun("calc", "build", start.Add(4*time.Hour), 40*time.Second, storage.ResultSuccess, "d2"),
+	}
+
+	stats := newTaskRunStats(runs, time.Time{}, time.Time{}, 4)
+	require.Len(t, stats, 2)
+
+	build := stats[0]
+	assert.Equal(t, "calc", build.AppName)
+	assert.Equal(t, "build", build.TaskName)
+	assert.Equal(t, 4, build.Runs)
+	assert.InDelta(t, 0.25, build.FailureRate(), 0.0001)
+	assert.Equal(t, 20*time.Second, build.P50)
+	assert.Equal(t, 40*time.Second, build.P95)
+	assert.Equal(t, 40*time.Second, build.Max)
+	assert.Equal(t, 1, build.Rebuilds)
+	assert.Equal(t,
+		[]time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 40 * time.Second},
+		build.Trend,
+	)
+
+	check := stats[1]
+	assert.Equal(t, "check", check.TaskName)
+	assert.Equal(t, 1, check.Runs)
+	assert.Zero(t, check.Rebuilds)
+	assert.Equal(t, []time.Duration{time.Second, -1, -1, -1}, check.Trend)
+}
+
+func TestNewTaskRunStatsWithoutRuns(t *testing.T) {
+	assert.Empty(t, newTaskRunStats(nil, time.Time{}, time.Time{}, 4))
+}
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	assert.Equal(t, time.Duration(5), percentile(durations, 50))
+	assert.Equal(t, time.Duration(10), percentile(durations, 95))
+	assert.Equal(t, time.Duration(1), percentile(durations, 0))
+	assert.Equal(t, time.Duration(7), percentile([]time.Duration{7}, 95))
+}
+
+func TestStatsCommand(t *testing.T) {
+	initTest(t)
+
+	r := repotest.CreateBaurRepository(t)
+	app := r.CreateSimpleApp(t)
+
+	t.Setenv(envVarPSQLURL, "sqlite://"+filepath.Join(t.TempDir(), "baur.db"))
+	initDb(initDbCmd, nil)
+
+	taskID := fmt.Sprintf("%s.%s", app.Name, app.Tasks[0].Name)
+
+	for range 2 {
+		runCmd := newRunCmd()
+		runCmd.force = true
+		runCmd.Run(&runCmd.Command, []string{taskID})
+	}
+
+	stdoutBuf, _ := interceptCmdOutput(t)
+	statsCmd := newStatsCmd()
+	statsCmd.format.Val = flag.FormatJSON
+	statsCmd.Run(&statsCmd.Command, []string{taskID})
+
+	var result []map[string]any
+	require.NoError(t, json.Unmarshal(stdoutBuf.Bytes(), &result), stdoutBuf.String())
+	require.Len(t, result, 1)
+	assert.Equal(t, app.Name, result[0]["AppName"])
+	assert.Equal(t, app.Tasks[0].Name, result[0]["TaskName"])
+	assert.EqualValues(t, 2, result[0]["Runs"])
+	assert.EqualValues(t, 0, result[0]["FailureRate"])
+	assert.EqualValues(t, 1, result[0]["Rebuilds"])
+	assert.Len(t, result[0]["TrendDurationSeconds"], defStatsWindows)
+
+	var cmdFailed bool
+	exitFunc = func(code int) {
+		cmdFailed = code != 0
+	}
+
+	statsCmd = newStatsCmd()
+	statsCmd.Run(&statsCmd.Command, []string{"doesnotexist"})
+	assert.True(t, cmdFailed)
+}
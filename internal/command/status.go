@@ -195,40 +195,53 @@ func (c *statusCmd) run(_ *cobra.Command, args []string) {
 
 	showProgress := len(tasks) >= 5 && !c.quiet && c.format.Val == flag.FormatPlain
 
-	statusMgr := baur.NewTaskStatusEvaluator(
-		repo.Path,
+	inputResolver := newInputResolver(
+		repo,
+		mustGetRepoState(repo.Path),
+		baur.AsInputStrings(c.inputStr...),
+		!c.requireCleanGitWorktree,
+	)
+	saveFileDigestCache := useFileDigestCache(repo, inputResolver)
+	defer saveFileDigestCache()
+
+	statusMgr := newTaskStatusEvaluator(
+		repo,
 		storageClt,
-		baur.NewInputResolver(
-			mustGetRepoState(repo.Path),
-			repo.Path,
-			baur.AsInputStrings(c.inputStr...),
-			!c.requireCleanGitWorktree,
-		),
+		inputResolver,
 		c.lookupInputStr,
 	)
 
 	baur.SortTasksByID(tasks)
 
+	var statuses []*baur.TaskStatusResult
+	if storageQueryNeeded {
+		var resolved int
+
+		statuses, err = statusMgr.Statuses(ctx, tasks, func(*baur.Task) {
+			// resolving the inputs of all tasks can take some
+			// time, output progress dots to let the user know
+			// that something is happening
+			if !showProgress {
+				return
+			}
+
+			resolved++
+			stdout.Printf(".")
+
+			if resolved == len(tasks) {
+				stdout.Printf("\n\n")
+			}
+		})
+		exitOnErr(err, "evaluating task status failed")
+	}
+
 	for i, task := range tasks {
 		var taskRun *storage.TaskRunWithID
 		var taskStatus baur.TaskStatus
 
 		if storageQueryNeeded {
-			var err error
-
-			taskStatus, _, taskRun, err = statusMgr.Status(ctx, task)
-			exitOnErrf(err, "%s: evaluating task status failed", task)
-
-			// querying the build status for all applications can
-			// take some time, output progress dots to let the user
-			// know that something is happening
-			if showProgress {
-				stdout.Printf(".")
-
-				if i+1 == len(tasks) {
-					stdout.Printf("\n\n")
-				}
-			}
+			taskStatus = statuses[i].Status
+			taskRun = statuses[i].TaskRun
 		}
 
 		if c.buildStatus.IsSet() && taskStatus != c.buildStatus.Status {
@@ -56,12 +56,25 @@ func (i *storageTaskInfo) Digest() (*digest.Digest, error) {
 	return digest.FromString(i.InputTaskInfo.Digest)
 }
 
+type storageInputCommandOutput struct {
+	*storage.InputCommandOutput
+}
+
+func (i *storageInputCommandOutput) String() string {
+	return "cmd: " + i.Command
+}
+
+func (i *storageInputCommandOutput) Digest() (*digest.Digest, error) {
+	return digest.FromString(i.InputCommandOutput.Digest)
+}
+
 func toBaurInputs(inputs *storage.Inputs) *baur.Inputs {
 	result := make([]baur.Input, 0,
 		len(inputs.Files)+
 			len(inputs.Strings)+
 			len(inputs.EnvironmentVariables)+
-			len(inputs.TaskInfo),
+			len(inputs.TaskInfo)+
+			len(inputs.CommandOutputs),
 	)
 
 	for _, in := range inputs.Files {
@@ -80,5 +93,9 @@ func toBaurInputs(inputs *storage.Inputs) *baur.Inputs {
 		result = append(result, &storageTaskInfo{InputTaskInfo: in})
 	}
 
+	for _, in := range inputs.CommandOutputs {
+		result = append(result, &storageInputCommandOutput{InputCommandOutput: in})
+	}
+
 	return baur.NewInputs(result)
 }
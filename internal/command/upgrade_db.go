@@ -21,7 +21,7 @@ This changes the database structure and makes the database incompatible with
 older baur version.
 It is not reversible.
 
-The Postgres URL is read from the repository configuration file.
+The database URL is read from the repository configuration file.
 Alternatively the URL can be passed as argument or
 by setting the '%s' environment variable.`,
 	term.Highlight(envVarPSQLURL))
@@ -37,7 +37,7 @@ type upgradeDbCmd struct {
 func newUpgradeDatabaseCmd() *upgradeDbCmd {
 	cmd := upgradeDbCmd{
 		Command: cobra.Command{
-			Use:               "db [POSTGRES-URL]",
+			Use:               "db [DATABASE-URL]",
 			Short:             "upgrade the database schema",
 			Long:              strings.TrimSpace(upgradeDbLongHelp),
 			Args:              cobra.MaximumNArgs(1),
@@ -60,7 +60,7 @@ func (*upgradeDbCmd) run(_ *cobra.Command, args []string) {
 		if err != nil {
 			if os.IsNotExist(err) {
 				stderr.Printf("could not find '%s' repository config file.\n"+
-					"Run '%s' first or pass the Postgres URL as argument.\n",
+					"Run '%s' first or pass the database URL as argument.\n",
 					term.Highlight(baur.RepositoryCfgFile), term.Highlight(cmdInitRepo))
 				exitFunc(exitCodeError)
 			}
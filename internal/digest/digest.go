@@ -18,6 +18,8 @@ const (
 	SHA384
 
 	GitObjectID
+	// XXH64 is the non-cryptographic XXH64 algorithm
+	XXH64
 )
 
 // String returns the textual representation
@@ -30,6 +32,8 @@ func (t Algorithm) String() string {
 		return "sha384"
 	case GitObjectID:
 		return "gitobjectid"
+	case XXH64:
+		return "xxh64"
 	default:
 		return "undefined"
 	}
@@ -67,6 +71,12 @@ func FromString(in string) (*Digest, error) {
 		}
 
 		algorithm = SHA384
+	case "xxh64":
+		if len(spl[1]) != 16 {
+			return nil, fmt.Errorf("hash length is %d, expected length 16", len(spl[1]))
+		}
+
+		algorithm = XXH64
 	default:
 		return nil, fmt.Errorf("unsupported format %q", a)
 	}
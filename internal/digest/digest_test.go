@@ -20,3 +20,20 @@ func TestFromString(t *testing.T) {
 		t.Errorf("String() returned %q expected %q", d.String(), shash)
 	}
 }
+
+func TestFromStringXXH64(t *testing.T) {
+	const shash = "xxh64:ef46db3751d8e999"
+
+	d, err := FromString(shash)
+	if err != nil {
+		t.Fatalf("parsing %q failed: %s", shash, err)
+	}
+
+	if d.Algorithm != XXH64 {
+		t.Errorf("wrong algorithm %q parsed, expected XXH64", d.Algorithm)
+	}
+
+	if d.String() != shash {
+		t.Errorf("String() returned %q expected %q", d.String(), shash)
+	}
+}
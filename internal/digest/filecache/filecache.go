@@ -0,0 +1,293 @@
+// Package filecache provides a persistent cache for digests of files.
+//
+// Cache entries are identified by the absolute path of a file and are only
+// used when the size, modification time, inode number and status change time
+// of the file did not change since the digest was calculated.
+// Digests that also depend on other files or settings, like git object IDs
+// that depend on git attributes, are not cached.
+package filecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/baur/v5/internal/digest"
+)
+
+// formatVersion is the version of the format of cache files. Cache files
+// with a different version are ignored.
+// Version 2: git object IDs are not cached anymore.
+const formatVersion = 2
+
+// racyInterval is the minimum duration between the modification time of a
+// file and the time its digest is calculated, to store the digest in the
+// cache.
+// File system timestamps can have a coarse granularity, when a file is
+// modified shortly after its digest was calculated, its modification time
+// might not change.
+const racyInterval = 2 * time.Second
+
+type PrintfFn func(format string, a ...any)
+
+// HashFn calculates the digest of the file at path.
+type HashFn func(path string) (*digest.Digest, error)
+
+type fileStat struct {
+	Size  int64  `json:"size"`
+	MTime int64  `json:"mtime"`
+	CTime int64  `json:"ctime"`
+	Inode uint64 `json:"inode"`
+}
+
+type entry struct {
+	fileStat
+	Algorithm digest.Algorithm `json:"algorithm"`
+	Sum       []byte           `json:"sum"`
+}
+
+type cacheFile struct {
+	Version int               `json:"version"`
+	Entries map[string]*entry `json:"entries"`
+}
+
+// Cache stores digests of files.
+// Changes are only persisted when Save is called.
+type Cache struct {
+	path      string
+	debugLogf PrintfFn
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// accessed contains the paths of the entries that were looked up
+	// since the cache was opened
+	accessed map[string]struct{}
+	modified bool
+}
+
+// DefaultPath returns the path of the cache file for the repository in
+// repoDir. The file is located in the user's cache directory
+// ($XDG_CACHE_HOME on Linux).
+func DefaultPath(repoDir string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(repoDir))
+
+	return filepath.Join(cacheDir, "baur", "file-digests", hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// Open loads the cache from the file at path.
+// If the file does not exist, is corrupted or was written in a different
+// format, an empty cache is returned.
+func Open(path string, debugLogf PrintfFn) (*Cache, error) {
+	c := Cache{
+		path:      path,
+		debugLogf: debugLogf,
+		entries:   map[string]*entry{},
+		accessed:  map[string]struct{}{},
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			debugLogf("filecache: %s does not exist, starting with an empty cache\n", path)
+			return &c, nil
+		}
+
+		return nil, err
+	}
+
+	var f cacheFile
+	if err := json.Unmarshal(content, &f); err != nil {
+		debugLogf("filecache: ignoring %s, parsing it failed: %s\n", path, err)
+		return &c, nil
+	}
+
+	if f.Version != formatVersion {
+		debugLogf("filecache: ignoring %s, it has format version %d, expecting %d\n", path, f.Version, formatVersion)
+		return &c, nil
+	}
+
+	if f.Entries != nil {
+		c.entries = f.Entries
+	}
+
+	debugLogf("filecache: loaded %d digests from %s\n", len(c.entries), path)
+
+	return &c, nil
+}
+
+// HashFn returns a function that returns the digest of a file from the
+// cache. If the cache does not contain an up-to-date digest of the file with
+// the given algorithm, it is calculated with hashFn and stored in the cache.
+// If algorithm is [digest.GitObjectID], hashFn is returned, git converts the
+// file content depending on git attributes and configuration, that are not
+// part of the cache entries.
+func (c *Cache) HashFn(algorithm digest.Algorithm, hashFn HashFn) HashFn {
+	if algorithm == digest.GitObjectID {
+		c.debugLogf("filecache: digests of algorithm %s are not cached\n", algorithm)
+		return hashFn
+	}
+
+	return func(path string) (*digest.Digest, error) {
+		return c.digest(path, algorithm, hashFn)
+	}
+}
+
+func (c *Cache) digest(path string, algorithm digest.Algorithm, hashFn HashFn) (*digest.Digest, error) {
+	before, err := stat(path)
+	if err != nil {
+		c.delete(path)
+		// hashFn returns a more meaningful error
+		return hashFn(path)
+	}
+
+	if d := c.get(path, algorithm, before); d != nil {
+		return d, nil
+	}
+
+	start := time.Now()
+
+	d, err := hashFn(path)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := stat(path)
+	if err != nil || *after != *before {
+		c.debugLogf("filecache: %s changed while calculating its digest, not caching it\n", path)
+		c.delete(path)
+		return d, nil
+	}
+
+	if time.Unix(0, before.MTime).After(start.Add(-racyInterval)) {
+		c.debugLogf("filecache: %s was modified recently, not caching its digest\n", path)
+		c.delete(path)
+		return d, nil
+	}
+
+	c.put(path, &entry{fileStat: *before, Algorithm: d.Algorithm, Sum: d.Sum})
+
+	return d, nil
+}
+
+func (c *Cache) get(path string, algorithm digest.Algorithm, st *fileStat) *digest.Digest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.accessed[path] = struct{}{}
+
+	e, exists := c.entries[path]
+	if !exists || e.Algorithm != algorithm || e.fileStat != *st {
+		return nil
+	}
+
+	return &digest.Digest{Algorithm: e.Algorithm, Sum: e.Sum}
+}
+
+func (c *Cache) put(path string, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[path] = e
+	c.modified = true
+}
+
+func (c *Cache) delete(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[path]; !exists {
+		return
+	}
+
+	delete(c.entries, path)
+	c.modified = true
+}
+
+// removeOutdated removes the entries of files that do not exist anymore or
+// whose stat information changed. Entries that were accessed since the cache
+// was opened are skipped, they are up to date.
+// The caller must hold c.mu.
+func (c *Cache) removeOutdated() {
+	var cnt int
+
+	for path, e := range c.entries {
+		if _, exists := c.accessed[path]; exists {
+			continue
+		}
+
+		st, err := stat(path)
+		if err == nil && *st == e.fileStat {
+			continue
+		}
+
+		delete(c.entries, path)
+		cnt++
+	}
+
+	if cnt > 0 {
+		c.modified = true
+		c.debugLogf("filecache: removed %d outdated digests\n", cnt)
+	}
+}
+
+// Save writes the cache to its file, if it was modified.
+// Before, entries of files that were deleted or changed are removed.
+// The file is replaced atomically. When multiple processes save the same
+// cache concurrently, the changes of the last one are persisted.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeOutdated()
+
+	if !c.modified {
+		return nil
+	}
+
+	content, err := json.Marshal(&cacheFile{Version: formatVersion, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("writing %s failed: %w", f.Name(), err)
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if err := os.Rename(f.Name(), c.path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	c.modified = false
+	c.debugLogf("filecache: stored %d digests in %s\n", len(c.entries), c.path)
+
+	return nil
+}
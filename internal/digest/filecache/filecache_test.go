@@ -0,0 +1,248 @@
+package filecache
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/digest"
+	"github.com/simplesurance/baur/v5/internal/digest/sha384"
+)
+
+type countingHasher struct {
+	calls int
+}
+
+func (h *countingHasher) File(path string) (*digest.Digest, error) {
+	h.calls++
+	return sha384.File(path)
+}
+
+// writeOldFile writes content to path and sets its modification time to a
+// point in time that is not within the racy interval.
+func writeOldFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	mtime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+func TestDigestIsReusedAfterSave(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "cache", "digests.json")
+	filePath := filepath.Join(dir, "file")
+	writeOldFile(t, filePath, "hello")
+
+	expected, err := sha384.File(filePath)
+	require.NoError(t, err)
+
+	hasher := countingHasher{}
+
+	cache, err := Open(cachePath, t.Logf)
+	require.NoError(t, err)
+	hashFn := cache.HashFn(digest.SHA384, hasher.File)
+
+	for range 2 {
+		d, err := hashFn(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, expected, d)
+	}
+	assert.Equal(t, 1, hasher.calls)
+
+	require.NoError(t, cache.Save())
+
+	cache, err = Open(cachePath, t.Logf)
+	require.NoError(t, err)
+	hashFn = cache.HashFn(digest.SHA384, hasher.File)
+
+	d, err := hashFn(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, d)
+	assert.Equal(t, 1, hasher.calls)
+}
+
+func TestOutdatedEntriesAreRemovedOnSave(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "digests.json")
+	deleted := filepath.Join(dir, "deleted")
+	changed := filepath.Join(dir, "changed")
+	unchanged := filepath.Join(dir, "unchanged")
+	writeOldFile(t, deleted, "1")
+	writeOldFile(t, changed, "2")
+	writeOldFile(t, unchanged, "3")
+
+	cache, err := Open(cachePath, t.Logf)
+	require.NoError(t, err)
+	hashFn := cache.HashFn(digest.SHA384, sha384.File)
+
+	for _, p := range []string{deleted, changed, unchanged} {
+		_, err := hashFn(p)
+		require.NoError(t, err)
+	}
+	require.NoError(t, cache.Save())
+
+	require.NoError(t, os.Remove(deleted))
+	writeOldFile(t, changed, "changed")
+
+	cache, err = Open(cachePath, t.Logf)
+	require.NoError(t, err)
+	require.NoError(t, cache.Save())
+
+	cache, err = Open(cachePath, t.Logf)
+	require.NoError(t, err)
+	assert.Len(t, cache.entries, 1)
+	assert.Contains(t, cache.entries, unchanged)
+}
+
+func TestChangedFileIsRehashed(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	writeOldFile(t, filePath, "hello")
+
+	hasher := countingHasher{}
+
+	cache, err := Open(filepath.Join(dir, "digests.json"), t.Logf)
+	require.NoError(t, err)
+	hashFn := cache.HashFn(digest.SHA384, hasher.File)
+
+	_, err = hashFn(filePath)
+	require.NoError(t, err)
+
+	writeOldFile(t, filePath, "hello world")
+	expected, err := sha384.File(filePath)
+	require.NoError(t, err)
+
+	d, err := hashFn(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, d)
+	assert.Equal(t, 2, hasher.calls)
+}
+
+func TestFileWithSameSizeAndMtimeIsRehashed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("status change time is not supported on windows")
+	}
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	writeOldFile(t, filePath, "hello")
+
+	fi, err := os.Stat(filePath)
+	require.NoError(t, err)
+
+	hasher := countingHasher{}
+
+	cache, err := Open(filepath.Join(dir, "digests.json"), t.Logf)
+	require.NoError(t, err)
+	hashFn := cache.HashFn(digest.SHA384, hasher.File)
+
+	_, err = hashFn(filePath)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filePath, []byte("world"), 0o644))
+	require.NoError(t, os.Chtimes(filePath, fi.ModTime(), fi.ModTime()))
+	expected, err := sha384.File(filePath)
+	require.NoError(t, err)
+
+	d, err := hashFn(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, expected, d)
+	assert.Equal(t, 2, hasher.calls)
+}
+
+func TestRecentlyModifiedFileIsNotCached(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(filePath, []byte("hello"), 0o644))
+
+	hasher := countingHasher{}
+
+	cache, err := Open(filepath.Join(dir, "digests.json"), t.Logf)
+	require.NoError(t, err)
+	hashFn := cache.HashFn(digest.SHA384, hasher.File)
+
+	for range 2 {
+		_, err := hashFn(filePath)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, hasher.calls)
+}
+
+func TestDigestOfOtherAlgorithmIsNotUsed(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	writeOldFile(t, filePath, "hello")
+
+	hasher := countingHasher{}
+
+	cache, err := Open(filepath.Join(dir, "digests.json"), t.Logf)
+	require.NoError(t, err)
+
+	_, err = cache.HashFn(digest.SHA384, hasher.File)(filePath)
+	require.NoError(t, err)
+
+	_, err = cache.HashFn(digest.SHA256, hasher.File)(filePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, hasher.calls)
+}
+
+func TestGitObjectIDsAreNotCached(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "file")
+	writeOldFile(t, filePath, "hello")
+
+	hasher := countingHasher{}
+
+	cache, err := Open(filepath.Join(dir, "digests.json"), t.Logf)
+	require.NoError(t, err)
+
+	hashFn := cache.HashFn(digest.GitObjectID, hasher.File)
+	for range 2 {
+		_, err = hashFn(filePath)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, hasher.calls)
+	assert.Empty(t, cache.entries)
+}
+
+func TestCorruptedCacheFileIsIgnored(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "digests.json")
+	filePath := filepath.Join(dir, "file")
+	writeOldFile(t, filePath, "hello")
+
+	require.NoError(t, os.WriteFile(cachePath, []byte(`{"version":1,"entries":`), 0o644))
+
+	hasher := countingHasher{}
+
+	cache, err := Open(cachePath, t.Logf)
+	require.NoError(t, err)
+
+	_, err = cache.HashFn(digest.SHA384, hasher.File)(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, hasher.calls)
+
+	require.NoError(t, cache.Save())
+
+	cache, err = Open(cachePath, t.Logf)
+	require.NoError(t, err)
+	_, err = cache.HashFn(digest.SHA384, hasher.File)(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, hasher.calls)
+}
+
+func TestNonExistingFileFails(t *testing.T) {
+	cache, err := Open(filepath.Join(t.TempDir(), "digests.json"), t.Logf)
+	require.NoError(t, err)
+
+	_, err = cache.HashFn(digest.SHA384, sha384.File)(filepath.Join(t.TempDir(), "missing"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
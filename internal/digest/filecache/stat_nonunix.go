@@ -0,0 +1,21 @@
+//go:build !unix
+
+package filecache
+
+import (
+	"os"
+)
+
+// stat returns the size and modification time of the file at path, the inode
+// number and status change time are not available and always 0.
+func stat(path string) (*fileStat, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileStat{
+		Size:  fi.Size(),
+		MTime: fi.ModTime().UnixNano(),
+	}, nil
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package filecache
+
+import (
+	"io/fs"
+
+	"golang.org/x/sys/unix"
+)
+
+func stat(path string) (*fileStat, error) {
+	var st unix.Stat_t
+
+	if err := unix.Stat(path, &st); err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: err}
+	}
+
+	return &fileStat{
+		Size:  st.Size,
+		MTime: st.Mtim.Nano(),
+		CTime: st.Ctim.Nano(),
+		Inode: uint64(st.Ino), //nolint:unconvert // the type differs between platforms
+	}, nil
+}
@@ -12,13 +12,13 @@ type PrintfFn func(format string, a ...any)
 // Hash calculates git object IDs for files.
 // Object IDs are read the first time a digest is requested from the git repository.
 type Hash struct {
-	repoDir   string
+	objectIDs *git.ObjectIDs
 	debugLogf PrintfFn
 }
 
 func New(gitRepositoryDir string, debugLogf PrintfFn) *Hash {
 	return &Hash{
-		repoDir:   gitRepositoryDir,
+		objectIDs: git.NewObjectIDs(gitRepositoryDir, git.PrintfFn(debugLogf)),
 		debugLogf: debugLogf,
 	}
 }
@@ -26,7 +26,7 @@ func New(gitRepositoryDir string, debugLogf PrintfFn) *Hash {
 // File calculcates the git object ID for the file at absPath.
 func (h *Hash) File(absPath string) (*digest.Digest, error) {
 	h.debugLogf("gitobjectid: object id not in cache, calculating ID for %q", absPath)
-	objectID, err := git.ObjectID(context.TODO(), absPath, h.repoDir)
+	objectID, err := h.objectIDs.Get(context.TODO(), absPath)
 	if err != nil {
 		return nil, err
 	}
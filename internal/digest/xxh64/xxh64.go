@@ -0,0 +1,33 @@
+package xxh64
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/simplesurance/baur/v5/internal/digest"
+)
+
+// File returns the XXH64 digest of the file at path.
+// XXH64 is not a cryptographic hash function, it is significantly faster
+// than SHA-2 functions.
+func File(path string) (*digest.Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening file failed: %w", err)
+	}
+
+	defer f.Close()
+
+	h := xxhash.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("reading file failed: %w", err)
+	}
+
+	return &digest.Digest{
+		Algorithm: digest.XXH64,
+		Sum:       h.Sum(nil),
+	}, nil
+}
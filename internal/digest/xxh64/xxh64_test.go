@@ -0,0 +1,25 @@
+package xxh64
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	const expectedDigest = "xxh64:ef46db3751d8e999"
+
+	path := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := File(path)
+	if err != nil {
+		t.Fatal("calculating digest failed:", err)
+	}
+
+	if d.String() != expectedDigest {
+		t.Errorf("digest is %q expected %q", d.String(), expectedDigest)
+	}
+}
@@ -42,6 +42,7 @@ type Cmd struct {
 	stderr io.Writer
 
 	maxStoredErrBytesPerStream int
+	maxStoredOutputBytes       int
 
 	expectSuccess bool
 
@@ -76,6 +77,14 @@ func (c *Cmd) Stderr(w io.Writer) *Cmd {
 	return c
 }
 
+// StoreOutput records the combined stdout and stderr output of the command.
+// The first and last maxBytes/2 bytes of the output are retained, it can
+// be retrieved via Result.Output().
+func (c *Cmd) StoreOutput(maxBytes int) *Cmd {
+	c.maxStoredOutputBytes = maxBytes
+	return c
+}
+
 // LogFn sets a Printf-style function as logger.
 func (c *Cmd) LogFn(fn PrintfFn) *Cmd {
 	c.logFn = fn
@@ -208,11 +217,18 @@ func (c *Cmd) Run(ctx context.Context) (*Result, error) {
 	}
 
 	// if a write to one of the writers of the MultiWriter fails, the command will fail
+	var outputSaver *syncWriter
+	if c.maxStoredOutputBytes > 0 {
+		// stdout and stderr are written concurrently by different
+		// goroutines
+		outputSaver = &syncWriter{w: &prefixSuffixSaver{N: c.maxStoredOutputBytes / 2}}
+	}
+
 	stdoutPss := prefixSuffixSaver{N: c.maxStoredErrBytesPerStream}
-	cmd.Stdout = newMultiWriter(&stdoutPss, c.stdout, stdoutLogWriter)
+	cmd.Stdout = newMultiWriter(&stdoutPss, c.stdout, stdoutLogWriter, outputSaver.writer())
 
 	stderrPss := prefixSuffixSaver{N: c.maxStoredErrBytesPerStream}
-	cmd.Stderr = newMultiWriter(&stderrPss, c.stderr, stderrLogWriter)
+	cmd.Stderr = newMultiWriter(&stderrPss, c.stderr, stderrLogWriter, outputSaver.writer())
 
 	// lock to thread because of:
 	// https://github.com/golang/go/issues/27505#issuecomment-713706104
@@ -250,6 +266,9 @@ func (c *Cmd) Run(ctx context.Context) (*Result, error) {
 		stderr:   &stderrPss,
 		ee:       ee,
 	}
+	if outputSaver != nil {
+		result.output = outputSaver.w
+	}
 	c.logf("command terminated with exit code: %d\n", result.ExitCode)
 
 	if c.expectSuccess && !result.Success {
@@ -56,6 +56,41 @@ func TestLongStdoutOutputIsTruncated(t *testing.T) {
 	assert.LessOrEqual(t, len(res.stdout.Bytes()), 2*maxErrOutputBytesPerStream+1024)
 }
 
+func TestStoreOutput(t *testing.T) {
+	ctx := t.Context()
+
+	res, err := Command("sh", "-c", "echo -n out; sleep 0.1; echo -n err 1>&2").
+		StoreOutput(1024).
+		Run(ctx)
+	require.NoError(t, err)
+	require.NoError(t, res.ExpectSuccess())
+
+	assert.Equal(t, "outerr", string(res.Output()))
+}
+
+func TestStoreOutputIsTruncated(t *testing.T) {
+	const maxBytes = 1024
+	ctx := t.Context()
+
+	res, err := Command("sh", "-c", "echo -n start; head -c 100000 /dev/zero; echo -n end").
+		StoreOutput(maxBytes).
+		Run(ctx)
+	require.NoError(t, err)
+	require.NoError(t, res.ExpectSuccess())
+
+	out := res.Output()
+	assert.True(t, bytes.HasPrefix(out, []byte("start")))
+	assert.True(t, bytes.HasSuffix(out, []byte("end")))
+	assert.Contains(t, string(out), "omitting")
+	assert.LessOrEqual(t, len(out), maxBytes+100)
+}
+
+func TestOutputIsNilWhenNotStored(t *testing.T) {
+	res, err := Command("echo", "hello").Run(t.Context())
+	require.NoError(t, err)
+	assert.Nil(t, res.Output())
+}
+
 func TestCancellingRuningCommand(t *testing.T) {
 	ctx, cancelFn := context.WithTimeout(t.Context(), time.Second)
 	t.Cleanup(cancelFn)
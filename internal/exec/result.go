@@ -70,6 +70,20 @@ type Result struct {
 
 	stdout *prefixSuffixSaver
 	stderr *prefixSuffixSaver
+	output *prefixSuffixSaver
+}
+
+// Output returns the combined stdout and stderr output of the command.
+// If the output exceeded the limit, the middle part is replaced by a
+// message stating the number of omitted bytes.
+// If storing the output was not enabled via Cmd.StoreOutput, nil is
+// returned.
+func (r *Result) Output() []byte {
+	if r.output == nil {
+		return nil
+	}
+
+	return r.output.Bytes()
 }
 
 // ExpectSuccess the command did not execute successful
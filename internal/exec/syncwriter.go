@@ -0,0 +1,28 @@
+package exec
+
+import (
+	"io"
+	"sync"
+)
+
+// syncWriter serializes writes to a prefixSuffixSaver.
+type syncWriter struct {
+	mu sync.Mutex
+	w  *prefixSuffixSaver
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+// writer returns s as io.Writer, if s is nil, nil is returned.
+func (s *syncWriter) writer() io.Writer {
+	if s == nil {
+		return nil
+	}
+
+	return s
+}
@@ -0,0 +1,448 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/docker/docker/api/types/registry"
+)
+
+const (
+	// defaultRegistryAPIURL is the URL of the registry API of
+	// [DefaultRegistry].
+	defaultRegistryAPIURL = "https://registry-1.docker.io"
+	// maxErrBodyLen is the max. number of bytes of a response body that
+	// are included in error messages.
+	maxErrBodyLen = 512
+)
+
+// manifestMediaTypes are the media types of image manifests that are
+// accepted when the digest of a tag is resolved.
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+var errNotFound = errors.New("not found")
+
+// ParseURI splits an image URI, in the format that is returned by
+// [Client.Upload], into its registry address, repository and tag.
+// If the URI does not contain a registry address, [DefaultRegistry] is
+// returned as registry address.
+func ParseURI(uri string) (registryAddr, repository, tag string, err error) {
+	var ref string
+
+	if after, ok := strings.CutPrefix(uri, DefaultRegistry); ok {
+		registryAddr = DefaultRegistry
+		ref = strings.TrimPrefix(after, "/")
+	} else {
+		var scheme string
+
+		ref = uri
+		if idx := strings.Index(ref, "://"); idx != -1 {
+			scheme = ref[:idx+3]
+			ref = ref[idx+3:]
+		}
+
+		host, path, found := strings.Cut(ref, "/")
+		if found && (scheme != "" || strings.ContainsAny(host, ".:") || host == "localhost") {
+			registryAddr = scheme + host
+			ref = path
+		} else {
+			registryAddr = DefaultRegistry
+		}
+	}
+
+	idx := strings.LastIndex(ref, ":")
+	if idx == -1 || strings.Contains(ref[idx:], "/") {
+		return "", "", "", fmt.Errorf("%q does not contain a tag", uri)
+	}
+
+	repository = ref[:idx]
+	tag = ref[idx+1:]
+
+	if repository == "" {
+		return "", "", "", fmt.Errorf("%q does not contain a repository", uri)
+	}
+	if tag == "" {
+		return "", "", "", fmt.Errorf("%q contains an empty tag", uri)
+	}
+
+	return registryAddr, repository, tag, nil
+}
+
+// registryAPIURL returns the base URL of the registry HTTP API for an
+// address returned by [ParseURI] and the repository name that is used in
+// API requests.
+func registryAPIURL(registryAddr, repository string) (apiURL, repo string) {
+	if registryAddr == DefaultRegistry {
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+
+		return defaultRegistryAPIURL, repository
+	}
+
+	if strings.Contains(registryAddr, "://") {
+		return strings.TrimSuffix(registryAddr, "/"), repository
+	}
+
+	return "https://" + registryAddr, repository
+}
+
+// Delete deletes the image with the given URI from its registry.
+// The URI must be in the format that is returned by [Client.Upload].
+//
+// The registry API only supports deleting manifests by their digest, the tag
+// is resolved to its manifest digest and the manifest is deleted.
+// This removes all tags in the repository that reference the same manifest.
+// The registry must have deletion enabled, its garbage collector removes the
+// unreferenced image layers.
+// Deleting an image that does not exist is not an error.
+func (c *Client) Delete(ctx context.Context, uri string) error {
+	registryAddr, repository, tag, err := ParseURI(uri)
+	if err != nil {
+		return err
+	}
+
+	apiURL, repository := registryAPIURL(registryAddr, repository)
+	clt := registryClient{
+		httpClt:    c.httpClient(),
+		apiURL:     apiURL,
+		auth:       c.getAuth(registryAddr),
+		debugLogFn: c.debugLogFn,
+	}
+
+	digest, err := clt.manifestDigest(ctx, repository, tag)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			c.debugLogFn("docker: image %q does not exist, nothing to delete", uri)
+			return nil
+		}
+
+		return fmt.Errorf("resolving manifest digest of %q failed: %w", uri, err)
+	}
+
+	c.debugLogFn("docker: deleting manifest %q of image %q", digest, uri)
+
+	err = clt.deleteManifest(ctx, repository, digest)
+	if err != nil {
+		if errors.Is(err, errNotFound) {
+			c.debugLogFn("docker: manifest %q of image %q does not exist, nothing to delete", digest, uri)
+			return nil
+		}
+
+		return fmt.Errorf("deleting manifest %q of %q failed: %w", digest, uri, err)
+	}
+
+	return nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.httpClt != nil {
+		return c.httpClt
+	}
+
+	return http.DefaultClient
+}
+
+// registryClient is a minimal client for the docker registry HTTP API V2.
+// It authenticates via basic auth or via bearer tokens that are requested
+// from the authorization server that the registry announces.
+type registryClient struct {
+	httpClt    *http.Client
+	apiURL     string
+	auth       registry.AuthConfig
+	debugLogFn func(string, ...any)
+
+	bearerToken string
+}
+
+func (r *registryClient) manifestDigest(ctx context.Context, repository, tag string) (string, error) {
+	resp, err := r.do(ctx, http.MethodHead, "/v2/"+repository+"/manifests/"+tag, func(req *http.Request) {
+		req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", errors.New("registry response contains no Docker-Content-Digest header")
+	}
+
+	return digest, nil
+}
+
+func (r *registryClient) deleteManifest(ctx context.Context, repository, digest string) error {
+	resp, err := r.do(ctx, http.MethodDelete, "/v2/"+repository+"/manifests/"+digest, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusMethodNotAllowed {
+		return errors.New("registry does not allow deleting images")
+	}
+
+	return checkStatus(resp, http.StatusAccepted, http.StatusOK)
+}
+
+// do sends a request to the registry.
+// If the registry responds with 401 Unauthorized, the client authenticates
+// according to the WWW-Authenticate challenge and retries the request once.
+func (r *registryClient) do(ctx context.Context, method, path string, prepare func(*http.Request)) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, r.apiURL+path, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if prepare != nil {
+			prepare(req)
+		}
+
+		r.setAuthHeader(req)
+
+		return r.httpClt.Do(req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	if err := r.authenticate(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+
+	return send()
+}
+
+func (r *registryClient) setAuthHeader(req *http.Request) {
+	if r.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+r.bearerToken)
+		return
+	}
+
+	if user, passwd := r.credentials(); user != "" {
+		req.SetBasicAuth(user, passwd)
+	}
+}
+
+// credentials returns the username and password of the auth configuration.
+func (r *registryClient) credentials() (user, passwd string) {
+	if r.auth.Username != "" {
+		return r.auth.Username, r.auth.Password
+	}
+
+	if r.auth.Auth == "" {
+		return "", ""
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(r.auth.Auth)
+	if err != nil {
+		r.debugLogFn("docker: decoding auth data failed: %s", err)
+		return "", ""
+	}
+
+	user, passwd, _ = strings.Cut(string(decoded), ":")
+	return user, passwd
+}
+
+// authenticate prepares the client to authenticate according to the
+// WWW-Authenticate challenge of a registry response.
+func (r *registryClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if user, _ := r.credentials(); user == "" {
+			return errors.New("registry requires basic authentication but no credentials are configured")
+		}
+
+		if r.bearerToken == "" {
+			return errors.New("registry rejected the credentials")
+		}
+
+		// the registry prefers basic auth, drop the token
+		r.bearerToken = ""
+		return nil
+
+	case "bearer":
+		// tokens are scoped to the actions of the request, a new one is
+		// requested for every challenge
+		if r.auth.RegistryToken != "" {
+			if r.bearerToken == r.auth.RegistryToken {
+				return errors.New("registry rejected the configured registry token")
+			}
+
+			r.bearerToken = r.auth.RegistryToken
+			return nil
+		}
+
+		token, err := r.fetchToken(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		r.bearerToken = token
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported authentication challenge: %q", challenge)
+	}
+}
+
+// fetchToken requests a bearer token from the authorization server
+// specified in the challenge params.
+func (r *registryClient) fetchToken(ctx context.Context, params map[string]string) (string, error) {
+	realm := params["realm"]
+	if realm == "" {
+		return "", errors.New("bearer challenge has no realm")
+	}
+
+	var req *http.Request
+	var err error
+
+	if r.auth.IdentityToken != "" {
+		form := url.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", r.auth.IdentityToken)
+		form.Set("service", params["service"])
+		form.Set("scope", params["scope"])
+		form.Set("client_id", "baur")
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, realm, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		tokenURL, err := url.Parse(realm)
+		if err != nil {
+			return "", fmt.Errorf("parsing realm %q failed: %w", realm, err)
+		}
+
+		q := tokenURL.Query()
+		for _, k := range []string{"service", "scope"} {
+			if v := params[k]; v != "" {
+				q.Set(k, v)
+			}
+		}
+		tokenURL.RawQuery = q.Encode()
+
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return "", err
+		}
+
+		if user, passwd := r.credentials(); user != "" {
+			req.SetBasicAuth(user, passwd)
+		}
+	}
+
+	r.debugLogFn("docker: requesting bearer token from %q", realm)
+
+	resp, err := r.httpClt.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := checkStatus(resp, http.StatusOK); err != nil {
+		return "", fmt.Errorf("requesting token failed: %w", err)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("decoding token response failed: %w", err)
+	}
+
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+
+	if tokenResp.AccessToken != "" {
+		return tokenResp.AccessToken, nil
+	}
+
+	return "", errors.New("token response contains no token")
+}
+
+// parseChallenge parses the value of a WWW-Authenticate header, e.g.:
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	params = map[string]string{}
+
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+
+	for rest != "" {
+		var key, val string
+		var found bool
+
+		rest = strings.TrimLeft(rest, " ,")
+
+		key, rest, found = strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+
+		if strings.HasPrefix(rest, `"`) {
+			val, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			val, rest, _ = strings.Cut(rest, ",")
+		}
+
+		params[strings.ToLower(strings.TrimSpace(key))] = val
+	}
+
+	return scheme, params
+}
+
+// checkStatus returns an error if the status code of resp is not one of
+// expected.
+// If the status is 404 Not Found, an error wrapping errNotFound is returned.
+func checkStatus(resp *http.Response, expected ...int) error {
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return nil
+		}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrBodyLen))
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s %s: %w", resp.Request.Method, resp.Request.URL, errNotFound)
+	}
+
+	return fmt.Errorf("%s %s: registry responded with %s: %s",
+		resp.Request.Method, resp.Request.URL, resp.Status, strings.TrimSpace(string(body)),
+	)
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 
 	cerrdefs "github.com/containerd/errdefs"
@@ -29,6 +30,9 @@ type Client struct {
 	clt        *client.Client
 	auths      map[string]registry.AuthConfig
 	debugLogFn func(string, ...any)
+	// httpClt is used for requests to the registry API, if it is nil
+	// [http.DefaultClient] is used.
+	httpClt *http.Client
 }
 
 var defLogFn = func(string, ...any) {}
@@ -1,8 +1,13 @@
 package docker
 
 import (
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/docker/docker/api/types/registry"
@@ -120,3 +125,200 @@ func TestGetAuth(t *testing.T) {
 		assert.Equal(t, myRegistryUser, auth.Username)
 	})
 }
+
+func TestParseURI(t *testing.T) {
+	tcs := []struct {
+		uri                  string
+		expectedRegistryAddr string
+		expectedRepository   string
+		expectedTag          string
+	}{
+		{
+			uri:                  DefaultRegistry + "/myapp:v1",
+			expectedRegistryAddr: DefaultRegistry,
+			expectedRepository:   "myapp",
+			expectedTag:          "v1",
+		},
+		{
+			uri:                  "myregistry.com:5000/team/myapp:1.2.3",
+			expectedRegistryAddr: "myregistry.com:5000",
+			expectedRepository:   "team/myapp",
+			expectedTag:          "1.2.3",
+		},
+		{
+			uri:                  "https://myregistry.com/myapp:latest",
+			expectedRegistryAddr: "https://myregistry.com",
+			expectedRepository:   "myapp",
+			expectedTag:          "latest",
+		},
+		{
+			uri:                  "team/myapp:v1",
+			expectedRegistryAddr: DefaultRegistry,
+			expectedRepository:   "team/myapp",
+			expectedTag:          "v1",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.uri, func(t *testing.T) {
+			registryAddr, repository, tag, err := ParseURI(tc.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRegistryAddr, registryAddr)
+			assert.Equal(t, tc.expectedRepository, repository)
+			assert.Equal(t, tc.expectedTag, tag)
+		})
+	}
+
+	for _, uri := range []string{"myregistry.com:5000/myapp", "myregistry.com/myapp:", "myregistry.com/:v1"} {
+		t.Run(uri, func(t *testing.T) {
+			_, _, _, err := ParseURI(uri)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	const user = "hugo"
+	const passwd = "hello"
+	const digest = "sha256:4b6cbbd3a3c5c0a6a8e2a1d6c0f7e1f8f1a1b2c3d4e5f6a7b8c9d0e1f2a3b4c5"
+
+	var mu sync.Mutex
+	deleted := false
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	authorized := func(w http.ResponseWriter, r *http.Request, scope string) bool {
+		if r.Header.Get("Authorization") == "Bearer token-"+scope {
+			return true
+		}
+
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+			`Bearer realm="%s/token",service="registry",scope="%s"`, srv.URL, scope,
+		))
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return false
+	}
+
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, r *http.Request) {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != user || p != passwd {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		assert.Equal(t, "registry", r.URL.Query().Get("service"))
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"token": "token-" + r.URL.Query().Get("scope"),
+		})
+	})
+
+	mux.HandleFunc("HEAD /v2/myapp/manifests/v1", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r, "repository:myapp:pull") {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if deleted {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Docker-Content-Digest", digest)
+	})
+
+	mux.HandleFunc("DELETE /v2/myapp/manifests/"+digest, func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r, "repository:myapp:delete") {
+			return
+		}
+
+		mu.Lock()
+		deleted = true
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	client := &Client{
+		auths: map[string]registry.AuthConfig{
+			srv.URL: {Username: user, Password: passwd, ServerAddress: srv.URL},
+		},
+		debugLogFn: t.Logf,
+		httpClt:    srv.Client(),
+	}
+
+	uri := srv.URL + "/myapp:v1"
+
+	require.NoError(t, client.Delete(t.Context(), uri))
+	assert.True(t, deleted, "manifest was not deleted")
+
+	require.NoError(t, client.Delete(t.Context(), uri), "deleting a non-existing image failed")
+
+	client.auths[srv.URL] = registry.AuthConfig{Username: user, Password: "wrong", ServerAddress: srv.URL}
+	mu.Lock()
+	deleted = false
+	mu.Unlock()
+	require.Error(t, client.Delete(t.Context(), uri), "delete succeeded with wrong credentials")
+}
+
+func TestImageRef(t *testing.T) {
+	tcs := []struct {
+		uri                  string
+		expectedRef          string
+		expectedRegistryAddr string
+	}{
+		{
+			uri:                  DefaultRegistry + "/myapp:v1",
+			expectedRef:          "myapp:v1",
+			expectedRegistryAddr: DefaultRegistry,
+		},
+		{
+			uri:                  "myregistry.com:5000/team/myapp:1.2.3",
+			expectedRef:          "myregistry.com:5000/team/myapp:1.2.3",
+			expectedRegistryAddr: "myregistry.com:5000",
+		},
+		{
+			uri:                  "https://myregistry.com/myapp:latest",
+			expectedRef:          "myregistry.com/myapp:latest",
+			expectedRegistryAddr: "https://myregistry.com",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.uri, func(t *testing.T) {
+			ref, registryAddr, err := imageRef(tc.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRef, ref)
+			assert.Equal(t, tc.expectedRegistryAddr, registryAddr)
+		})
+	}
+}
+
+func TestReadProgressStream(t *testing.T) {
+	client := &Client{debugLogFn: t.Logf}
+
+	t.Run("success", func(t *testing.T) {
+		stream := `{"status":"Pulling from library/alpine","id":"3.20"}
+{"status":"Download complete","id":"abc"}
+{"status":"Status: Downloaded newer image for alpine:3.20"}
+`
+		require.NoError(t, client.readProgressStream(strings.NewReader(stream)))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		stream := `{"status":"Pulling from library/alpine","id":"3.20"}
+{"errorDetail":{"message":"manifest unknown"},"error":"manifest unknown"}
+`
+		err := client.readProgressStream(strings.NewReader(stream))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "manifest unknown")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		require.Error(t, client.readProgressStream(strings.NewReader(`{"status":"Pull`)))
+	})
+}
@@ -0,0 +1,143 @@
+package docker
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// imageRef converts an image URI, in the format that is returned by
+// [Client.Upload], to a reference that is accepted by the docker daemon.
+// It returns the reference and the registry address of the image.
+func imageRef(uri string) (ref, registryAddr string, err error) {
+	registryAddr, repository, tag, err := ParseURI(uri)
+	if err != nil {
+		return "", "", err
+	}
+
+	if registryAddr == DefaultRegistry {
+		return repository + ":" + tag, registryAddr, nil
+	}
+
+	host := registryAddr
+	if idx := strings.Index(host, "://"); idx != -1 {
+		host = host[idx+3:]
+	}
+
+	return strings.TrimSuffix(host, "/") + "/" + repository + ":" + tag, registryAddr, nil
+}
+
+// Pull pulls the image with the given URI from its registry.
+// The URI must be in the format that is returned by [Client.Upload].
+// It returns the reference of the pulled image.
+func (c *Client) Pull(ctx context.Context, uri string) (string, error) {
+	ref, registryAddr, err := imageRef(uri)
+	if err != nil {
+		return "", err
+	}
+
+	authBytes, err := json.Marshal(c.getAuth(registryAddr))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal auth config: %w", err)
+	}
+
+	c.debugLogFn("docker: pulling image %q", ref)
+	resp, err := c.clt.ImagePull(ctx, ref, image.PullOptions{
+		RegistryAuth: base64.URLEncoding.EncodeToString(authBytes),
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Close()
+
+	// the pull is only complete when the response has been read, errors
+	// that happen during the pull are reported in the response stream
+	if err := c.readProgressStream(resp); err != nil {
+		return "", fmt.Errorf("pulling image %q failed: %w", ref, err)
+	}
+
+	return ref, nil
+}
+
+// readProgressStream reads the JSON progress messages from r until EOF.
+// If a message reports an error, it is returned.
+func (c *Client) readProgressStream(r io.Reader) error {
+	dec := json.NewDecoder(r)
+
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if msg.Status != "" {
+			c.debugLogFn("docker: %s %s", msg.ID, msg.Status)
+		}
+	}
+}
+
+// Save pulls the image with the given URI from its registry and writes it as
+// tar archive to destFile.
+// If destFile exists, it is overwritten.
+// The archive is written to a temporary file in the directory of destFile
+// that is renamed to destFile when it was written successfully.
+func (c *Client) Save(ctx context.Context, uri, destFile string) error {
+	ref, err := c.Pull(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	c.debugLogFn("docker: saving image %q to %q", ref, destFile)
+	resp, err := c.clt.ImageSave(ctx, []string{ref})
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	f, err := os.CreateTemp(filepath.Dir(destFile), "."+filepath.Base(destFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file only readable by the owner
+	if err := f.Chmod(0o644); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	if _, err := io.Copy(f, resp); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("writing %s failed: %w", destFile, err)
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("writing %s failed: %w", destFile, err)
+	}
+
+	if err := os.Rename(f.Name(), destFile); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
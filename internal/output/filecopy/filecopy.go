@@ -105,3 +105,30 @@ func (c *Client) Upload(src, dst string) (string, error) {
 
 	return dst, copyFile(src, dst)
 }
+
+// Download copies the file at src, that has been created by [Client.Upload],
+// to dst.
+// If dst exists, it is overwritten.
+func (c *Client) Download(src, dst string) error {
+	c.debugLogFn("filecopy: copying '%s' to '%s'", src, dst)
+
+	return copyFile(src, dst)
+}
+
+// Delete removes the file at path.
+// Deleting a file that does not exist is not an error.
+func (c *Client) Delete(path string) error {
+	err := os.Remove(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.debugLogFn("filecopy: '%s' does not exist, nothing to delete", path)
+			return nil
+		}
+
+		return err
+	}
+
+	c.debugLogFn("filecopy: deleted '%s'", path)
+
+	return nil
+}
@@ -16,6 +16,7 @@ import (
 
 // Client downloads and uploads objects from/to S3.
 type Client struct {
+	clt        *s3.Client
 	uploader   *manager.Uploader
 	downloader *manager.Downloader
 }
@@ -54,6 +55,7 @@ func NewClient(ctx context.Context, logger Logger) (*Client, error) {
 	)
 
 	return &Client{
+		clt:        clt,
 		uploader:   manager.NewUploader(clt),
 		downloader: manager.NewDownloader(clt),
 	}, nil
@@ -108,6 +110,16 @@ func (c *Client) Download(ctx context.Context, bucket, key, filepath string) err
 	return nil
 }
 
+// Delete deletes an object from an s3 bucket.
+// Deleting an object that does not exist is not an error.
+func (c *Client) Delete(ctx context.Context, bucket, key string) error {
+	_, err := c.clt.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
 func ParseURL(u string) (bucket, key string, err error) {
 	url, err := url.Parse(u)
 	if err != nil {
@@ -9,7 +9,19 @@ import (
 // Resolver resolves a glob path to files. The functionality is the same then
 // filepath.Glob() with the addition that '**' is supported to match files
 // directories recursively.
-type Resolver struct{}
+type Resolver struct {
+	baurIgnore *IgnoreMatcher
+	gitIgnore  *IgnoreMatcher
+}
+
+// NewResolver returns a Resolver that can exclude paths that are ignored by
+// .baurignore and .gitignore files in rootDir and its subdirectories.
+func NewResolver(rootDir string) *Resolver {
+	return &Resolver{
+		baurIgnore: NewIgnoreMatcher(rootDir, BaurIgnoreFile),
+		gitIgnore:  NewIgnoreMatcher(rootDir, GitIgnoreFile, BaurIgnoreFile),
+	}
+}
 
 // Resolve resolves globPath to file paths.
 // Files are resolved in the same way then filepath.Glob() does, with 2 Exceptions:
@@ -43,3 +55,32 @@ func (r *Resolver) Matches(path string, patterns []string) (bool, string, error)
 
 	return false, "", nil
 }
+
+// WithoutIgnored returns paths without the ones that are excluded by
+// .baurignore files. If gitIgnored is true, paths that are excluded by
+// .gitignore files are omitted additionally.
+// If the Resolver was not created via NewResolver, paths is returned.
+func (r *Resolver) WithoutIgnored(paths []string, gitIgnored bool) ([]string, error) {
+	matcher := r.baurIgnore
+	if gitIgnored {
+		matcher = r.gitIgnore
+	}
+
+	if matcher == nil {
+		return paths, nil
+	}
+
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ignored, err := matcher.Ignored(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ignored {
+			result = append(result, path)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,223 @@
+package glob
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const (
+	// BaurIgnoreFile is the name of files that contain patterns of paths
+	// that are excluded from file inputs.
+	BaurIgnoreFile = ".baurignore"
+	// GitIgnoreFile is the name of git's ignore files.
+	GitIgnoreFile = ".gitignore"
+)
+
+type ignorePattern struct {
+	// dir is the directory of the ignore file containing the pattern.
+	dir     string
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+func (p *ignorePattern) matches(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	rel, err := filepath.Rel(p.dir, path)
+	if err != nil {
+		return false
+	}
+
+	// the pattern was validated when the ignore file was parsed
+	return doublestar.MatchUnvalidated(p.pattern, filepath.ToSlash(rel))
+}
+
+// IgnoreMatcher determines if paths are excluded by ignore files.
+// Ignore files use the .gitignore format. The patterns of an ignore file apply
+// to paths in the directory of the file and its subdirectories. Patterns of
+// ignore files in subdirectories take precedence over the ones in parent
+// directories.
+// Only ignore files in the root directory and its subdirectories are read.
+type IgnoreMatcher struct {
+	rootDir   string
+	fileNames []string
+
+	mu sync.Mutex
+	// patterns caches the patterns of the ignore files by directory.
+	patterns map[string][]*ignorePattern
+}
+
+// NewIgnoreMatcher returns an IgnoreMatcher that reads ignore files named
+// fileNames. If a directory contains multiple of them, patterns of
+// files that come later in fileNames take precedence.
+func NewIgnoreMatcher(rootDir string, fileNames ...string) *IgnoreMatcher {
+	return &IgnoreMatcher{
+		rootDir:   rootDir,
+		fileNames: fileNames,
+		patterns:  map[string][]*ignorePattern{},
+	}
+}
+
+// Ignored returns true if path is excluded by an ignore file.
+// Paths outside of the root directory are never excluded.
+// Like in git, a file can not be re-included if one of its parent
+// directories is excluded.
+func (m *IgnoreMatcher) Ignored(path string) (bool, error) {
+	rel, err := filepath.Rel(m.rootDir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, nil
+	}
+
+	var patterns []*ignorePattern
+	dir := m.rootDir
+	components := strings.Split(rel, string(filepath.Separator))
+
+	for i, c := range components {
+		dirPatterns, err := m.dirPatterns(dir)
+		if err != nil {
+			return false, err
+		}
+
+		patterns = append(patterns, dirPatterns...)
+
+		dir = filepath.Join(dir, c)
+		if ignored(patterns, dir, i < len(components)-1) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ignored returns true if the last pattern in patterns that matches path is
+// not negated.
+func ignored(patterns []*ignorePattern, path string, isDir bool) bool {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if patterns[i].matches(path, isDir) {
+			return !patterns[i].negate
+		}
+	}
+
+	return false
+}
+
+func (m *IgnoreMatcher) dirPatterns(dir string) ([]*ignorePattern, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if patterns, exists := m.patterns[dir]; exists {
+		return patterns, nil
+	}
+
+	var result []*ignorePattern
+	for _, name := range m.fileNames {
+		patterns, err := parseIgnoreFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, patterns...)
+	}
+
+	m.patterns[dir] = result
+
+	return result, nil
+}
+
+// parseIgnoreFile parses the ignore file at path.
+// If the file does not exist, nil is returned.
+func parseIgnoreFile(path string) ([]*ignorePattern, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []*ignorePattern
+	dir := filepath.Dir(path)
+	sc := bufio.NewScanner(f)
+
+	for lineNr := 1; sc.Scan(); lineNr++ {
+		p, err := parseIgnorePattern(dir, sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNr, err)
+		}
+
+		if p != nil {
+			result = append(result, p)
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s failed: %w", path, err)
+	}
+
+	return result, nil
+}
+
+// parseIgnorePattern parses a line of an ignore file in the directory dir.
+// If the line does not contain a pattern, nil is returned.
+func parseIgnorePattern(dir, line string) (*ignorePattern, error) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimUnescapedTrailingSpaces(line)
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+
+	result := ignorePattern{dir: dir}
+
+	if strings.HasPrefix(line, "!") {
+		result.negate = true
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		result.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return nil, nil
+	}
+
+	// a pattern with a separator at the beginning or middle is relative
+	// to the directory of the ignore file, otherwise it matches at any
+	// level
+	if strings.HasPrefix(line, "/") {
+		line = line[1:]
+	} else if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+
+	if !doublestar.ValidatePattern(line) {
+		return nil, fmt.Errorf("invalid pattern %q", line)
+	}
+
+	result.pattern = line
+
+	return &result, nil
+}
+
+func trimUnescapedTrailingSpaces(s string) string {
+	trimmed := strings.TrimRight(s, " ")
+	if len(trimmed) < len(s) && strings.HasSuffix(trimmed, `\`) {
+		return trimmed + " "
+	}
+
+	return trimmed
+}
@@ -0,0 +1,119 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, GitIgnoreFile), `
+# comment
+*.log
+/build
+dist/
+!keep.log
+docs/*.html
+`)
+	writeFile(t, filepath.Join(root, "app", GitIgnoreFile), `
+!important.log
+generated/
+`)
+	writeFile(t, filepath.Join(root, "app", BaurIgnoreFile), `
+*.tmp
+`)
+
+	testcases := []struct {
+		path    string
+		ignored bool
+	}{
+		{path: "main.go", ignored: false},
+		{path: "debug.log", ignored: true},
+		{path: "app/debug.log", ignored: true},
+		{path: "keep.log", ignored: false},
+		{path: "app/important.log", ignored: false},
+		{path: "important.log", ignored: true},
+		{path: "build/out.bin", ignored: true},
+		{path: "app/build/out.bin", ignored: false},
+		{path: "dist", ignored: false},
+		{path: "dist/out.bin", ignored: true},
+		{path: "app/dist/out.bin", ignored: true},
+		{path: "docs/index.html", ignored: true},
+		{path: "docs/api/index.html", ignored: false},
+		{path: "app/generated/x.go", ignored: true},
+		{path: "generated/x.go", ignored: false},
+		{path: "app/x.tmp", ignored: true},
+		{path: "x.tmp", ignored: false},
+	}
+
+	m := NewIgnoreMatcher(root, GitIgnoreFile, BaurIgnoreFile)
+
+	for _, tc := range testcases {
+		t.Run(tc.path, func(t *testing.T) {
+			ignored, err := m.Ignored(filepath.Join(root, tc.path))
+			require.NoError(t, err)
+			assert.Equal(t, tc.ignored, ignored)
+		})
+	}
+
+	t.Run("outside root dir", func(t *testing.T) {
+		ignored, err := m.Ignored(filepath.Join(filepath.Dir(root), "debug.log"))
+		require.NoError(t, err)
+		assert.False(t, ignored)
+	})
+}
+
+func TestParentDirCanNotBeReincluded(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, GitIgnoreFile), "build/\n!build/keep.txt\n")
+
+	ignored, err := NewIgnoreMatcher(root, GitIgnoreFile).Ignored(filepath.Join(root, "build", "keep.txt"))
+	require.NoError(t, err)
+	assert.True(t, ignored)
+}
+
+func TestWithoutIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, GitIgnoreFile), "*.o\n")
+	writeFile(t, filepath.Join(root, BaurIgnoreFile), "*.tmp\n")
+
+	paths := []string{
+		filepath.Join(root, "main.c"),
+		filepath.Join(root, "main.o"),
+		filepath.Join(root, "main.tmp"),
+	}
+
+	r := NewResolver(root)
+
+	result, err := r.WithoutIgnored(paths, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{paths[0], paths[1]}, result)
+
+	result, err = r.WithoutIgnored(paths, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{paths[0]}, result)
+
+	result, err = (&Resolver{}).WithoutIgnored(paths, true)
+	require.NoError(t, err)
+	assert.Equal(t, paths, result)
+}
+
+func TestInvalidIgnorePatternFails(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, BaurIgnoreFile), "[a-\n")
+
+	_, err := NewIgnoreMatcher(root, BaurIgnoreFile).Ignored(filepath.Join(root, "x"))
+	require.ErrorContains(t, err, BaurIgnoreFile+":1")
+}
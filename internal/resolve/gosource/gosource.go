@@ -104,7 +104,9 @@ func (r *Resolver) Resolve(ctx context.Context,
 	// relative query paths are interpreted as relative to the cwd, not the
 	// cfg.WorkDir passed to packages.Resolve
 	// (https://github.com/golang/go/issues/65965).
-	// As woraround convert them to abs paths manually:
+	// As woraround convert them to abs paths manually.
+	// queries is copied to not modify the slice of the caller.
+	queries = slices.Clone(queries)
 	toAbsFilePatternPaths(workdir, queries)
 
 	queries, err := resolveGlobs(workdir, queries)
@@ -246,8 +246,9 @@ func (r *Repo) TaskIDs() []string {
 }
 
 type repoOptions struct {
-	keepTmpDir  bool
-	createNewDB bool
+	keepTmpDir    bool
+	createNewDB   bool
+	storeTaskLogs bool
 }
 
 type Opt func(*repoOptions)
@@ -266,6 +267,14 @@ func WithNewDB() Opt {
 	}
 }
 
+// WithStoreTaskLogs enables recording the output of task runs in the
+// repository configuration.
+func WithStoreTaskLogs() Opt {
+	return func(o *repoOptions) {
+		o.storeTaskLogs = true
+	}
+}
+
 // CreateBaurRepository creates a new baur repository in a temporary directory
 // and a new postgres database with a unique name.
 // The funcion changes the current working directory to the created repository directory.
@@ -327,6 +336,10 @@ func CreateBaurRepository(t *testing.T, opts ...Opt) *Repo {
 		Database: cfg.Database{
 			PGSQLURL: dbURL,
 		},
+
+		TaskLogs: cfg.TaskLogs{
+			Store: options.storeTaskLogs,
+		},
 	}
 
 	if err := cfgR.ToFile(filepath.Join(tempDir, baur.RepositoryCfgFile)); err != nil {
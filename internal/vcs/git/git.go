@@ -112,11 +112,18 @@ func UntrackedFiles(dir string) ([]string, error) {
 	return res, nil
 }
 
-// ObjectID calculates the git ID (hash) of a fille.
+// ObjectID calculates the git ID (hash) of a file by running git hash-object.
+// [ObjectIDs] calculates the IDs in-process when git does not convert the
+// file content.
 func ObjectID(ctx context.Context, absFilePath, repoRelFilePath string) (string, error) {
-	// TODO: calculate the object ID instead of running an external command
-	// git hash-object, or by calculating the ID on our own as described in https://git-scm.com/book/en/v2/Git-Internals-Git-Objects
-	result, err := exec.Command("git", "hash-object", "--path", repoRelFilePath, absFilePath).
+	return hashObject(ctx, "", absFilePath, repoRelFilePath)
+}
+
+// hashObject runs git hash-object in dir for the file at absFilePath.
+// The git attributes of relFilePath, relative to dir, are applied.
+func hashObject(ctx context.Context, dir, absFilePath, relFilePath string) (string, error) {
+	result, err := exec.Command("git", "hash-object", "--path", relFilePath, absFilePath).
+		Directory(dir).
 		ExpectSuccess().
 		RunCombinedOut(ctx)
 	if err != nil {
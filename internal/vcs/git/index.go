@@ -0,0 +1,298 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// source of the format description: gitformat-index manpage (Git 2.43.0)
+
+const (
+	indexSignature   = "DIRC"
+	indexHeaderLen   = 12
+	indexChecksumLen = sha1.Size
+	// indexEntryFixedLen is the length of the fields of an index entry
+	// that precede the extended flags and path name.
+	indexEntryFixedLen = 62
+
+	indexFlagAssumeValid  = 0x8000
+	indexFlagExtended     = 0x4000
+	indexFlagStageMask    = 0x3000
+	indexFlagStageShift   = 12
+	indexFlagSkipWorktree = 0x4000
+	indexFlagIntentToAdd  = 0x2000
+)
+
+// errIndexUnsupported is returned when an index can not be read in-process,
+// because it uses a feature that is not supported.
+var errIndexUnsupported = errors.New("unsupported git index")
+
+// indexEntry is an entry of the git index.
+// The stat fields contain the lower 32 bits of the values that were
+// retrieved when the file was last updated in the index.
+type indexEntry struct {
+	CTimeSec  uint32
+	CTimeNsec uint32
+	MTimeSec  uint32
+	MTimeNsec uint32
+	Dev       uint32
+	Ino       uint32
+	Mode      Mode
+	UID       uint32
+	GID       uint32
+	Size      uint32
+	ObjectID  string
+	// Path is the path of the file relative to the root of the
+	// repository, with forward slashes as separator.
+	Path         string
+	Stage        int
+	AssumeValid  bool
+	SkipWorktree bool
+	IntentToAdd  bool
+}
+
+// readIndex reads the entries of the git index file at path.
+// If the index uses a feature that is not supported, an error wrapping
+// errIndexUnsupported is returned.
+// Only indexes of repositories using SHA-1 object IDs are supported.
+func readIndex(path string) ([]*indexEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := parseIndex(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+func parseIndex(data []byte) ([]*indexEntry, error) {
+	if len(data) < indexHeaderLen+indexChecksumLen {
+		return nil, errors.New("index is too short")
+	}
+
+	content := data[:len(data)-indexChecksumLen]
+	storedChecksum := data[len(content):]
+	// the checksum is zero when the index was written with
+	// index.skipHash enabled
+	if !bytes.Equal(storedChecksum, make([]byte, indexChecksumLen)) {
+		checksum := sha1.Sum(content)
+		if !bytes.Equal(checksum[:], storedChecksum) {
+			return nil, errors.New("index checksum mismatch")
+		}
+	}
+
+	if string(content[:4]) != indexSignature {
+		return nil, errors.New("invalid index signature")
+	}
+
+	version := binary.BigEndian.Uint32(content[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("%w: version %d", errIndexUnsupported, version)
+	}
+
+	cnt := binary.BigEndian.Uint32(content[8:12])
+	p := indexParser{data: content, pos: indexHeaderLen, version: version}
+
+	entries := make([]*indexEntry, 0, cnt)
+	for range cnt {
+		e, err := p.entry()
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := p.checkExtensions(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+type indexParser struct {
+	data     []byte
+	pos      int
+	version  uint32
+	prevPath []byte
+}
+
+func (p *indexParser) entry() (*indexEntry, error) {
+	start := p.pos
+
+	if len(p.data)-p.pos < indexEntryFixedLen {
+		return nil, errors.New("index entry is truncated")
+	}
+
+	b := p.data[p.pos:]
+	e := indexEntry{
+		CTimeSec:  binary.BigEndian.Uint32(b[0:]),
+		CTimeNsec: binary.BigEndian.Uint32(b[4:]),
+		MTimeSec:  binary.BigEndian.Uint32(b[8:]),
+		MTimeNsec: binary.BigEndian.Uint32(b[12:]),
+		Dev:       binary.BigEndian.Uint32(b[16:]),
+		Ino:       binary.BigEndian.Uint32(b[20:]),
+		Mode:      Mode(binary.BigEndian.Uint32(b[24:])),
+		UID:       binary.BigEndian.Uint32(b[28:]),
+		GID:       binary.BigEndian.Uint32(b[32:]),
+		Size:      binary.BigEndian.Uint32(b[36:]),
+		ObjectID:  hex.EncodeToString(b[40:60]),
+	}
+
+	flags := binary.BigEndian.Uint16(b[60:])
+	e.AssumeValid = flags&indexFlagAssumeValid != 0
+	e.Stage = int(flags&indexFlagStageMask) >> indexFlagStageShift
+	p.pos += indexEntryFixedLen
+
+	if flags&indexFlagExtended != 0 {
+		if p.version < 3 {
+			return nil, errors.New("index entry has extended flags in a version 2 index")
+		}
+
+		if len(p.data)-p.pos < 2 {
+			return nil, errors.New("index entry is truncated")
+		}
+
+		flags2 := binary.BigEndian.Uint16(p.data[p.pos:])
+		e.SkipWorktree = flags2&indexFlagSkipWorktree != 0
+		e.IntentToAdd = flags2&indexFlagIntentToAdd != 0
+		p.pos += 2
+	}
+
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	e.Path = path
+
+	if p.version < 4 {
+		// entries are padded with 1-8 NUL bytes to a multiple of 8
+		// bytes, one NUL byte terminating the path was already
+		// consumed
+		entryLen := p.pos - start
+		p.pos += (8 - entryLen%8) % 8
+		if p.pos > len(p.data) {
+			return nil, errors.New("index entry padding is truncated")
+		}
+	}
+
+	return &e, nil
+}
+
+// path reads the NUL terminated path of an entry.
+// In version 4 indexes, the path is prefix-compressed relative to the path
+// of the previous entry.
+func (p *indexParser) path() (string, error) {
+	var prefix []byte
+
+	if p.version == 4 {
+		strip, err := p.varint()
+		if err != nil {
+			return "", err
+		}
+
+		if strip > uint64(len(p.prevPath)) {
+			return "", errors.New("index entry path prefix is longer than the previous path")
+		}
+
+		prefix = p.prevPath[:len(p.prevPath)-int(strip)]
+	}
+
+	end := bytes.IndexByte(p.data[p.pos:], 0)
+	if end == -1 {
+		return "", errors.New("index entry path is not terminated")
+	}
+
+	path := make([]byte, 0, len(prefix)+end)
+	path = append(path, prefix...)
+	path = append(path, p.data[p.pos:p.pos+end]...)
+	p.pos += end + 1
+	p.prevPath = path
+
+	return string(path), nil
+}
+
+// varint reads a variable length integer in the offset encoding that is used
+// by git.
+func (p *indexParser) varint() (uint64, error) {
+	if p.pos >= len(p.data) {
+		return 0, errors.New("index entry is truncated")
+	}
+
+	c := p.data[p.pos]
+	p.pos++
+	val := uint64(c & 0x7f)
+
+	for c&0x80 != 0 {
+		if p.pos >= len(p.data) {
+			return 0, errors.New("index entry is truncated")
+		}
+
+		val++
+		if val>>57 != 0 {
+			return 0, errors.New("index entry path prefix length overflows")
+		}
+
+		c = p.data[p.pos]
+		p.pos++
+		val = (val << 7) + uint64(c&0x7f)
+	}
+
+	return val, nil
+}
+
+// checkExtensions returns an error wrapping errIndexUnsupported if the index
+// contains an extension that must be understood to interpret the entries
+// correctly, like the split index or sparse index extensions.
+// Extensions with a signature starting with an uppercase letter are optional
+// and ignored.
+func (p *indexParser) checkExtensions() error {
+	for len(p.data)-p.pos >= 8 {
+		sig := p.data[p.pos : p.pos+4]
+		size := binary.BigEndian.Uint32(p.data[p.pos+4:])
+
+		if sig[0] < 'A' || sig[0] > 'Z' {
+			return fmt.Errorf("%w: index has extension %q", errIndexUnsupported, sig)
+		}
+
+		if uint64(size) > uint64(len(p.data)-p.pos-8) {
+			return fmt.Errorf("index extension %q is truncated", sig)
+		}
+
+		p.pos += 8 + int(size)
+	}
+
+	if p.pos != len(p.data) {
+		return errors.New("index has trailing data")
+	}
+
+	return nil
+}
+
+// blobObjectID returns the git object ID of a blob with size bytes that are
+// read from r.
+func blobObjectID(r io.Reader, size int64) (string, error) {
+	h := sha1.New()
+	_, _ = fmt.Fprintf(h, "blob %d\x00", size)
+
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+
+	if n != size {
+		return "", fmt.Errorf("read %d bytes, expected %d", n, size)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,130 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/exec"
+	"github.com/simplesurance/baur/v5/internal/testutils/fstest"
+	"github.com/simplesurance/baur/v5/internal/testutils/gittest"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	res, err := exec.Command("git", args...).Directory(dir).ExpectSuccess().RunCombinedOut(t.Context())
+	require.NoError(t, err)
+
+	return res.StrOutput()
+}
+
+// lsFilesStage returns the output of git ls-files --stage as a map of
+// paths to object IDs.
+func lsFilesStage(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	result := map[string]string{}
+	for line := range strings.Lines(runGit(t, dir, "ls-files", "--stage")) {
+		// example line: 100644 53bba79de99f79b670f62958e917c81ecc04c6e7 0	file
+		meta, path, found := strings.Cut(strings.TrimSuffix(line, "\n"), "\t")
+		require.True(t, found, "unexpected line: %q", line)
+
+		fields := strings.Fields(meta)
+		require.Len(t, fields, 3)
+		result[path] = fields[1]
+	}
+
+	return result
+}
+
+func TestReadIndexVersions(t *testing.T) {
+	for _, version := range []int{2, 3, 4} {
+		t.Run(fmt.Sprintf("version%d", version), func(t *testing.T) {
+			dir := t.TempDir()
+			gittest.CreateRepository(t, dir)
+
+			fstest.WriteToFile(t, []byte("1"), filepath.Join(dir, "file"))
+			fstest.WriteToFile(t, []byte("2"), filepath.Join(dir, "dir", "file"))
+			fstest.WriteToFile(t, []byte("3"), filepath.Join(dir, "dir", "sub", "a-file-with-a-long-name"))
+			fstest.WriteToFile(t, []byte("4"), filepath.Join(dir, "dir", "sub", "b"))
+			gittest.CommitFilesToGit(t, dir)
+
+			runGit(t, dir, "update-index", "--index-version", fmt.Sprint(version))
+			if version > 2 {
+				runGit(t, dir, "update-index", "--skip-worktree", "dir/sub/b")
+			}
+
+			entries, err := readIndex(filepath.Join(dir, ".git", "index"))
+			require.NoError(t, err)
+
+			expected := lsFilesStage(t, dir)
+			actual := make(map[string]string, len(entries))
+			for _, e := range entries {
+				actual[e.Path] = e.ObjectID
+				assert.Equal(t, ObjectTypeFile, e.Mode&ObjectTypeFile, e.Path)
+				assert.Equal(t, version > 2 && e.Path == "dir/sub/b", e.SkipWorktree, e.Path)
+			}
+
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestReadIndexSplitIndexIsUnsupported(t *testing.T) {
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	fstest.WriteToFile(t, []byte("1"), filepath.Join(dir, "file"))
+	gittest.CommitFilesToGit(t, dir)
+	runGit(t, dir, "update-index", "--split-index")
+
+	_, err := readIndex(filepath.Join(dir, ".git", "index"))
+	require.ErrorIs(t, err, errIndexUnsupported)
+}
+
+func TestReadIndexCorruptedFails(t *testing.T) {
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	fstest.WriteToFile(t, []byte("1"), filepath.Join(dir, "file"))
+	gittest.CommitFilesToGit(t, dir)
+
+	indexPath := filepath.Join(dir, ".git", "index")
+	data := fstest.ReadFile(t, indexPath)
+	data[len(data)/2] ^= 0xff
+	fstest.WriteToFile(t, data, indexPath)
+
+	_, err := readIndex(indexPath)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, errIndexUnsupported)
+}
+
+func TestWithoutUntrackedUsesIndex(t *testing.T) {
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	tracked := filepath.Join(dir, "tracked")
+	fstest.WriteToFile(t, []byte("1"), tracked)
+	fstest.WriteToFile(t, []byte("2"), filepath.Join(dir, "realdir", "tracked"))
+	fstest.Symlink(t, filepath.Join(dir, "realdir"), filepath.Join(dir, "symlinkdir"))
+	fstest.WriteToFile(t, []byte("ignored\n"), filepath.Join(dir, ".gitignore"))
+	gittest.CommitFilesToGit(t, dir)
+
+	untracked := filepath.Join(dir, "sub", "untracked")
+	fstest.WriteToFile(t, []byte("3"), untracked)
+	ignored := filepath.Join(dir, "ignored")
+	fstest.WriteToFile(t, []byte("4"), ignored)
+	inSymlinkDir := filepath.Join(dir, "symlinkdir", "tracked")
+
+	repo := NewRepository(dir, t.Logf)
+	res, err := repo.WithoutUntracked(tracked, "tracked", untracked, ignored, inSymlinkDir)
+	require.NoError(t, err)
+	require.NotNil(t, repo.index, "git index was not used")
+
+	assert.ElementsMatch(t, []string{tracked, "tracked", inSymlinkDir}, res)
+}
@@ -0,0 +1,141 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/simplesurance/baur/v5/internal/set"
+)
+
+// indexPaths determines if files are untracked by looking up their paths in
+// the git index.
+type indexPaths struct {
+	workTree string
+	// paths contains the working tree relative paths of all index entries.
+	paths set.Set[string]
+
+	mu sync.Mutex
+	// dirs caches the result of ignoresFilesIn by directory.
+	dirs map[string]bool
+}
+
+// readIndexPaths reads the paths of the entries in the index of the git
+// repository containing dir.
+// If the repository uses features that are not supported, an error wrapping
+// errIndexUnsupported is returned.
+func readIndexPaths(dir string) (*indexPaths, error) {
+	l, err := findLayout(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := readConfig(l); err != nil {
+		return nil, err
+	}
+
+	result := indexPaths{
+		workTree: l.workTree,
+		paths:    set.Set[string]{},
+		dirs:     map[string]bool{},
+	}
+
+	entries, err := readIndex(filepath.Join(l.gitDir, "index"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &result, nil
+		}
+
+		return nil, err
+	}
+
+	for _, e := range entries {
+		result.paths.Add(filepath.FromSlash(e.Path))
+	}
+
+	return &result, nil
+}
+
+// isUntracked returns true if the file at absPath is not tracked in the git
+// repository.
+// Like with git status, files in nested git repositories or in directories
+// that are symlinks are not considered to be untracked.
+func (p *indexPaths) isUntracked(absPath string) (bool, error) {
+	rel, err := filepath.Rel(p.workTree, absPath)
+	if err != nil {
+		return false, err
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		// git status only reports files in the working tree
+		return false, nil
+	}
+
+	if p.paths.Contains(rel) {
+		return false, nil
+	}
+
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		ignored, err := p.ignoresFilesIn(dir)
+		if err != nil {
+			return false, err
+		}
+
+		if ignored {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// ignoresFilesIn returns true if git status does not report untracked files
+// in the working tree relative directory dir, because it is tracked as
+// symlink or submodule, is a symlink or contains a git repository.
+func (p *indexPaths) ignoresFilesIn(dir string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if result, exists := p.dirs[dir]; exists {
+		return result, nil
+	}
+
+	result, err := p.ignoresFilesInUncached(dir)
+	if err != nil {
+		return false, err
+	}
+
+	p.dirs[dir] = result
+
+	return result, nil
+}
+
+func (p *indexPaths) ignoresFilesInUncached(dir string) (bool, error) {
+	if p.paths.Contains(dir) {
+		return true, nil
+	}
+
+	absDir := filepath.Join(p.workTree, dir)
+
+	fi, err := os.Lstat(absDir)
+	if err != nil {
+		return false, err
+	}
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return true, nil
+	}
+
+	_, err = os.Lstat(filepath.Join(absDir, ".git"))
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err
+}
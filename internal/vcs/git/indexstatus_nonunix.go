@@ -0,0 +1,11 @@
+//go:build !unix
+
+package git
+
+import "fmt"
+
+// readTrackedObjects always returns an error wrapping errIndexUnsupported, the
+// stat information in the git index can only be compared with files on Unix.
+func readTrackedObjects(string) (map[string]*TrackedObject, error) {
+	return nil, fmt.Errorf("%w: only supported on unix", errIndexUnsupported)
+}
@@ -0,0 +1,173 @@
+//go:build unix
+
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// readTrackedObjects reads the index of the git repository containing dir and
+// returns the objects of files that are tracked and unmodified in the working
+// tree, by their absolute path.
+//
+// Like git, the stat information of the index entries is compared with the
+// ones of the files to determine if they were modified. If they differ or the
+// entry is racily clean (the file was modified in the same timestamp
+// granularity as the index was written), the object ID of the file content is
+// calculated and compared.
+//
+// If the repository uses features that are not supported, an error wrapping
+// errIndexUnsupported is returned. If it can not be determined if a file is
+// modified, because git might apply filters to its content, errContentUncertain
+// is returned.
+func readTrackedObjects(dir string) (map[string]*TrackedObject, error) {
+	l, err := findLayout(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := readConfig(l)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.fileMode || !cfg.symlinks {
+		return nil, fmt.Errorf("%w: core.fileMode or core.symlinks is disabled", errIndexUnsupported)
+	}
+
+	indexPath := filepath.Join(l.gitDir, "index")
+
+	var indexSt unix.Stat_t
+	if err := unix.Stat(indexPath, &indexSt); err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			// repository without any tracked files
+			return map[string]*TrackedObject{}, nil
+		}
+
+		return nil, &os.PathError{Op: "stat", Path: indexPath, Err: err}
+	}
+
+	entries, err := readIndex(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mayFilter, err := filtersMightApply(l, cfg, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*TrackedObject, len(entries))
+	for _, e := range entries {
+		if e.Stage != 0 || e.SkipWorktree || e.IntentToAdd {
+			continue
+		}
+
+		if !isFileOrSymlink(e.Mode) {
+			continue
+		}
+
+		absPath := filepath.Join(l.workTree, filepath.FromSlash(e.Path))
+
+		unmodified, err := isUnmodified(e, absPath, &indexSt)
+		if err != nil {
+			if errors.Is(err, errContentUncertain) && !mayFilter {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if unmodified {
+			result[absPath] = &TrackedObject{ObjectID: e.ObjectID, Mode: e.Mode}
+		}
+	}
+
+	return result, nil
+}
+
+func isFileOrSymlink(m Mode) bool {
+	t := m & 0o170000
+	return t == ObjectTypeFile || t == ObjectTypeSymlink
+}
+
+// isUnmodified returns true if the file at absPath matches the index entry e.
+// If the content differs, false and an error wrapping errContentUncertain is
+// returned.
+func isUnmodified(e *indexEntry, absPath string, indexSt *unix.Stat_t) (bool, error) {
+	if e.AssumeValid {
+		return true, nil
+	}
+
+	var st unix.Stat_t
+	if err := unix.Lstat(absPath, &st); err != nil {
+		if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENOTDIR) {
+			return false, nil
+		}
+
+		return false, &os.PathError{Op: "lstat", Path: absPath, Err: err}
+	}
+
+	isSymlink := e.Mode&0o170000 == ObjectTypeSymlink
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFLNK:
+		if !isSymlink {
+			return false, nil
+		}
+	case unix.S_IFREG:
+		if isSymlink {
+			return false, nil
+		}
+
+		if (st.Mode&0o100 != 0) != (e.Mode&0o100 != 0) {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+
+	if statMatches(e, &st) && !isRacy(e, indexSt) {
+		return true, nil
+	}
+
+	objectID, err := worktreeObjectID(absPath, isSymlink, st.Size)
+	if err != nil {
+		return false, err
+	}
+
+	if objectID == e.ObjectID {
+		return true, nil
+	}
+
+	if isSymlink {
+		return false, nil
+	}
+
+	return false, fmt.Errorf("%s: %w", absPath, errContentUncertain)
+}
+
+func statMatches(e *indexEntry, st *unix.Stat_t) bool {
+	return e.MTimeSec == uint32(st.Mtim.Sec) &&
+		(e.MTimeNsec == 0 || e.MTimeNsec == uint32(st.Mtim.Nsec)) &&
+		e.CTimeSec == uint32(st.Ctim.Sec) &&
+		(e.CTimeNsec == 0 || e.CTimeNsec == uint32(st.Ctim.Nsec)) &&
+		e.Ino == uint32(st.Ino) &&
+		e.UID == st.Uid &&
+		e.GID == st.Gid &&
+		e.Size == uint32(st.Size)
+}
+
+// isRacy returns true if the file of e was modified at the same time or after
+// the index was written. A modification of the file shortly after it was
+// added to the index might not have changed its stat information.
+func isRacy(e *indexEntry, indexSt *unix.Stat_t) bool {
+	indexSec := uint32(indexSt.Mtim.Sec)
+	indexNsec := uint32(indexSt.Mtim.Nsec)
+
+	return indexSec < e.MTimeSec || (indexSec == e.MTimeSec && indexNsec <= e.MTimeNsec)
+}
@@ -0,0 +1,142 @@
+//go:build unix
+
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/testutils/fstest"
+	"github.com/simplesurance/baur/v5/internal/testutils/gittest"
+)
+
+func trackedObjectsCLI(t *testing.T, dir string) map[string]*TrackedObject {
+	t.Helper()
+
+	to := NewTrackedObjects(dir, t.Logf)
+	require.NoError(t, to.loadFromRepositoryCLI(t.Context()))
+
+	return to.info
+}
+
+// setMtimeInPast sets the modification time of paths to a point in time
+// before the git index is written, to prevent that entries are racily clean.
+func setMtimeInPast(t *testing.T, paths ...string) {
+	t.Helper()
+
+	mtime := time.Now().Add(-time.Hour)
+	for _, p := range paths {
+		require.NoError(t, os.Chtimes(p, mtime, mtime))
+	}
+}
+
+func TestReadTrackedObjectsMatchesLsFiles(t *testing.T) {
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	unmodified := filepath.Join(dir, "unmodified")
+	modified := filepath.Join(dir, "modified")
+	modifiedSameSize := filepath.Join(dir, "sub", "modified_same_size")
+	touched := filepath.Join(dir, "sub", "touched")
+	deleted := filepath.Join(dir, "deleted")
+	execBitChanged := filepath.Join(dir, "exec_bit_changed")
+	symlink := filepath.Join(dir, "symlink")
+	symlinkChanged := filepath.Join(dir, "symlink_changed")
+
+	fstest.WriteToFile(t, []byte("1"), unmodified)
+	fstest.WriteToFile(t, []byte("2"), modified)
+	fstest.WriteToFile(t, []byte("3"), modifiedSameSize)
+	fstest.WriteToFile(t, []byte("4"), touched)
+	fstest.WriteToFile(t, []byte("5"), deleted)
+	fstest.WriteToFile(t, []byte("6"), execBitChanged)
+	fstest.Symlink(t, "unmodified", symlink)
+	fstest.Symlink(t, "unmodified", symlinkChanged)
+	setMtimeInPast(t, unmodified, modified, modifiedSameSize, touched, deleted, execBitChanged)
+
+	gittest.CommitFilesToGit(t, dir)
+	// refresh the stat information in the index
+	runGit(t, dir, "status")
+
+	fstest.WriteToFile(t, []byte("changed"), modified)
+	fstest.WriteToFile(t, []byte("x"), modifiedSameSize)
+	require.NoError(t, os.Chtimes(touched, time.Now(), time.Now()))
+	require.NoError(t, os.Remove(deleted))
+	fstest.Chmod(t, execBitChanged, 0o755)
+	require.NoError(t, os.Remove(symlinkChanged))
+	fstest.Symlink(t, "modified", symlinkChanged)
+	fstest.WriteToFile(t, []byte("7"), filepath.Join(dir, "untracked"))
+
+	actual, err := readTrackedObjects(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, trackedObjectsCLI(t, dir), actual)
+	assert.Contains(t, actual, unmodified)
+	assert.Contains(t, actual, touched)
+	assert.Contains(t, actual, symlink)
+	assert.NotContains(t, actual, modifiedSameSize)
+}
+
+func TestReadTrackedObjectsRacilyCleanEntries(t *testing.T) {
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	f := filepath.Join(dir, "file")
+	fstest.WriteToFile(t, []byte("1"), f)
+	gittest.CommitFilesToGit(t, dir)
+
+	fi, err := os.Stat(f)
+	require.NoError(t, err)
+
+	// modify the file without changing its size and modification time,
+	// the stat information of the index entry still matches
+	fstest.WriteToFile(t, []byte("2"), f)
+	require.NoError(t, os.Chtimes(f, fi.ModTime(), fi.ModTime()))
+	indexPath := filepath.Join(dir, ".git", "index")
+	require.NoError(t, os.Chtimes(indexPath, fi.ModTime(), fi.ModTime()))
+
+	actual, err := readTrackedObjects(dir)
+	require.NoError(t, err)
+	assert.NotContains(t, actual, f)
+}
+
+func TestReadTrackedObjectsWithGitAttributesIsUncertain(t *testing.T) {
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	f := filepath.Join(dir, "file")
+	fstest.WriteToFile(t, []byte("1\n"), f)
+	fstest.WriteToFile(t, []byte("* text eol=crlf\n"), filepath.Join(dir, ".gitattributes"))
+	gittest.CommitFilesToGit(t, dir)
+
+	fstest.WriteToFile(t, []byte("2\n"), f)
+
+	_, err := readTrackedObjects(dir)
+	require.ErrorIs(t, err, errContentUncertain)
+
+	to := NewTrackedObjects(dir, t.Logf)
+	_, err = to.Get(t.Context(), f)
+	require.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+func TestReadTrackedObjectsSplitIndexFallsBack(t *testing.T) {
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	f := filepath.Join(dir, "file")
+	fstest.WriteToFile(t, []byte("1"), f)
+	gittest.CommitFilesToGit(t, dir)
+	runGit(t, dir, "update-index", "--split-index")
+
+	_, err := readTrackedObjects(dir)
+	require.ErrorIs(t, err, errIndexUnsupported)
+
+	to := NewTrackedObjects(dir, t.Logf)
+	obj, err := to.Get(t.Context(), f)
+	require.NoError(t, err)
+	assert.Equal(t, lsFilesStage(t, dir)["file"], obj.ObjectID)
+}
@@ -0,0 +1,221 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// layout describes where the files of a git repository are located.
+type layout struct {
+	// workTree is the top-level directory of the working tree.
+	workTree string
+	// gitDir is the directory containing the index of the working tree.
+	gitDir string
+	// commonDir is the directory containing the configuration and
+	// objects, it differs from gitDir for linked worktrees.
+	commonDir string
+}
+
+// findLayout determines the layout of the git repository containing dir.
+// If the location of the repository is overwritten by environment variables
+// an error wrapping errIndexUnsupported is returned.
+func findLayout(dir string) (*layout, error) {
+	for _, envVar := range []string{"GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE", "GIT_COMMON_DIR"} {
+		if os.Getenv(envVar) != "" {
+			return nil, fmt.Errorf("%w: environment variable %s is set", errIndexUnsupported, envVar)
+		}
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dotGit := filepath.Join(dir, ".git")
+		fi, err := os.Stat(dotGit)
+		if err == nil {
+			l := layout{workTree: dir}
+
+			if fi.IsDir() {
+				l.gitDir = dotGit
+			} else if l.gitDir, err = readGitDirFile(dotGit); err != nil {
+				return nil, err
+			}
+
+			if l.commonDir, err = readCommonDir(l.gitDir); err != nil {
+				return nil, err
+			}
+
+			return &l, nil
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, ErrRepositoryNotFound
+		}
+		dir = parent
+	}
+}
+
+// readGitDirFile returns the directory that a .git file of a linked worktree
+// or submodule refers to.
+func readGitDirFile(path string) (string, error) {
+	const prefix = "gitdir:"
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	line, _, _ := strings.Cut(string(content), "\n")
+	gitDir, found := strings.CutPrefix(line, prefix)
+	if !found {
+		return "", fmt.Errorf("%s: missing %q prefix", path, prefix)
+	}
+
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+
+	return gitDir, nil
+}
+
+// readCommonDir returns the common directory of gitDir, it is specified in
+// the commondir file of linked worktrees.
+func readCommonDir(gitDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return gitDir, nil
+		}
+
+		return "", err
+	}
+
+	commonDir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+
+	return commonDir, nil
+}
+
+// config contains the settings of the repository configuration that
+// are relevant for comparing index entries with the working tree.
+type config struct {
+	fileMode bool
+	symlinks bool
+	// autoCRLF is true if core.autocrlf is set to true or input.
+	autoCRLF bool
+	// attributesFile is true if core.attributesFile is set.
+	attributesFile bool
+}
+
+// readConfig reads the repository configuration of l.
+// Settings in the global and system configuration files are not considered.
+// If the configuration contains settings that change where or how files of the
+// working tree are stored, an error wrapping errIndexUnsupported is returned.
+func readConfig(l *layout) (*config, error) {
+	cfg := config{fileMode: true, symlinks: true}
+
+	for _, path := range []string{
+		filepath.Join(l.commonDir, "config"),
+		filepath.Join(l.gitDir, "config.worktree"),
+	} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if err := cfg.parse(content); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func (c *config) parse(content []byte) error {
+	var section string
+
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end == -1 {
+				return fmt.Errorf("invalid section header %q", line)
+			}
+
+			section, _, _ = strings.Cut(strings.ToLower(line[1:end]), " ")
+			if section == "include" || section == "includeif" {
+				return fmt.Errorf("%w: config includes other files", errIndexUnsupported)
+			}
+
+			continue
+		}
+
+		if i := strings.IndexAny(line, "#;"); i != -1 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		key, val, hasVal := strings.Cut(line, "=")
+		key = section + "." + strings.ToLower(strings.TrimSpace(key))
+		val = strings.ToLower(strings.Trim(strings.TrimSpace(val), `"`))
+		if !hasVal {
+			// a key without value is a boolean true
+			val = "true"
+		}
+
+		switch key {
+		case "core.filemode":
+			c.fileMode = parseBool(val)
+		case "core.symlinks":
+			c.symlinks = parseBool(val)
+		case "core.autocrlf":
+			c.autoCRLF = val == "input" || parseBool(val)
+		case "core.attributesfile":
+			c.attributesFile = val != ""
+		case "core.worktree":
+			return fmt.Errorf("%w: core.worktree is set", errIndexUnsupported)
+		case "core.bare":
+			if parseBool(val) {
+				return fmt.Errorf("%w: repository is bare", errIndexUnsupported)
+			}
+		case "extensions.objectformat":
+			if val != "sha1" {
+				return fmt.Errorf("%w: object format %s", errIndexUnsupported, val)
+			}
+		}
+	}
+
+	return sc.Err()
+}
+
+func parseBool(val string) bool {
+	switch val {
+	case "true", "yes", "on", "1":
+		return true
+	default:
+		return false
+	}
+}
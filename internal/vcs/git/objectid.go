@@ -0,0 +1,301 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/simplesurance/baur/v5/internal/exec"
+)
+
+// errContentUncertain is returned when it can not be determined in-process
+// if git converts the content of a file in the working tree, e.g. because of
+// git filters or end-of-line conversions.
+var errContentUncertain = errors.New("content of file might differ because of git filters")
+
+// ObjectIDs calculates the git object IDs of files in the working tree of a
+// git repository.
+// If git stores the content of files unmodified, the IDs are calculated
+// in-process. Otherwise, or if it can not be determined, they are calculated
+// via git hash-object.
+type ObjectIDs struct {
+	repositoryDir string
+	debugLogf     PrintfFn
+
+	initOnce sync.Once
+	// workTree is empty if the object IDs can not be calculated in-process.
+	workTree string
+
+	mu sync.Mutex
+	// attributeDirs caches by directory if it contains a .gitattributes
+	// file.
+	attributeDirs map[string]bool
+}
+
+func NewObjectIDs(gitRepositoryDir string, debugLogf PrintfFn) *ObjectIDs {
+	return &ObjectIDs{
+		repositoryDir: gitRepositoryDir,
+		debugLogf:     debugLogf,
+		attributeDirs: map[string]bool{},
+	}
+}
+
+// Get returns the git object ID of the file at absPath.
+func (o *ObjectIDs) Get(ctx context.Context, absPath string) (string, error) {
+	o.initOnce.Do(func() { o.init(ctx) })
+
+	if o.workTree != "" {
+		id, err := o.calc(absPath)
+		if err == nil {
+			return id, nil
+		}
+
+		if !errors.Is(err, errContentUncertain) {
+			return "", err
+		}
+	}
+
+	rel, err := filepath.Rel(o.repositoryDir, absPath)
+	if err != nil {
+		return "", err
+	}
+
+	return hashObject(ctx, o.repositoryDir, absPath, filepath.ToSlash(rel))
+}
+
+func (o *ObjectIDs) init(ctx context.Context) {
+	workTree, err := unfilteredWorkTree(ctx, o.repositoryDir)
+	if err != nil {
+		o.debugLogf("git: object IDs can not be calculated in-process, falling back to git hash-object: %s\n", err)
+		return
+	}
+
+	o.workTree = workTree
+}
+
+// calc calculates the object ID of the regular file at absPath in-process.
+// If the file is a symlink or git attributes might apply to it, an error
+// wrapping errContentUncertain is returned.
+func (o *ObjectIDs) calc(absPath string) (string, error) {
+	rel, err := filepath.Rel(o.workTree, absPath)
+	if err != nil {
+		return "", err
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s: %w: file is outside of the working tree", absPath, errContentUncertain)
+	}
+
+	for dir := filepath.Dir(rel); ; dir = filepath.Dir(dir) {
+		exists, err := o.hasAttributesFile(dir)
+		if err != nil {
+			return "", err
+		}
+
+		if exists {
+			return "", fmt.Errorf("%s: %w: .gitattributes file exists in %s", absPath, errContentUncertain, dir)
+		}
+
+		if dir == "." {
+			break
+		}
+	}
+
+	fi, err := os.Lstat(absPath)
+	if err != nil {
+		return "", err
+	}
+
+	if !fi.Mode().IsRegular() {
+		return "", fmt.Errorf("%s: %w: not a regular file", absPath, errContentUncertain)
+	}
+
+	return worktreeObjectID(absPath, false, fi.Size())
+}
+
+// hasAttributesFile returns true if the working tree relative directory dir
+// contains a .gitattributes file.
+func (o *ObjectIDs) hasAttributesFile(dir string) (bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if exists, cached := o.attributeDirs[dir]; cached {
+		return exists, nil
+	}
+
+	_, err := os.Lstat(filepath.Join(o.workTree, dir, ".gitattributes"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	o.attributeDirs[dir] = err == nil
+
+	return err == nil, nil
+}
+
+// unfilteredWorkTree returns the working tree of the git repository containing
+// dir, if git stores the content of its files unmodified.
+// If git might convert the content, an error wrapping errContentUncertain is
+// returned. .gitattributes files in the working tree that are not in the index
+// are not considered.
+func unfilteredWorkTree(ctx context.Context, dir string) (string, error) {
+	if os.Getenv("GIT_ATTR_SOURCE") != "" {
+		return "", fmt.Errorf("%w: environment variable GIT_ATTR_SOURCE is set", errContentUncertain)
+	}
+
+	l, err := findLayout(dir)
+	if err != nil {
+		return "", err
+	}
+
+	cfg, err := readConfig(l)
+	if err != nil {
+		return "", err
+	}
+
+	// readConfig only reads the repository configuration, the settings
+	// can also be set in the global and system configuration
+	if err := readConversionSettings(ctx, l.workTree, cfg); err != nil {
+		return "", err
+	}
+
+	entries, err := readIndex(filepath.Join(l.gitDir, "index"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	mayFilter, err := filtersMightApply(l, cfg, entries)
+	if err != nil {
+		return "", err
+	}
+
+	if mayFilter {
+		return "", errContentUncertain
+	}
+
+	exists, err := globalAttributesFileExists()
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		return "", fmt.Errorf("%w: global attributes file exists", errContentUncertain)
+	}
+
+	return l.workTree, nil
+}
+
+// readConversionSettings sets the fields of cfg that are relevant for
+// converting file content from the configuration that git applies in dir.
+// A setting that is enabled in any configuration file is considered to be
+// enabled.
+func readConversionSettings(ctx context.Context, dir string, cfg *config) error {
+	res, err := exec.Command("git", "config", "--get-regexp", `^core\.(autocrlf|attributesfile)$`).
+		Directory(dir).
+		RunCombinedOut(ctx)
+	if err != nil {
+		return err
+	}
+
+	// exit code 1 means that none of the keys is set
+	if res.ExitCode == 1 {
+		return nil
+	}
+
+	if res.ExitCode != 0 {
+		return res.ExpectSuccess()
+	}
+
+	for line := range strings.Lines(res.StrOutput()) {
+		key, val, _ := strings.Cut(strings.TrimSpace(line), " ")
+		val = strings.ToLower(val)
+
+		switch key {
+		case "core.autocrlf":
+			cfg.autoCRLF = cfg.autoCRLF || val == "input" || parseBool(val)
+		case "core.attributesfile":
+			cfg.attributesFile = cfg.attributesFile || val != ""
+		}
+	}
+
+	return nil
+}
+
+// globalAttributesFileExists returns true if the attributes file exists that
+// git reads when core.attributesFile is not set.
+func globalAttributesFileExists() (bool, error) {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			// without a home directory, git does not read the file
+			return false, nil
+		}
+
+		configDir = filepath.Join(home, ".config")
+	}
+
+	_, err := os.Stat(filepath.Join(configDir, "git", "attributes"))
+	if err == nil {
+		return true, nil
+	}
+
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// filtersMightApply returns true if git might convert the content of files
+// when storing them in the object database.
+func filtersMightApply(l *layout, cfg *config, entries []*indexEntry) (bool, error) {
+	if cfg.autoCRLF || cfg.attributesFile {
+		return true, nil
+	}
+
+	_, err := os.Stat(filepath.Join(l.commonDir, "info", "attributes"))
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	for _, e := range entries {
+		if path.Base(e.Path) == ".gitattributes" {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func worktreeObjectID(absPath string, isSymlink bool, size int64) (string, error) {
+	if isSymlink {
+		target, err := os.Readlink(absPath)
+		if err != nil {
+			return "", err
+		}
+
+		return blobObjectID(strings.NewReader(target), int64(len(target)))
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	id, err := blobObjectID(f, size)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", absPath, err)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/testutils/fstest"
+	"github.com/simplesurance/baur/v5/internal/testutils/gittest"
+)
+
+// isolateGitConfig prevents that the global and system git configuration
+// of the environment affect the test.
+func isolateGitConfig(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+func TestObjectIDsAreCalculatedInProcess(t *testing.T) {
+	isolateGitConfig(t)
+
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	committed := filepath.Join(dir, "committed")
+	modified := filepath.Join(dir, "sub", "modified")
+	untracked := filepath.Join(dir, "sub", "untracked")
+	crlf := filepath.Join(dir, "crlf")
+
+	fstest.WriteToFile(t, []byte("1"), committed)
+	fstest.WriteToFile(t, []byte("2"), modified)
+	gittest.CommitFilesToGit(t, dir)
+
+	fstest.WriteToFile(t, []byte("changed"), modified)
+	fstest.WriteToFile(t, []byte("3"), untracked)
+	fstest.WriteToFile(t, []byte("a\r\nb\r\n"), crlf)
+
+	ids := NewObjectIDs(dir, t.Logf)
+	for _, p := range []string{committed, modified, untracked, crlf} {
+		rel, err := filepath.Rel(dir, p)
+		require.NoError(t, err)
+
+		expected, err := ObjectID(t.Context(), p, rel)
+		require.NoError(t, err)
+
+		actual, err := ids.Get(t.Context(), p)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual, rel)
+	}
+
+	assert.Equal(t, dir, ids.workTree, "object ids were not calculated in-process")
+}
+
+func TestObjectIDsWithAutoCRLFAreCalculatedByGit(t *testing.T) {
+	isolateGitConfig(t)
+
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+	runGit(t, dir, "config", "core.autocrlf", "true")
+
+	f := filepath.Join(dir, "file")
+	fstest.WriteToFile(t, []byte("a\r\nb\r\n"), f)
+
+	// git converts the line endings when storing the file
+	expected, err := blobObjectID(strings.NewReader("a\nb\n"), 4)
+	require.NoError(t, err)
+
+	ids := NewObjectIDs(dir, t.Logf)
+	actual, err := ids.Get(t.Context(), f)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+	assert.Empty(t, ids.workTree, "object ids were calculated in-process")
+}
+
+func TestObjectIDsOfFilesWithAttributesAreNotCalculatedInProcess(t *testing.T) {
+	isolateGitConfig(t)
+
+	dir := t.TempDir()
+	gittest.CreateRepository(t, dir)
+
+	withAttrs := filepath.Join(dir, "sub", "file")
+	withoutAttrs := filepath.Join(dir, "file")
+	fstest.WriteToFile(t, []byte("a\r\nb\r\n"), withAttrs)
+	fstest.WriteToFile(t, []byte("2"), withoutAttrs)
+	fstest.WriteToFile(t, []byte("* text\n"), filepath.Join(dir, "sub", ".gitattributes"))
+
+	ids := NewObjectIDs(dir, t.Logf)
+	_, err := ids.Get(t.Context(), withoutAttrs)
+	require.NoError(t, err)
+	require.Equal(t, dir, ids.workTree)
+
+	_, err = ids.calc(withAttrs)
+	require.ErrorIs(t, err, errContentUncertain)
+
+	expected, err := blobObjectID(strings.NewReader("a\nb\n"), 4)
+	require.NoError(t, err)
+	actual, err := ids.Get(t.Context(), withAttrs)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	_, err = ids.calc(withoutAttrs)
+	require.NoError(t, err)
+}
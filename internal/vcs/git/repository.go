@@ -16,18 +16,22 @@ var ErrRepositoryNotFound = errors.New("git repository not found")
 
 // Repository reads information from a Git repository.
 type Repository struct {
-	path string
+	path      string
+	debugLogf PrintfFn
 
 	lock            sync.Mutex
 	commitID        *string
 	worktreeIsDirty *bool
 	untrackedFiles  set.Set[string]
+	// index is used to determine untracked files instead of
+	// untrackedFiles, if the git index can be read in-process.
+	index *indexPaths
 }
 
 // NewRepositoryWithCheck returns a new Repository object for the git repository at dir.
 // If dir is not part of a git repository, the "git" command is not installed
 // or can not be located in $PATH an error is returned.
-func NewRepositoryWithCheck(dir string) (*Repository, error) {
+func NewRepositoryWithCheck(dir string, debugLogf PrintfFn) (*Repository, error) {
 	if !CommandIsInstalled() {
 		return nil, errors.New("git command not found, ensure git is installed and in $PATH")
 	}
@@ -41,14 +45,16 @@ func NewRepositoryWithCheck(dir string) (*Repository, error) {
 		return nil, ErrRepositoryNotFound
 	}
 
-	return NewRepository(dir), nil
+	return NewRepository(dir, debugLogf), nil
 }
 
 // NewRepository returns a new Repository object that interacts with the git
 // repository in dir.
-func NewRepository(dir string) *Repository {
+// debugLogf is called to log debug messages.
+func NewRepository(dir string, debugLogf PrintfFn) *Repository {
 	return &Repository{
-		path: dir,
+		path:      dir,
+		debugLogf: debugLogf,
 	}
 }
 
@@ -99,6 +105,10 @@ func (g *Repository) WithoutUntracked(paths ...string) ([]string, error) {
 		return nil, err
 	}
 
+	if g.index != nil {
+		return g.withoutUntrackedInIndex(res, paths)
+	}
+
 	for _, p := range paths {
 		var relPath string
 		if filepath.IsAbs(p) {
@@ -121,11 +131,47 @@ func (g *Repository) WithoutUntracked(paths ...string) ([]string, error) {
 	return res, nil
 }
 
+func (g *Repository) withoutUntrackedInIndex(res, paths []string) ([]string, error) {
+	for _, p := range paths {
+		absPath := p
+		if !filepath.IsAbs(p) {
+			absPath = filepath.Join(g.path, p)
+		}
+
+		untracked, err := g.index.isUntracked(absPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if untracked {
+			continue
+		}
+
+		res = append(res, p)
+	}
+
+	return res, nil
+}
+
+// initUntrackedFiles reads the paths of tracked files from the git index.
+// If it is not possible, the untracked files are retrieved via git status.
 func (g *Repository) initUntrackedFiles() error {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
+	if g.index != nil {
+		return nil
+	}
+
 	if g.untrackedFiles == nil {
+		index, err := readIndexPaths(g.path)
+		if err == nil {
+			g.index = index
+			return nil
+		}
+
+		g.debugLogf("git: reading git index failed, falling back to git status: %s\n", err)
+
 		files, err := UntrackedFiles(g.path)
 		if err != nil {
 			return err
@@ -42,7 +42,22 @@ func (h *TrackedObjects) loadFromRepositoryOnce(ctx context.Context) error {
 	return h.loadErr
 }
 
+// loadFromRepository reads the objects from the git index. If it is not
+// possible, the objects are retrieved via git ls-files.
 func (h *TrackedObjects) loadFromRepository(ctx context.Context) error {
+	info, err := readTrackedObjects(h.repositoryDir)
+	if err == nil {
+		h.info = info
+		h.debugLogf("gitobjectid: loaded %d object IDs from git index (%s)\n", len(h.info), h.repositoryDir)
+		return nil
+	}
+
+	h.debugLogf("gitobjectid: reading git index failed, falling back to git ls-files: %s\n", err)
+
+	return h.loadFromRepositoryCLI(ctx)
+}
+
+func (h *TrackedObjects) loadFromRepositoryCLI(ctx context.Context) error {
 	ch := make(chan *Object)
 	finishedCh := make(chan struct{})
 	go h.createDb(ch, finishedCh)
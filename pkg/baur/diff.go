@@ -5,16 +5,20 @@ import (
 	"sort"
 )
 
-// DiffType represents the difference betweeen two baur Inputs.
+// DiffType represents the difference betweeen two baur Inputs or release
+// tasks.
 type DiffType int
 
 const (
 	DigestMismatch DiffType = iota
 	Removed
 	Added
+	// Changed is used for release tasks that have the same total input
+	// digest but differ in their task runs or uploads.
+	Changed
 )
 
-var diffTypeStrings = [...]string{"D", "-", "+"}
+var diffTypeStrings = [...]string{"D", "-", "+", "C"}
 
 func (d DiffType) String() string {
 	return diffTypeStrings[d]
@@ -0,0 +1,64 @@
+package baur
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/simplesurance/baur/v5/internal/digest"
+	"github.com/simplesurance/baur/v5/internal/digest/sha384"
+)
+
+// InputCommandOutput represents the standard output of a command that is
+// tracked as baur input.
+// It is safe for concurrent use, the output of a command is resolved once
+// and shared by all tasks that have it as input.
+type InputCommandOutput struct {
+	command []string
+	output  []byte
+
+	digestOnce sync.Once
+	digest     *digest.Digest
+	digestErr  error
+}
+
+// NewInputCommandOutput creates an InputCommandOutput for command that
+// produced output on stdout.
+func NewInputCommandOutput(command []string, output []byte) *InputCommandOutput {
+	return &InputCommandOutput{command: command, output: output}
+}
+
+func (c *InputCommandOutput) Digest() (*digest.Digest, error) {
+	c.digestOnce.Do(func() {
+		c.digest, c.digestErr = c.calcDigest()
+	})
+
+	return c.digest, c.digestErr
+}
+
+func (c *InputCommandOutput) calcDigest() (*digest.Digest, error) {
+	sha := sha384.New()
+
+	// arguments can not contain NUL bytes, separating them by NUL
+	// ensures that different commands result in different digests
+	err := sha.AddBytes([]byte("CMD: " + strings.Join(c.command, "\x00") + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	err = sha.AddBytes(c.output)
+	if err != nil {
+		return nil, err
+	}
+
+	return sha.Digest(), nil
+}
+
+// String returns the command prefixed with "cmd: ".
+func (c *InputCommandOutput) String() string {
+	return "cmd: " + c.Command()
+}
+
+// Command returns the command and its arguments separated by spaces.
+func (c *InputCommandOutput) Command() string {
+	return strings.Join(c.command, " ")
+}
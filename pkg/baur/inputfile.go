@@ -2,6 +2,7 @@ package baur
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/simplesurance/baur/v5/internal/digest"
 	"github.com/simplesurance/baur/v5/internal/digest/sha384"
@@ -20,7 +21,11 @@ type InputFile struct {
 	fileHasher FileHashFn
 	digest     *digest.Digest
 
-	contentDigest *digest.Digest
+	// contentDigestMu protects contentDigest, it is calculated on
+	// demand and the InputFile can be shared between tasks whose
+	// digests are calculated concurrently.
+	contentDigestMu sync.Mutex
+	contentDigest   *digest.Digest
 }
 
 func WithHashFn(h FileHashFn) InputFileOpt {
@@ -75,12 +80,15 @@ func (f *InputFile) AbsPath() string {
 }
 
 // CalcDigest calculates the digest of the file.
-// The Digest is the sha384 sum of the repoRelPath and the content of the file.
+// The Digest is the sha384 sum of the repoRelPath and the content digest of
+// the file. Content digests that are not git object IDs are prefixed with
+// the name of their algorithm.
 // If neither a file hash function nor the content digest was provided on
 // construction of f, errors.ErrUnsupported is returned.
 func (f *InputFile) CalcDigest() (*digest.Digest, error) {
-	if f.contentDigest == nil && f.fileHasher == nil {
-		return nil, errors.ErrUnsupported
+	contentDigest, err := f.getContentDigest()
+	if err != nil {
+		return nil, err
 	}
 
 	h := sha384.New()
@@ -102,14 +110,6 @@ func (f *InputFile) CalcDigest() (*digest.Digest, error) {
 		}
 	}
 
-	if f.contentDigest == nil {
-		d, err := f.fileHasher(f.absPath)
-		if err != nil {
-			return nil, err
-		}
-		f.contentDigest = d
-	}
-
 	if f.ownerHasExecutablePerm {
 		if err := h.AddBytes([]byte("E:1")); err != nil {
 			return nil, err
@@ -123,13 +123,41 @@ func (f *InputFile) CalcDigest() (*digest.Digest, error) {
 	if err := h.AddBytes([]byte("C:")); err != nil {
 		return nil, err
 	}
-	if err := h.AddBytes(f.contentDigest.Sum); err != nil {
+	// git object IDs are not prefixed with the algorithm to keep the
+	// digests compatible with the ones of previous baur versions
+	if contentDigest.Algorithm != digest.GitObjectID {
+		if err := h.AddBytes([]byte(contentDigest.Algorithm.String() + ":")); err != nil {
+			return nil, err
+		}
+	}
+	if err := h.AddBytes(contentDigest.Sum); err != nil {
 		return nil, err
 	}
 
 	return h.Digest(), nil
 }
 
+// getContentDigest returns the content digest of the file, if it is not
+// known it is calculated via the file hash function.
+func (f *InputFile) getContentDigest() (*digest.Digest, error) {
+	f.contentDigestMu.Lock()
+	defer f.contentDigestMu.Unlock()
+
+	if f.contentDigest == nil && f.fileHasher == nil {
+		return nil, errors.ErrUnsupported
+	}
+
+	if f.contentDigest == nil {
+		d, err := f.fileHasher(f.absPath)
+		if err != nil {
+			return nil, err
+		}
+		f.contentDigest = d
+	}
+
+	return f.contentDigest, nil
+}
+
 // Digest returns the previous calculated digest.
 // If the digest wasn't calculated yet, CalcDigest() is called.
 func (f *InputFile) Digest() (*digest.Digest, error) {
@@ -1,6 +1,8 @@
 package baur
 
 import (
+	"sync"
+
 	"github.com/simplesurance/baur/v5/internal/digest"
 )
 
@@ -10,7 +12,9 @@ type FileHashFn func(path string) (*digest.Digest, error)
 
 // InputFileSingletonCache stores previously created Inputs and returns
 // them for the same path instead of creating another instance.
+// It is safe for concurrent use.
 type InputFileSingletonCache struct {
+	mu    sync.Mutex
 	cache map[string]*InputFile
 }
 
@@ -22,12 +26,24 @@ func NewInputFileSingletonCache() *InputFileSingletonCache {
 }
 
 func (c *InputFileSingletonCache) Get(absPath string) (f *InputFile, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	f, exists = c.cache[absPath]
 	return f, exists
 }
 
 // Add adds f to the cache and returns it.
+// If an InputFile for the same path was added concurrently, f is discarded and
+// the cached InputFile is returned.
 func (c *InputFileSingletonCache) Add(f *InputFile) *InputFile {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, exists := c.cache[f.AbsPath()]; exists {
+		return cached
+	}
+
 	c.cache[f.AbsPath()] = f
 	return f
 }
@@ -1,6 +1,7 @@
 package baur
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -9,9 +10,13 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/simplesurance/baur/v5/internal/digest"
+	"github.com/simplesurance/baur/v5/internal/digest/filecache"
 	"github.com/simplesurance/baur/v5/internal/digest/gitobjectid"
+	"github.com/simplesurance/baur/v5/internal/digest/xxh64"
+	"github.com/simplesurance/baur/v5/internal/exec"
 	"github.com/simplesurance/baur/v5/internal/fs"
 	"github.com/simplesurance/baur/v5/internal/log"
 	"github.com/simplesurance/baur/v5/internal/resolve/glob"
@@ -35,46 +40,105 @@ type goSourceResolver interface {
 
 // InputResolver resolves input definitions of a task to a concrete set of
 // inputs.
+// The inputs of multiple tasks can be resolved concurrently.
 type InputResolver struct {
 	repoDir                 string
 	globPathResolver        *glob.Resolver
 	goSourceResolver        goSourceResolver
 	environmentVariables    map[string]string
+	environmentVariablesSet sync.Once
 	gitRepo                 GitUntrackedFilesResolver
 	inputFileSingletonCache *InputFileSingletonCache
 	resolverCache           *inputResolverCache
 	gitTrackedDb            *git.TrackedObjects
 	fileHashfn              FileHashFn
+	fileDigestAlgorithm     digest.Algorithm
 	fixedInputs             []Input
+	// commandOutputs caches the results of running the commands of
+	// InputCommandOutputs by commandOutputCacheKey, commands are only
+	// run once per InputResolver.
+	commandOutputs   map[string]*commandOutputResult
+	commandOutputsMu sync.Mutex
+}
+
+// commandOutputResult is the result of running the command of an
+// InputCommandOutput.
+type commandOutputResult struct {
+	once  sync.Once
+	input *InputCommandOutput
+	err   error
 }
 
 type GitUntrackedFilesResolver interface {
 	WithoutUntracked(paths ...string) ([]string, error)
 }
 
-func NewInputResolver(gitRepo GitUntrackedFilesResolver, repoDir string, fixedInputs []Input, hashGitUntrackedFiles bool) *InputResolver {
+type InputResolverOpt func(*InputResolver)
+
+// WithFileDigestAlgorithm sets the algorithm that is used to calculate the
+// digests of files that are untracked or modified in the git repository.
+// Supported algorithms are [digest.GitObjectID] (default) and
+// [digest.XXH64].
+func WithFileDigestAlgorithm(algorithm digest.Algorithm) InputResolverOpt {
+	return func(i *InputResolver) {
+		i.fileDigestAlgorithm = algorithm
+	}
+}
+
+func NewInputResolver(
+	gitRepo GitUntrackedFilesResolver,
+	repoDir string,
+	fixedInputs []Input,
+	hashGitUntrackedFiles bool,
+	opts ...InputResolverOpt,
+) *InputResolver {
 	result := InputResolver{
 		repoDir:                 repoDir,
-		globPathResolver:        &glob.Resolver{},
+		globPathResolver:        glob.NewResolver(repoDir),
 		goSourceResolver:        gosource.NewResolver(log.Debugf),
 		gitRepo:                 gitRepo,
 		resolverCache:           newInputResolverCache(),
 		inputFileSingletonCache: NewInputFileSingletonCache(),
+		fileDigestAlgorithm:     digest.GitObjectID,
 		fixedInputs:             fixedInputs,
+		commandOutputs:          map[string]*commandOutputResult{},
+	}
+
+	for _, opt := range opts {
+		opt(&result)
 	}
 
 	result.gitTrackedDb = git.NewTrackedObjects(repoDir, log.Debugf)
-	g := gitobjectid.New(repoDir, log.Debugf)
-	log.Debugf("inputresolver: using gitobject file hasher\n")
 
-	if hashGitUntrackedFiles {
-		result.fileHashfn = g.File
+	if !hashGitUntrackedFiles {
 		return &result
 	}
 
+	switch result.fileDigestAlgorithm {
+	case digest.XXH64:
+		log.Debugf("inputresolver: using xxh64 file hasher\n")
+		result.fileHashfn = xxh64.File
+	default:
+		log.Debugf("inputresolver: using gitobject file hasher\n")
+		result.fileHashfn = gitobjectid.New(repoDir, log.Debugf).File
+	}
+
 	return &result
 }
 
+// UseFileDigestCache configures the resolver to look up digests of files
+// that are not tracked or modified in the git repository in c, before
+// calculating them.
+// It has no effect when the resolver does not calculate digests of those
+// files.
+func (i *InputResolver) UseFileDigestCache(c *filecache.Cache) {
+	if i.fileHashfn == nil {
+		return
+	}
+
+	i.fileHashfn = FileHashFn(c.HashFn(i.fileDigestAlgorithm, filecache.HashFn(i.fileHashfn)))
+}
+
 // Resolve resolves the input definition of a task. The result is stored in task.inputs.
 // If an input definition does not resolve to >=1 paths, an error is returned.
 // The resolved Files are deduplicated.
@@ -105,6 +169,11 @@ func (i *InputResolver) Resolve(ctx context.Context, task *Task) (*Inputs, error
 		return nil, err
 	}
 
+	commandOutputs, err := i.resolveCommandOutputInputs(ctx, task.Directory, task.UnresolvedInputs.CommandOutput)
+	if err != nil {
+		return nil, fmt.Errorf("resolving command output inputs failed: %w", err)
+	}
+
 	stats := i.resolverCache.Statistics()
 	log.Debugf("inputresolver: cache statistic: %d entries, %d hits, %d miss, ratio %.2f%%\n",
 		stats.Entries, stats.Hits, stats.Miss, stats.HitRatio())
@@ -113,8 +182,10 @@ func (i *InputResolver) Resolve(ctx context.Context, task *Task) (*Inputs, error
 		uniqInputs,
 		envVarMapToInputslice(envVars),
 		inputTasks,
+		commandOutputs,
 		i.fixedInputs,
 	))
+	inputs.fileDigestAlgorithm = i.fileDigestAlgorithm
 
 	return inputs, nil
 }
@@ -193,9 +264,10 @@ func (i *InputResolver) resolveFileInputs(appDir string, inputs []cfg.FileInputs
 			}
 
 			cacheKey := inputResolverFileCacheKey{
-				Path:           path,
-				GitTrackedOnly: in.GitTrackedOnly,
-				Optional:       in.Optional,
+				Path:              path,
+				GitTrackedOnly:    in.GitTrackedOnly,
+				ExcludeGitIgnored: in.ExcludeGitIgnored,
+				Optional:          in.Optional,
 			}
 			if files := i.resolverCache.GetFileInputs(&cacheKey); files != nil {
 				result = append(result, files...)
@@ -207,6 +279,11 @@ func (i *InputResolver) resolveFileInputs(appDir string, inputs []cfg.FileInputs
 				return nil, err
 			}
 
+			resolvedPaths, err = i.globPathResolver.WithoutIgnored(resolvedPaths, in.ExcludeGitIgnored)
+			if err != nil {
+				return nil, fmt.Errorf("removing ignored files for input %q failed: %w", path, err)
+			}
+
 			if len(resolvedPaths) > 0 && in.GitTrackedOnly {
 				trackedOnlyPaths, err := i.gitRepo.WithoutUntracked(resolvedPaths...)
 				if err != nil {
@@ -424,24 +501,22 @@ func (i *InputResolver) queryGitTrackedDb(ctx context.Context, absPath string) (
 }
 
 func (i *InputResolver) setEnvVars() {
-	if i.environmentVariables != nil {
-		return
-	}
-
-	// os.Environ() does not return env variables that are declared but undefined.
-	// environment variables that have an empty string assigned are returned.
-	environ := os.Environ()
-	i.environmentVariables = make(map[string]string, len(environ))
+	i.environmentVariablesSet.Do(func() {
+		// os.Environ() does not return env variables that are declared but undefined.
+		// environment variables that have an empty string assigned are returned.
+		environ := os.Environ()
+		i.environmentVariables = make(map[string]string, len(environ))
+
+		for _, env := range environ {
+			k, v, found := strings.Cut(env, "=")
+			if !found {
+				// impossible scenario
+				panic(fmt.Sprintf("element %q returned by os.Environ() does not contain a '=' character", env))
+			}
 
-	for _, env := range environ {
-		k, v, found := strings.Cut(env, "=")
-		if !found {
-			// impossible scenario
-			panic(fmt.Sprintf("element %q returned by os.Environ() does not contain a '=' character", env))
+			i.environmentVariables[k] = v
 		}
-
-		i.environmentVariables[k] = v
-	}
+	})
 }
 
 func (i *InputResolver) getEnvVar(namePattern string) (map[string]string, error) {
@@ -498,6 +573,55 @@ func (i *InputResolver) resolveEnvVarInputs(inputs []cfg.EnvVarsInputs) (map[str
 	return resolvedEnvVars, nil
 }
 
+func commandOutputCacheKey(dir string, command []string) string {
+	return dir + "\x00" + strings.Join(command, "\x00")
+}
+
+// resolveCommandOutputInputs runs the commands of inputs in dir and returns
+// their standard outputs as inputs.
+// Commands that were already run in dir by the InputResolver are not run
+// again, the cached result is returned.
+func (i *InputResolver) resolveCommandOutputInputs(ctx context.Context, dir string, inputs []cfg.CommandOutputInputs) ([]Input, error) {
+	result := make([]Input, 0, len(inputs))
+
+	for _, in := range inputs {
+		res := i.commandOutputResult(commandOutputCacheKey(dir, in.Command))
+		res.once.Do(func() {
+			var stdout bytes.Buffer
+			_, res.err = exec.Command(in.Command[0], in.Command[1:]...).
+				Directory(dir).
+				Stdout(&stdout).
+				ExpectSuccess().
+				Run(ctx)
+			if res.err == nil {
+				res.input = NewInputCommandOutput(in.Command, stdout.Bytes())
+			}
+		})
+		if res.err != nil {
+			return nil, res.err
+		}
+
+		result = append(result, res.input)
+	}
+
+	return result, nil
+}
+
+// commandOutputResult returns the cached commandOutputResult for cacheKey,
+// if none exists an empty one is added to the cache.
+func (i *InputResolver) commandOutputResult(cacheKey string) *commandOutputResult {
+	i.commandOutputsMu.Lock()
+	defer i.commandOutputsMu.Unlock()
+
+	res, exists := i.commandOutputs[cacheKey]
+	if !exists {
+		res = &commandOutputResult{}
+		i.commandOutputs[cacheKey] = res
+	}
+
+	return res
+}
+
 func envVarMapToInputslice(envVars map[string]string) []Input {
 	res := make([]Input, 0, len(envVars))
 
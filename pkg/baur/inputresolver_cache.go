@@ -29,9 +29,10 @@ type inputResolverCacheStats struct {
 }
 
 type inputResolverFileCacheKey struct {
-	Path           string
-	GitTrackedOnly bool
-	Optional       bool
+	Path              string
+	GitTrackedOnly    bool
+	ExcludeGitIgnored bool
+	Optional          bool
 
 	createKeyOnce sync.Once
 	key           string
@@ -43,6 +44,7 @@ func (k *inputResolverFileCacheKey) cacheKey() string {
 
 		key.WriteString(k.Path)
 		key.WriteString(strconv.FormatBool(k.GitTrackedOnly))
+		key.WriteString(strconv.FormatBool(k.ExcludeGitIgnored))
 		key.WriteString(strconv.FormatBool(k.Optional))
 
 		k.key = key.String()
@@ -7,11 +7,13 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/simplesurance/baur/v5/internal/digest"
+	"github.com/simplesurance/baur/v5/internal/digest/filecache"
 	"github.com/simplesurance/baur/v5/internal/exec"
 	"github.com/simplesurance/baur/v5/internal/fs"
 	"github.com/simplesurance/baur/v5/internal/log"
@@ -274,7 +276,7 @@ func TestFilesOptional(t *testing.T) {
 				gittest.CommitFilesToGit(t, tempDir)
 			}
 
-			r := NewInputResolver(git.NewRepository(tempDir), tempDir, nil, true)
+			r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true)
 
 			tc.task.Directory = tempDir
 
@@ -314,7 +316,7 @@ func TestPathsAfterMissingOptionalOneAreNotIgnored(t *testing.T) {
 	tempDir := t.TempDir()
 	gittest.CreateRepository(t, tempDir)
 
-	r := NewInputResolver(git.NewRepository(tempDir), tempDir, nil, true)
+	r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true)
 
 	fstest.WriteToFile(t, []byte("123"), filepath.Join(tempDir, fname))
 
@@ -359,7 +361,7 @@ func TestResolverIgnoredGitUntrackedFiles(t *testing.T) {
 	const untrackedFilename = "file2.txt"
 	fstest.WriteToFile(t, []byte("123"), filepath.Join(appDir, untrackedFilename))
 
-	r := NewInputResolver(git.NewRepository(gitDir), gitDir, nil, true)
+	r := NewInputResolver(git.NewRepository(gitDir, t.Logf), gitDir, nil, true)
 
 	resolvedFiles, err := r.resolveFileInputs(appDir, []cfg.FileInputs{
 		{
@@ -529,7 +531,7 @@ func TestExcludedFiles(t *testing.T) {
 				fstest.WriteToFile(t, []byte(f), filepath.Join(tempDir, f))
 			}
 
-			resolver := NewInputResolver(git.NewRepository(tempDir), tempDir, nil, true)
+			resolver := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true)
 			result, err := resolver.Resolve(t.Context(), &Task{
 				Directory:        tempDir,
 				UnresolvedInputs: &tc.Inputs,
@@ -543,6 +545,61 @@ func TestExcludedFiles(t *testing.T) {
 	}
 }
 
+func TestIgnoreFiles(t *testing.T) {
+	testcases := []struct {
+		Name              string
+		ExcludeGitIgnored bool
+		ExpectedResult    []string
+	}{
+		{
+			Name:           "baurignore",
+			ExpectedResult: []string{".baurignore", ".gitignore", "main.c", "main.o"},
+		},
+		{
+			Name:              "baurignore_and_gitignore",
+			ExcludeGitIgnored: true,
+			ExpectedResult:    []string{".baurignore", ".gitignore", "main.c"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			log.RedirectToTestingLog(t)
+			tempDir := t.TempDir()
+			gittest.CreateRepository(t, tempDir)
+
+			fstest.WriteToFile(t, []byte("*.o\n"), filepath.Join(tempDir, ".gitignore"))
+			fstest.WriteToFile(t, []byte("tmp/\n"), filepath.Join(tempDir, ".baurignore"))
+			for _, f := range []string{"main.c", "main.o", "tmp/main.c"} {
+				fstest.WriteToFile(t, []byte(f), filepath.Join(tempDir, f))
+			}
+
+			resolver := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true)
+			result, err := resolver.Resolve(t.Context(), &Task{
+				Directory: tempDir,
+				UnresolvedInputs: &cfg.Input{
+					Files: []cfg.FileInputs{
+						{
+							Paths:             []string{"*"},
+							ExcludeGitIgnored: tc.ExcludeGitIgnored,
+						},
+						{
+							Paths:             []string{"tmp/*"},
+							ExcludeGitIgnored: tc.ExcludeGitIgnored,
+							Optional:          true,
+						},
+					},
+				},
+			})
+
+			require.NoError(t, err)
+
+			strResult := toStrSlice(result.Inputs())
+			assert.ElementsMatch(t, strResult, tc.ExpectedResult)
+		})
+	}
+}
+
 func toStrSlice[S ~[]T, T fmt.Stringer](in S) []string {
 	result := make([]string, len(in))
 
@@ -577,7 +634,7 @@ func TestGoResolverFilesAreExcluded(t *testing.T) {
 	fstest.WriteToFile(t, []byte("b"), f2)
 	gittest.CreateRepository(t, baseDir)
 
-	resolver := NewInputResolver(git.NewRepository(baseDir), baseDir, nil, true)
+	resolver := NewInputResolver(git.NewRepository(baseDir, t.Logf), baseDir, nil, true)
 	resolver.goSourceResolver = &goSourceResolverMock{result: []string{f1, f2}}
 
 	result, err := resolver.Resolve(
@@ -714,7 +771,7 @@ func TestResolveSymlink(t *testing.T) {
 			repoDir := filepath.Join(testdataDir, "symlinks")
 
 			gittest.CreateRepository(t, repoDir)
-			r := NewInputResolver(git.NewRepository(repoDir), repoDir, nil, true)
+			r := NewInputResolver(git.NewRepository(repoDir, t.Logf), repoDir, nil, true)
 
 			result, err := r.Resolve(t.Context(), &Task{
 				Directory: filepath.Join(repoDir, tc.testdir),
@@ -843,7 +900,7 @@ func prepareSymlinkTestDir(t *testing.T, commitFilesToGit bool) *symlinkTestInfo
 func resolveInputs(t *testing.T, task *Task, hashGitUntracked bool) (*Inputs, *digest.Digest) {
 	t.Helper()
 
-	resolver := NewInputResolver(git.NewRepository(task.RepositoryRoot), task.RepositoryRoot, nil, hashGitUntracked)
+	resolver := NewInputResolver(git.NewRepository(task.RepositoryRoot, t.Logf), task.RepositoryRoot, nil, hashGitUntracked)
 	result, err := resolver.Resolve(
 		t.Context(),
 		task,
@@ -922,7 +979,7 @@ func TestHashGitUntrackedFilesDisabled(t *testing.T) {
 		},
 	}
 
-	r := NewInputResolver(git.NewRepository(tempDir), tempDir, nil, false)
+	r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, false)
 	_, err := r.Resolve(t.Context(), task)
 	require.ErrorIs(t, err, git.ErrObjectNotFound)
 }
@@ -957,7 +1014,7 @@ func TestFileInSymlinkDir(t *testing.T) {
 				},
 			}
 
-			r := NewInputResolver(git.NewRepository(tempDir), tempDir, nil, !hashGitUntracked)
+			r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, !hashGitUntracked)
 			inputs, err := r.Resolve(t.Context(), task)
 			require.NoError(t, err)
 			require.Len(t, inputs.Inputs(), 1)
@@ -975,3 +1032,140 @@ func TestFileInSymlinkDir(t *testing.T) {
 	testFn(true)
 	testFn(false)
 }
+
+func TestFileDigestCache(t *testing.T) {
+	const fname = "hello"
+
+	log.RedirectToTestingLog(t)
+	tempDir := t.TempDir()
+	gittest.CreateRepository(t, tempDir)
+
+	fpath := filepath.Join(tempDir, fname)
+	fstest.WriteToFile(t, []byte("123"), fpath)
+	mtime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(fpath, mtime, mtime))
+
+	task := &Task{
+		Directory: tempDir,
+		UnresolvedInputs: &cfg.Input{
+			Files: []cfg.FileInputs{{Paths: []string{fname}}},
+		},
+	}
+
+	cachePath := filepath.Join(t.TempDir(), "digests.json")
+	var hashFnCalls int
+
+	resolve := func() *digest.Digest {
+		cache, err := filecache.Open(cachePath, t.Logf)
+		require.NoError(t, err)
+
+		r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true, WithFileDigestAlgorithm(digest.XXH64))
+		hashFn := r.fileHashfn
+		r.fileHashfn = func(path string) (*digest.Digest, error) {
+			hashFnCalls++
+			return hashFn(path)
+		}
+		r.UseFileDigestCache(cache)
+
+		inputs, err := r.Resolve(t.Context(), task)
+		require.NoError(t, err)
+
+		d, err := inputs.Digest()
+		require.NoError(t, err)
+		require.NoError(t, cache.Save())
+
+		return d
+	}
+
+	uncachedDigest := resolve()
+	require.Equal(t, 1, hashFnCalls)
+
+	assert.Equal(t, uncachedDigest, resolve())
+	assert.Equal(t, 1, hashFnCalls)
+}
+
+func TestFileDigestCacheGitAttributesChange(t *testing.T) {
+	const fname = "hello"
+
+	log.RedirectToTestingLog(t)
+	tempDir := t.TempDir()
+	gittest.CreateRepository(t, tempDir)
+
+	fpath := filepath.Join(tempDir, fname)
+	fstest.WriteToFile(t, []byte("a\r\nb\r\n"), fpath)
+	mtime := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(fpath, mtime, mtime))
+
+	task := &Task{
+		Directory: tempDir,
+		UnresolvedInputs: &cfg.Input{
+			Files: []cfg.FileInputs{{Paths: []string{fname}}},
+		},
+	}
+
+	cachePath := filepath.Join(t.TempDir(), "digests.json")
+
+	resolve := func() *digest.Digest {
+		cache, err := filecache.Open(cachePath, t.Logf)
+		require.NoError(t, err)
+
+		r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true)
+		r.UseFileDigestCache(cache)
+
+		inputs, err := r.Resolve(t.Context(), task)
+		require.NoError(t, err)
+
+		d, err := inputs.Digest()
+		require.NoError(t, err)
+		require.NoError(t, cache.Save())
+
+		return d
+	}
+
+	digestBefore := resolve()
+
+	// git converts the line endings of the file when the text attribute
+	// is set, this changes its object ID
+	fstest.WriteToFile(t, []byte(fname+" text\n"), filepath.Join(tempDir, ".gitattributes"))
+
+	assert.NotEqual(t, digestBefore.String(), resolve().String())
+}
+
+func TestFileDigestAlgorithm(t *testing.T) {
+	const fname = "hello"
+
+	log.RedirectToTestingLog(t)
+	tempDir := t.TempDir()
+	gittest.CreateRepository(t, tempDir)
+
+	fstest.WriteToFile(t, []byte("123"), filepath.Join(tempDir, fname))
+
+	task := &Task{
+		Directory: tempDir,
+		UnresolvedInputs: &cfg.Input{
+			Files: []cfg.FileInputs{{Paths: []string{fname}}},
+		},
+	}
+
+	resolve := func(opts ...InputResolverOpt) (*Inputs, *digest.Digest) {
+		r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true, opts...)
+		inputs, err := r.Resolve(t.Context(), task)
+		require.NoError(t, err)
+
+		d, err := inputs.Digest()
+		require.NoError(t, err)
+
+		return inputs, d
+	}
+
+	_, gitObjectIDDigest := resolve()
+
+	inputs, xxh64Digest := resolve(WithFileDigestAlgorithm(digest.XXH64))
+	require.Len(t, inputs.Inputs(), 1)
+	require.IsType(t, &InputFile{}, inputs.Inputs()[0])
+	assert.Equal(t, digest.XXH64, inputs.Inputs()[0].(*InputFile).contentDigest.Algorithm)
+	assert.NotEqual(t, gitObjectIDDigest, xxh64Digest)
+
+	_, d := resolve(WithFileDigestAlgorithm(digest.XXH64))
+	assert.Equal(t, xxh64Digest, d)
+}
@@ -5,13 +5,19 @@ package baur
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/simplesurance/baur/v5/internal/exec"
 	"github.com/simplesurance/baur/v5/internal/log"
 	"github.com/simplesurance/baur/v5/internal/testutils/gittest"
+	"github.com/simplesurance/baur/v5/internal/vcs/git"
+	"github.com/simplesurance/baur/v5/pkg/cfg"
+	"github.com/simplesurance/baur/v5/pkg/storage/memory"
 )
 
 func TestSymlinkTargetFilePermissionsChange(t *testing.T) {
@@ -31,3 +37,161 @@ func TestSymlinkTargetFilePermissionsChange(t *testing.T) {
 			})
 	}
 }
+
+func TestResolveCommandOutputInputs(t *testing.T) {
+	log.RedirectToTestingLog(t)
+
+	dir := t.TempDir()
+	versionFile := filepath.Join(dir, "version")
+	runCntFile := filepath.Join(dir, "runs")
+
+	require.NoError(t, os.WriteFile(versionFile, []byte("1.0"), 0o644))
+
+	task := Task{
+		ID:        "app.build",
+		Directory: dir,
+		UnresolvedInputs: &cfg.Input{
+			CommandOutput: []cfg.CommandOutputInputs{
+				{Command: []string{"sh", "-c", "echo run >> runs; cat version"}},
+			},
+		},
+	}
+
+	resolver := NewInputResolver(&DummyGitUntrackedFilesResolver{}, dir, nil, true)
+	inputs, err := resolver.Resolve(t.Context(), &task)
+	require.NoError(t, err)
+	require.Len(t, inputs.Inputs(), 1)
+	assert.Equal(t, "cmd: sh -c echo run >> runs; cat version", inputs.Inputs()[0].String())
+
+	digest1, err := inputs.Digest()
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(versionFile, []byte("2.0"), 0o644))
+
+	t.Run("result is cached", func(t *testing.T) {
+		inputs, err := resolver.Resolve(t.Context(), &task)
+		require.NoError(t, err)
+
+		digest, err := inputs.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, digest1.String(), digest.String())
+
+		runs, err := os.ReadFile(runCntFile)
+		require.NoError(t, err)
+		assert.Equal(t, "run\n", string(runs))
+	})
+
+	t.Run("output change changes digest", func(t *testing.T) {
+		resolver := NewInputResolver(&DummyGitUntrackedFilesResolver{}, dir, nil, true)
+		inputs, err := resolver.Resolve(t.Context(), &task)
+		require.NoError(t, err)
+
+		digest, err := inputs.Digest()
+		require.NoError(t, err)
+		assert.NotEqual(t, digest1.String(), digest.String())
+	})
+
+	t.Run("failing command returns error", func(t *testing.T) {
+		task := task
+		task.UnresolvedInputs = &cfg.Input{
+			CommandOutput: []cfg.CommandOutputInputs{{Command: []string{"false"}}},
+		}
+
+		resolver := NewInputResolver(&DummyGitUntrackedFilesResolver{}, dir, nil, true)
+		_, err := resolver.Resolve(t.Context(), &task)
+		require.Error(t, err)
+	})
+}
+
+func TestResolveCommandOutputInputsConcurrently(t *testing.T) {
+	const resolveCnt = 10
+
+	log.RedirectToTestingLog(t)
+
+	dir := t.TempDir()
+	runCntFile := filepath.Join(dir, "runs")
+
+	task := Task{
+		ID:        "app.build",
+		Directory: dir,
+		UnresolvedInputs: &cfg.Input{
+			CommandOutput: []cfg.CommandOutputInputs{
+				{Command: []string{"sh", "-c", "echo run >> runs; echo 1.0"}},
+			},
+		},
+	}
+
+	resolver := NewInputResolver(&DummyGitUntrackedFilesResolver{}, dir, nil, true)
+
+	var wg sync.WaitGroup
+	errs := make([]error, resolveCnt)
+	for i := range resolveCnt {
+		wg.Go(func() {
+			_, errs[i] = resolver.Resolve(t.Context(), &task)
+		})
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	runs, err := os.ReadFile(runCntFile)
+	require.NoError(t, err)
+	assert.Equal(t, "run\n", string(runs))
+}
+
+func TestStatusesWithSharedInputsConcurrently(t *testing.T) {
+	const taskCnt = 20
+
+	ctx := t.Context()
+
+	log.RedirectToTestingLog(t)
+	tempDir := t.TempDir()
+	gittest.CreateRepository(t, tempDir)
+
+	tasks := make([]*Task, 0, taskCnt)
+	for i := range taskCnt {
+		tasks = append(tasks, &Task{
+			AppName:   "shop",
+			Name:      fmt.Sprintf("task%d", i),
+			Directory: tempDir,
+			UnresolvedInputs: &cfg.Input{
+				CommandOutput: []cfg.CommandOutputInputs{
+					{Command: []string{"sh", "-c", "echo 1.0"}},
+				},
+			},
+		})
+	}
+
+	store := memory.New()
+	require.NoError(t, store.Init(ctx))
+
+	// the shared inputs are created by each InputResolver, evaluate the
+	// statuses multiple times to increase the chance that races happen
+	for range 10 {
+		te := NewTaskStatusEvaluator(
+			tempDir,
+			store,
+			NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, AsInputStrings("hello"), true),
+			"",
+			WithResolveParallelism(8),
+		)
+
+		result, err := te.Statuses(ctx, tasks, nil)
+		require.NoError(t, err)
+		require.Len(t, result, taskCnt)
+
+		expectedDigest, err := result[0].Inputs.Digest()
+		require.NoError(t, err)
+
+		for _, st := range result {
+			assert.Equal(t, TaskStatusExecutionPending, st.Status)
+			assert.Len(t, st.Inputs.Inputs(), 2)
+
+			digest, err := st.Inputs.Digest()
+			require.NoError(t, err)
+			assert.Equal(t, expectedDigest.String(), digest.String(), st.Task.Name)
+		}
+	}
+}
@@ -9,15 +9,32 @@ import (
 	"github.com/simplesurance/baur/v5/internal/digest/sha384"
 )
 
+// Versions of the calculation of the total input digest.
+const (
+	// inputsDigestV1 is the sha384 sum of the input digests.
+	inputsDigestV1 = 1
+	// inputsDigestV2 is the sha384 sum of the version, the name of the
+	// algorithm that is used to calculate digests of files that are
+	// untracked or modified in git and the v1 digest.
+	// It is used when the file digest algorithm is not
+	// [digest.GitObjectID], the total digests of tasks then never match
+	// the ones that were calculated with a different algorithm.
+	inputsDigestV2 = 2
+)
+
 // Inputs are resolved Inputs of a task.
 type Inputs struct {
 	inputs []Input
 	digest *digest.Digest
+	// fileDigestAlgorithm is the algorithm that was used to calculate
+	// digests of files that are untracked or modified in git, it
+	// determines the version of the total input digest.
+	fileDigestAlgorithm digest.Algorithm
 }
 
 // NewInputs returns an new Inputs
 func NewInputs(in []Input) *Inputs {
-	return &Inputs{inputs: in}
+	return &Inputs{inputs: in, fileDigestAlgorithm: digest.GitObjectID}
 }
 
 // Inputs returns all stored Inputs
@@ -60,11 +77,41 @@ func (in *Inputs) Digest() (*digest.Digest, error) {
 		return nil, err
 	}
 
+	if in.digestVersion() == inputsDigestV2 {
+		totalDigest, err = digestV2(in.fileDigestAlgorithm, totalDigest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	in.digest = totalDigest
 
 	return in.digest, nil
 }
 
+func (in *Inputs) digestVersion() int {
+	if in.fileDigestAlgorithm == digest.GitObjectID {
+		return inputsDigestV1
+	}
+
+	return inputsDigestV2
+}
+
+func digestV2(fileDigestAlgorithm digest.Algorithm, v1Digest *digest.Digest) (*digest.Digest, error) {
+	h := sha384.New()
+
+	err := h.AddBytes(fmt.Appendf(nil, "V:%d\nF:%s\n", inputsDigestV2, fileDigestAlgorithm))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.AddBytes(v1Digest.Sum); err != nil {
+		return nil, err
+	}
+
+	return h.Digest(), nil
+}
+
 // Sort order the inputs alphabetically by their string representation.
 func (in *Inputs) Sort() {
 	slices.SortFunc(in.inputs, func(a, b Input) int {
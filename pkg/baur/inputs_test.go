@@ -0,0 +1,43 @@
+package baur
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/digest"
+	"github.com/simplesurance/baur/v5/internal/digest/sha384"
+)
+
+func TestInputsDigestVersions(t *testing.T) {
+	in := AsInputStrings("hello", "world")
+
+	digests := make([]*digest.Digest, 0, len(in))
+	for _, i := range in {
+		d, err := i.Digest()
+		require.NoError(t, err)
+		digests = append(digests, d)
+	}
+
+	v1Digest, err := sha384.Sum(digests)
+	require.NoError(t, err)
+
+	inputs := NewInputs(in)
+	d, err := inputs.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, v1Digest, d, "total digest for git object IDs differs from the v1 digest")
+
+	inputs = NewInputs(in)
+	inputs.fileDigestAlgorithm = digest.XXH64
+	v2Digest, err := inputs.Digest()
+	require.NoError(t, err)
+	assert.NotEqual(t, v1Digest, v2Digest)
+	assert.Equal(t, digest.SHA384, v2Digest.Algorithm)
+
+	inputs = NewInputs(in)
+	inputs.fileDigestAlgorithm = digest.XXH64
+	d, err = inputs.Digest()
+	require.NoError(t, err)
+	assert.Equal(t, v2Digest, d)
+}
@@ -2,15 +2,21 @@ package baur
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/simplesurance/baur/v5/internal/digest"
 	"github.com/simplesurance/baur/v5/internal/digest/sha384"
 )
 
-// InputString represents a string
+// InputString represents a string.
+// It is safe for concurrent use, the same InputString can be an input of
+// multiple tasks.
 type InputString struct {
-	value  string
-	digest *digest.Digest
+	value string
+
+	digestOnce sync.Once
+	digest     *digest.Digest
+	digestErr  error
 }
 
 // NewInputString returns a new InputString
@@ -19,14 +25,13 @@ func NewInputString(val string) *InputString {
 }
 
 // Digest returns the previous calculated digest.
-// If the digest wasn't calculated yet, CalcDigest() is called and it's return
-// values are returned.
+// If the digest wasn't calculated yet, it is calculated and returned.
 func (i *InputString) Digest() (*digest.Digest, error) {
-	if i.digest != nil {
-		return i.digest, nil
-	}
+	i.digestOnce.Do(func() {
+		i.digest, i.digestErr = i.calcDigest()
+	})
 
-	return i.calcDigest()
+	return i.digest, i.digestErr
 }
 
 // String returns it's string representation (string:VAL)
@@ -39,7 +44,7 @@ func (i *InputString) Value() string {
 	return i.value
 }
 
-// CalcDigest calculates the digest of the string, saves it and returns it.
+// calcDigest calculates the digest of the string and returns it.
 func (i *InputString) calcDigest() (*digest.Digest, error) {
 	sha := sha384.New()
 
@@ -48,9 +53,7 @@ func (i *InputString) calcDigest() (*digest.Digest, error) {
 		return nil, err
 	}
 
-	i.digest = sha.Digest()
-
-	return i.digest, nil
+	return sha.Digest(), nil
 }
 
 // AsInputStrings returns InputStrings for all elements in strs.
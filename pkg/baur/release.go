@@ -25,8 +25,8 @@ type ReleaseOutput struct {
 }
 
 type ReleaseUpload struct {
-	UploadMethod string
-	URI          string
+	UploadMethod string `yaml:"UploadMethod"`
+	URI          string `yaml:"URI"`
 }
 
 // ToJSON encodes the Release to JSON and writes the result to w.
@@ -0,0 +1,175 @@
+package baur
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/internal/set"
+)
+
+// ReleaseTaskDiff describes how a task differs between two releases.
+type ReleaseTaskDiff struct {
+	// State is [Added] if the task only exists in the new release,
+	// [Removed] if it only exists in the old release, [DigestMismatch] if
+	// the total input digests of the task runs differ and [Changed] if the
+	// task runs or their uploads differ.
+	State    DiffType
+	AppName  string
+	TaskName string
+	// RunID1 and TotalInputDigest1 are unset if the task does not exist in
+	// the old release.
+	RunID1            int
+	TotalInputDigest1 string
+	// RunID2 and TotalInputDigest2 are unset if the task does not exist in
+	// the new release.
+	RunID2            int
+	TotalInputDigest2 string
+	// RemovedURIs are the output URIs of the task that only exist in the
+	// old release, AddedURIs the ones that only exist in the new release.
+	// Both are sorted.
+	RemovedURIs []string
+	AddedURIs   []string
+}
+
+// TaskID returns the ID of the task.
+func (d *ReleaseTaskDiff) TaskID() string {
+	return taskID(d.AppName, d.TaskName)
+}
+
+type releaseTask struct {
+	runID            int
+	totalInputDigest string
+	uris             set.Set[string]
+}
+
+// DiffReleases returns the tasks that differ between the releases named
+// oldName and newName, sorted by their task IDs.
+// Tasks that are the same in both releases are not part of the result.
+// If one of the releases does not exist, an error wrapping
+// [storage.ErrNotExist] is returned.
+func (m *ReleaseManager) DiffReleases(ctx context.Context, oldName, newName string) ([]*ReleaseTaskDiff, error) {
+	oldTasks, err := m.releaseTasks(ctx, oldName)
+	if err != nil {
+		return nil, err
+	}
+
+	newTasks, err := m.releaseTasks(ctx, newName)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*ReleaseTaskDiff
+
+	for tid, oldTask := range oldTasks {
+		newTask, exists := newTasks[tid]
+		if !exists {
+			d := newReleaseTaskDiff(Removed, tid)
+			d.RunID1 = oldTask.runID
+			d.TotalInputDigest1 = oldTask.totalInputDigest
+			d.RemovedURIs = sortedSlice(oldTask.uris)
+			result = append(result, d)
+			continue
+		}
+
+		removedURIs := difference(oldTask.uris, newTask.uris)
+		addedURIs := difference(newTask.uris, oldTask.uris)
+		if oldTask.runID == newTask.runID && len(removedURIs) == 0 && len(addedURIs) == 0 {
+			continue
+		}
+
+		d := newReleaseTaskDiff(Changed, tid)
+		if oldTask.totalInputDigest != newTask.totalInputDigest {
+			d.State = DigestMismatch
+		}
+		d.RunID1 = oldTask.runID
+		d.TotalInputDigest1 = oldTask.totalInputDigest
+		d.RunID2 = newTask.runID
+		d.TotalInputDigest2 = newTask.totalInputDigest
+		d.RemovedURIs = sortedSlice(removedURIs)
+		d.AddedURIs = sortedSlice(addedURIs)
+		result = append(result, d)
+	}
+
+	for tid, newTask := range newTasks {
+		if _, exists := oldTasks[tid]; exists {
+			continue
+		}
+
+		d := newReleaseTaskDiff(Added, tid)
+		d.RunID2 = newTask.runID
+		d.TotalInputDigest2 = newTask.totalInputDigest
+		d.AddedURIs = sortedSlice(newTask.uris)
+		result = append(result, d)
+	}
+
+	slices.SortFunc(result, func(a, b *ReleaseTaskDiff) int {
+		return strings.Compare(a.TaskID(), b.TaskID())
+	})
+
+	return result, nil
+}
+
+func newReleaseTaskDiff(state DiffType, tid string) *ReleaseTaskDiff {
+	appName, taskName, _ := strings.Cut(tid, ".")
+
+	return &ReleaseTaskDiff{
+		State:    state,
+		AppName:  appName,
+		TaskName: taskName,
+	}
+}
+
+// releaseTasks returns the task runs and their output URIs of a release,
+// by task ID.
+func (m *ReleaseManager) releaseTasks(ctx context.Context, releaseName string) (map[string]*releaseTask, error) {
+	taskRuns, err := m.storage.ReleaseTaskRuns(ctx, releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", releaseName, err)
+	}
+
+	result := map[string]*releaseTask{}
+	for _, tr := range taskRuns {
+		tid := taskID(tr.AppName, tr.TaskName)
+
+		t, exists := result[tid]
+		if !exists {
+			t = &releaseTask{
+				runID:            tr.RunID,
+				totalInputDigest: tr.TotalInputDigest,
+				uris:             set.Set[string]{},
+			}
+			result[tid] = t
+		}
+
+		if tr.URI != "" {
+			t.uris.Add(tr.URI)
+		}
+	}
+
+	return result, nil
+}
+
+// difference returns the elements of a that are not in b.
+func difference(a, b set.Set[string]) set.Set[string] {
+	result := set.Set[string]{}
+	for v := range a {
+		if !b.Contains(v) {
+			result.Add(v)
+		}
+	}
+
+	return result
+}
+
+func sortedSlice(s set.Set[string]) []string {
+	if len(s) == 0 {
+		return nil
+	}
+
+	result := s.Slice()
+	slices.Sort(result)
+
+	return result
+}
@@ -0,0 +1,112 @@
+package baur
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/memory"
+)
+
+func saveReleaseDiffTestRun(ctx context.Context, t *testing.T, store storage.Storer, app, task, digest string, uris ...string) int {
+	t.Helper()
+
+	tr := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  app,
+			TaskName:         task,
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now(),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: digest,
+		},
+	}
+
+	for _, uri := range uris {
+		tr.Outputs = append(tr.Outputs, &storage.Output{
+			Name:      uri,
+			Type:      storage.ArtifactTypeFile,
+			Digest:    digest + uri,
+			SizeBytes: 1,
+			Uploads:   []*storage.Upload{{URI: uri, Method: storage.UploadMethodS3}},
+		})
+	}
+
+	id, err := store.SaveTaskRun(ctx, &tr)
+	require.NoError(t, err)
+
+	return id
+}
+
+func TestReleaseManagerDiffReleases(t *testing.T) {
+	ctx := t.Context()
+
+	var store storage.Storer = memory.New()
+	require.NoError(t, store.Init(ctx))
+
+	unchanged := saveReleaseDiffTestRun(ctx, t, store, "shop", "check", "1")
+	removed := saveReleaseDiffTestRun(ctx, t, store, "shop", "lint", "2")
+	buildOld := saveReleaseDiffTestRun(ctx, t, store, "shop", "build", "3", "s3://bucket/shop-1")
+	buildNew := saveReleaseDiffTestRun(ctx, t, store, "shop", "build", "4", "s3://bucket/shop-2")
+	rebuiltOld := saveReleaseDiffTestRun(ctx, t, store, "cart", "build", "5", "s3://bucket/cart")
+	rebuiltNew := saveReleaseDiffTestRun(ctx, t, store, "cart", "build", "5", "s3://bucket/cart")
+	added := saveReleaseDiffTestRun(ctx, t, store, "shop", "test", "6")
+
+	require.NoError(t, store.CreateRelease(ctx, "v1", "", time.Now(), []int{unchanged, removed, buildOld, rebuiltOld}, nil))
+	require.NoError(t, store.CreateRelease(ctx, "v2", "", time.Now(), []int{unchanged, buildNew, rebuiltNew, added}, nil))
+
+	rm := NewReleaseManager(&store, nil, nil)
+	diffs, err := rm.DiffReleases(ctx, "v1", "v2")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]*ReleaseTaskDiff{
+			{
+				State:             Changed,
+				AppName:           "cart",
+				TaskName:          "build",
+				RunID1:            rebuiltOld,
+				TotalInputDigest1: "5",
+				RunID2:            rebuiltNew,
+				TotalInputDigest2: "5",
+			},
+			{
+				State:             DigestMismatch,
+				AppName:           "shop",
+				TaskName:          "build",
+				RunID1:            buildOld,
+				TotalInputDigest1: "3",
+				RunID2:            buildNew,
+				TotalInputDigest2: "4",
+				RemovedURIs:       []string{"s3://bucket/shop-1"},
+				AddedURIs:         []string{"s3://bucket/shop-2"},
+			},
+			{
+				State:             Removed,
+				AppName:           "shop",
+				TaskName:          "lint",
+				RunID1:            removed,
+				TotalInputDigest1: "2",
+			},
+			{
+				State:             Added,
+				AppName:           "shop",
+				TaskName:          "test",
+				RunID2:            added,
+				TotalInputDigest2: "6",
+			},
+		},
+		diffs,
+	)
+
+	diffs, err = rm.DiffReleases(ctx, "v1", "v1")
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	_, err = rm.DiffReleases(ctx, "v1", "v3")
+	require.ErrorIs(t, err, storage.ErrNotExist)
+}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/simplesurance/baur/v5/internal/digest"
+	"github.com/simplesurance/baur/v5/internal/digest/sha384"
 	"github.com/simplesurance/baur/v5/internal/fs"
 	"github.com/simplesurance/baur/v5/internal/output/s3"
+	"github.com/simplesurance/baur/v5/internal/routines"
 	"github.com/simplesurance/baur/v5/internal/set"
 	"github.com/simplesurance/baur/v5/pkg/storage"
 )
@@ -18,18 +24,62 @@ type S3Downloader interface {
 	Download(ctx context.Context, bucket, key, filepath string) error
 }
 
+// FileCopyDownloader retrieves outputs that were uploaded with
+// [storage.UploadMethodFileCopy].
+type FileCopyDownloader interface {
+	Download(src, dst string) error
+}
+
+// DockerDownloader retrieves images that were uploaded with
+// [storage.UploadMethodDockerRegistry].
+type DockerDownloader interface {
+	Pull(ctx context.Context, uri string) (string, error)
+	Save(ctx context.Context, uri, destFile string) error
+}
+
 type ReleaseManager struct {
-	storage storage.Storer
-	logger  Logger
-	s3clt   S3Downloader
+	storage     storage.Storer
+	logger      Logger
+	s3clt       S3Downloader
+	filecopyClt FileCopyDownloader
+	dockerClt   DockerDownloader
+}
+
+// ReleaseManagerOpt is an option for [NewReleaseManager].
+type ReleaseManagerOpt func(*ReleaseManager)
+
+// WithFileCopyDownloader sets the downloader that is used for outputs that
+// were uploaded with [storage.UploadMethodFileCopy].
+func WithFileCopyDownloader(d FileCopyDownloader) ReleaseManagerOpt {
+	return func(m *ReleaseManager) {
+		m.filecopyClt = d
+	}
 }
 
-func NewReleaseManager(storage *storage.Storer, s3clt *s3.Client, logger Logger) *ReleaseManager {
-	return &ReleaseManager{
+// WithDockerDownloader sets the downloader that is used for outputs that
+// were uploaded with [storage.UploadMethodDockerRegistry].
+func WithDockerDownloader(d DockerDownloader) ReleaseManagerOpt {
+	return func(m *ReleaseManager) {
+		m.dockerClt = d
+	}
+}
+
+func NewReleaseManager(storage *storage.Storer, s3clt *s3.Client, logger Logger, opts ...ReleaseManagerOpt) *ReleaseManager {
+	m := ReleaseManager{
 		storage: *storage,
-		s3clt:   s3clt,
 		logger:  logger,
 	}
+
+	// assigning a nil *s3.Client would result in a non-nil interface value
+	if s3clt != nil {
+		m.s3clt = s3clt
+	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return &m
 }
 
 type DownloadOutputsParams struct {
@@ -38,16 +88,95 @@ type DownloadOutputsParams struct {
 	// TaskIDs specifies the tasks for which the output are downloaded, if
 	// empty outputs of all tasks are downloaded.
 	TaskIDs []string
+	// DockerSave specifies if docker images are stored as tar archive at
+	// DestDir/TASK-ID/OUTPUT-NAME.tar, instead of only being pulled.
+	DockerSave bool
+	// Parallel is the max. number of outputs that are downloaded in
+	// parallel, if it is 0 outputs are downloaded one by one.
+	Parallel uint
 	// DownloadStartFn is a callback function that is called before the
-	// download of an output starts
+	// download of an output starts.
+	// destFilepath is empty if a docker image is only pulled.
 	DownloadStartFn func(taskID, url, destFilepath string)
+	// SkipFn is a callback function that is called when an output is not
+	// downloaded because destFilepath already exists and its digest
+	// matches the recorded one.
+	SkipFn func(taskID, url, destFilepath string)
+}
+
+// OutputDigestMismatch describes a downloaded output that has a different
+// digest than the one that was recorded for it.
+type OutputDigestMismatch struct {
+	TaskID         string
+	URI            string
+	DestFile       string
+	ExpectedDigest string
+	ActualDigest   string
+}
+
+// DigestMismatchError is returned by [ReleaseManager.DownloadOutputs] when
+// the digests of downloaded outputs do not match the recorded digests.
+type DigestMismatchError struct {
+	// Mismatches is sorted by task ID and URI.
+	Mismatches []*OutputDigestMismatch
+}
+
+func (e *DigestMismatchError) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "the digests of %d downloaded outputs do not match the recorded digests:", len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		fmt.Fprintf(&sb, "\n%s: %s: expected digest %s, got %s", m.TaskID, m.URI, m.ExpectedDigest, m.ActualDigest)
+	}
+
+	return sb.String()
+}
+
+// outputDownload describes an output that is downloaded to destFile.
+type outputDownload struct {
+	taskID   string
+	tr       *storage.ReleaseTaskRunsResult
+	destFile string
+}
+
+// verifiable returns true if the digest of the downloaded file can be
+// compared with the recorded digest.
+// The recorded digest of docker images is their image ID, it can not be
+// compared to the digest of a file.
+func (d *outputDownload) verifiable() bool {
+	return d.destFile != "" && d.tr.UploadMethod != storage.UploadMethodDockerRegistry
+}
+
+// canDownload returns true if a downloader for outputs uploaded with method
+// is configured.
+func (m *ReleaseManager) canDownload(method storage.UploadMethod) bool {
+	switch method {
+	case storage.UploadMethodS3:
+		return m.s3clt != nil
+	case storage.UploadMethodFileCopy:
+		return m.filecopyClt != nil
+	case storage.UploadMethodDockerRegistry:
+		return m.dockerClt != nil
+	default:
+		return false
+	}
 }
 
 // DownloadOutputs downloads outputs of a release.
-// Currently it is only supported to download outputs that have been uploaded
-// to S3, others are ignored.
-// If the same output was uploaded to multiple S3 destinations, it is only
+// Outputs are downloaded with the downloader for their upload method, outputs
+// with an upload method for which no downloader is configured are ignored.
+// S3 and filecopy outputs are stored at DestDir/TASK-ID/OUTPUT-NAME, docker
+// images are pulled or, if [DownloadOutputsParams.DockerSave] is true,
+// stored as tar archives.
+// If the same output was uploaded to multiple destinations, it is only
 // downloaded from one of them.
+//
+// The digests of downloaded S3 and filecopy outputs are compared with the
+// recorded digests. Files that do not match are deleted, the mismatches are
+// reported by returning a [*DigestMismatchError] after all downloads
+// finished. Outputs that already exist in DestDir with the recorded digest
+// are not downloaded again.
+// The callback functions in p are never called concurrently.
 // If a task specified in [DownloadOutputs.TaskIDs] is not part of the release,
 // an error is returned.
 func (m *ReleaseManager) DownloadOutputs(
@@ -62,11 +191,12 @@ func (m *ReleaseManager) DownloadOutputs(
 	// downloadedOutputs keeps track of which outputs per task has been downloaded.
 	// Each output can be uploaded to multiple destinations, to prevent
 	// downloading the same output multiple times, only download the first
-	// upload to S3 per output.
+	// supported upload per output.
 	downloadedOutputs := set.Set[string]{}
+	var downloads []*outputDownload
 
 	includes := set.From(p.TaskIDs)
-	if err := validateWantedTaskIDsHaveOutputs(tasksRuns, includes); err != nil {
+	if err := validateWantedTaskIDsHaveOutputs(tasksRuns, includes, m.canDownload); err != nil {
 		return err
 	}
 
@@ -79,19 +209,18 @@ func (m *ReleaseManager) DownloadOutputs(
 		if tr.OutputName == "" {
 			m.logger.Debugf(
 				"%s: skipping task run, it did not produce any outputs\n",
-				tid, storage.UploadMethodS3,
+				tid,
 			)
 			continue
 		}
 
-		if tr.UploadMethod != storage.UploadMethodS3 {
+		if !m.canDownload(tr.UploadMethod) {
 			m.logger.Debugf(
-				"%s: %s: %s: skipping download, upload method is %s, only %s is supported\n",
+				"%s: %s: %s: skipping download, downloading outputs uploaded with %s is not supported\n",
 				tid,
 				tr.OutputName,
 				tr.URI,
 				tr.UploadMethod,
-				storage.UploadMethodS3,
 			)
 			continue
 		}
@@ -106,48 +235,216 @@ func (m *ReleaseManager) DownloadOutputs(
 			continue
 		}
 
-		destDir := filepath.Join(p.DestDir, tid)
-		if err := fs.Mkdir(destDir); err != nil {
-			return err
+		downloadedOutputs.Add(taskOutputID)
+
+		var destFile string
+		if tr.UploadMethod != storage.UploadMethodDockerRegistry || p.DockerSave {
+			destDir := filepath.Join(p.DestDir, tid)
+			if err := fs.Mkdir(destDir); err != nil {
+				return err
+			}
+			destFile = filepath.Join(destDir, filepath.Base(tr.OutputName))
+
+			if tr.UploadMethod == storage.UploadMethodDockerRegistry {
+				destFile += ".tar"
+			}
 		}
-		destFile := filepath.Join(destDir, filepath.Base(tr.OutputName))
 
-		bucket, objectKey, err := s3.ParseURL(tr.URI)
-		if err != nil {
-			return fmt.Errorf(
-				"%s (%d): stored uri of output %s is malformed: %s: %w",
-				tid, tr.RunID, tr.OutputName, tr.URI, err,
-			)
+		downloads = append(downloads, &outputDownload{
+			taskID:   tid,
+			tr:       tr,
+			destFile: destFile,
+		})
+	}
+
+	return m.runDownloads(ctx, downloads, &p)
+}
+
+// runDownloads executes downloads with max. p.Parallel goroutines.
+// When a download fails, outstanding downloads are not started and the error
+// is returned.
+func (m *ReleaseManager) runDownloads(ctx context.Context, downloads []*outputDownload, p *DownloadOutputsParams) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var mu sync.Mutex // protects mismatches and serializes callbacks
+	var mismatches []*OutputDigestMismatch
+
+	pool := routines.NewPool(max(p.Parallel, 1))
+	for _, d := range downloads {
+		pool.Queue(func() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			mismatch, err := m.downloadVerified(ctx, d, p, &mu)
+			if err != nil {
+				cancel(fmt.Errorf(
+					"%s (%d): download of %s failed: %w",
+					d.taskID, d.tr.RunID, d.tr.URI, err,
+				))
+				return
+			}
+
+			if mismatch != nil {
+				mu.Lock()
+				mismatches = append(mismatches, mismatch)
+				mu.Unlock()
+			}
+		})
+	}
+	pool.Wait()
+
+	if err := context.Cause(ctx); err != nil {
+		return err
+	}
+
+	if len(mismatches) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(mismatches, func(a, b *OutputDigestMismatch) int {
+		if c := strings.Compare(a.TaskID, b.TaskID); c != 0 {
+			return c
 		}
+		return strings.Compare(a.URI, b.URI)
+	})
+
+	return &DigestMismatchError{Mismatches: mismatches}
+}
 
-		if p.DownloadStartFn != nil {
-			p.DownloadStartFn(tid, tr.URI, destFile)
+// downloadVerified downloads the output of d, if it is not already stored
+// at d.destFile, and verifies its digest.
+// If the digest of the downloaded file does not match the recorded one, the
+// file is deleted and the mismatch is returned.
+// callbackMu is locked while the callback functions of p are called.
+func (m *ReleaseManager) downloadVerified(
+	ctx context.Context,
+	d *outputDownload,
+	p *DownloadOutputsParams,
+	callbackMu *sync.Mutex,
+) (*OutputDigestMismatch, error) {
+	callback := func(fn func(taskID, url, destFilepath string)) {
+		if fn == nil {
+			return
 		}
 
-		err = m.s3clt.Download(ctx, bucket, objectKey, destFile)
+		callbackMu.Lock()
+		defer callbackMu.Unlock()
+		fn(d.taskID, d.tr.URI, d.destFile)
+	}
+
+	if d.verifiable() && fs.FileExists(d.destFile) {
+		dg, err := fileDigest(d.tr.OutputDigest, d.destFile)
 		if err != nil {
-			return fmt.Errorf(
-				"%s (%d): download of %s failed: %w",
-				tid, tr.RunID, tr.URI, err,
-			)
+			return nil, err
 		}
 
-		downloadedOutputs.Add(taskOutputID)
+		if dg == d.tr.OutputDigest {
+			callback(p.SkipFn)
+			return nil, nil
+		}
+
+		m.logger.Debugf(
+			"%s: %s: digest of existing file %s is %s, expected %s, downloading it again\n",
+			d.taskID, d.tr.OutputName, d.destFile, dg, d.tr.OutputDigest,
+		)
+	}
+
+	callback(p.DownloadStartFn)
+
+	if err := m.download(ctx, d.tr, d.destFile); err != nil {
+		return nil, err
+	}
+
+	if !d.verifiable() {
+		return nil, nil
+	}
+
+	dg, err := fileDigest(d.tr.OutputDigest, d.destFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if dg == d.tr.OutputDigest {
+		return nil, nil
+	}
+
+	if err := os.Remove(d.destFile); err != nil {
+		return nil, fmt.Errorf("deleting %s with mismatching digest failed: %w", d.destFile, err)
+	}
+
+	return &OutputDigestMismatch{
+		TaskID:         d.taskID,
+		URI:            d.tr.URI,
+		DestFile:       d.destFile,
+		ExpectedDigest: d.tr.OutputDigest,
+		ActualDigest:   dg,
+	}, nil
+}
+
+// fileDigest calculates the digest of the file at path, with the algorithm of
+// the digest expected.
+func fileDigest(expected, path string) (string, error) {
+	expectedDigest, err := digest.FromString(expected)
+	if err != nil {
+		return "", fmt.Errorf("recorded digest %q is invalid: %w", expected, err)
+	}
+
+	if expectedDigest.Algorithm != digest.SHA384 {
+		return "", fmt.Errorf("recorded digest %q: verifying %s digests is not supported", expected, expectedDigest.Algorithm)
+	}
+
+	dg, err := sha384.File(path)
+	if err != nil {
+		return "", fmt.Errorf("calculating digest of %s failed: %w", path, err)
 	}
 
-	return nil
+	return dg.String(), nil
+}
+
+// download retrieves the output of tr with the downloader for its upload
+// method.
+// If destFile is empty, docker images are only pulled, otherwise the output
+// is stored at destFile.
+func (m *ReleaseManager) download(ctx context.Context, tr *storage.ReleaseTaskRunsResult, destFile string) error {
+	switch tr.UploadMethod {
+	case storage.UploadMethodS3:
+		bucket, objectKey, err := s3.ParseURL(tr.URI)
+		if err != nil {
+			return fmt.Errorf("stored uri is malformed: %w", err)
+		}
+
+		return m.s3clt.Download(ctx, bucket, objectKey, destFile)
+
+	case storage.UploadMethodFileCopy:
+		return m.filecopyClt.Download(tr.URI, destFile)
+
+	case storage.UploadMethodDockerRegistry:
+		if destFile != "" {
+			return m.dockerClt.Save(ctx, tr.URI, destFile)
+		}
+
+		_, err := m.dockerClt.Pull(ctx, tr.URI)
+		return err
+
+	default:
+		return fmt.Errorf("unsupported upload method %q", tr.UploadMethod)
+	}
 }
 
 // validateWantedTaskIDsHaveOutputs ensures that taskRuns contains a task run
-// with an S3 upload for each task ID in taskIDs.
+// with an upload for which canDownload returns true for each task ID in
+// taskIDs.
 // If it doesn't, an error is returned.
 // if taskIDs is empty, nil is returned.
 func validateWantedTaskIDsHaveOutputs(
 	taskRuns []*storage.ReleaseTaskRunsResult,
 	taskIDs set.Set[string],
+	canDownload func(storage.UploadMethod) bool,
 ) error {
 	var missingTaskIDs []string
-	var tasksMissingS3Uploads []string
+	var tasksMissingUploads []string
 
 	if len(taskIDs) == 0 {
 		return nil
@@ -173,7 +470,7 @@ func validateWantedTaskIDsHaveOutputs(
 
 		found := false
 		for _, tr := range taskRuns {
-			if tr.UploadMethod != storage.UploadMethodS3 {
+			if tr.OutputName == "" || !canDownload(tr.UploadMethod) {
 				continue
 			}
 			found = true
@@ -181,11 +478,11 @@ func validateWantedTaskIDsHaveOutputs(
 		}
 
 		if !found {
-			tasksMissingS3Uploads = append(tasksMissingS3Uploads, wantedTaskID)
+			tasksMissingUploads = append(tasksMissingUploads, wantedTaskID)
 		}
 	}
 
-	if len(missingTaskIDs) == 0 && len(tasksMissingS3Uploads) == 0 {
+	if len(missingTaskIDs) == 0 && len(tasksMissingUploads) == 0 {
 		return nil
 	}
 
@@ -193,16 +490,34 @@ func validateWantedTaskIDsHaveOutputs(
 	if len(missingTaskIDs) > 0 {
 		errStr = "The following task IDs are not part of the release: " + strings.Join(missingTaskIDs, ", ")
 	}
-	if len(tasksMissingS3Uploads) > 0 {
+	if len(tasksMissingUploads) > 0 {
 		if errStr != "" {
 			errStr += "\n"
 		}
-		errStr += "The following tasks do not have an S3 output: " + strings.Join(tasksMissingS3Uploads, ", ")
+		errStr += "The following tasks do not have a downloadable output: " + strings.Join(tasksMissingUploads, ", ")
 	}
 
 	return errors.New(errStr)
 }
 
+// TaskRunIDs returns the IDs of the task runs of the release called name, by
+// task ID.
+// If the release does not exist, an error wrapping [storage.ErrNotExist] is
+// returned.
+func (m *ReleaseManager) TaskRunIDs(ctx context.Context, name string) (map[string]int, error) {
+	taskRuns, err := m.storage.ReleaseTaskRuns(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", name, err)
+	}
+
+	result := make(map[string]int, len(taskRuns))
+	for _, tr := range taskRuns {
+		result[taskID(tr.AppName, tr.TaskName)] = tr.RunID
+	}
+
+	return result, nil
+}
+
 func (m *ReleaseManager) GetRelease(ctx context.Context, name string) (*Release, error) {
 	metadata, err := m.storage.ReleaseMetadata(ctx, name)
 	if err != nil {
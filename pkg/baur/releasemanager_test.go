@@ -0,0 +1,277 @@
+package baur
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/internal/digest/sha384"
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/internal/output/filecopy"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/memory"
+)
+
+func TestReleaseManagerGetRelease(t *testing.T) {
+	ctx := t.Context()
+
+	var store storage.Storer = memory.New()
+	require.NoError(t, store.Init(ctx))
+
+	id, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "shop",
+			TaskName:         "build",
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now(),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1",
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "2",
+				SizeBytes: 3,
+				Uploads: []*storage.Upload{
+					{URI: "s3://bucket/binary", Method: storage.UploadMethodS3},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.CreateRelease(ctx, "v1", "", time.Now(), []int{id}, bytes.NewBufferString("meta")))
+
+	rm := NewReleaseManager(&store, nil, nil)
+	rel, err := rm.GetRelease(ctx, "v1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v1", rel.ReleaseName)
+	assert.Equal(t, []byte("meta"), rel.Metadata)
+	require.Contains(t, rel.Applications, "shop")
+	require.Contains(t, rel.Applications["shop"].TaskRuns, "build")
+
+	taskRun := rel.Applications["shop"].TaskRuns["build"]
+	assert.Equal(t, id, taskRun.RunID)
+	require.Len(t, taskRun.Outputs, 1)
+	for _, o := range taskRun.Outputs {
+		require.Len(t, o.Uploads, 1)
+		assert.Equal(t, "s3://bucket/binary", o.Uploads[0].URI)
+	}
+
+	_, err = rm.GetRelease(ctx, "v2")
+	require.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+type dockerDownloaderMock struct {
+	pulled []string
+	saved  map[string]string
+}
+
+func (d *dockerDownloaderMock) Pull(_ context.Context, uri string) (string, error) {
+	d.pulled = append(d.pulled, uri)
+	return uri, nil
+}
+
+func (d *dockerDownloaderMock) Save(_ context.Context, uri, destFile string) error {
+	d.saved[uri] = destFile
+	return os.WriteFile(destFile, []byte(uri), 0o644)
+}
+
+func TestReleaseManagerDownloadOutputs(t *testing.T) {
+	log.RedirectToTestingLog(t)
+	ctx := t.Context()
+
+	var store storage.Storer = memory.New()
+	require.NoError(t, store.Init(ctx))
+
+	uploadDir := t.TempDir()
+	uploadedFile := filepath.Join(uploadDir, "binary")
+	require.NoError(t, os.WriteFile(uploadedFile, []byte("hello"), 0o644))
+	uploadedDigest, err := sha384.File(uploadedFile)
+	require.NoError(t, err)
+
+	id, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  "shop",
+			TaskName:         "build",
+			StartTimestamp:   time.Now(),
+			StopTimestamp:    time.Now(),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: "1",
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "dist/binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    uploadedDigest.String(),
+				SizeBytes: 5,
+				Uploads: []*storage.Upload{
+					{URI: uploadedFile, Method: storage.UploadMethodFileCopy},
+					{URI: "s3://bucket/binary", Method: storage.UploadMethodS3},
+				},
+			},
+			{
+				Name:      "image.id",
+				Type:      storage.ArtifactTypeDocker,
+				Digest:    "3",
+				SizeBytes: 10,
+				Uploads: []*storage.Upload{
+					{URI: "registry.example.com/shop:v1", Method: storage.UploadMethodDockerRegistry},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.CreateRelease(ctx, "v1", "", time.Now(), []int{id}, nil))
+
+	t.Run("pull", func(t *testing.T) {
+		dockerClt := dockerDownloaderMock{saved: map[string]string{}}
+		rm := NewReleaseManager(&store, nil, log.StdLogger,
+			WithFileCopyDownloader(filecopy.New(nil)),
+			WithDockerDownloader(&dockerClt),
+		)
+
+		destDir := t.TempDir()
+		var downloads []string
+		err := rm.DownloadOutputs(ctx, DownloadOutputsParams{
+			ReleaseName: "v1",
+			DestDir:     destDir,
+			Parallel:    2,
+			DownloadStartFn: func(_, url, _ string) {
+				downloads = append(downloads, url)
+			},
+		})
+		require.NoError(t, err)
+
+		assert.ElementsMatch(t, []string{uploadedFile, "registry.example.com/shop:v1"}, downloads)
+		assert.FileExists(t, filepath.Join(destDir, "shop.build", "binary"))
+		assert.Equal(t, []string{"registry.example.com/shop:v1"}, dockerClt.pulled)
+		assert.Empty(t, dockerClt.saved)
+	})
+
+	t.Run("save", func(t *testing.T) {
+		dockerClt := dockerDownloaderMock{saved: map[string]string{}}
+		rm := NewReleaseManager(&store, nil, log.StdLogger,
+			WithDockerDownloader(&dockerClt),
+		)
+
+		destDir := t.TempDir()
+		err := rm.DownloadOutputs(ctx, DownloadOutputsParams{
+			ReleaseName: "v1",
+			DestDir:     destDir,
+			TaskIDs:     []string{"shop.build"},
+			DockerSave:  true,
+		})
+		require.NoError(t, err)
+
+		assert.Empty(t, dockerClt.pulled)
+		assert.Equal(t,
+			map[string]string{"registry.example.com/shop:v1": filepath.Join(destDir, "shop.build", "image.id.tar")},
+			dockerClt.saved,
+		)
+		assert.NoFileExists(t, filepath.Join(destDir, "shop.build", "binary"))
+	})
+
+	t.Run("without downloaders", func(t *testing.T) {
+		rm := NewReleaseManager(&store, nil, log.StdLogger)
+		err := rm.DownloadOutputs(ctx, DownloadOutputsParams{
+			ReleaseName: "v1",
+			DestDir:     t.TempDir(),
+			TaskIDs:     []string{"shop.build"},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestReleaseManagerDownloadOutputsVerifiesDigests(t *testing.T) {
+	log.RedirectToTestingLog(t)
+	ctx := t.Context()
+
+	var store storage.Storer = memory.New()
+	require.NoError(t, store.Init(ctx))
+
+	uploadDir := t.TempDir()
+	goodFile := filepath.Join(uploadDir, "good")
+	require.NoError(t, os.WriteFile(goodFile, []byte("good"), 0o644))
+	goodDigest, err := sha384.File(goodFile)
+	require.NoError(t, err)
+
+	corruptFile := filepath.Join(uploadDir, "corrupt")
+	require.NoError(t, os.WriteFile(corruptFile, []byte("corrupt"), 0o644))
+
+	var runIDs []int
+	for _, output := range []struct{ task, uri string }{
+		{task: "build", uri: goodFile},
+		{task: "dist", uri: corruptFile},
+	} {
+		id, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  "shop",
+				TaskName:         output.task,
+				StartTimestamp:   time.Now(),
+				StopTimestamp:    time.Now(),
+				Result:           storage.ResultSuccess,
+				TotalInputDigest: output.task,
+			},
+			Outputs: []*storage.Output{
+				{
+					Name:      filepath.Base(output.uri),
+					Type:      storage.ArtifactTypeFile,
+					Digest:    goodDigest.String(),
+					SizeBytes: 4,
+					Uploads: []*storage.Upload{
+						{URI: output.uri, Method: storage.UploadMethodFileCopy},
+					},
+				},
+			},
+		})
+		require.NoError(t, err)
+		runIDs = append(runIDs, id)
+	}
+
+	require.NoError(t, store.CreateRelease(ctx, "v1", "", time.Now(), runIDs, nil))
+
+	rm := NewReleaseManager(&store, nil, log.StdLogger, WithFileCopyDownloader(filecopy.New(nil)))
+	destDir := t.TempDir()
+
+	var downloads, skipped []string
+	p := DownloadOutputsParams{
+		ReleaseName: "v1",
+		DestDir:     destDir,
+		Parallel:    2,
+		DownloadStartFn: func(taskID, _, _ string) {
+			downloads = append(downloads, taskID)
+		},
+		SkipFn: func(taskID, _, _ string) {
+			skipped = append(skipped, taskID)
+		},
+	}
+
+	err = rm.DownloadOutputs(ctx, p)
+	var mismatchErr *DigestMismatchError
+	require.ErrorAs(t, err, &mismatchErr)
+	require.Len(t, mismatchErr.Mismatches, 1)
+	assert.Equal(t, "shop.dist", mismatchErr.Mismatches[0].TaskID)
+	assert.Equal(t, corruptFile, mismatchErr.Mismatches[0].URI)
+	assert.Equal(t, goodDigest.String(), mismatchErr.Mismatches[0].ExpectedDigest)
+	assert.NotEqual(t, goodDigest.String(), mismatchErr.Mismatches[0].ActualDigest)
+	assert.NoFileExists(t, filepath.Join(destDir, "shop.dist", "corrupt"))
+	assert.FileExists(t, filepath.Join(destDir, "shop.build", "good"))
+	assert.ElementsMatch(t, []string{"shop.build", "shop.dist"}, downloads)
+	assert.Empty(t, skipped)
+
+	downloads, skipped = nil, nil
+	p.TaskIDs = []string{"shop.build"}
+	require.NoError(t, rm.DownloadOutputs(ctx, p))
+	assert.Empty(t, downloads)
+	assert.Equal(t, []string{"shop.build"}, skipped)
+}
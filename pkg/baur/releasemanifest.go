@@ -0,0 +1,127 @@
+package baur
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// ReleaseManifest is a complete description of a release.
+type ReleaseManifest struct {
+	Name      string    `json:"Name" yaml:"Name"`
+	CreatedAt time.Time `json:"CreatedAt" yaml:"CreatedAt"`
+	// BaseRelease is the name of the release the release was derived
+	// from, it is empty if it was not derived from another release.
+	BaseRelease string `json:"BaseRelease" yaml:"BaseRelease"`
+	// Metadata is the base64 encoded metadata that was stored with the
+	// release, it is empty if none was stored.
+	Metadata string `json:"Metadata" yaml:"Metadata"`
+	// Tasks is sorted by application and task name.
+	Tasks []*ReleaseManifestTask `json:"Tasks" yaml:"Tasks"`
+}
+
+// ReleaseManifestTask describes a task run that is part of a release.
+type ReleaseManifestTask struct {
+	AppName          string `json:"AppName" yaml:"AppName"`
+	TaskName         string `json:"TaskName" yaml:"TaskName"`
+	RunID            int    `json:"RunID" yaml:"RunID"`
+	VCSRevision      string `json:"VCSRevision" yaml:"VCSRevision"`
+	VCSIsDirty       bool   `json:"VCSIsDirty" yaml:"VCSIsDirty"`
+	TotalInputDigest string `json:"TotalInputDigest" yaml:"TotalInputDigest"`
+	// Outputs is sorted by name.
+	Outputs []*ReleaseManifestOutput `json:"Outputs" yaml:"Outputs"`
+}
+
+// ReleaseManifestOutput describes an output of a task run that is part of a
+// release.
+type ReleaseManifestOutput struct {
+	Name      string `json:"Name" yaml:"Name"`
+	Type      string `json:"Type" yaml:"Type"`
+	Digest    string `json:"Digest" yaml:"Digest"`
+	SizeBytes uint64 `json:"SizeBytes" yaml:"SizeBytes"`
+	// Uploads is sorted by URI.
+	Uploads []*ReleaseUpload `json:"Uploads" yaml:"Uploads"`
+}
+
+// Manifest returns the manifest of the release with the given name.
+// If the release does not exist, an error wrapping [storage.ErrNotExist] is
+// returned.
+func (m *ReleaseManager) Manifest(ctx context.Context, name string) (*ReleaseManifest, error) {
+	result := ReleaseManifest{Name: name}
+
+	err := m.storage.Releases(
+		ctx,
+		[]*storage.Filter{{Field: storage.FieldReleaseName, Operator: storage.OpEQ, Value: name}},
+		func(r *storage.Release) error {
+			result.CreatedAt = r.CreatedAt
+			result.BaseRelease = r.BaseRelease
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", name, err)
+	}
+
+	metadata, err := m.storage.ReleaseMetadata(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: retrieving metadata failed: %w", name, err)
+	}
+	result.Metadata = base64.StdEncoding.EncodeToString(metadata)
+
+	taskRuns, err := m.storage.ReleaseTaskRuns(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", name, err)
+	}
+
+	// taskRuns is sorted by app name, task name, output name and URI
+	var task *ReleaseManifestTask
+	var output *ReleaseManifestOutput
+	var outputID int
+
+	for _, tr := range taskRuns {
+		if task == nil || task.RunID != tr.RunID {
+			run, err := m.storage.TaskRun(ctx, tr.RunID)
+			if err != nil {
+				return nil, fmt.Errorf("retrieving task run %d failed: %w", tr.RunID, err)
+			}
+
+			task = &ReleaseManifestTask{
+				AppName:          tr.AppName,
+				TaskName:         tr.TaskName,
+				RunID:            tr.RunID,
+				VCSRevision:      run.VCSRevision,
+				VCSIsDirty:       run.VCSIsDirty,
+				TotalInputDigest: run.TotalInputDigest,
+				Outputs:          []*ReleaseManifestOutput{},
+			}
+			output = nil
+			result.Tasks = append(result.Tasks, task)
+		}
+
+		if tr.URI == "" {
+			// the task run has no outputs
+			continue
+		}
+
+		if output == nil || outputID != tr.OutputID {
+			output = &ReleaseManifestOutput{
+				Name:      tr.OutputName,
+				Type:      string(tr.OutputType),
+				Digest:    tr.OutputDigest,
+				SizeBytes: tr.OutputSizeBytes,
+			}
+			outputID = tr.OutputID
+			task.Outputs = append(task.Outputs, output)
+		}
+
+		output.Uploads = append(output.Uploads, &ReleaseUpload{
+			UploadMethod: string(tr.UploadMethod),
+			URI:          tr.URI,
+		})
+	}
+
+	return &result, nil
+}
@@ -9,6 +9,7 @@ import (
 )
 
 // StoreRun stores the result of a task run in a baur storage.
+// labels are stored with the task run, they can be nil.
 func StoreRun(
 	ctx context.Context,
 	storer storage.Storer,
@@ -17,6 +18,7 @@ func StoreRun(
 	inputs *Inputs,
 	runResult *RunResult,
 	uploads []*UploadResult,
+	labels map[string]string,
 ) (int, error) {
 	var commitID string
 	var isDirty bool
@@ -53,6 +55,14 @@ func StoreRun(
 		return -1, err
 	}
 
+	var log []byte
+	if out := runResult.Output(); len(out) > 0 {
+		log, err = compressLog(out)
+		if err != nil {
+			return -1, fmt.Errorf("compressing command output failed: %w", err)
+		}
+	}
+
 	tr := storage.TaskRunFull{
 		TaskRun: storage.TaskRun{
 			ApplicationName:  task.AppName,
@@ -63,9 +73,11 @@ func StoreRun(
 			StopTimestamp:    runResult.StopTime,
 			TotalInputDigest: totalDigest.String(),
 			Result:           result,
+			Labels:           labels,
 		},
 		Inputs:  *storageInputs,
 		Outputs: storageOutputs,
+		Log:     log,
 	}
 
 	return storer.SaveTaskRun(ctx, &tr)
@@ -103,6 +115,11 @@ func inputsToStorageInputs(inputs *Inputs) (*storage.Inputs, error) {
 				Name:   v.TaskID(),
 				Digest: digest.String(),
 			})
+		case *InputCommandOutput:
+			result.CommandOutputs = append(result.CommandOutputs, &storage.InputCommandOutput{
+				Command: v.Command(),
+				Digest:  digest.String(),
+			})
 		default:
 			return nil, fmt.Errorf("unsupported input type %T", v)
 
@@ -0,0 +1,49 @@
+package baur
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// MaxTaskRunLogSize is the max. number of bytes of the command output of a
+// task run that is recorded. When the output is bigger, the beginning and end
+// are kept and the middle part is omitted.
+const MaxTaskRunLogSize = 1024 * 1024
+
+// compressLog returns the gzip compressed log.
+func compressLog(log []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(log); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecompressTaskRunLog decompresses a task run log that was recorded by
+// [StoreRun].
+func DecompressTaskRunLog(compressed []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing log failed: %w", err)
+	}
+	defer r.Close()
+
+	// the uncompressed size is limited by MaxTaskRunLogSize when the log
+	// was recorded, the limit prevents that malicious records exhaust
+	// the memory
+	result, err := io.ReadAll(io.LimitReader(r, 4*MaxTaskRunLogSize))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing log failed: %w", err)
+	}
+
+	return result, nil
+}
@@ -30,7 +30,10 @@ type TaskRunner struct {
 	skipEnabled         uint32 // must be accessed via atomic operations
 	LogFn               exec.PrintfFn
 	GitUntrackedFilesFn func(dir string) ([]string, error)
-	taskInfoCreator     *TaskInfoCreator
+	// StoreLog enables recording the output of the task commands, it is
+	// returned by [RunResult.Output].
+	StoreLog        bool
+	taskInfoCreator *TaskInfoCreator
 }
 
 func NewTaskRunner(taskInfoCreator *TaskInfoCreator, skipAfterError bool) *TaskRunner {
@@ -107,13 +110,17 @@ func (t *TaskRunner) Run(task *Task) (*RunResult, error) {
 	}
 	defer deleteTempTaskInfoFilesFn()
 
-	startTime := time.Now()
-	execResult, err := exec.Command(task.Command[0], task.Command[1:]...).
+	cmd := exec.Command(task.Command[0], task.Command[1:]...).
 		Directory(task.Directory).
 		LogPrefix(color.YellowString(fmt.Sprintf("%s: ", task))).
 		LogFn(t.LogFn).
-		Env(append(os.Environ(), env...)).
-		Run(context.TODO())
+		Env(append(os.Environ(), env...))
+	if t.StoreLog {
+		cmd.StoreOutput(MaxTaskRunLogSize)
+	}
+
+	startTime := time.Now()
+	execResult, err := cmd.Run(context.TODO())
 	if err != nil {
 		return nil, err
 	}
@@ -2,10 +2,11 @@ package baur
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"slices"
+	"sync"
 
+	"github.com/simplesurance/baur/v5/internal/routines"
 	"github.com/simplesurance/baur/v5/pkg/storage"
 )
 
@@ -18,6 +19,18 @@ type TaskStatusEvaluator struct {
 	store         storage.Storer
 
 	lookupInputStr string
+	parallelism    uint
+}
+
+type TaskStatusEvaluatorOpt func(*TaskStatusEvaluator)
+
+// WithResolveParallelism sets the max. number of tasks whose inputs are
+// resolved concurrently by [TaskStatusEvaluator.Statuses].
+// The default is 1.
+func WithResolveParallelism(n uint) TaskStatusEvaluatorOpt {
+	return func(t *TaskStatusEvaluator) {
+		t.parallelism = max(n, 1)
+	}
 }
 
 func NewTaskStatusEvaluator(
@@ -25,13 +38,21 @@ func NewTaskStatusEvaluator(
 	store storage.Storer,
 	inputResolver *InputResolver,
 	lookupInputStr string,
+	opts ...TaskStatusEvaluatorOpt,
 ) *TaskStatusEvaluator {
-	return &TaskStatusEvaluator{
+	result := TaskStatusEvaluator{
 		repositoryDir:  repositoryDir,
 		inputResolver:  inputResolver,
 		store:          store,
 		lookupInputStr: lookupInputStr,
+		parallelism:    1,
 	}
+
+	for _, opt := range opts {
+		opt(&result)
+	}
+
+	return &result
 }
 
 func replaceInputStrings(inputs *Inputs, replacement []Input) *Inputs {
@@ -40,7 +61,35 @@ func replaceInputStrings(inputs *Inputs, replacement []Input) *Inputs {
 		_, ok := input.(*InputString)
 		return ok
 	})
-	return NewInputs(append(result, replacement...))
+	newInputs := NewInputs(append(result, replacement...))
+	newInputs.fileDigestAlgorithm = inputs.fileDigestAlgorithm
+
+	return newInputs
+}
+
+// TaskStatusResult is the status of a task that was determined by
+// [TaskStatusEvaluator.Statuses].
+type TaskStatusResult struct {
+	Task   *Task
+	Status TaskStatus
+	// Inputs are the inputs that were used to look up the task run.
+	Inputs *Inputs
+	// TaskRun is the existing run of the task, it is nil if Status is
+	// not TaskStatusRunExist.
+	TaskRun *storage.TaskRunWithID
+}
+
+// statusLookup contains the total input digests of a task that are looked up
+// in the storage.
+type statusLookup struct {
+	task   *Task
+	inputs *Inputs
+	digest storage.AppTaskDigest
+
+	// inputsWithLookupStr and lookupStrDigest are only set if a
+	// lookupInputStr is used.
+	inputsWithLookupStr *Inputs
+	lookupStrDigest     storage.AppTaskDigest
 }
 
 // Status resolves the inputs of the task, calculates the total input digest
@@ -52,55 +101,180 @@ func replaceInputStrings(inputs *Inputs, replacement []Input) *Inputs {
 // The method caches results of successful (err==nil) calls per Task. Running
 // it multiple times for the same task returns the same result.
 func (t *TaskStatusEvaluator) Status(ctx context.Context, task *Task) (TaskStatus, *Inputs, *storage.TaskRunWithID, error) {
-	if len(t.lookupInputStr) != 0 && len(task.UnresolvedInputs.TaskInfos) > 0 {
-		return TaskStatusUndefined, nil, nil,
-			fmt.Errorf("task %q defines TaskInfo Inputs, using them when specifying '--lookup-input-str' is unsupported", task.ID)
+	lookup, err := t.newStatusLookup(ctx, task)
+	if err != nil {
+		return TaskStatusUndefined, nil, nil, err
 	}
 
-	inputs, err := t.inputResolver.Resolve(ctx, task)
+	runs, err := t.latestTaskRuns(ctx, []*statusLookup{lookup})
 	if err != nil {
 		return TaskStatusUndefined, nil, nil, err
 	}
 
-	run, err := t.getTaskStatus(ctx, task, inputs)
-	if err == nil {
-		return TaskStatusRunExist, inputs, run, nil
+	result := lookup.result(runs)
+
+	return result.Status, result.Inputs, result.TaskRun, nil
+}
+
+// Statuses determines the status of multiple tasks like [Status], the
+// storage is queried once for all tasks.
+// The inputs of up to the configured parallelism (see
+// [WithResolveParallelism]) tasks are resolved concurrently.
+// The results are returned in the same order as tasks.
+// If onResolved is not nil, it is called after the inputs of a task were
+// resolved, e.g. to show the progress. It is not called concurrently.
+func (t *TaskStatusEvaluator) Statuses(ctx context.Context, tasks []*Task, onResolved func(*Task)) ([]*TaskStatusResult, error) {
+	lookups, err := t.newStatusLookups(ctx, tasks, onResolved)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := t.latestTaskRuns(ctx, lookups)
+	if err != nil {
+		return nil, err
 	}
 
-	if !errors.Is(err, storage.ErrNotExist) {
-		return TaskStatusUndefined, nil, nil, err
+	result := make([]*TaskStatusResult, 0, len(lookups))
+	for _, lookup := range lookups {
+		result = append(result, lookup.result(runs))
 	}
 
-	if t.lookupInputStr == "" {
-		return TaskStatusExecutionPending, inputs, run, nil
+	return result, nil
+}
+
+// newStatusLookups creates the statusLookups for tasks concurrently.
+// When creating one fails, the creation of the remaining ones is canceled
+// and the error is returned.
+func (t *TaskStatusEvaluator) newStatusLookups(ctx context.Context, tasks []*Task, onResolved func(*Task)) ([]*statusLookup, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex // protects firstErr and serializes onResolved calls
+	var firstErr error
+
+	result := make([]*statusLookup, len(tasks))
+	pool := routines.NewPool(t.parallelism)
+
+	for idx, task := range tasks {
+		pool.Queue(func() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			lookup, err := t.newStatusLookup(ctx, task)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", task, err)
+					cancel()
+				}
+
+				return
+			}
+
+			result[idx] = lookup
+
+			if onResolved != nil {
+				onResolved(task)
+			}
+		})
+	}
+
+	pool.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// newStatusLookup resolves the inputs of task and calculates the total input
+// digests that are looked up in the storage.
+func (t *TaskStatusEvaluator) newStatusLookup(ctx context.Context, task *Task) (*statusLookup, error) {
+	if len(t.lookupInputStr) != 0 && len(task.UnresolvedInputs.TaskInfos) > 0 {
+		return nil, fmt.Errorf("task %q defines TaskInfo Inputs, using them when specifying '--lookup-input-str' is unsupported", task.ID)
 	}
 
-	inputsWithLookupStr := replaceInputStrings(inputs, AsInputStrings(t.lookupInputStr))
-	run, err = t.getTaskStatus(ctx, task, inputsWithLookupStr)
+	inputs, err := t.inputResolver.Resolve(ctx, task)
 	if err != nil {
-		if errors.Is(err, storage.ErrNotExist) {
-			// inputs instead of inputsLookupInputStr must be returned, if the task
-			// must be run it should be recorded with the inputStr not with the
-			// lookupInputStr
-			return TaskStatusExecutionPending, inputs, run, nil
-		}
+		return nil, err
+	}
 
-		return TaskStatusUndefined, nil, nil, err
+	result := statusLookup{task: task, inputs: inputs}
+
+	result.digest, err = appTaskDigest(task, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.lookupInputStr == "" {
+		return &result, nil
+	}
+
+	result.inputsWithLookupStr = replaceInputStrings(inputs, AsInputStrings(t.lookupInputStr))
+	result.lookupStrDigest, err = appTaskDigest(task, result.inputsWithLookupStr)
+	if err != nil {
+		return nil, err
 	}
 
-	return TaskStatusRunExist, inputsWithLookupStr, run, nil
+	return &result, nil
 }
 
-func (t *TaskStatusEvaluator) getTaskStatus(ctx context.Context, task *Task, inputs *Inputs) (*storage.TaskRunWithID, error) {
+func appTaskDigest(task *Task, inputs *Inputs) (storage.AppTaskDigest, error) {
 	totalInputDigest, err := inputs.Digest()
 	if err != nil {
-		return nil, fmt.Errorf("calculating total input digest failed: %w", err)
+		return storage.AppTaskDigest{}, fmt.Errorf("calculating total input digest failed: %w", err)
+	}
+
+	return storage.AppTaskDigest{
+		AppName:          task.AppName,
+		TaskName:         task.Name,
+		TotalInputDigest: totalInputDigest.String(),
+	}, nil
+}
+
+// latestTaskRuns queries the storage for the newest task runs of the total
+// input digests of lookups.
+func (t *TaskStatusEvaluator) latestTaskRuns(ctx context.Context, lookups []*statusLookup) (map[storage.AppTaskDigest]*storage.TaskRunWithID, error) {
+	digests := make([]storage.AppTaskDigest, 0, len(lookups))
+	for _, lookup := range lookups {
+		digests = append(digests, lookup.digest)
+
+		if lookup.inputsWithLookupStr != nil {
+			digests = append(digests, lookup.lookupStrDigest)
+		}
 	}
 
-	run, err := t.store.LatestTaskRunByDigest(ctx, task.AppName, task.Name, totalInputDigest.String())
+	runs, err := t.store.LatestTaskRunsByDigests(ctx, digests)
 	if err != nil {
 		return nil, fmt.Errorf("querying storage for task run status failed: %w", err)
 	}
 
-	return run, nil
+	return runs, nil
+}
+
+// result returns the status of the task, runs are the existing task runs.
+func (l *statusLookup) result(runs map[storage.AppTaskDigest]*storage.TaskRunWithID) *TaskStatusResult {
+	if run, exists := runs[l.digest]; exists {
+		return &TaskStatusResult{Task: l.task, Status: TaskStatusRunExist, Inputs: l.inputs, TaskRun: run}
+	}
+
+	if l.inputsWithLookupStr != nil {
+		if run, exists := runs[l.lookupStrDigest]; exists {
+			return &TaskStatusResult{Task: l.task, Status: TaskStatusRunExist, Inputs: l.inputsWithLookupStr, TaskRun: run}
+		}
+	}
+
+	// inputs instead of inputsWithLookupStr must be returned, if the task
+	// must be run it should be recorded with the inputStr not with the
+	// lookupInputStr
+	return &TaskStatusResult{Task: l.task, Status: TaskStatusExecutionPending, Inputs: l.inputs}
 }
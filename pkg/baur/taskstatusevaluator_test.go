@@ -1,11 +1,22 @@
 package baur
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/simplesurance/baur/v5/internal/log"
+	"github.com/simplesurance/baur/v5/internal/testutils/fstest"
+	"github.com/simplesurance/baur/v5/internal/testutils/gittest"
+	"github.com/simplesurance/baur/v5/internal/vcs/git"
 	"github.com/simplesurance/baur/v5/pkg/cfg"
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/memory"
 )
 
 func TestStatusEvaluatorFailsWhenTaskInfoAndLookupStrIsUsed(t *testing.T) {
@@ -30,3 +41,195 @@ func TestReplaceInputStrings(t *testing.T) {
 
 	require.Contains(t, result.inputs[0].String(), "after")
 }
+
+// countingStorer counts the calls of LatestTaskRunsByDigests.
+type countingStorer struct {
+	storage.Storer
+	latestTaskRunsByDigestsCalls int
+}
+
+func (s *countingStorer) LatestTaskRunsByDigests(ctx context.Context, digests []storage.AppTaskDigest) (map[storage.AppTaskDigest]*storage.TaskRunWithID, error) {
+	s.latestTaskRunsByDigestsCalls++
+	return s.Storer.LatestTaskRunsByDigests(ctx, digests)
+}
+
+func TestStatuses(t *testing.T) {
+	ctx := t.Context()
+
+	log.RedirectToTestingLog(t)
+	tempDir := t.TempDir()
+	gittest.CreateRepository(t, tempDir)
+
+	newTask := func(name string) *Task {
+		return &Task{
+			AppName:          "shop",
+			Name:             name,
+			Directory:        tempDir,
+			UnresolvedInputs: &cfg.Input{},
+		}
+	}
+	runWithInputStr := newTask("build")
+	runWithLookupStr := newTask("check")
+	pending := newTask("test")
+	tasks := []*Task{runWithInputStr, runWithLookupStr, pending}
+
+	store := countingStorer{Storer: memory.New()}
+	require.NoError(t, store.Init(ctx))
+
+	saveRun := func(task *Task, inputStr string) int {
+		r := NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, AsInputStrings(inputStr), true)
+		inputs, err := r.Resolve(ctx, task)
+		require.NoError(t, err)
+		d, err := inputs.Digest()
+		require.NoError(t, err)
+
+		id, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  task.AppName,
+				TaskName:         task.Name,
+				StartTimestamp:   time.Now(),
+				StopTimestamp:    time.Now(),
+				Result:           storage.ResultSuccess,
+				TotalInputDigest: d.String(),
+			},
+		})
+		require.NoError(t, err)
+
+		return id
+	}
+	runID := saveRun(runWithInputStr, "current")
+	lookupRunID := saveRun(runWithLookupStr, "lookup")
+
+	te := NewTaskStatusEvaluator(
+		tempDir,
+		&store,
+		NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, AsInputStrings("current"), true),
+		"lookup",
+	)
+
+	var resolved []*Task
+	result, err := te.Statuses(ctx, tasks, func(task *Task) {
+		resolved = append(resolved, task)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.latestTaskRunsByDigestsCalls)
+	assert.Equal(t, tasks, resolved)
+	require.Len(t, result, 3)
+
+	assert.Equal(t, runWithInputStr, result[0].Task)
+	assert.Equal(t, TaskStatusRunExist, result[0].Status)
+	require.NotNil(t, result[0].TaskRun)
+	assert.Equal(t, runID, result[0].TaskRun.ID)
+	assert.Contains(t, result[0].Inputs.Inputs()[0].String(), "current")
+
+	assert.Equal(t, runWithLookupStr, result[1].Task)
+	assert.Equal(t, TaskStatusRunExist, result[1].Status)
+	require.NotNil(t, result[1].TaskRun)
+	assert.Equal(t, lookupRunID, result[1].TaskRun.ID)
+	assert.Contains(t, result[1].Inputs.Inputs()[0].String(), "lookup")
+
+	assert.Equal(t, pending, result[2].Task)
+	assert.Equal(t, TaskStatusExecutionPending, result[2].Status)
+	assert.Nil(t, result[2].TaskRun)
+	assert.Contains(t, result[2].Inputs.Inputs()[0].String(), "current")
+}
+
+func TestStatusesResolvesInputsConcurrently(t *testing.T) {
+	const taskCnt = 20
+
+	ctx := t.Context()
+
+	log.RedirectToTestingLog(t)
+	tempDir := t.TempDir()
+	gittest.CreateRepository(t, tempDir)
+
+	fstest.WriteToFile(t, []byte("shared"), filepath.Join(tempDir, "shared"))
+	fstest.WriteToFile(t, []byte("committed"), filepath.Join(tempDir, "committed"))
+	gittest.CommitFilesToGit(t, tempDir)
+
+	tasks := make([]*Task, 0, taskCnt)
+	for i := range taskCnt {
+		name := fmt.Sprintf("task%d", i)
+		fstest.WriteToFile(t, []byte(name), filepath.Join(tempDir, name))
+
+		tasks = append(tasks, &Task{
+			AppName:   "shop",
+			Name:      name,
+			Directory: tempDir,
+			UnresolvedInputs: &cfg.Input{
+				Files: []cfg.FileInputs{{Paths: []string{"shared", "committed", name}}},
+			},
+		})
+	}
+
+	store := memory.New()
+	require.NoError(t, store.Init(ctx))
+
+	statuses := func(parallelism uint) []*TaskStatusResult {
+		te := NewTaskStatusEvaluator(
+			tempDir,
+			store,
+			NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true),
+			"",
+			WithResolveParallelism(parallelism),
+		)
+
+		var resolvedCnt int
+		result, err := te.Statuses(ctx, tasks, func(*Task) { resolvedCnt++ })
+		require.NoError(t, err)
+		require.Equal(t, taskCnt, resolvedCnt)
+
+		return result
+	}
+
+	expected := statuses(1)
+	actual := statuses(8)
+	require.Len(t, actual, taskCnt)
+
+	for i, st := range actual {
+		assert.Equal(t, tasks[i], st.Task)
+		assert.Equal(t, TaskStatusExecutionPending, st.Status)
+
+		expectedDigest, err := expected[i].Inputs.Digest()
+		require.NoError(t, err)
+		actualDigest, err := st.Inputs.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, expectedDigest.String(), actualDigest.String(), tasks[i].Name)
+	}
+}
+
+func TestStatusesFailsWhenResolvingATaskFails(t *testing.T) {
+	log.RedirectToTestingLog(t)
+	tempDir := t.TempDir()
+	gittest.CreateRepository(t, tempDir)
+
+	fstest.WriteToFile(t, []byte("1"), filepath.Join(tempDir, "exists"))
+
+	newTask := func(name, input string) *Task {
+		return &Task{
+			ID:        "shop." + name,
+			AppName:   "shop",
+			Name:      name,
+			Directory: tempDir,
+			UnresolvedInputs: &cfg.Input{
+				Files: []cfg.FileInputs{{Paths: []string{input}}},
+			},
+		}
+	}
+
+	te := NewTaskStatusEvaluator(
+		tempDir,
+		memory.New(),
+		NewInputResolver(git.NewRepository(tempDir, t.Logf), tempDir, nil, true),
+		"",
+		WithResolveParallelism(4),
+	)
+
+	_, err := te.Statuses(t.Context(), []*Task{
+		newTask("build", "exists"),
+		newTask("check", "missing"),
+		newTask("test", "exists"),
+	}, nil)
+	require.ErrorContains(t, err, "shop.check")
+	require.ErrorContains(t, err, "does not exist")
+}
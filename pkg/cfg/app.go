@@ -54,6 +54,11 @@ func ExampleApp(name string) *App {
 							EnvVarName: "CHECK_TASK_INFO_FILEPATH",
 						},
 					},
+					CommandOutput: []CommandOutputInputs{
+						{
+							Command: []string{"go", "version"},
+						},
+					},
 				},
 				Output: Output{
 					File: []FileOutput{
@@ -0,0 +1,32 @@
+package cfg
+
+// CommandOutputInputs specifies a command whose standard output is tracked as
+// input.
+type CommandOutputInputs struct {
+	Command []string `toml:"command" comment:"Command that is run in the application directory, a digest of its standard output is tracked as input.\n The first element is the executable, the remaining elements are passed as arguments.\n The command is not run in a shell, it must terminate with exit code 0."`
+}
+
+func (c *CommandOutputInputs) resolve(resolver Resolver) error {
+	for i, arg := range c.Command {
+		var err error
+
+		if c.Command[i], err = resolver.Resolve(arg); err != nil {
+			return fieldErrorWrap(err, "command", arg)
+		}
+	}
+
+	return nil
+}
+
+// validate checks that the stored information is valid.
+func (c *CommandOutputInputs) validate() error {
+	if len(c.Command) == 0 {
+		return newFieldError("can not be empty", "command")
+	}
+
+	if c.Command[0] == "" {
+		return newFieldError("first element can not be empty", "command")
+	}
+
+	return nil
+}
@@ -125,6 +125,11 @@ func ExampleInclude() *Include {
 				EnvironmentVariables: []EnvVarsInputs{
 					{},
 				},
+				CommandOutput: []CommandOutputInputs{
+					{
+						Command: []string{"go", "version"},
+					},
+				},
 			},
 		},
 		Output: []*OutputInclude{
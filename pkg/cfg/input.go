@@ -4,8 +4,9 @@ package cfg
 type Input struct {
 	EnvironmentVariables []EnvVarsInputs
 	Files                []FileInputs
-	GolangSources        []GolangSources `comment:"Inputs specified by resolving dependencies of Golang source files or packages."`
-	TaskInfos            []TaskInfo      `comment:"Information about another baur task."`
+	GolangSources        []GolangSources       `comment:"Inputs specified by resolving dependencies of Golang source files or packages."`
+	TaskInfos            []TaskInfo            `comment:"Information about another baur task."`
+	CommandOutput        []CommandOutputInputs `comment:"Inputs specified by the standard output of commands, e.g. to track toolchain versions."`
 	ExcludedFiles        FileExcludeList
 }
 
@@ -14,7 +15,8 @@ func (in *Input) IsEmpty() bool {
 		len(in.GolangSources) == 0 &&
 		len(in.EnvironmentVariables) == 0 &&
 		len(in.ExcludedFiles.Paths) == 0 &&
-		len(in.TaskInfos) == 0
+		len(in.TaskInfos) == 0 &&
+		len(in.CommandOutput) == 0
 }
 
 func (in *Input) fileInputs() []FileInputs {
@@ -37,6 +39,10 @@ func (in *Input) taskInfos() []TaskInfo {
 	return in.TaskInfos
 }
 
+func (in *Input) commandOutputs() []CommandOutputInputs {
+	return in.CommandOutput
+}
+
 // merge appends the information in other to in.
 func (in *Input) merge(other inputDef) {
 	in.Files = append(in.Files, other.fileInputs()...)
@@ -44,6 +50,7 @@ func (in *Input) merge(other inputDef) {
 	in.EnvironmentVariables = append(in.EnvironmentVariables, other.envVariables()...)
 	in.ExcludedFiles.Paths = append(in.ExcludedFiles.Paths, other.excludedFiles().Paths...)
 	in.TaskInfos = append(in.TaskInfos, other.taskInfos()...)
+	in.CommandOutput = append(in.CommandOutput, other.commandOutputs()...)
 }
 
 func (in *Input) resolve(resolver Resolver) error {
@@ -65,6 +72,12 @@ func (in *Input) resolve(resolver Resolver) error {
 		in.GolangSources[i] = gs
 	}
 
+	for i := range in.CommandOutput {
+		if err := in.CommandOutput[i].resolve(resolver); err != nil {
+			return fieldErrorWrap(err, "CommandOutput")
+		}
+	}
+
 	return nil
 }
 
@@ -96,5 +109,11 @@ func inputValidate(i inputDef) error {
 		return fieldErrorWrap(err, "TaskInfos")
 	}
 
+	for _, co := range i.commandOutputs() {
+		if err := co.validate(); err != nil {
+			return fieldErrorWrap(err, "CommandOutput")
+		}
+	}
+
 	return nil
 }
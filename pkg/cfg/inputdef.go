@@ -6,4 +6,5 @@ type inputDef interface {
 	golangSourcesInputs() []GolangSources
 	excludedFiles() *FileExcludeList
 	taskInfos() []TaskInfo
+	commandOutputs() []CommandOutputInputs
 }
@@ -12,7 +12,8 @@ type InputInclude struct {
 	Files                []FileInputs
 	GolangSources        []GolangSources `comment:"Inputs specified by resolving dependencies of Golang source files or packages."`
 	ExcludedFiles        FileExcludeList
-	TaskInfos            []TaskInfo `comment:"Information about task of the same App"`
+	TaskInfos            []TaskInfo            `comment:"Information about task of the same App"`
+	CommandOutput        []CommandOutputInputs `comment:"Inputs specified by the standard output of commands, e.g. to track toolchain versions."`
 
 	filepath string
 }
@@ -37,12 +38,17 @@ func (in *InputInclude) taskInfos() []TaskInfo {
 	return in.TaskInfos
 }
 
+func (in *InputInclude) commandOutputs() []CommandOutputInputs {
+	return in.CommandOutput
+}
+
 func (in *InputInclude) IsEmpty() bool {
 	return len(in.Files) == 0 &&
 		len(in.GolangSources) == 0 &&
 		len(in.ExcludedFiles.Paths) == 0 &&
 		len(in.EnvironmentVariables) == 0 &&
-		len(in.TaskInfos) == 0
+		len(in.TaskInfos) == 0 &&
+		len(in.CommandOutput) == 0
 }
 
 // validate checks if the stored information is valid.
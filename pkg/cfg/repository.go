@@ -22,13 +22,16 @@ type Repository struct {
 
 	Database Database
 	Discover Discover
+	Cache    Cache
+	Digest   Digest
+	TaskLogs TaskLogs
 
 	filePath string
 }
 
 // Database contains database configuration
 type Database struct {
-	PGSQLURL string `toml:"postgresql_url" comment:"PostgreSQL database Connection string (https://www.postgresql.org/docs/current/static/libpq-connect.html#LIBPQ-CONNSTRING)\n The setting is overwritten by the environment variable BAUR_POSTGRESQL_URL."`
+	PGSQLURL string `toml:"postgresql_url" comment:"PostgreSQL database Connection string (https://www.postgresql.org/docs/current/static/libpq-connect.html#LIBPQ-CONNSTRING)\n Alternatively a SQLite database file can be used by specifying a URL in the format sqlite:///path/to/baur.db.\n The database can also be accessed via a baur server (baur serve) by specifying its https:// URL, the authentication token is read from the environment variable BAUR_SERVER_TOKEN.\n The setting is overwritten by the environment variable BAUR_POSTGRESQL_URL."`
 }
 
 // Discover stores the [Discover] section of the repository configuration.
@@ -37,6 +40,27 @@ type Discover struct {
 	SearchDepth int      `toml:"search_depth" comment:"Descend at most search_depth levels to find application configs"`
 }
 
+// Cache stores the [Cache] section of the repository configuration.
+type Cache struct {
+	FileDigests bool `toml:"file_digests" comment:"Store the digests of untracked and modified files in the user cache directory ($XDG_CACHE_HOME/baur on Linux).\n The digests are reused by following baur invocations when the size, modification time, inode number and status change time of the files did not change.\n Only digests of the xxh64 file_algorithm are stored, git object IDs depend on git attributes and configuration."`
+}
+
+// Digest stores the [Digest] section of the repository configuration.
+type Digest struct {
+	FileAlgorithm string `toml:"file_algorithm" comment:"Algorithm to calculate digests of files that are untracked or modified in git, valid values:\n gitobjectid - git object IDs (default)\n xxh64       - XXH64, faster but not a cryptographic hash function\n Digests of tracked and unmodified files are always read from git.\n Changing the algorithm changes the total input digests of all tasks, all tasks become pending."`
+}
+
+// TaskLogs stores the [TaskLogs] section of the repository configuration.
+type TaskLogs struct {
+	Store bool `toml:"store" comment:"Record the combined stdout and stderr output of task runs in the database, it can be shown with 'baur logs'.\n Of outputs bigger than 1 MiB only the beginning and end are recorded."`
+}
+
+// File digest algorithms that can be configured in [Digest.FileAlgorithm].
+const (
+	FileDigestAlgorithmGitObjectID = "gitobjectid"
+	FileDigestAlgorithmXXH64       = "xxh64"
+)
+
 // RepositoryFromFile reads the repository config from a file and returns it.
 func RepositoryFromFile(cfgPath string) (*Repository, error) {
 	config := Repository{}
@@ -69,6 +93,10 @@ func ExampleRepository() *Repository {
 		Database: Database{
 			PGSQLURL: "postgres://postgres@localhost:5432/baur?sslmode=disable&connect_timeout=5",
 		},
+
+		Digest: Digest{
+			FileAlgorithm: FileDigestAlgorithmGitObjectID,
+		},
 	}
 }
 
@@ -98,9 +126,27 @@ func (r *Repository) Validate() error {
 		return fieldErrorWrap(err, "Discover")
 	}
 
+	err = r.Digest.validate()
+	if err != nil {
+		return fieldErrorWrap(err, "Digest")
+	}
+
 	return nil
 }
 
+// validate validates the Digest section.
+func (d *Digest) validate() error {
+	switch d.FileAlgorithm {
+	case "", FileDigestAlgorithmGitObjectID, FileDigestAlgorithmXXH64:
+		return nil
+	default:
+		return newFieldError(fmt.Sprintf("unsupported algorithm %q, must be %q or %q",
+			d.FileAlgorithm, FileDigestAlgorithmGitObjectID, FileDigestAlgorithmXXH64),
+			"file_algorithm",
+		)
+	}
+}
+
 // validate validates the Discover section and sets defaults.
 func (d *Discover) validate() error {
 	if len(d.Dirs) == 0 {
@@ -40,3 +40,12 @@ func Test_ExampleRepository_WrittenAndReadCfgIsValid(t *testing.T) {
 		t.Error("validating conf from file failed: ", err)
 	}
 }
+
+func TestRepositoryInvalidFileDigestAlgorithmFails(t *testing.T) {
+	r := ExampleRepository()
+	r.Digest.FileAlgorithm = "md5"
+
+	if err := r.Validate(); err == nil {
+		t.Error("validation succeeded for an unsupported file digest algorithm")
+	}
+}
@@ -19,6 +19,29 @@ const (
 	FieldID
 	FieldInputString
 	FieldInputFilePath
+	FieldResult
+	FieldVCSRevision
+	FieldVCSIsDirty
+	FieldTotalInputDigest
+	// FieldOutputDigest is the digest of an output of a task run.
+	// Task runs can have multiple outputs, a filter matches if it matches
+	// one of them.
+	FieldOutputDigest
+	// FieldOutputURI is the URI of an upload of an output of a task run.
+	// Task runs can have multiple uploads, a filter matches if it matches
+	// one of them.
+	FieldOutputURI
+	// FieldLabel is a label of a task run, filter values are in the
+	// format KEY=VALUE (see [LabelString]).
+	// Task runs can have multiple labels, a filter matches if it matches
+	// one of them.
+	FieldLabel
+	// FieldReleaseName is the name of a release, it can only be used in
+	// release queries.
+	FieldReleaseName
+	// FieldReleaseCreatedAt is the creation time of a release, it can only
+	// be used in release queries.
+	FieldReleaseCreatedAt
 )
 
 func (f Field) String() string {
@@ -35,6 +58,26 @@ func (f Field) String() string {
 		return "FieldID"
 	case FieldInputString:
 		return "FieldInputString"
+	case FieldInputFilePath:
+		return "FieldInputFilePath"
+	case FieldResult:
+		return "FieldResult"
+	case FieldVCSRevision:
+		return "FieldVCSRevision"
+	case FieldVCSIsDirty:
+		return "FieldVCSIsDirty"
+	case FieldTotalInputDigest:
+		return "FieldTotalInputDigest"
+	case FieldOutputDigest:
+		return "FieldOutputDigest"
+	case FieldOutputURI:
+		return "FieldOutputURI"
+	case FieldLabel:
+		return "FieldLabel"
+	case FieldReleaseName:
+		return "FieldReleaseName"
+	case FieldReleaseCreatedAt:
+		return "FieldReleaseCreatedAt"
 	default:
 		return "FieldUndefined"
 	}
@@ -60,6 +103,15 @@ const (
 	// OpIN represents a In operator, works like the SQL IN operator, the
 	// corresponding Value field in The filter struct must be a slice
 	OpIN
+	// OpNE represents a not equal (!=) operator
+	OpNE
+	// OpNotIN represents a Not In operator, it is the negation of OpIN, the
+	// corresponding Value field in The filter struct must be a slice
+	OpNotIN
+	// OpPrefix matches string values that start with the value of the
+	// filter, the corresponding Value field in the filter struct must be a
+	// string. The matching is case-sensitive.
+	OpPrefix
 )
 
 func (o Op) String() string {
@@ -68,6 +120,16 @@ func (o Op) String() string {
 		return "OpEQ"
 	case OpGT:
 		return "OpGT"
+	case OpLT:
+		return "OpLT"
+	case OpIN:
+		return "OpIN"
+	case OpNE:
+		return "OpNE"
+	case OpNotIN:
+		return "OpNotIN"
+	case OpPrefix:
+		return "OpPrefix"
 	default:
 		return "OpUndefined"
 	}
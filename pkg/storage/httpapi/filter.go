@@ -0,0 +1,154 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// Types of [FilterValue].
+const (
+	ValueTypeString   = "string"
+	ValueTypeStrings  = "strings"
+	ValueTypeInt      = "int"
+	ValueTypeInts     = "ints"
+	ValueTypeBool     = "bool"
+	ValueTypeTime     = "time"
+	ValueTypeDuration = "duration"
+)
+
+// Filter is the JSON representation of a [storage.Filter].
+type Filter struct {
+	Field    storage.Field `json:"field"`
+	Operator storage.Op    `json:"operator"`
+	Value    FilterValue   `json:"value"`
+}
+
+// FilterValue is the JSON representation of [storage.Filter.Value].
+// The value of the any typed field is encoded together with its type, to be
+// able to decode it to the same Go type.
+type FilterValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// NewFilters converts storage filters to their JSON representation.
+func NewFilters(filters []*storage.Filter) ([]*Filter, error) {
+	result := make([]*Filter, 0, len(filters))
+
+	for _, f := range filters {
+		v, err := newFilterValue(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", f.Field, err)
+		}
+
+		result = append(result, &Filter{
+			Field:    f.Field,
+			Operator: f.Operator,
+			Value:    *v,
+		})
+	}
+
+	return result, nil
+}
+
+func newFilterValue(v any) (*FilterValue, error) {
+	var typ string
+
+	switch val := v.(type) {
+	case string:
+		typ = ValueTypeString
+	case storage.Result:
+		// sent as string, the storage implementations handle both
+		// types the same
+		typ = ValueTypeString
+		v = string(val)
+	case []string:
+		typ = ValueTypeStrings
+	case int:
+		typ = ValueTypeInt
+	case []int:
+		typ = ValueTypeInts
+	case bool:
+		typ = ValueTypeBool
+	case time.Time:
+		typ = ValueTypeTime
+	case time.Duration:
+		typ = ValueTypeDuration
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilterValue{Type: typ, Value: buf}, nil
+}
+
+// ToStorage converts the filters to storage filters.
+func ToStorage(filters []*Filter) ([]*storage.Filter, error) {
+	result := make([]*storage.Filter, 0, len(filters))
+
+	for _, f := range filters {
+		v, err := f.Value.decode()
+		if err != nil {
+			return nil, fmt.Errorf("filter %s: %w", f.Field, err)
+		}
+
+		result = append(result, &storage.Filter{
+			Field:    f.Field,
+			Operator: f.Operator,
+			Value:    v,
+		})
+	}
+
+	return result, nil
+}
+
+func (v *FilterValue) decode() (any, error) {
+	var err error
+	var result any
+
+	switch v.Type {
+	case ValueTypeString:
+		var s string
+		err = json.Unmarshal(v.Value, &s)
+		result = s
+	case ValueTypeStrings:
+		var s []string
+		err = json.Unmarshal(v.Value, &s)
+		result = s
+	case ValueTypeInt:
+		var i int
+		err = json.Unmarshal(v.Value, &i)
+		result = i
+	case ValueTypeInts:
+		var i []int
+		err = json.Unmarshal(v.Value, &i)
+		result = i
+	case ValueTypeBool:
+		var b bool
+		err = json.Unmarshal(v.Value, &b)
+		result = b
+	case ValueTypeTime:
+		var t time.Time
+		err = json.Unmarshal(v.Value, &t)
+		result = t
+	case ValueTypeDuration:
+		var d time.Duration
+		err = json.Unmarshal(v.Value, &d)
+		result = d
+	default:
+		return nil, fmt.Errorf("unsupported value type %q", v.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s value failed: %w", v.Type, err)
+	}
+
+	return result, nil
+}
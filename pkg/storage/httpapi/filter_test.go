@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func TestFilterRoundTrip(t *testing.T) {
+	filters := []*storage.Filter{
+		{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "app"},
+		{Field: storage.FieldApplicationName, Operator: storage.OpIN, Value: []string{"a", "b"}},
+		{Field: storage.FieldID, Operator: storage.OpEQ, Value: 5},
+		{Field: storage.FieldID, Operator: storage.OpIN, Value: []int{1, 2}},
+		{Field: storage.FieldDuration, Operator: storage.OpGT, Value: 3 * time.Second},
+		{Field: storage.FieldStartTime, Operator: storage.OpLT, Value: time.Date(2024, 3, 1, 10, 0, 0, 5, time.UTC)},
+		{Field: storage.FieldUndefined, Operator: storage.OpEQ, Value: true},
+	}
+
+	apiFilters, err := NewFilters(filters)
+	require.NoError(t, err)
+
+	buf, err := json.Marshal(apiFilters)
+	require.NoError(t, err)
+
+	var decoded []*Filter
+	require.NoError(t, json.Unmarshal(buf, &decoded))
+
+	result, err := ToStorage(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, filters, result)
+}
+
+func TestNewFiltersUnsupportedType(t *testing.T) {
+	_, err := NewFilters([]*storage.Filter{
+		{Field: storage.FieldID, Operator: storage.OpEQ, Value: 1.5},
+	})
+	require.Error(t, err)
+}
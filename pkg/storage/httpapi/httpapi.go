@@ -0,0 +1,212 @@
+// Package httpapi defines the HTTP/JSON protocol that is used to access a
+// baur storage remotely.
+// The server side is implemented by the httpserver package, the client side
+// by the httpclient package.
+//
+// All requests must be authenticated with a bearer token in the
+// Authorization header.
+// Responses of failed requests have a non-2xx status code and an [Error]
+// body.
+// Operations that return multiple records (task runs, releases) stream them
+// as JSON Lines, one [StreamRecord] per line.
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// Version is the version of the API.
+// It is increased when incompatible changes are made.
+const Version = 1
+
+// ContentTypeJSONLines is the content type of streamed responses.
+const ContentTypeJSONLines = "application/jsonl"
+
+// Paths of the API endpoints.
+// Path parameters are specified in the [net/http.ServeMux] pattern syntax.
+const (
+	PathInfo = "/v1/info"
+
+	PathSchemaVersion    = "/v1/schema/version"
+	PathSchemaCompatible = "/v1/schema/compatible"
+	PathSchemaInit       = "/v1/schema/init"
+	PathSchemaUpgrade    = "/v1/schema/upgrade"
+
+	PathTaskRuns            = "/v1/taskruns"
+	PathTaskRunsQuery       = "/v1/taskruns/query"
+	PathTaskRunsLatest      = "/v1/taskruns/latest"
+	PathTaskRunsLatestQuery = "/v1/taskruns/latest/query"
+	PathTaskRunsDelete      = "/v1/taskruns/delete"
+	PathTaskRun             = "/v1/taskruns/{id}"
+	PathTaskRunInputs       = "/v1/taskruns/{id}/inputs"
+	PathTaskRunOutputs      = "/v1/taskruns/{id}/outputs"
+	PathTaskRunLog          = "/v1/taskruns/{id}/log"
+
+	PathReleases        = "/v1/releases"
+	PathReleasesQuery   = "/v1/releases/query"
+	PathReleasesDelete  = "/v1/releases/delete"
+	PathRelease         = "/v1/releases/{name}"
+	PathReleaseExists   = "/v1/releases/{name}/exists"
+	PathReleaseTaskRuns = "/v1/releases/{name}/taskruns"
+	PathReleaseMetadata = "/v1/releases/{name}/metadata"
+)
+
+// Query parameters of the [PathTaskRunsLatest] endpoint.
+const (
+	ParamAppName          = "app"
+	ParamTaskName         = "task"
+	ParamTotalInputDigest = "digest"
+)
+
+// Error codes of [Error].
+const (
+	ErrCodeNotExist     = "not_exist"
+	ErrCodeExists       = "exists"
+	ErrCodeUnauthorized = "unauthorized"
+	ErrCodeBadRequest   = "bad_request"
+	ErrCodeInternal     = "internal"
+)
+
+// Error is the response body of failed requests.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewError creates an Error for err.
+// [storage.ErrNotExist] and [storage.ErrExists] are mapped to their error
+// codes, all other errors to [ErrCodeInternal].
+func NewError(err error) *Error {
+	code := ErrCodeInternal
+
+	switch {
+	case errors.Is(err, storage.ErrNotExist):
+		code = ErrCodeNotExist
+	case errors.Is(err, storage.ErrExists):
+		code = ErrCodeExists
+	}
+
+	return &Error{Code: code, Message: err.Error()}
+}
+
+// remoteError is an error that happened on the server.
+type remoteError struct {
+	msg string
+	// wrapped is the storage error that corresponds to the error code, it
+	// can be nil.
+	wrapped error
+}
+
+func (e *remoteError) Error() string {
+	return "server: " + e.msg
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.wrapped
+}
+
+// Err converts e to an error.
+// The errors for [ErrCodeNotExist] and [ErrCodeExists] wrap
+// [storage.ErrNotExist] and [storage.ErrExists].
+func (e *Error) Err() error {
+	switch e.Code {
+	case ErrCodeNotExist:
+		return &remoteError{msg: e.Message, wrapped: storage.ErrNotExist}
+	case ErrCodeExists:
+		return &remoteError{msg: e.Message, wrapped: storage.ErrExists}
+	default:
+		return &remoteError{msg: fmt.Sprintf("%s (%s)", e.Message, e.Code)}
+	}
+}
+
+// Info is the response of the [PathInfo] endpoint.
+type Info struct {
+	APIVersion       int   `json:"api_version"`
+	MaxSchemaVersion int32 `json:"max_schema_version"`
+}
+
+// SchemaVersion is the response of the [PathSchemaVersion] endpoint.
+type SchemaVersion struct {
+	Version int32 `json:"version"`
+}
+
+// TaskRunID is the response of the [PathTaskRuns] endpoint.
+type TaskRunID struct {
+	ID int `json:"id"`
+}
+
+// TaskRunsQuery is the request body of the [PathTaskRunsQuery] endpoint.
+type TaskRunsQuery struct {
+	Filters []*Filter         `json:"filters,omitempty"`
+	Sorters []*storage.Sorter `json:"sorters,omitempty"`
+	Limit   uint              `json:"limit"`
+}
+
+// LatestTaskRunsQuery is the request body of the [PathTaskRunsLatestQuery]
+// endpoint.
+type LatestTaskRunsQuery struct {
+	Digests []storage.AppTaskDigest `json:"digests"`
+}
+
+// LatestTaskRuns is the response of the [PathTaskRunsLatestQuery] endpoint.
+// It contains the newest task run for each of the queried digests for that
+// a task run exists, in no particular order.
+type LatestTaskRuns struct {
+	TaskRuns []*storage.TaskRunWithID `json:"task_runs"`
+}
+
+// DeleteRequest is the request body of the [PathReleasesDelete] endpoint.
+type DeleteRequest struct {
+	Before  time.Time `json:"before"`
+	Pretend bool      `json:"pretend"`
+}
+
+// TaskRunsDeleteRequest is the request body of the [PathTaskRunsDelete]
+// endpoint.
+type TaskRunsDeleteRequest struct {
+	Before    time.Time                 `json:"before"`
+	Retention storage.TaskRunsRetention `json:"retention"`
+	Pretend   bool                      `json:"pretend"`
+}
+
+// TaskRunsDeleteResponse is the response of the [PathTaskRunsDelete]
+// endpoint.
+// Deleting can fail after some records were deleted, then Result and Error
+// are set.
+type TaskRunsDeleteResponse struct {
+	Result *storage.TaskRunsDeleteResult `json:"result,omitempty"`
+	Error  *Error                        `json:"error,omitempty"`
+}
+
+// CreateRelease is the request body of the [PathReleases] endpoint.
+type CreateRelease struct {
+	Name        string    `json:"name"`
+	BaseRelease string    `json:"base_release,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	TaskRunIDs  []int     `json:"task_run_ids"`
+	Metadata    []byte    `json:"metadata,omitempty"`
+}
+
+// ReleasesQuery is the request body of the [PathReleasesQuery] endpoint.
+type ReleasesQuery struct {
+	Filters []*Filter `json:"filters,omitempty"`
+}
+
+// ReleaseExists is the response of the [PathReleaseExists] endpoint.
+type ReleaseExists struct {
+	Exists bool `json:"exists"`
+}
+
+// StreamRecord is a line of a streamed response.
+// Exactly one of the fields is set.
+// When an error happens during streaming, a record with the Error field set
+// is sent and the stream ends.
+type StreamRecord struct {
+	TaskRun *storage.TaskRunWithID `json:"task_run,omitempty"`
+	Release *storage.Release       `json:"release,omitempty"`
+	Error   *Error                 `json:"error,omitempty"`
+}
@@ -0,0 +1,226 @@
+// Package httpclient is a baur-storage implementation that accesses a
+// storage remotely via a baur HTTP server.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/pkg/storage/httpapi"
+)
+
+// Logger is an interface for logging debug informations
+type Logger interface {
+	Debugln(v ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugln(...any) {}
+
+// Client is a storage client that sends requests to a baur HTTP server.
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+	logger     Logger
+
+	maxSchemaVersion int32
+}
+
+// Option is an option for [New].
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client that is used to send requests.
+// By default [http.DefaultClient] is used.
+func WithHTTPClient(c *http.Client) Option {
+	return func(clt *Client) {
+		clt.httpClient = c
+	}
+}
+
+// IsURL returns true if strURL is an URL that is handled by this package,
+// it's scheme is http or https.
+func IsURL(strURL string) bool {
+	return strings.HasPrefix(strURL, "https://") || strings.HasPrefix(strURL, "http://")
+}
+
+// New returns a new client for the baur server at strURL.
+// token is sent as bearer token to authenticate the requests.
+// The function queries the server to verify that it is reachable, the
+// token is valid and the server supports the API version of the client.
+// If logger is nil, logging is disabled.
+func New(ctx context.Context, strURL, token string, logger Logger, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported URL scheme %q, expecting https or http", u.Scheme)
+	}
+
+	if token == "" {
+		return nil, errors.New("authentication token is empty")
+	}
+
+	if logger == nil {
+		logger = nopLogger{}
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	clt := Client{
+		baseURL:    u,
+		token:      token,
+		httpClient: http.DefaultClient,
+		logger:     logger,
+	}
+
+	for _, opt := range opts {
+		opt(&clt)
+	}
+
+	logger.Debugln("httpclient: connecting to", u.Redacted())
+
+	var info httpapi.Info
+	if err := clt.do(ctx, http.MethodGet, httpapi.PathInfo, nil, &info); err != nil {
+		return nil, err
+	}
+
+	if info.APIVersion != httpapi.Version {
+		return nil, fmt.Errorf(
+			"server uses API version %d, client supports version %d, ensure that baur client and server have compatible versions",
+			info.APIVersion, httpapi.Version,
+		)
+	}
+
+	clt.maxSchemaVersion = info.MaxSchemaVersion
+
+	return &clt, nil
+}
+
+// Close closes idle connections to the server.
+func (c *Client) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+func taskRunPath(pattern string, id int) string {
+	return strings.Replace(pattern, "{id}", strconv.Itoa(id), 1)
+}
+
+func releasePath(pattern, name string) string {
+	return strings.Replace(pattern, "{name}", url.PathEscape(name), 1)
+}
+
+// send sends a request to the server and returns the response if it has a
+// 2xx status code.
+// Otherwise the error from the response body is returned.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, reqBody any) (*http.Response, error) {
+	var body io.Reader
+
+	if reqBody != nil {
+		buf, err := json.Marshal(reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request failed: %w", err)
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	u := c.baseURL.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+
+	var apiErr httpapi.Error
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Code == "" {
+		return nil, fmt.Errorf("%s %s: server responded with status %s", method, path, resp.Status)
+	}
+
+	return nil, apiErr.Err()
+}
+
+// do sends a request and decodes the JSON response body into respBody.
+// If respBody is nil, the response body is ignored.
+func (c *Client) do(ctx context.Context, method, path string, reqBody, respBody any) error {
+	return c.doQuery(ctx, method, path, nil, reqBody, respBody)
+}
+
+func (c *Client) doQuery(ctx context.Context, method, path string, query url.Values, reqBody, respBody any) error {
+	resp, err := c.send(ctx, method, path, query, reqBody)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if respBody == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
+		return fmt.Errorf("%s %s: decoding response failed: %w", method, path, err)
+	}
+
+	return nil
+}
+
+// stream sends a request for a streamed response and passes every received
+// record to cb.
+func (c *Client) stream(ctx context.Context, method, path string, reqBody any, cb func(*httpapi.StreamRecord) error) error {
+	resp, err := c.send(ctx, method, path, nil, reqBody)
+	if err != nil {
+		return err
+	}
+	// when cb fails, the body is closed before it was read completely,
+	// this aborts the request and the server stops streaming
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var rec httpapi.StreamRecord
+
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: decoding response failed: %w", method, path, err)
+		}
+
+		if rec.Error != nil {
+			return rec.Error.Err()
+		}
+
+		if err := cb(&rec); err != nil {
+			return err
+		}
+	}
+}
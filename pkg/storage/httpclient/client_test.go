@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/httpapi"
+	"github.com/simplesurance/baur/v5/pkg/storage/httpserver"
+	"github.com/simplesurance/baur/v5/pkg/storage/memory"
+	"github.com/simplesurance/baur/v5/pkg/storage/storagetest"
+)
+
+const testToken = "secret"
+
+// newTestServer starts a TLS server that serves the storage API backed by
+// storer.
+func newTestServer(t *testing.T, storer storage.Storer) *httptest.Server {
+	t.Helper()
+
+	h, err := httpserver.New(storer, testToken, nil)
+	require.NoError(t, err)
+
+	srv := httptest.NewTLSServer(h)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func newTestClient(t *testing.T, storer storage.Storer) *Client {
+	t.Helper()
+
+	srv := newTestServer(t, storer)
+
+	clt, err := New(context.Background(), srv.URL, testToken, nil, WithHTTPClient(srv.Client()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = clt.Close() })
+
+	return clt
+}
+
+func TestStorer(t *testing.T) {
+	storagetest.Run(t, func() storage.Storer {
+		return newTestClient(t, memory.New())
+	})
+}
+
+func TestNewFailsWithInvalidToken(t *testing.T) {
+	srv := newTestServer(t, memory.New())
+
+	_, err := New(context.Background(), srv.URL, "wrong", nil, WithHTTPClient(srv.Client()))
+	require.ErrorContains(t, err, httpapi.ErrCodeUnauthorized)
+}
+
+func TestNewFailsOnAPIVersionMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"api_version": 9999, "max_schema_version": 1}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := New(context.Background(), srv.URL, testToken, nil)
+	require.ErrorContains(t, err, "API version")
+}
+
+func TestTaskRunsStreamsResults(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	require.NoError(t, store.Init(ctx))
+
+	for range 10 {
+		_, err := store.SaveTaskRun(ctx, &storage.TaskRunFull{
+			TaskRun: storage.TaskRun{
+				ApplicationName:  "app",
+				TaskName:         "build",
+				StartTimestamp:   time.Now(),
+				StopTimestamp:    time.Now(),
+				TotalInputDigest: "sha384:1",
+				Result:           storage.ResultSuccess,
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	clt := newTestClient(t, store)
+
+	var cnt int
+	errStop := errors.New("stop")
+	err := clt.TaskRuns(ctx, nil, nil, storage.NoLimit, func(*storage.TaskRunWithID) error {
+		cnt++
+		if cnt == 3 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, 3, cnt)
+
+	// the client must still be usable after a stream was aborted
+	_, err = clt.TaskRun(ctx, 1)
+	require.NoError(t, err)
+}
@@ -0,0 +1,284 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/httpapi"
+)
+
+func (c *Client) SchemaVersion(ctx context.Context) (int32, error) {
+	var v httpapi.SchemaVersion
+
+	if err := c.do(ctx, http.MethodGet, httpapi.PathSchemaVersion, nil, &v); err != nil {
+		return -1, err
+	}
+
+	return v.Version, nil
+}
+
+// MaxSchemaVersion returns the max. schema version that is supported by the
+// server.
+func (c *Client) MaxSchemaVersion() int32 {
+	return c.maxSchemaVersion
+}
+
+func (c *Client) IsCompatible(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, httpapi.PathSchemaCompatible, nil, nil)
+}
+
+func (c *Client) Init(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, httpapi.PathSchemaInit, nil, nil)
+}
+
+func (c *Client) Upgrade(ctx context.Context) error {
+	return c.do(ctx, http.MethodPost, httpapi.PathSchemaUpgrade, nil, nil)
+}
+
+func (c *Client) SaveTaskRun(ctx context.Context, taskRun *storage.TaskRunFull) (int, error) {
+	var resp httpapi.TaskRunID
+
+	if err := c.do(ctx, http.MethodPost, httpapi.PathTaskRuns, taskRun, &resp); err != nil {
+		return -1, err
+	}
+
+	return resp.ID, nil
+}
+
+func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string) (*storage.TaskRunWithID, error) {
+	var result storage.TaskRunWithID
+
+	q := url.Values{}
+	q.Set(httpapi.ParamAppName, appName)
+	q.Set(httpapi.ParamTaskName, taskName)
+	q.Set(httpapi.ParamTotalInputDigest, totalInputDigest)
+
+	if err := c.doQuery(ctx, http.MethodGet, httpapi.PathTaskRunsLatest, q, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) LatestTaskRunsByDigests(ctx context.Context, digests []storage.AppTaskDigest) (map[storage.AppTaskDigest]*storage.TaskRunWithID, error) {
+	var resp httpapi.LatestTaskRuns
+
+	result := make(map[storage.AppTaskDigest]*storage.TaskRunWithID, len(digests))
+	if len(digests) == 0 {
+		return result, nil
+	}
+
+	req := httpapi.LatestTaskRunsQuery{Digests: digests}
+	if err := c.do(ctx, http.MethodPost, httpapi.PathTaskRunsLatestQuery, &req, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, tr := range resp.TaskRuns {
+		key := storage.AppTaskDigest{
+			AppName:          tr.ApplicationName,
+			TaskName:         tr.TaskName,
+			TotalInputDigest: tr.TotalInputDigest,
+		}
+		result[key] = tr
+	}
+
+	return result, nil
+}
+
+func (c *Client) TaskRun(ctx context.Context, id int) (*storage.TaskRunWithID, error) {
+	var result storage.TaskRunWithID
+
+	if err := c.do(ctx, http.MethodGet, taskRunPath(httpapi.PathTaskRun, id), nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// TaskRuns queries the server for task runs that match the filters.
+// The server streams the results, cb is called while the response is
+// received.
+func (c *Client) TaskRuns(
+	ctx context.Context,
+	filters []*storage.Filter,
+	sorters []*storage.Sorter,
+	limit uint,
+	cb func(*storage.TaskRunWithID) error,
+) error {
+	apiFilters, err := httpapi.NewFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	q := httpapi.TaskRunsQuery{
+		Filters: apiFilters,
+		Sorters: sorters,
+		Limit:   limit,
+	}
+
+	return c.stream(ctx, http.MethodPost, httpapi.PathTaskRunsQuery, &q, func(rec *httpapi.StreamRecord) error {
+		if rec.TaskRun == nil {
+			return errors.New("server sent a record without a task run")
+		}
+
+		if err := cb(rec.TaskRun); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (c *Client) TaskRunsDelete(ctx context.Context, before time.Time, retention storage.TaskRunsRetention, pretend bool) (*storage.TaskRunsDeleteResult, error) {
+	var resp httpapi.TaskRunsDeleteResponse
+
+	req := httpapi.TaskRunsDeleteRequest{Before: before, Retention: retention, Pretend: pretend}
+	if err := c.do(ctx, http.MethodPost, httpapi.PathTaskRunsDelete, &req, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error != nil {
+		return resp.Result, resp.Error.Err()
+	}
+
+	return resp.Result, nil
+}
+
+func (c *Client) Inputs(ctx context.Context, taskRunID int) (*storage.Inputs, error) {
+	var result storage.Inputs
+
+	if err := c.do(ctx, http.MethodGet, taskRunPath(httpapi.PathTaskRunInputs, taskRunID), nil, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) Outputs(ctx context.Context, taskRunID int) ([]*storage.Output, error) {
+	var result []*storage.Output
+
+	if err := c.do(ctx, http.MethodGet, taskRunPath(httpapi.PathTaskRunOutputs, taskRunID), nil, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) TaskRunLog(ctx context.Context, taskRunID int) ([]byte, error) {
+	path := taskRunPath(httpapi.PathTaskRunLog, taskRunID)
+
+	resp, err := c.send(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: reading response failed: %w", http.MethodGet, path, err)
+	}
+
+	return result, nil
+}
+
+func (c *Client) CreateRelease(ctx context.Context, releaseName, baseReleaseName string, createdAt time.Time, taskRunIDs []int, metadata io.Reader) error {
+	req := httpapi.CreateRelease{
+		Name:        releaseName,
+		BaseRelease: baseReleaseName,
+		CreatedAt:   createdAt,
+		TaskRunIDs:  taskRunIDs,
+	}
+
+	if metadata != nil {
+		var err error
+
+		req.Metadata, err = io.ReadAll(metadata)
+		if err != nil {
+			return fmt.Errorf("reading metadata failed: %w", err)
+		}
+	}
+
+	return c.do(ctx, http.MethodPost, httpapi.PathReleases, &req, nil)
+}
+
+func (c *Client) ReleaseExists(ctx context.Context, name string) (bool, error) {
+	var resp httpapi.ReleaseExists
+
+	if err := c.do(ctx, http.MethodGet, releasePath(httpapi.PathReleaseExists, name), nil, &resp); err != nil {
+		return false, err
+	}
+
+	return resp.Exists, nil
+}
+
+func (c *Client) ReleaseTaskRuns(ctx context.Context, releaseName string) ([]*storage.ReleaseTaskRunsResult, error) {
+	var result []*storage.ReleaseTaskRunsResult
+
+	if err := c.do(ctx, http.MethodGet, releasePath(httpapi.PathReleaseTaskRuns, releaseName), nil, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) ReleaseMetadata(ctx context.Context, releaseName string) ([]byte, error) {
+	path := releasePath(httpapi.PathReleaseMetadata, releaseName)
+
+	resp, err := c.send(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: reading response failed: %w", http.MethodGet, path, err)
+	}
+
+	return result, nil
+}
+
+// Releases queries the releases that match filters from the server.
+// The server streams the results, cb is called while the response is
+// received.
+func (c *Client) Releases(ctx context.Context, filters []*storage.Filter, cb func(*storage.Release) error) error {
+	apiFilters, err := httpapi.NewFilters(filters)
+	if err != nil {
+		return err
+	}
+
+	q := httpapi.ReleasesQuery{Filters: apiFilters}
+
+	return c.stream(ctx, http.MethodPost, httpapi.PathReleasesQuery, &q, func(rec *httpapi.StreamRecord) error {
+		if rec.Release == nil {
+			return errors.New("server sent a record without a release")
+		}
+
+		if err := cb(rec.Release); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (c *Client) ReleaseDelete(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, releasePath(httpapi.PathRelease, name), nil, nil)
+}
+
+func (c *Client) ReleasesDelete(ctx context.Context, before time.Time, pretend bool) (*storage.ReleasesDeleteResult, error) {
+	var result storage.ReleasesDeleteResult
+
+	req := httpapi.DeleteRequest{Before: before, Pretend: pretend}
+	if err := c.do(ctx, http.MethodPost, httpapi.PathReleasesDelete, &req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
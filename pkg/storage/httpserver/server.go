@@ -0,0 +1,488 @@
+// Package httpserver provides an HTTP handler that exposes the operations of
+// a [storage.Storer] via the API defined in the httpapi package.
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/httpapi"
+)
+
+// maxRequestBodySize is the max. size of request bodies.
+// Requests with bigger bodies are rejected.
+const maxRequestBodySize = 64 * 1024 * 1024
+
+// Logger is an interface for logging debug informations
+type Logger interface {
+	Debugln(v ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugln(...any) {}
+
+// Handler serves API requests by running the operations on a
+// [storage.Storer].
+type Handler struct {
+	storer storage.Storer
+	token  []byte
+	logger Logger
+	mux    *http.ServeMux
+}
+
+// New returns a Handler that runs the requested operations on storer.
+// Only requests that contain token as bearer token in the Authorization
+// header are served. token must not be empty.
+// If logger is nil, logging is disabled.
+func New(storer storage.Storer, token string, logger Logger) (*Handler, error) {
+	if token == "" {
+		return nil, errors.New("authentication token is empty")
+	}
+
+	if logger == nil {
+		logger = nopLogger{}
+	}
+
+	h := Handler{
+		storer: storer,
+		token:  []byte(token),
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET "+httpapi.PathInfo, h.info)
+
+	h.mux.HandleFunc("GET "+httpapi.PathSchemaVersion, h.schemaVersion)
+	h.mux.HandleFunc("GET "+httpapi.PathSchemaCompatible, h.isCompatible)
+	h.mux.HandleFunc("POST "+httpapi.PathSchemaInit, h.init)
+	h.mux.HandleFunc("POST "+httpapi.PathSchemaUpgrade, h.upgrade)
+
+	h.mux.HandleFunc("POST "+httpapi.PathTaskRuns, h.saveTaskRun)
+	h.mux.HandleFunc("POST "+httpapi.PathTaskRunsQuery, h.taskRuns)
+	h.mux.HandleFunc("GET "+httpapi.PathTaskRunsLatest, h.latestTaskRunByDigest)
+	h.mux.HandleFunc("POST "+httpapi.PathTaskRunsLatestQuery, h.latestTaskRunsByDigests)
+	h.mux.HandleFunc("POST "+httpapi.PathTaskRunsDelete, h.taskRunsDelete)
+	h.mux.HandleFunc("GET "+httpapi.PathTaskRun, h.taskRun)
+	h.mux.HandleFunc("GET "+httpapi.PathTaskRunInputs, h.inputs)
+	h.mux.HandleFunc("GET "+httpapi.PathTaskRunOutputs, h.outputs)
+	h.mux.HandleFunc("GET "+httpapi.PathTaskRunLog, h.taskRunLog)
+
+	h.mux.HandleFunc("GET "+httpapi.PathReleases, h.releases)
+	h.mux.HandleFunc("POST "+httpapi.PathReleases, h.createRelease)
+	h.mux.HandleFunc("POST "+httpapi.PathReleasesQuery, h.releasesQuery)
+	h.mux.HandleFunc("POST "+httpapi.PathReleasesDelete, h.releasesDelete)
+	h.mux.HandleFunc("DELETE "+httpapi.PathRelease, h.releaseDelete)
+	h.mux.HandleFunc("GET "+httpapi.PathReleaseExists, h.releaseExists)
+	h.mux.HandleFunc("GET "+httpapi.PathReleaseTaskRuns, h.releaseTaskRuns)
+	h.mux.HandleFunc("GET "+httpapi.PathReleaseMetadata, h.releaseMetadata)
+
+	return &h, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.isAuthorized(r) {
+		h.logger.Debugln("httpserver: rejecting unauthorized request from", r.RemoteAddr, r.Method, r.URL.Path)
+		writeError(w, http.StatusUnauthorized, &httpapi.Error{
+			Code:    httpapi.ErrCodeUnauthorized,
+			Message: "missing or invalid authentication token",
+		})
+		return
+	}
+
+	h.logger.Debugln("httpserver:", r.RemoteAddr, r.Method, r.URL.Path)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) isAuthorized(r *http.Request) bool {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), h.token) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// an error can only happen when the connection broke, the client
+	// will notice it
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, e *httpapi.Error) {
+	writeJSON(w, status, e)
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusBadRequest, &httpapi.Error{
+		Code:    httpapi.ErrCodeBadRequest,
+		Message: err.Error(),
+	})
+}
+
+// writeStorageError writes an error response for an error returned by the
+// storage.
+func (h *Handler) writeStorageError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := httpapi.NewError(err)
+
+	var status int
+	switch apiErr.Code {
+	case httpapi.ErrCodeNotExist:
+		status = http.StatusNotFound
+	case httpapi.ErrCodeExists:
+		status = http.StatusConflict
+	default:
+		status = http.StatusInternalServerError
+		h.logger.Debugln("httpserver:", r.Method, r.URL.Path, "failed:", err)
+	}
+
+	writeError(w, status, apiErr)
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("parsing request body failed: %w", err)
+	}
+
+	return nil
+}
+
+func taskRunIDParam(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return -1, fmt.Errorf("invalid task run id: %w", err)
+	}
+
+	return id, nil
+}
+
+func (h *Handler) info(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, &httpapi.Info{
+		APIVersion:       httpapi.Version,
+		MaxSchemaVersion: h.storer.MaxSchemaVersion(),
+	})
+}
+
+func (h *Handler) schemaVersion(w http.ResponseWriter, r *http.Request) {
+	v, err := h.storer.SchemaVersion(r.Context())
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &httpapi.SchemaVersion{Version: v})
+}
+
+// runNoResult runs fn and responds with StatusNoContent if it succeeds.
+func (h *Handler) runNoResult(w http.ResponseWriter, r *http.Request, fn func(context.Context) error) {
+	if err := fn(r.Context()); err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) isCompatible(w http.ResponseWriter, r *http.Request) {
+	h.runNoResult(w, r, h.storer.IsCompatible)
+}
+
+func (h *Handler) init(w http.ResponseWriter, r *http.Request) {
+	h.runNoResult(w, r, h.storer.Init)
+}
+
+func (h *Handler) upgrade(w http.ResponseWriter, r *http.Request) {
+	h.runNoResult(w, r, h.storer.Upgrade)
+}
+
+func (h *Handler) saveTaskRun(w http.ResponseWriter, r *http.Request) {
+	var tr storage.TaskRunFull
+
+	if err := decodeBody(r, &tr); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	id, err := h.storer.SaveTaskRun(r.Context(), &tr)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, &httpapi.TaskRunID{ID: id})
+}
+
+func (h *Handler) latestTaskRunByDigest(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	tr, err := h.storer.LatestTaskRunByDigest(
+		r.Context(),
+		q.Get(httpapi.ParamAppName),
+		q.Get(httpapi.ParamTaskName),
+		q.Get(httpapi.ParamTotalInputDigest),
+	)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tr)
+}
+
+func (h *Handler) latestTaskRunsByDigests(w http.ResponseWriter, r *http.Request) {
+	var req httpapi.LatestTaskRunsQuery
+
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	runs, err := h.storer.LatestTaskRunsByDigests(r.Context(), req.Digests)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	resp := httpapi.LatestTaskRuns{TaskRuns: make([]*storage.TaskRunWithID, 0, len(runs))}
+	for _, tr := range runs {
+		resp.TaskRuns = append(resp.TaskRuns, tr)
+	}
+
+	writeJSON(w, http.StatusOK, &resp)
+}
+
+func (h *Handler) taskRun(w http.ResponseWriter, r *http.Request) {
+	id, err := taskRunIDParam(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	tr, err := h.storer.TaskRun(r.Context(), id)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tr)
+}
+
+func (h *Handler) inputs(w http.ResponseWriter, r *http.Request) {
+	id, err := taskRunIDParam(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	inputs, err := h.storer.Inputs(r.Context(), id)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, inputs)
+}
+
+func (h *Handler) outputs(w http.ResponseWriter, r *http.Request) {
+	id, err := taskRunIDParam(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	outputs, err := h.storer.Outputs(r.Context(), id)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, outputs)
+}
+
+func (h *Handler) taskRunLog(w http.ResponseWriter, r *http.Request) {
+	id, err := taskRunIDParam(r)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	log, err := h.storer.TaskRunLog(r.Context(), id)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(log)
+}
+
+func (h *Handler) taskRuns(w http.ResponseWriter, r *http.Request) {
+	var q httpapi.TaskRunsQuery
+
+	if err := decodeBody(r, &q); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	filters, err := httpapi.ToStorage(q.Filters)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	sw := newStreamWriter(w)
+	err = h.storer.TaskRuns(r.Context(), filters, q.Sorters, q.Limit, func(tr *storage.TaskRunWithID) error {
+		return sw.write(&httpapi.StreamRecord{TaskRun: tr})
+	})
+	h.finishStream(w, r, sw, err)
+}
+
+func (h *Handler) taskRunsDelete(w http.ResponseWriter, r *http.Request) {
+	var req httpapi.TaskRunsDeleteRequest
+
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	result, err := h.storer.TaskRunsDelete(r.Context(), req.Before, req.Retention, req.Pretend)
+	if err != nil && result == nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	resp := httpapi.TaskRunsDeleteResponse{Result: result}
+	if err != nil {
+		h.logger.Debugln("httpserver:", r.Method, r.URL.Path, "failed:", err)
+		resp.Error = httpapi.NewError(err)
+	}
+
+	writeJSON(w, http.StatusOK, &resp)
+}
+
+func (h *Handler) createRelease(w http.ResponseWriter, r *http.Request) {
+	var req httpapi.CreateRelease
+
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	var metadata io.Reader
+	if req.Metadata != nil {
+		metadata = bytes.NewReader(req.Metadata)
+	}
+
+	err := h.storer.CreateRelease(r.Context(), req.Name, req.BaseRelease, req.CreatedAt, req.TaskRunIDs, metadata)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) releaseExists(w http.ResponseWriter, r *http.Request) {
+	exists, err := h.storer.ReleaseExists(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &httpapi.ReleaseExists{Exists: exists})
+}
+
+func (h *Handler) releaseTaskRuns(w http.ResponseWriter, r *http.Request) {
+	result, err := h.storer.ReleaseTaskRuns(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) releaseMetadata(w http.ResponseWriter, r *http.Request) {
+	metadata, err := h.storer.ReleaseMetadata(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(metadata)
+}
+
+func (h *Handler) releases(w http.ResponseWriter, r *http.Request) {
+	h.streamReleases(w, r, nil)
+}
+
+func (h *Handler) releasesQuery(w http.ResponseWriter, r *http.Request) {
+	var q httpapi.ReleasesQuery
+
+	if err := decodeBody(r, &q); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	filters, err := httpapi.ToStorage(q.Filters)
+	if err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	h.streamReleases(w, r, filters)
+}
+
+func (h *Handler) streamReleases(w http.ResponseWriter, r *http.Request, filters []*storage.Filter) {
+	sw := newStreamWriter(w)
+	err := h.storer.Releases(r.Context(), filters, func(rel *storage.Release) error {
+		return sw.write(&httpapi.StreamRecord{Release: rel})
+	})
+	h.finishStream(w, r, sw, err)
+}
+
+func (h *Handler) releaseDelete(w http.ResponseWriter, r *http.Request) {
+	err := h.storer.ReleaseDelete(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) releasesDelete(w http.ResponseWriter, r *http.Request) {
+	var req httpapi.DeleteRequest
+
+	if err := decodeBody(r, &req); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	result, err := h.storer.ReleasesDelete(r.Context(), req.Before, req.Pretend)
+	if err != nil {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/simplesurance/baur/v5/pkg/storage/httpapi"
+)
+
+// streamWriter writes [httpapi.StreamRecord]s as JSON Lines.
+// The response header is sent with the first record, this allows to respond
+// with an error status code when the operation fails before a record was
+// produced.
+type streamWriter struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	rc      *http.ResponseController
+	started bool
+}
+
+func newStreamWriter(w http.ResponseWriter) *streamWriter {
+	return &streamWriter{
+		w:   w,
+		enc: json.NewEncoder(w),
+		rc:  http.NewResponseController(w),
+	}
+}
+
+func (s *streamWriter) start() {
+	s.w.Header().Set("Content-Type", httpapi.ContentTypeJSONLines)
+	s.w.WriteHeader(http.StatusOK)
+	s.started = true
+}
+
+func (s *streamWriter) write(rec *httpapi.StreamRecord) error {
+	if !s.started {
+		s.start()
+	}
+
+	if err := s.enc.Encode(rec); err != nil {
+		return err
+	}
+
+	// records are flushed immediately, to make them available to the
+	// client while the storage is still being queried
+	return s.rc.Flush()
+}
+
+// finishStream completes a streamed response, after the storage operation
+// finished with err.
+func (h *Handler) finishStream(w http.ResponseWriter, r *http.Request, sw *streamWriter, err error) {
+	if err == nil {
+		if !sw.started {
+			sw.start()
+		}
+		return
+	}
+
+	if !sw.started {
+		h.writeStorageError(w, r, err)
+		return
+	}
+
+	h.logger.Debugln("httpserver:", r.Method, r.URL.Path, "failed while streaming:", err)
+	_ = sw.write(&httpapi.StreamRecord{Error: httpapi.NewError(err)})
+}
@@ -0,0 +1,189 @@
+package jsonl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// ExportResult contains the number of exported records.
+type ExportResult struct {
+	TaskRuns int
+	Releases int
+}
+
+// Export writes all task runs and releases of s to w.
+func Export(ctx context.Context, s storage.Storer, w io.Writer) (*ExportResult, error) {
+	var result ExportResult
+
+	enc := json.NewEncoder(w)
+
+	err := enc.Encode(&record{
+		Type: recordTypeHeader,
+		Header: &header{
+			FormatVersion: FormatVersion,
+			CreatedAt:     time.Now(),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("writing header failed: %w", err)
+	}
+
+	result.TaskRuns, err = exportTaskRuns(ctx, s, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Releases, err = exportReleases(ctx, s, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// exportBatchSize is the max. number of task runs that are queried at once
+// and held in memory during the export.
+const exportBatchSize = 500
+
+func exportTaskRuns(ctx context.Context, s storage.Storer, enc *json.Encoder) (int, error) {
+	var cnt int
+	lastID := -1
+
+	for {
+		batch, err := queryTaskRunBatch(ctx, s, lastID)
+		if err != nil {
+			return cnt, err
+		}
+
+		for _, tr := range batch {
+			if err := exportTaskRun(ctx, s, enc, tr); err != nil {
+				return cnt, err
+			}
+
+			cnt++
+		}
+
+		if len(batch) < exportBatchSize {
+			return cnt, nil
+		}
+
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+// queryTaskRunBatch returns up to exportBatchSize task runs with an ID
+// greater than afterID, ordered by their IDs.
+// The inputs, outputs and logs are not queried in the TaskRuns callback,
+// storage implementations might not support running queries while iterating
+// over results.
+func queryTaskRunBatch(ctx context.Context, s storage.Storer, afterID int) ([]*storage.TaskRunWithID, error) {
+	result := make([]*storage.TaskRunWithID, 0, exportBatchSize)
+
+	err := s.TaskRuns(
+		ctx,
+		[]*storage.Filter{{Field: storage.FieldID, Operator: storage.OpGT, Value: afterID}},
+		[]*storage.Sorter{{Field: storage.FieldID, Order: storage.OrderAsc}},
+		exportBatchSize,
+		func(tr *storage.TaskRunWithID) error {
+			result = append(result, tr)
+			return nil
+		},
+	)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("querying task runs failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func exportTaskRun(ctx context.Context, s storage.Storer, enc *json.Encoder, tr *storage.TaskRunWithID) error {
+	inputs, err := s.Inputs(ctx, tr.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		return fmt.Errorf("querying inputs of task run %d failed: %w", tr.ID, err)
+	}
+
+	outputs, err := s.Outputs(ctx, tr.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		return fmt.Errorf("querying outputs of task run %d failed: %w", tr.ID, err)
+	}
+
+	log, err := s.TaskRunLog(ctx, tr.ID)
+	if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		return fmt.Errorf("querying log of task run %d failed: %w", tr.ID, err)
+	}
+
+	rec := newTaskRun(tr, inputs, outputs)
+	rec.Log = log
+
+	err = enc.Encode(&record{
+		Type:    recordTypeTaskRun,
+		TaskRun: rec,
+	})
+	if err != nil {
+		return fmt.Errorf("writing task run %d failed: %w", tr.ID, err)
+	}
+
+	return nil
+}
+
+func exportReleases(ctx context.Context, s storage.Storer, enc *json.Encoder) (int, error) {
+	var releases []*storage.Release
+
+	err := s.Releases(ctx, nil, func(r *storage.Release) error {
+		releases = append(releases, r)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("querying releases failed: %w", err)
+	}
+
+	for _, r := range releases {
+		taskRuns, err := s.ReleaseTaskRuns(ctx, r.Name)
+		if err != nil {
+			return 0, fmt.Errorf("querying task runs of release %q failed: %w", r.Name, err)
+		}
+
+		metadata, err := s.ReleaseMetadata(ctx, r.Name)
+		if err != nil {
+			return 0, fmt.Errorf("querying metadata of release %q failed: %w", r.Name, err)
+		}
+
+		// ReleaseTaskRuns returns a record per upload, a task run can
+		// be contained multiple times
+		ids := make([]int, 0, len(taskRuns))
+		for _, tr := range taskRuns {
+			ids = append(ids, tr.RunID)
+		}
+		slices.Sort(ids)
+		ids = slices.Compact(ids)
+
+		err = enc.Encode(&record{
+			Type: recordTypeRelease,
+			Release: &release{
+				Name:        r.Name,
+				BaseRelease: r.BaseRelease,
+				CreatedAt:   r.CreatedAt,
+				TaskRunIDs:  ids,
+				Metadata:    metadata,
+			},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("writing release %q failed: %w", r.Name, err)
+		}
+	}
+
+	return len(releases), nil
+}
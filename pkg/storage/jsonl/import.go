@@ -0,0 +1,256 @@
+package jsonl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// timestampPrecision is the precision in that timestamps are compared when
+// checking if a task run already exists.
+// It is the lowest precision of the supported storage implementations.
+const timestampPrecision = time.Microsecond
+
+// ImportResult contains the number of imported and skipped records.
+// Records are skipped when they already exist in the storage.
+type ImportResult struct {
+	TaskRunsImported int
+	TaskRunsSkipped  int
+	ReleasesImported int
+	ReleasesSkipped  int
+}
+
+// Import reads records in the format written by [Export] from r and stores
+// them in s.
+// The import is idempotent. Task runs that already exist in the storage
+// with the same application name, task name, total input digest, result and
+// timestamps, are not stored again. Releases that exist with the same name
+// are skipped.
+func Import(ctx context.Context, s storage.Storer, r io.Reader) (*ImportResult, error) {
+	var result ImportResult
+	var recordNr int
+
+	// maps the task run IDs from the export to the IDs in s
+	taskRunIDs := map[int]int{}
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	for {
+		var rec record
+
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		recordNr++
+
+		if err != nil {
+			return &result, fmt.Errorf("record %d: parsing failed: %w", recordNr, err)
+		}
+
+		if recordNr == 1 {
+			if err := validateHeader(&rec); err != nil {
+				return &result, fmt.Errorf("record %d: %w", recordNr, err)
+			}
+			continue
+		}
+
+		switch rec.Type {
+		case recordTypeTaskRun:
+			if rec.TaskRun == nil {
+				return &result, fmt.Errorf("record %d: %s field is missing", recordNr, recordTypeTaskRun)
+			}
+
+			id, imported, err := importTaskRun(ctx, s, rec.TaskRun)
+			if err != nil {
+				return &result, fmt.Errorf("record %d: importing task run %d failed: %w", recordNr, rec.TaskRun.ID, err)
+			}
+
+			taskRunIDs[rec.TaskRun.ID] = id
+			if imported {
+				result.TaskRunsImported++
+			} else {
+				result.TaskRunsSkipped++
+			}
+
+		case recordTypeRelease:
+			if rec.Release == nil {
+				return &result, fmt.Errorf("record %d: %s field is missing", recordNr, recordTypeRelease)
+			}
+
+			imported, err := importRelease(ctx, s, rec.Release, taskRunIDs)
+			if err != nil {
+				return &result, fmt.Errorf("record %d: importing release %q failed: %w", recordNr, rec.Release.Name, err)
+			}
+
+			if imported {
+				result.ReleasesImported++
+			} else {
+				result.ReleasesSkipped++
+			}
+
+		case recordTypeHeader:
+			return &result, fmt.Errorf("record %d: unexpected header record, header is only allowed as first record", recordNr)
+
+		default:
+			return &result, fmt.Errorf("record %d: unsupported record type %q", recordNr, rec.Type)
+		}
+	}
+
+	if recordNr == 0 {
+		return &result, errors.New("input is empty, header record is missing")
+	}
+
+	return &result, nil
+}
+
+func validateHeader(rec *record) error {
+	if rec.Type != recordTypeHeader || rec.Header == nil {
+		return fmt.Errorf("expected a %s record, got %q", recordTypeHeader, rec.Type)
+	}
+
+	if rec.Header.FormatVersion < 1 || rec.Header.FormatVersion > FormatVersion {
+		return fmt.Errorf("unsupported format version %d, supported versions: 1-%d",
+			rec.Header.FormatVersion, FormatVersion,
+		)
+	}
+
+	return nil
+}
+
+// importTaskRun stores tr in s if it does not exist.
+// It returns the ID of the task run in s and true if it was stored.
+func importTaskRun(ctx context.Context, s storage.Storer, tr *taskRun) (int, bool, error) {
+	id, err := findTaskRun(ctx, s, tr)
+	if err == nil {
+		return id, false, nil
+	}
+
+	if !errors.Is(err, storage.ErrNotExist) {
+		return -1, false, err
+	}
+
+	id, err = s.SaveTaskRun(ctx, tr.toStorage())
+	if err != nil {
+		return -1, false, err
+	}
+
+	return id, true, nil
+}
+
+func timestampsEqual(a, b time.Time) bool {
+	return a.Truncate(timestampPrecision).Equal(b.Truncate(timestampPrecision))
+}
+
+// findTaskRun returns the ID of a task run in s that matches tr.
+// If none exist, storage.ErrNotExist is returned.
+func findTaskRun(ctx context.Context, s storage.Storer, tr *taskRun) (int, error) {
+	var id int
+
+	filters := []*storage.Filter{
+		{
+			Field:    storage.FieldApplicationName,
+			Operator: storage.OpEQ,
+			Value:    tr.ApplicationName,
+		},
+		{
+			Field:    storage.FieldTaskName,
+			Operator: storage.OpEQ,
+			Value:    tr.TaskName,
+		},
+		{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpGT,
+			Value:    tr.StartTimestamp.Add(-timestampPrecision),
+		},
+		{
+			Field:    storage.FieldStartTime,
+			Operator: storage.OpLT,
+			Value:    tr.StartTimestamp.Add(timestampPrecision),
+		},
+	}
+
+	errFound := errors.New("found")
+
+	err := s.TaskRuns(ctx, filters, nil, storage.NoLimit, func(candidate *storage.TaskRunWithID) error {
+		if candidate.TotalInputDigest == tr.TotalInputDigest &&
+			string(candidate.Result) == tr.Result &&
+			timestampsEqual(candidate.StartTimestamp, tr.StartTimestamp) &&
+			timestampsEqual(candidate.StopTimestamp, tr.StopTimestamp) {
+			id = candidate.ID
+			return errFound
+		}
+
+		return nil
+	})
+	if errors.Is(err, errFound) {
+		return id, nil
+	}
+
+	if err != nil {
+		return -1, err
+	}
+
+	return -1, storage.ErrNotExist
+}
+
+// importRelease creates rel in s if no release with the same name exists.
+// It returns true if the release was created.
+func importRelease(ctx context.Context, s storage.Storer, rel *release, taskRunIDs map[int]int) (bool, error) {
+	var metadata io.Reader
+
+	exists, err := s.ReleaseExists(ctx, rel.Name)
+	if err != nil {
+		return false, err
+	}
+
+	if exists {
+		return false, nil
+	}
+
+	ids := make([]int, 0, len(rel.TaskRunIDs))
+	for _, exportID := range rel.TaskRunIDs {
+		id, ok := taskRunIDs[exportID]
+		if !ok {
+			return false, fmt.Errorf("task run %d is referenced but was not part of the import", exportID)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if rel.Metadata != nil {
+		metadata = bytes.NewReader(rel.Metadata)
+	}
+
+	// releases are exported ordered by their creation time, the base
+	// release is imported before the releases derived from it
+	baseRelease := rel.BaseRelease
+	if baseRelease != "" {
+		exists, err := s.ReleaseExists(ctx, baseRelease)
+		if err != nil {
+			return false, err
+		}
+
+		if !exists {
+			baseRelease = ""
+		}
+	}
+
+	err = s.CreateRelease(ctx, rel.Name, baseRelease, rel.CreatedAt, ids, metadata)
+	if err != nil {
+		if errors.Is(err, storage.ErrExists) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
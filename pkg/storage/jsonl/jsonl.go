@@ -0,0 +1,228 @@
+// Package jsonl exports and imports the records of a baur storage in a
+// portable JSON Lines format.
+//
+// Every line of the format is a JSON object with a "type" field that
+// specifies which other field of the object contains the record.
+// The first line is a header record that contains the version of the format,
+// followed by all task runs and afterwards all releases.
+// Releases reference task runs by the IDs they had in the exported storage.
+package jsonl
+
+import (
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// FormatVersion is the version of the format that is written by [Export].
+// [Import] supports reading all versions <= FormatVersion.
+//
+// Versions:
+//   - 1: initial format
+//   - 2: task runs contain labels
+//   - 3: task runs contain the log of the task command
+//   - 4: releases contain the name of their base release
+//   - 5: task runs contain command output inputs
+const FormatVersion = 5
+
+const (
+	recordTypeHeader  = "header"
+	recordTypeTaskRun = "task_run"
+	recordTypeRelease = "release"
+)
+
+type record struct {
+	Type    string   `json:"type"`
+	Header  *header  `json:"header,omitempty"`
+	TaskRun *taskRun `json:"task_run,omitempty"`
+	Release *release `json:"release,omitempty"`
+}
+
+type header struct {
+	FormatVersion int       `json:"format_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type inputFile struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+}
+
+type inputString struct {
+	String string `json:"string"`
+	Digest string `json:"digest"`
+}
+
+type inputEnvVar struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+type inputTaskInfo struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
+}
+
+type inputCommandOutput struct {
+	Command string `json:"command"`
+	Digest  string `json:"digest"`
+}
+
+type inputs struct {
+	Files                []*inputFile          `json:"files,omitempty"`
+	Strings              []*inputString        `json:"strings,omitempty"`
+	EnvironmentVariables []*inputEnvVar        `json:"environment_variables,omitempty"`
+	TaskInfos            []*inputTaskInfo      `json:"task_infos,omitempty"`
+	CommandOutputs       []*inputCommandOutput `json:"command_outputs,omitempty"`
+}
+
+type upload struct {
+	URI            string    `json:"uri"`
+	Method         string    `json:"method"`
+	StartTimestamp time.Time `json:"start_timestamp"`
+	StopTimestamp  time.Time `json:"stop_timestamp"`
+}
+
+type output struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Digest    string    `json:"digest"`
+	SizeBytes uint64    `json:"size_bytes"`
+	Uploads   []*upload `json:"uploads"`
+}
+
+type taskRun struct {
+	ID               int               `json:"id"`
+	ApplicationName  string            `json:"application_name"`
+	TaskName         string            `json:"task_name"`
+	VCSRevision      string            `json:"vcs_revision"`
+	VCSIsDirty       bool              `json:"vcs_is_dirty"`
+	StartTimestamp   time.Time         `json:"start_timestamp"`
+	StopTimestamp    time.Time         `json:"stop_timestamp"`
+	TotalInputDigest string            `json:"total_input_digest"`
+	Result           string            `json:"result"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	Inputs           inputs            `json:"inputs"`
+	Outputs          []*output         `json:"outputs,omitempty"`
+	// Log is the gzip compressed log of the task run, it is encoded as
+	// base64 string.
+	Log []byte `json:"log,omitempty"`
+}
+
+type release struct {
+	Name        string    `json:"name"`
+	BaseRelease string    `json:"base_release,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	TaskRunIDs  []int     `json:"task_run_ids"`
+	Metadata    []byte    `json:"metadata,omitempty"`
+}
+
+func newTaskRun(tr *storage.TaskRunWithID, in *storage.Inputs, outputs []*storage.Output) *taskRun {
+	result := taskRun{
+		ID:               tr.ID,
+		ApplicationName:  tr.ApplicationName,
+		TaskName:         tr.TaskName,
+		VCSRevision:      tr.VCSRevision,
+		VCSIsDirty:       tr.VCSIsDirty,
+		StartTimestamp:   tr.StartTimestamp,
+		StopTimestamp:    tr.StopTimestamp,
+		TotalInputDigest: tr.TotalInputDigest,
+		Result:           string(tr.Result),
+		Labels:           tr.Labels,
+	}
+
+	if in != nil {
+		for _, f := range in.Files {
+			result.Inputs.Files = append(result.Inputs.Files, &inputFile{Path: f.Path, Digest: f.Digest})
+		}
+		for _, s := range in.Strings {
+			result.Inputs.Strings = append(result.Inputs.Strings, &inputString{String: s.String, Digest: s.Digest})
+		}
+		for _, e := range in.EnvironmentVariables {
+			result.Inputs.EnvironmentVariables = append(result.Inputs.EnvironmentVariables, &inputEnvVar{Name: e.Name, Digest: e.Digest})
+		}
+		for _, ti := range in.TaskInfo {
+			result.Inputs.TaskInfos = append(result.Inputs.TaskInfos, &inputTaskInfo{Name: ti.Name, Digest: ti.Digest})
+		}
+		for _, co := range in.CommandOutputs {
+			result.Inputs.CommandOutputs = append(result.Inputs.CommandOutputs, &inputCommandOutput{Command: co.Command, Digest: co.Digest})
+		}
+	}
+
+	for _, o := range outputs {
+		out := output{
+			Name:      o.Name,
+			Type:      string(o.Type),
+			Digest:    o.Digest,
+			SizeBytes: o.SizeBytes,
+		}
+
+		for _, u := range o.Uploads {
+			out.Uploads = append(out.Uploads, &upload{
+				URI:            u.URI,
+				Method:         string(u.Method),
+				StartTimestamp: u.UploadStartTimestamp,
+				StopTimestamp:  u.UploadStopTimestamp,
+			})
+		}
+
+		result.Outputs = append(result.Outputs, &out)
+	}
+
+	return &result
+}
+
+func (tr *taskRun) toStorage() *storage.TaskRunFull {
+	result := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  tr.ApplicationName,
+			TaskName:         tr.TaskName,
+			VCSRevision:      tr.VCSRevision,
+			VCSIsDirty:       tr.VCSIsDirty,
+			StartTimestamp:   tr.StartTimestamp,
+			StopTimestamp:    tr.StopTimestamp,
+			TotalInputDigest: tr.TotalInputDigest,
+			Result:           storage.Result(tr.Result),
+			Labels:           tr.Labels,
+		},
+		Log: tr.Log,
+	}
+
+	for _, f := range tr.Inputs.Files {
+		result.Inputs.Files = append(result.Inputs.Files, &storage.InputFile{Path: f.Path, Digest: f.Digest})
+	}
+	for _, s := range tr.Inputs.Strings {
+		result.Inputs.Strings = append(result.Inputs.Strings, &storage.InputString{String: s.String, Digest: s.Digest})
+	}
+	for _, e := range tr.Inputs.EnvironmentVariables {
+		result.Inputs.EnvironmentVariables = append(result.Inputs.EnvironmentVariables, &storage.InputEnvVar{Name: e.Name, Digest: e.Digest})
+	}
+	for _, ti := range tr.Inputs.TaskInfos {
+		result.Inputs.TaskInfo = append(result.Inputs.TaskInfo, &storage.InputTaskInfo{Name: ti.Name, Digest: ti.Digest})
+	}
+	for _, co := range tr.Inputs.CommandOutputs {
+		result.Inputs.CommandOutputs = append(result.Inputs.CommandOutputs, &storage.InputCommandOutput{Command: co.Command, Digest: co.Digest})
+	}
+
+	for _, o := range tr.Outputs {
+		out := storage.Output{
+			Name:      o.Name,
+			Type:      storage.ArtifactType(o.Type),
+			Digest:    o.Digest,
+			SizeBytes: o.SizeBytes,
+		}
+
+		for _, u := range o.Uploads {
+			out.Uploads = append(out.Uploads, &storage.Upload{
+				URI:                  u.URI,
+				Method:               storage.UploadMethod(u.Method),
+				UploadStartTimestamp: u.StartTimestamp,
+				UploadStopTimestamp:  u.StopTimestamp,
+			})
+		}
+
+		result.Outputs = append(result.Outputs, &out)
+	}
+
+	return &result
+}
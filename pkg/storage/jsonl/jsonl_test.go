@@ -0,0 +1,294 @@
+package jsonl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/memory"
+)
+
+var ctx = context.Background()
+
+var baseTime = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newStore(t *testing.T) *memory.Store {
+	t.Helper()
+
+	s := memory.New()
+	require.NoError(t, s.Init(ctx))
+
+	return s
+}
+
+func newStorageTaskRun(app, digest string, start time.Time) *storage.TaskRunFull {
+	return &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  app,
+			TaskName:         "build",
+			VCSRevision:      "123",
+			VCSIsDirty:       true,
+			StartTimestamp:   start,
+			StopTimestamp:    start.Add(time.Minute),
+			TotalInputDigest: digest,
+			Result:           storage.ResultSuccess,
+			Labels:           map[string]string{"ci": "true", "branch": "main"},
+		},
+		Inputs: storage.Inputs{
+			Files: []*storage.InputFile{{Path: "main.go", Digest: "sha384:1"}},
+			Strings: []*storage.InputString{
+				{String: "hello", Digest: "sha384:2"},
+			},
+			EnvironmentVariables: []*storage.InputEnvVar{
+				{Name: "GOOS", Digest: "sha384:3"},
+			},
+			TaskInfo: []*storage.InputTaskInfo{
+				{Name: "app.check", Digest: "sha384:4"},
+			},
+			CommandOutputs: []*storage.InputCommandOutput{
+				{Command: "go version", Digest: "sha384:5"},
+			},
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "sha384:" + digest,
+				SizeBytes: 1024,
+				Uploads: []*storage.Upload{
+					{
+						URI:                  "s3://bucket/" + app + "-" + digest,
+						Method:               storage.UploadMethodS3,
+						UploadStartTimestamp: start.Add(time.Minute),
+						UploadStopTimestamp:  start.Add(2 * time.Minute),
+					},
+				},
+			},
+		},
+	}
+}
+
+func populate(t *testing.T, s storage.Storer) (int, int) {
+	t.Helper()
+
+	tr1 := newStorageTaskRun("app1", "1", baseTime)
+	tr1.Log = []byte("compressed log")
+	id1, err := s.SaveTaskRun(ctx, tr1)
+	require.NoError(t, err)
+
+	tr := newStorageTaskRun("app2", "2", baseTime.Add(time.Hour))
+	tr.Outputs = nil
+	id2, err := s.SaveTaskRun(ctx, tr)
+	require.NoError(t, err)
+
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime.Add(2*time.Hour), []int{id1, id2}, strings.NewReader("meta")))
+	require.NoError(t, s.CreateRelease(ctx, "v2", "v1", baseTime.Add(3*time.Hour), []int{id2}, nil))
+
+	return id1, id2
+}
+
+func exportTo(t *testing.T, s storage.Storer) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	_, err := Export(ctx, s, &buf)
+	require.NoError(t, err)
+
+	return &buf
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	src := newStore(t)
+	srcID1, srcID2 := populate(t, src)
+
+	var buf bytes.Buffer
+	exportRes, err := Export(ctx, src, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, &ExportResult{TaskRuns: 2, Releases: 2}, exportRes)
+
+	dst := newStore(t)
+	// ensure that IDs in the destination differ from the exported ones
+	_, err = dst.SaveTaskRun(ctx, newStorageTaskRun("other", "3", baseTime))
+	require.NoError(t, err)
+
+	importRes, err := Import(ctx, dst, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, &ImportResult{TaskRunsImported: 2, ReleasesImported: 2}, importRes)
+
+	for _, srcID := range []int{srcID1, srcID2} {
+		srcTr, err := src.TaskRun(ctx, srcID)
+		require.NoError(t, err)
+
+		var dstTr *storage.TaskRunWithID
+		err = dst.TaskRuns(ctx,
+			[]*storage.Filter{{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: srcTr.ApplicationName}},
+			nil,
+			storage.NoLimit,
+			func(tr *storage.TaskRunWithID) error {
+				dstTr = tr
+				return nil
+			},
+		)
+		require.NoError(t, err)
+		require.NotEqual(t, srcID, dstTr.ID)
+		assert.Equal(t, srcTr.TaskRun, dstTr.TaskRun)
+
+		srcInputs, err := src.Inputs(ctx, srcID)
+		require.NoError(t, err)
+		dstInputs, err := dst.Inputs(ctx, dstTr.ID)
+		require.NoError(t, err)
+		assert.Equal(t, srcInputs, dstInputs)
+
+		srcOutputs, err := src.Outputs(ctx, srcID)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrNotExist)
+		}
+		dstOutputs, err := dst.Outputs(ctx, dstTr.ID)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrNotExist)
+		}
+		assert.Equal(t, srcOutputs, dstOutputs)
+
+		srcLog, err := src.TaskRunLog(ctx, srcID)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrNotExist)
+		}
+		dstLog, err := dst.TaskRunLog(ctx, dstTr.ID)
+		if err != nil {
+			require.ErrorIs(t, err, storage.ErrNotExist)
+		}
+		assert.Equal(t, srcLog, dstLog)
+	}
+
+	metadata, err := dst.ReleaseMetadata(ctx, "v1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("meta"), metadata)
+
+	rtr, err := dst.ReleaseTaskRuns(ctx, "v1")
+	require.NoError(t, err)
+	assert.Len(t, rtr, 2)
+
+	rtr, err = dst.ReleaseTaskRuns(ctx, "v2")
+	require.NoError(t, err)
+	require.Len(t, rtr, 1)
+	assert.Equal(t, "app2", rtr[0].AppName)
+
+	var releases []*storage.Release
+	err = dst.Releases(ctx, nil, func(r *storage.Release) error {
+		releases = append(releases, r)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t,
+		[]*storage.Release{
+			{Name: "v1", CreatedAt: baseTime.Add(2 * time.Hour)},
+			{Name: "v2", CreatedAt: baseTime.Add(3 * time.Hour), BaseRelease: "v1"},
+		},
+		releases,
+	)
+}
+
+func TestImportIsIdempotent(t *testing.T) {
+	src := newStore(t)
+	populate(t, src)
+	exported := exportTo(t, src).Bytes()
+
+	dst := newStore(t)
+
+	_, err := Import(ctx, dst, bytes.NewReader(exported))
+	require.NoError(t, err)
+
+	res, err := Import(ctx, dst, bytes.NewReader(exported))
+	require.NoError(t, err)
+	assert.Equal(t, &ImportResult{TaskRunsSkipped: 2, ReleasesSkipped: 2}, res)
+
+	var cnt int
+	err = dst.TaskRuns(ctx, nil, nil, storage.NoLimit, func(*storage.TaskRunWithID) error {
+		cnt++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, cnt)
+}
+
+func TestExportEmptyStorage(t *testing.T) {
+	s := newStore(t)
+
+	var buf bytes.Buffer
+	res, err := Export(ctx, s, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, &ExportResult{}, res)
+
+	importRes, err := Import(ctx, newStore(t), &buf)
+	require.NoError(t, err)
+	assert.Equal(t, &ImportResult{}, importRes)
+}
+
+func TestExportMultipleBatches(t *testing.T) {
+	const taskRunCnt = 2*exportBatchSize + 1
+
+	src := newStore(t)
+	for i := range taskRunCnt {
+		_, err := src.SaveTaskRun(ctx, newStorageTaskRun("app", fmt.Sprint(i), baseTime))
+		require.NoError(t, err)
+	}
+
+	var buf bytes.Buffer
+	res, err := Export(ctx, src, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, &ExportResult{TaskRuns: taskRunCnt}, res)
+
+	importRes, err := Import(ctx, newStore(t), &buf)
+	require.NoError(t, err)
+	assert.Equal(t, &ImportResult{TaskRunsImported: taskRunCnt}, importRes)
+}
+
+func TestImportInvalidInput(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "empty",
+			input: "",
+		},
+		{
+			name:  "missingHeader",
+			input: `{"type":"release","release":{"name":"v1","task_run_ids":[1]}}`,
+		},
+		{
+			name:  "unsupportedVersion",
+			input: `{"type":"header","header":{"format_version":999}}`,
+		},
+		{
+			name: "unknownRecordType",
+			input: `{"type":"header","header":{"format_version":1}}
+{"type":"something"}`,
+		},
+		{
+			name: "releaseReferencesUnknownTaskRun",
+			input: `{"type":"header","header":{"format_version":1}}
+{"type":"release","release":{"name":"v1","task_run_ids":[1]}}`,
+		},
+		{
+			name: "invalidJSON",
+			input: `{"type":"header","header":{"format_version":1}}
+{"type":`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Import(ctx, newStore(t), strings.NewReader(tc.input))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+type taskKey struct {
+	appName  string
+	taskName string
+}
+
+type vcsKey struct {
+	revision string
+	dirty    bool
+}
+
+// records contains the keys of the records that a set of task runs
+// reference.
+// The keys correspond to the unique constraints of the database tables of
+// the other storage implementations, this allows to report the same delete
+// counts.
+type records struct {
+	tasks   map[taskKey]struct{}
+	apps    map[string]struct{}
+	outputs map[outputKey]struct{}
+	uploads int64
+	// uploadURIs contains the destinations of the uploads, independent
+	// of the upload method
+	uploadURIs map[string]struct{}
+	uploadDsts map[storage.UnreferencedUpload]struct{}
+	// imageDigests contains the digests of the uploaded docker images
+	imageDigests map[string]struct{}
+	// uploadImageDigests contains the digests of the docker images
+	// that were uploaded to a destination
+	uploadImageDigests map[storage.UnreferencedUpload][]string
+	inputs             map[any]struct{}
+	vcs                map[vcsKey]struct{}
+}
+
+type (
+	inputFileKey   storage.InputFile
+	inputStringKey string
+	inputEnvVarKey string
+	inputTaskKey   storage.InputTaskInfo
+	inputCmdKey    storage.InputCommandOutput
+)
+
+func newRecords() *records {
+	return &records{
+		tasks:      map[taskKey]struct{}{},
+		apps:       map[string]struct{}{},
+		outputs:    map[outputKey]struct{}{},
+		uploadURIs: map[string]struct{}{},
+		uploadDsts: map[storage.UnreferencedUpload]struct{}{},
+
+		imageDigests:       map[string]struct{}{},
+		uploadImageDigests: map[storage.UnreferencedUpload][]string{},
+		inputs:             map[any]struct{}{},
+		vcs:                map[vcsKey]struct{}{},
+	}
+}
+
+func (r *records) add(tr *storage.TaskRunFull) {
+	r.tasks[taskKey{appName: tr.ApplicationName, taskName: tr.TaskName}] = struct{}{}
+	r.apps[tr.ApplicationName] = struct{}{}
+	r.vcs[vcsKey{revision: tr.VCSRevision, dirty: tr.VCSIsDirty}] = struct{}{}
+
+	for _, o := range tr.Outputs {
+		r.outputs[outputKey{name: o.Name, artType: o.Type, digest: o.Digest, sizeBytes: o.SizeBytes}] = struct{}{}
+		r.uploads += int64(len(o.Uploads))
+
+		for _, u := range o.Uploads {
+			dst := storage.UnreferencedUpload{URI: u.URI, Method: u.Method}
+			r.uploadURIs[u.URI] = struct{}{}
+			r.uploadDsts[dst] = struct{}{}
+
+			if o.Type == storage.ArtifactTypeDocker {
+				r.imageDigests[o.Digest] = struct{}{}
+				r.uploadImageDigests[dst] = append(r.uploadImageDigests[dst], o.Digest)
+			}
+		}
+	}
+
+	for _, in := range tr.Inputs.Files {
+		r.inputs[inputFileKey(*in)] = struct{}{}
+	}
+	for _, in := range tr.Inputs.Strings {
+		r.inputs[inputStringKey(in.Digest)] = struct{}{}
+	}
+	for _, in := range tr.Inputs.EnvironmentVariables {
+		r.inputs[inputEnvVarKey(in.Digest)] = struct{}{}
+	}
+	for _, in := range tr.Inputs.TaskInfo {
+		r.inputs[inputTaskKey(*in)] = struct{}{}
+	}
+	for _, in := range tr.Inputs.CommandOutputs {
+		r.inputs[inputCmdKey(*in)] = struct{}{}
+	}
+}
+
+func (s *Store) ReleasesDelete(_ context.Context, before time.Time, pretend bool) (*storage.ReleasesDeleteResult, error) {
+	var result storage.ReleasesDeleteResult
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, rel := range s.releases {
+		if !rel.createdAt.Before(before) {
+			continue
+		}
+
+		result.DeletedReleases++
+
+		if !pretend {
+			delete(s.releases, name)
+		}
+	}
+
+	return &result, nil
+}
+
+func (s *Store) TaskRunsDelete(_ context.Context, before time.Time, retention storage.TaskRunsRetention, pretend bool) (*storage.TaskRunsDeleteResult, error) {
+	var result storage.TaskRunsDeleteResult
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	released := map[int]struct{}{}
+	for _, rel := range s.releases {
+		for _, id := range rel.taskRunIDs {
+			released[id] = struct{}{}
+		}
+	}
+
+	retained := s.retainedTaskRuns(&retention)
+
+	all := newRecords()
+	remaining := newRecords()
+	var deleteIDs []int
+
+	for id, tr := range s.taskRuns {
+		all.add(tr)
+
+		_, isReleased := released[id]
+		_, isRetained := retained[id]
+		if isReleased || isRetained || !tr.StartTimestamp.Before(before) {
+			remaining.add(tr)
+			continue
+		}
+
+		deleteIDs = append(deleteIDs, id)
+	}
+
+	result.DeletedTaskRuns = int64(len(deleteIDs))
+	result.DeletedTasks = int64(len(all.tasks) - len(remaining.tasks))
+	result.DeletedApps = int64(len(all.apps) - len(remaining.apps))
+	result.DeletedOutputs = int64(len(all.outputs) - len(remaining.outputs))
+	result.DeletedUploads = all.uploads - remaining.uploads
+	result.DeletedInputs = int64(len(all.inputs) - len(remaining.inputs))
+	result.DeletedVCS = int64(len(all.vcs) - len(remaining.vcs))
+	result.UnreferencedUploads = unreferencedUploads(all, remaining)
+
+	if pretend {
+		return &result, nil
+	}
+
+	for _, id := range deleteIDs {
+		delete(s.taskRuns, id)
+	}
+
+	for key := range s.outputIDs {
+		if _, exists := remaining.outputs[key]; !exists {
+			delete(s.outputIDs, key)
+		}
+	}
+
+	return &result, nil
+}
+
+// unreferencedUploads returns the upload destinations of all whose URIs are
+// not part of remaining.
+// Destinations of docker images are omitted when remaining contains an image
+// with the same digest, deleting the image manifest would also delete the
+// other tags that refer to it.
+func unreferencedUploads(all, remaining *records) []*storage.UnreferencedUpload {
+	var result []*storage.UnreferencedUpload
+
+	for dst := range all.uploadDsts {
+		if _, exists := remaining.uploadURIs[dst.URI]; exists {
+			continue
+		}
+
+		if containsAny(remaining.imageDigests, all.uploadImageDigests[dst]) {
+			continue
+		}
+
+		result = append(result, &dst)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].URI != result[j].URI {
+			return result[i].URI < result[j].URI
+		}
+
+		return result[i].Method < result[j].Method
+	})
+
+	return result
+}
+
+func containsAny(set map[string]struct{}, elems []string) bool {
+	for _, e := range elems {
+		if _, exists := set[e]; exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retainedTaskRuns returns the IDs of the task runs that are kept because of
+// retention.
+// The caller must hold s.mu.
+func (s *Store) retainedTaskRuns(retention *storage.TaskRunsRetention) map[int]struct{} {
+	type digestKey struct {
+		task   taskKey
+		digest string
+	}
+
+	result := map[int]struct{}{}
+
+	ids := make([]int, 0, len(s.taskRuns))
+	for id := range s.taskRuns {
+		ids = append(ids, id)
+	}
+
+	// newest first
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := s.taskRuns[ids[i]], s.taskRuns[ids[j]]
+		if !a.StartTimestamp.Equal(b.StartTimestamp) {
+			return a.StartTimestamp.After(b.StartTimestamp)
+		}
+
+		return ids[i] > ids[j]
+	})
+
+	perTask := map[taskKey]uint{}
+	successfulPerTask := map[taskKey]uint{}
+	perDigest := map[digestKey]uint{}
+
+	for _, id := range ids {
+		tr := s.taskRuns[id]
+		task := taskKey{appName: tr.ApplicationName, taskName: tr.TaskName}
+		digest := digestKey{task: task, digest: tr.TotalInputDigest}
+
+		perTask[task]++
+		perDigest[digest]++
+
+		if perTask[task] <= retention.KeepLast || perDigest[digest] <= retention.KeepLastPerInputDigest {
+			result[id] = struct{}{}
+		}
+
+		if tr.Result == storage.ResultSuccess {
+			successfulPerTask[task]++
+
+			if retention.KeepLastSuccessful && successfulPerTask[task] == 1 {
+				result[id] = struct{}{}
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,298 @@
+package memory
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// sortValue returns the value of the field f of tr.
+// Only fields that have a single value per task run are supported.
+func sortValue(tr *storage.TaskRunWithID, f storage.Field) (any, error) {
+	switch f {
+	case storage.FieldApplicationName:
+		return tr.ApplicationName, nil
+	case storage.FieldTaskName:
+		return tr.TaskName, nil
+	case storage.FieldDuration:
+		return tr.StopTimestamp.Sub(tr.StartTimestamp), nil
+	case storage.FieldStartTime:
+		return tr.StartTimestamp, nil
+	case storage.FieldID:
+		return tr.ID, nil
+	case storage.FieldResult:
+		return tr.Result, nil
+	case storage.FieldVCSRevision:
+		return tr.VCSRevision, nil
+	case storage.FieldVCSIsDirty:
+		return tr.VCSIsDirty, nil
+	case storage.FieldTotalInputDigest:
+		return tr.TotalInputDigest, nil
+
+	default:
+		return nil, fmt.Errorf("sorting by storage field %s is not supported", f)
+	}
+}
+
+// fieldValues returns the values of the field f of tr.
+// Input, output and label fields can have multiple values.
+func fieldValues(id int, tr *storage.TaskRunFull, f storage.Field) ([]any, error) {
+	switch f {
+	case storage.FieldInputString:
+		result := make([]any, 0, len(tr.Inputs.Strings))
+		for _, in := range tr.Inputs.Strings {
+			result = append(result, in.String)
+		}
+		return result, nil
+
+	case storage.FieldInputFilePath:
+		result := make([]any, 0, len(tr.Inputs.Files))
+		for _, in := range tr.Inputs.Files {
+			result = append(result, in.Path)
+		}
+		return result, nil
+
+	case storage.FieldOutputDigest:
+		result := make([]any, 0, len(tr.Outputs))
+		for _, out := range tr.Outputs {
+			result = append(result, out.Digest)
+		}
+		return result, nil
+
+	case storage.FieldOutputURI:
+		var result []any
+		for _, out := range tr.Outputs {
+			for _, upload := range out.Uploads {
+				result = append(result, upload.URI)
+			}
+		}
+		return result, nil
+
+	case storage.FieldLabel:
+		result := make([]any, 0, len(tr.Labels))
+		for k, v := range tr.Labels {
+			result = append(result, storage.LabelString(k, v))
+		}
+		return result, nil
+
+	default:
+		v, err := sortValue(&storage.TaskRunWithID{ID: id, TaskRun: tr.TaskRun}, f)
+		if err != nil {
+			return nil, fmt.Errorf("filtering by storage field %s is not supported", f)
+		}
+
+		return []any{v}, nil
+	}
+}
+
+// normalize converts v to a type that can be compared via [compare].
+// Integer types are converted to int64 and types with an underlying string
+// type to string.
+func normalize(v any) (any, error) {
+	if t, ok := v.(time.Time); ok {
+		return t, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() { //nolint:exhaustive // other kinds are unsupported
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil //nolint:gosec // values exceeding int64 are not used in filters
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// compare returns -1 if a < b, 0 if a == b and 1 if a > b.
+// An error is returned if a and b are of different or unsupported types.
+func compare(a, b any) (int, error) {
+	na, err := normalize(a)
+	if err != nil {
+		return 0, err
+	}
+
+	nb, err := normalize(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch va := na.(type) {
+	case time.Time:
+		if vb, ok := nb.(time.Time); ok {
+			return va.Compare(vb), nil
+		}
+	case int64:
+		if vb, ok := nb.(int64); ok {
+			switch {
+			case va < vb:
+				return -1, nil
+			case va > vb:
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	case string:
+		if vb, ok := nb.(string); ok {
+			return strings.Compare(va, vb), nil
+		}
+	case bool:
+		if vb, ok := nb.(bool); ok {
+			switch {
+			case va == vb:
+				return 0, nil
+			case !va:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("can not compare value of type %T with %T", a, b)
+}
+
+// matchesOp returns true if the comparison of v and the filter value via
+// op is true.
+func matchesOp(v any, op storage.Op, filterVal any) (bool, error) {
+	switch op {
+	case storage.OpEQ, storage.OpGT, storage.OpLT:
+		cmp, err := compare(v, filterVal)
+		if err != nil {
+			return false, err
+		}
+
+		switch op { //nolint:exhaustive // only the ops of the outer case are possible
+		case storage.OpEQ:
+			return cmp == 0, nil
+		case storage.OpGT:
+			return cmp > 0, nil
+		default:
+			return cmp < 0, nil
+		}
+
+	case storage.OpPrefix:
+		prefix, ok := filterVal.(string)
+		if !ok {
+			return false, fmt.Errorf("value of %s operator must be a string, got %T", op, filterVal)
+		}
+
+		s, err := normalize(v)
+		if err != nil {
+			return false, err
+		}
+
+		str, ok := s.(string)
+		if !ok {
+			return false, fmt.Errorf("%s operator can not be applied to values of type %T", op, v)
+		}
+
+		return strings.HasPrefix(str, prefix), nil
+
+	case storage.OpIN:
+		rv := reflect.ValueOf(filterVal)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return false, fmt.Errorf("value of %s operator must be a slice, got %T", op, filterVal)
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			cmp, err := compare(v, rv.Index(i).Interface())
+			if err != nil {
+				return false, err
+			}
+
+			if cmp == 0 {
+				return true, nil
+			}
+		}
+
+		return false, nil
+
+	default:
+		return false, fmt.Errorf("storage operator %s is not supported", op)
+	}
+}
+
+// matchesFilters returns true if tr matches all filters.
+func matchesFilters(id int, tr *storage.TaskRunFull, filters []*storage.Filter) (bool, error) {
+	for _, f := range filters {
+		values, err := fieldValues(id, tr, f.Field)
+		if err != nil {
+			return false, err
+		}
+
+		if (f.Operator == storage.OpNE || f.Operator == storage.OpNotIN) &&
+			(f.Field == storage.FieldInputString || f.Field == storage.FieldInputFilePath) {
+			return false, fmt.Errorf("storage operator %s is not supported for field %s", f.Operator, f.Field)
+		}
+
+		match, err := matchesFilter(f, values)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// releaseMatchesFilters returns true if rel matches all filters.
+func releaseMatchesFilters(rel *release, filters []*storage.Filter) (bool, error) {
+	for _, f := range filters {
+		var value any
+
+		switch f.Field { //nolint:exhaustive // other fields are task run fields
+		case storage.FieldReleaseName:
+			value = rel.name
+		case storage.FieldReleaseCreatedAt:
+			value = rel.createdAt
+		default:
+			return false, fmt.Errorf("filtering releases by storage field %s is not supported", f.Field)
+		}
+
+		match, err := matchesFilter(f, []any{value})
+		if err != nil || !match {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchesFilter returns true if values match the filter f.
+// Filters on fields with multiple values match if one of the values matches.
+// Negated operators match if none of the values equals the filter value.
+func matchesFilter(f *storage.Filter, values []any) (bool, error) {
+	op := f.Operator
+	negate := false
+
+	switch op { //nolint:exhaustive // other operators are not negated
+	case storage.OpNE:
+		op, negate = storage.OpEQ, true
+	case storage.OpNotIN:
+		op, negate = storage.OpIN, true
+	}
+
+	var match bool
+	for _, v := range values {
+		var err error
+
+		match, err = matchesOp(v, op, f.Value)
+		if err != nil {
+			return false, fmt.Errorf("evaluating filter on %s failed: %w", f.Field, err)
+		}
+
+		if match {
+			break
+		}
+	}
+
+	return match != negate, nil
+}
@@ -0,0 +1,120 @@
+// Package memory is a baur-storage implementation that keeps all data in
+// memory.
+// It is intended for tests and tools that embed baur and do not require that
+// the data is persisted.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// schemaVersion is the version that is reported as storage schema version.
+const schemaVersion int32 = 1
+
+type outputKey struct {
+	name      string
+	artType   storage.ArtifactType
+	digest    string
+	sizeBytes uint64
+}
+
+type release struct {
+	name       string
+	createdAt  time.Time
+	taskRunIDs []int
+	metadata   []byte
+	// base is the release the release was derived from, it is nil if it
+	// was not derived from another release or the base was deleted.
+	base *release
+}
+
+// Store is a thread-safe in-memory storage.
+// The zero value is not usable, instances must be created via [New].
+type Store struct {
+	mu sync.RWMutex
+
+	initialized bool
+
+	lastTaskRunID int
+	taskRuns      map[int]*storage.TaskRunFull
+
+	// outputIDs contains a unique ID per output, it is used as
+	// [storage.ReleaseTaskRunsResult.OutputID].
+	outputIDs    map[outputKey]int
+	lastOutputID int
+
+	releases map[string]*release
+}
+
+// New returns a new empty in-memory storage.
+// Like other storage implementations, [Store.Init] must be called before
+// [Store.IsCompatible] succeeds.
+func New() *Store {
+	return &Store{
+		taskRuns:  map[int]*storage.TaskRunFull{},
+		outputIDs: map[outputKey]int{},
+		releases:  map[string]*release{},
+	}
+}
+
+// Close is a no-op, it exists to satisfy the [storage.Storer] interface.
+func (*Store) Close() error {
+	return nil
+}
+
+func (s *Store) SchemaVersion(context.Context) (int32, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return -1, storage.ErrNotExist
+	}
+
+	return schemaVersion, nil
+}
+
+func (*Store) MaxSchemaVersion() int32 {
+	return schemaVersion
+}
+
+// IsCompatible returns storage.ErrNotExist if [Store.Init] has not been
+// called, otherwise nil.
+func (s *Store) IsCompatible(context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.initialized {
+		return storage.ErrNotExist
+	}
+
+	return nil
+}
+
+// Upgrade initializes the storage if it was not initialized before.
+func (s *Store) Upgrade(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.initialized = true
+
+	return nil
+}
+
+// Init initializes the storage. If it was already initialized,
+// storage.ErrExists is returned.
+func (s *Store) Init(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.initialized {
+		return storage.ErrExists
+	}
+
+	s.initialized = true
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/storagetest"
+)
+
+func TestStorer(t *testing.T) {
+	storagetest.Run(t, func() storage.Storer {
+		return New()
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	require.NoError(t, s.Init(ctx))
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			id, err := s.SaveTaskRun(ctx, &storage.TaskRunFull{
+				TaskRun: storage.TaskRun{
+					ApplicationName:  "app",
+					TaskName:         "build",
+					StartTimestamp:   time.Now(),
+					StopTimestamp:    time.Now(),
+					Result:           storage.ResultSuccess,
+					TotalInputDigest: fmt.Sprint(i),
+				},
+			})
+			assert.NoError(t, err)
+
+			err = s.TaskRuns(ctx, nil, nil, storage.NoLimit, func(tr *storage.TaskRunWithID) error {
+				// calling other methods from the callback must
+				// not deadlock
+				_, err := s.TaskRun(ctx, tr.ID)
+				return err
+			})
+			assert.NoError(t, err)
+
+			assert.NoError(t, s.CreateRelease(ctx, fmt.Sprint(i), "", time.Now(), []int{id}, nil))
+		}()
+	}
+
+	wg.Wait()
+
+	var cnt int
+	err := s.TaskRuns(ctx, nil, nil, storage.NoLimit, func(*storage.TaskRunWithID) error {
+		cnt++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 10, cnt)
+}
+
+func TestSaveTaskRunCopiesData(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	tr := storage.TaskRunFull{
+		TaskRun: storage.TaskRun{ApplicationName: "app", TaskName: "build"},
+		Inputs: storage.Inputs{
+			Files: []*storage.InputFile{{Path: "main.go", Digest: "1"}},
+		},
+	}
+
+	id, err := s.SaveTaskRun(ctx, &tr)
+	require.NoError(t, err)
+
+	tr.Inputs.Files[0].Digest = "2"
+
+	inputs, err := s.Inputs(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "1", inputs.Files[0].Digest)
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func (s *Store) CreateRelease(_ context.Context, releaseName, baseReleaseName string, createdAt time.Time, taskRunIDs []int, metadata io.Reader) error {
+	var data []byte
+
+	if len(taskRunIDs) == 0 {
+		return errors.New("no task run IDs were specified")
+	}
+
+	if metadata != nil {
+		var err error
+
+		data, err = io.ReadAll(metadata)
+		if err != nil {
+			return fmt.Errorf("reading metadata failed: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.releases[releaseName]; exists {
+		return storage.ErrExists
+	}
+
+	var base *release
+	if baseReleaseName != "" {
+		var exists bool
+
+		base, exists = s.releases[baseReleaseName]
+		if !exists {
+			return storage.ErrNotExist
+		}
+	}
+
+	ids := make([]int, 0, len(taskRunIDs))
+	seen := make(map[int]struct{}, len(taskRunIDs))
+	for _, id := range taskRunIDs {
+		if _, exists := s.taskRuns[id]; !exists {
+			return fmt.Errorf("task run with id %d does not exist", id)
+		}
+
+		if _, exists := seen[id]; exists {
+			continue
+		}
+
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	s.releases[releaseName] = &release{
+		name:       releaseName,
+		createdAt:  createdAt,
+		taskRunIDs: ids,
+		metadata:   data,
+		base:       base,
+	}
+
+	return nil
+}
+
+func (s *Store) ReleaseExists(_ context.Context, name string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.releases[name]
+
+	return exists, nil
+}
+
+func (s *Store) ReleaseTaskRuns(_ context.Context, releaseName string) ([]*storage.ReleaseTaskRunsResult, error) {
+	var result []*storage.ReleaseTaskRunsResult
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rel, exists := s.releases[releaseName]
+	if !exists {
+		return nil, storage.ErrNotExist
+	}
+
+	for _, id := range rel.taskRunIDs {
+		tr := s.taskRuns[id]
+
+		if len(tr.Outputs) == 0 {
+			result = append(result, &storage.ReleaseTaskRunsResult{
+				AppName:          tr.ApplicationName,
+				TaskName:         tr.TaskName,
+				RunID:            id,
+				TotalInputDigest: tr.TotalInputDigest,
+			})
+			continue
+		}
+
+		for _, o := range tr.Outputs {
+			outputID := s.outputIDs[outputKey{name: o.Name, artType: o.Type, digest: o.Digest, sizeBytes: o.SizeBytes}]
+
+			for _, u := range o.Uploads {
+				result = append(result, &storage.ReleaseTaskRunsResult{
+					AppName:          tr.ApplicationName,
+					TaskName:         tr.TaskName,
+					RunID:            id,
+					TotalInputDigest: tr.TotalInputDigest,
+					OutputID:         outputID,
+					OutputName:       o.Name,
+					OutputType:       o.Type,
+					OutputDigest:     o.Digest,
+					OutputSizeBytes:  o.SizeBytes,
+					URI:              u.URI,
+					UploadMethod:     u.Method,
+				})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+
+		if a.AppName != b.AppName {
+			return a.AppName < b.AppName
+		}
+
+		if a.TaskName != b.TaskName {
+			return a.TaskName < b.TaskName
+		}
+
+		if a.OutputName != b.OutputName {
+			return a.OutputName < b.OutputName
+		}
+
+		return a.URI < b.URI
+	})
+
+	return result, nil
+}
+
+func (s *Store) ReleaseMetadata(_ context.Context, releaseName string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rel, exists := s.releases[releaseName]
+	if !exists {
+		return nil, storage.ErrNotExist
+	}
+
+	if rel.metadata == nil {
+		return nil, nil
+	}
+
+	result := make([]byte, len(rel.metadata))
+	copy(result, rel.metadata)
+
+	return result, nil
+}
+
+// Releases passes the releases that match filters to cb.
+// The callback function is called after the internal lock was released,
+// it can call other methods of the Store.
+func (s *Store) Releases(_ context.Context, filters []*storage.Filter, cb func(*storage.Release) error) error {
+	s.mu.RLock()
+	result := make([]*storage.Release, 0, len(s.releases))
+	for _, rel := range s.releases {
+		match, err := releaseMatchesFilters(rel, filters)
+		if err != nil {
+			s.mu.RUnlock()
+			return err
+		}
+
+		if match {
+			r := storage.Release{Name: rel.name, CreatedAt: rel.createdAt}
+			if rel.base != nil {
+				r.BaseRelease = rel.base.name
+			}
+			result = append(result, &r)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(result) == 0 {
+		return storage.ErrNotExist
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+
+		return result[i].Name < result[j].Name
+	})
+
+	for _, rel := range result {
+		if err := cb(rel); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) ReleaseDelete(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rel, exists := s.releases[name]
+	if !exists {
+		return storage.ErrNotExist
+	}
+
+	delete(s.releases, name)
+
+	for _, r := range s.releases {
+		if r.base == rel {
+			r.base = nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,326 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func copyInputs(in *storage.Inputs) storage.Inputs {
+	var result storage.Inputs
+
+	for _, f := range in.Files {
+		c := *f
+		result.Files = append(result.Files, &c)
+	}
+
+	for _, str := range in.Strings {
+		c := *str
+		result.Strings = append(result.Strings, &c)
+	}
+
+	for _, e := range in.EnvironmentVariables {
+		c := *e
+		result.EnvironmentVariables = append(result.EnvironmentVariables, &c)
+	}
+
+	for _, ti := range in.TaskInfo {
+		c := *ti
+		result.TaskInfo = append(result.TaskInfo, &c)
+	}
+
+	for _, co := range in.CommandOutputs {
+		c := *co
+		result.CommandOutputs = append(result.CommandOutputs, &c)
+	}
+
+	return result
+}
+
+func copyOutputs(outputs []*storage.Output) []*storage.Output {
+	if outputs == nil {
+		return nil
+	}
+
+	result := make([]*storage.Output, 0, len(outputs))
+	for _, o := range outputs {
+		c := *o
+		c.Uploads = make([]*storage.Upload, 0, len(o.Uploads))
+
+		for _, u := range o.Uploads {
+			uc := *u
+			c.Uploads = append(c.Uploads, &uc)
+		}
+
+		result = append(result, &c)
+	}
+
+	return result
+}
+
+// withID returns a copy of tr with the given ID.
+func withID(id int, tr *storage.TaskRunFull) *storage.TaskRunWithID {
+	result := storage.TaskRunWithID{ID: id, TaskRun: tr.TaskRun}
+	result.Labels = maps.Clone(tr.Labels)
+
+	return &result
+}
+
+func (s *Store) SaveTaskRun(_ context.Context, taskRun *storage.TaskRunFull) (int, error) {
+	tr := storage.TaskRunFull{
+		TaskRun: taskRun.TaskRun,
+		Inputs:  copyInputs(&taskRun.Inputs),
+		Outputs: copyOutputs(taskRun.Outputs),
+		Log:     bytes.Clone(taskRun.Log),
+	}
+	if len(tr.Labels) == 0 {
+		tr.Labels = nil
+	} else {
+		tr.Labels = maps.Clone(tr.Labels)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, o := range tr.Outputs {
+		key := outputKey{name: o.Name, artType: o.Type, digest: o.Digest, sizeBytes: o.SizeBytes}
+		if _, exists := s.outputIDs[key]; !exists {
+			s.lastOutputID++
+			s.outputIDs[key] = s.lastOutputID
+		}
+	}
+
+	s.lastTaskRunID++
+	s.taskRuns[s.lastTaskRunID] = &tr
+
+	return s.lastTaskRunID, nil
+}
+
+func (s *Store) LatestTaskRunByDigest(_ context.Context, appName, taskName, totalInputDigest string) (*storage.TaskRunWithID, error) {
+	var result *storage.TaskRunWithID
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, tr := range s.taskRuns {
+		if tr.ApplicationName != appName ||
+			tr.TaskName != taskName ||
+			tr.TotalInputDigest != totalInputDigest {
+			continue
+		}
+
+		if result == nil ||
+			tr.StopTimestamp.After(result.StopTimestamp) ||
+			(tr.StopTimestamp.Equal(result.StopTimestamp) && id > result.ID) {
+			result = withID(id, tr)
+		}
+	}
+
+	if result == nil {
+		return nil, storage.ErrNotExist
+	}
+
+	return result, nil
+}
+
+func (s *Store) LatestTaskRunsByDigests(_ context.Context, digests []storage.AppTaskDigest) (map[storage.AppTaskDigest]*storage.TaskRunWithID, error) {
+	result := make(map[storage.AppTaskDigest]*storage.TaskRunWithID, len(digests))
+
+	wanted := make(map[storage.AppTaskDigest]struct{}, len(digests))
+	for _, d := range digests {
+		wanted[d] = struct{}{}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, tr := range s.taskRuns {
+		key := storage.AppTaskDigest{
+			AppName:          tr.ApplicationName,
+			TaskName:         tr.TaskName,
+			TotalInputDigest: tr.TotalInputDigest,
+		}
+		if _, exists := wanted[key]; !exists {
+			continue
+		}
+
+		latest, exists := result[key]
+		if !exists ||
+			tr.StopTimestamp.After(latest.StopTimestamp) ||
+			(tr.StopTimestamp.Equal(latest.StopTimestamp) && id > latest.ID) {
+			result[key] = withID(id, tr)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Store) TaskRun(_ context.Context, id int) (*storage.TaskRunWithID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tr, exists := s.taskRuns[id]
+	if !exists {
+		return nil, storage.ErrNotExist
+	}
+
+	return withID(id, tr), nil
+}
+
+// TaskRuns queries the storage for runs that match the filters.
+// The callback function is called after the internal lock was released,
+// it can call other methods of the Store.
+func (s *Store) TaskRuns(
+	_ context.Context,
+	filters []*storage.Filter,
+	sorters []*storage.Sorter,
+	limit uint,
+	cb func(*storage.TaskRunWithID) error,
+) error {
+	result, err := s.queryTaskRuns(filters, sorters, limit)
+	if err != nil {
+		return err
+	}
+
+	if len(result) == 0 {
+		return storage.ErrNotExist
+	}
+
+	for _, tr := range result {
+		if err := cb(tr); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) queryTaskRuns(filters []*storage.Filter, sorters []*storage.Sorter, limit uint) ([]*storage.TaskRunWithID, error) {
+	var result []*storage.TaskRunWithID
+
+	containsInputStringFilter := false
+	containsInputFileFilter := false
+	for _, filter := range filters {
+		if filter.Field == storage.FieldInputString {
+			containsInputStringFilter = true
+		} else if filter.Field == storage.FieldInputFilePath {
+			containsInputFileFilter = true
+		}
+	}
+
+	if containsInputFileFilter && containsInputStringFilter {
+		return nil, errors.New("either a FieldInputString or FieldInputFilePath filter can be specified, not both")
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for id, tr := range s.taskRuns {
+		match, err := matchesFilters(id, tr, filters)
+		if err != nil {
+			return nil, err
+		}
+
+		if match {
+			result = append(result, withID(id, tr))
+		}
+	}
+
+	if err := sortTaskRuns(result, sorters); err != nil {
+		return nil, err
+	}
+
+	if limit != storage.NoLimit && uint(len(result)) > limit {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// sortTaskRuns sorts taskRuns by sorters. Runs that are equal in all sort
+// fields are ordered by their ID.
+func sortTaskRuns(taskRuns []*storage.TaskRunWithID, sorters []*storage.Sorter) error {
+	for _, sorter := range sorters {
+		if sorter.Order != storage.OrderAsc && sorter.Order != storage.OrderDesc {
+			return fmt.Errorf("unsupported sort order %s", sorter.Order)
+		}
+
+		if _, err := sortValue(&storage.TaskRunWithID{}, sorter.Field); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(taskRuns, func(i, j int) bool {
+		for _, sorter := range sorters {
+			// errors can not happen, the fields were validated before
+			a, _ := sortValue(taskRuns[i], sorter.Field)
+			b, _ := sortValue(taskRuns[j], sorter.Field)
+			cmp, _ := compare(a, b)
+
+			if cmp == 0 {
+				continue
+			}
+
+			if sorter.Order == storage.OrderDesc {
+				return cmp > 0
+			}
+
+			return cmp < 0
+		}
+
+		return taskRuns[i].ID < taskRuns[j].ID
+	})
+
+	return nil
+}
+
+func (s *Store) Inputs(_ context.Context, taskRunID int) (*storage.Inputs, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tr, exists := s.taskRuns[taskRunID]
+	if !exists {
+		return nil, storage.ErrNotExist
+	}
+
+	if len(tr.Inputs.Files) == 0 &&
+		len(tr.Inputs.Strings) == 0 &&
+		len(tr.Inputs.EnvironmentVariables) == 0 &&
+		len(tr.Inputs.TaskInfo) == 0 &&
+		len(tr.Inputs.CommandOutputs) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	result := copyInputs(&tr.Inputs)
+
+	return &result, nil
+}
+
+func (s *Store) Outputs(_ context.Context, taskRunID int) ([]*storage.Output, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tr, exists := s.taskRuns[taskRunID]
+	if !exists || len(tr.Outputs) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	return copyOutputs(tr.Outputs), nil
+}
+
+func (s *Store) TaskRunLog(_ context.Context, taskRunID int) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tr, exists := s.taskRuns[taskRunID]
+	if !exists || tr.Log == nil {
+		return nil, storage.ErrNotExist
+	}
+
+	return bytes.Clone(tr.Log), nil
+}
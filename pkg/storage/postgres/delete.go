@@ -34,6 +34,24 @@ func (c *Client) ReleasesDelete(ctx context.Context, before time.Time, pretend b
 	return &result, nil
 }
 
+func (c *Client) ReleaseDelete(ctx context.Context, name string) error {
+	const query = `
+	      DELETE FROM release
+	       WHERE name = $1
+	`
+
+	t, err := c.db.Exec(ctx, query, name)
+	if err != nil {
+		return newQueryError(query, err, name)
+	}
+
+	if t.RowsAffected() == 0 {
+		return storage.ErrNotExist
+	}
+
+	return nil
+}
+
 func (*Client) deleteOldReleases(ctx context.Context, tx pgx.Tx, before time.Time) (int64, error) {
 	const query = `
 	      DELETE FROM release
@@ -48,30 +66,55 @@ func (*Client) deleteOldReleases(ctx context.Context, tx pgx.Tx, before time.Tim
 	return t.RowsAffected(), nil
 }
 
-func (c *Client) TaskRunsDelete(ctx context.Context, before time.Time, pretend bool) (*storage.TaskRunsDeleteResult, error) {
+func (c *Client) TaskRunsDelete(ctx context.Context, before time.Time, retention storage.TaskRunsRetention, pretend bool) (*storage.TaskRunsDeleteResult, error) {
 	var result *storage.TaskRunsDeleteResult
 
-	if pretend {
-		err := c.db.BeginFunc(ctx, func(tx pgx.Tx) (err error) {
-			result, err = c.taskRunsDelete(ctx, before, tx)
+	err := c.db.BeginFunc(ctx, func(tx pgx.Tx) (err error) {
+		if pretend {
 			defer tx.Rollback(ctx) //nolint: errcheck
-			return err
-		})
-		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			return nil, err
 		}
 
-		return result, nil
+		result, err = c.taskRunsDelete(ctx, before, &retention, tx)
+		return err
+	})
+	if err != nil && !(pretend && errors.Is(err, pgx.ErrTxClosed)) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// lockTaskRuns prevents that task runs and their outputs are created or
+// deleted by other transactions until the transaction of con ends.
+// The old task runs and the uploads that are unreferenced after deleting them
+// are determined by separate statements, they must see the same task runs.
+func (*Client) lockTaskRuns(ctx context.Context, con dbConn) error {
+	const query = `LOCK TABLE task_run, task_run_output IN SHARE ROW EXCLUSIVE MODE`
+
+	if _, err := con.Exec(ctx, query); err != nil {
+		return newQueryError(query, err)
 	}
 
-	return c.taskRunsDelete(ctx, before, c.db)
+	return nil
 }
 
-func (c *Client) taskRunsDelete(ctx context.Context, before time.Time, con dbConn) (*storage.TaskRunsDeleteResult, error) {
+func (c *Client) taskRunsDelete(ctx context.Context, before time.Time, retention *storage.TaskRunsRetention, con dbConn) (*storage.TaskRunsDeleteResult, error) {
 	var err error
 	var result storage.TaskRunsDeleteResult
 
-	result.DeletedTaskRuns, err = c.deleteOldTaskRuns(ctx, con, before)
+	if err := c.lockTaskRuns(ctx, con); err != nil {
+		return nil, err
+	}
+
+	// the unreferenced uploads are determined before the task runs are
+	// deleted, the outputs of the deleted task runs are required to find
+	// uploads that share an image with the remaining ones
+	result.UnreferencedUploads, err = c.unreferencedUploads(ctx, con, before, retention)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DeletedTaskRuns, err = c.deleteOldTaskRuns(ctx, con, before, retention)
 	if err != nil {
 		return nil, err
 	}
@@ -105,19 +148,64 @@ func (c *Client) taskRunsDelete(ctx context.Context, before time.Time, con dbCon
 	return &result, err
 }
 
-func (*Client) deleteOldTaskRuns(ctx context.Context, con dbConn, before time.Time) (int64, error) {
-	const query = `
-	      DELETE FROM task_run
+// oldTaskRunsQuery selects the IDs of the task runs that are deleted by
+// [Client.TaskRunsDelete], its arguments are returned by oldTaskRunsArgs.
+// The task runs that are retained are determined by ranking the task
+// runs per task, per task and result and per task and input digest,
+// a rank of 1 is the newest run.
+const oldTaskRunsQuery = `
+	      SELECT id
+	        FROM task_run
 	       WHERE start_timestamp < $1
 	         AND NOT EXISTS (
 			SELECT 1 FROM release_task_run
 			 WHERE task_run.id = release_task_run.task_run_id
 	       )
-	`
+	         AND id NOT IN (
+			SELECT ranked.id
+			  FROM (
+				SELECT id, result,
+				       row_number() OVER (
+					PARTITION BY task_id
+					ORDER BY start_timestamp DESC, id DESC
+				       ) AS task_rank,
+				       row_number() OVER (
+					PARTITION BY task_id, result
+					ORDER BY start_timestamp DESC, id DESC
+				       ) AS result_rank,
+				       row_number() OVER (
+					PARTITION BY task_id, total_input_digest
+					ORDER BY start_timestamp DESC, id DESC
+				       ) AS digest_rank
+				  FROM task_run
+			  ) AS ranked
+			 WHERE ranked.task_rank <= $2
+			    OR (ranked.result = 'success' AND ranked.result_rank <= $3)
+			    OR ranked.digest_rank <= $4
+	       )
+`
+
+func oldTaskRunsArgs(before time.Time, retention *storage.TaskRunsRetention) []any {
+	var keepSuccessful int64
+	if retention.KeepLastSuccessful {
+		keepSuccessful = 1
+	}
+
+	return []any{
+		before,
+		int64(retention.KeepLast),
+		keepSuccessful,
+		int64(retention.KeepLastPerInputDigest),
+	}
+}
 
-	t, err := con.Exec(ctx, query, before)
+func (*Client) deleteOldTaskRuns(ctx context.Context, con dbConn, before time.Time, retention *storage.TaskRunsRetention) (int64, error) {
+	const query = `DELETE FROM task_run WHERE id IN (` + oldTaskRunsQuery + `)`
+	args := oldTaskRunsArgs(before, retention)
+
+	t, err := con.Exec(ctx, query, args...)
 	if err != nil {
-		return 0, newQueryError(query, err, before)
+		return 0, newQueryError(query, err, args...)
 	}
 
 	return t.RowsAffected(), nil
@@ -170,6 +258,71 @@ func (*Client) deleteUnusedOutputs(ctx context.Context, con dbConn) (int64, erro
 	return t.RowsAffected(), nil
 }
 
+// unreferencedUploads returns the uploads that are not referenced anymore
+// when the task runs selected by oldTaskRunsQuery are deleted.
+// Uploads whose URI is used by a remaining upload are omitted.
+// Uploads of docker images are also omitted when an image with the same
+// digest is referenced by a remaining upload, deleting the image manifest
+// would also delete the other tags that refer to it.
+func (*Client) unreferencedUploads(ctx context.Context, con dbConn, before time.Time, retention *storage.TaskRunsRetention) ([]*storage.UnreferencedUpload, error) {
+	const query = `
+		WITH remaining_task_run_output AS (
+			SELECT task_run_output.upload_id, task_run_output.output_id
+			  FROM task_run_output
+			 WHERE task_run_output.task_run_id NOT IN (` + oldTaskRunsQuery + `)
+		), remaining_upload AS (
+			SELECT upload.uri, output.type, output.digest
+			  FROM remaining_task_run_output
+			  JOIN upload ON upload.id = remaining_task_run_output.upload_id
+			  JOIN output ON output.id = remaining_task_run_output.output_id
+		)
+		SELECT DISTINCT upload.uri, upload.method
+		  FROM upload
+		 WHERE upload.id NOT IN (
+			 SELECT remaining_task_run_output.upload_id
+			   FROM remaining_task_run_output
+		 )
+		   AND upload.uri NOT IN (
+			 SELECT remaining_upload.uri
+			   FROM remaining_upload
+		 )
+		   AND NOT EXISTS (
+			 SELECT 1
+			   FROM task_run_output
+			   JOIN output ON output.id = task_run_output.output_id
+			   JOIN remaining_upload ON remaining_upload.digest = output.digest
+			  WHERE task_run_output.upload_id = upload.id
+			    AND output.type = 'docker'
+			    AND remaining_upload.type = 'docker'
+		 )
+		 ORDER BY upload.uri COLLATE "C", upload.method COLLATE "C"
+		`
+	var result []*storage.UnreferencedUpload
+
+	args := oldTaskRunsArgs(before, retention)
+	rows, err := con.Query(ctx, query, args...)
+	if err != nil {
+		return nil, newQueryError(query, err, args...)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var upload storage.UnreferencedUpload
+
+		if err := rows.Scan(&upload.URI, &upload.Method); err != nil {
+			return nil, newQueryError(query, err, args...)
+		}
+
+		result = append(result, &upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, args...)
+	}
+
+	return result, nil
+}
+
 func (*Client) deleteUnusedUploads(ctx context.Context, con dbConn) (int64, error) {
 	const query = `
 		DELETE FROM upload
@@ -243,5 +396,18 @@ func (*Client) deleteUnusedInputs(ctx context.Context, con dbConn) (int64, error
 	}
 	cnt += t.RowsAffected()
 
+	const qInputCommandOutputs = `
+		DELETE FROM input_command_output
+	 	 WHERE id NOT IN (
+			 SELECT task_run_command_output_input.input_command_output_id
+			   FROM task_run_command_output_input
+		 )
+		`
+	t, err = con.Exec(ctx, qInputCommandOutputs)
+	if err != nil {
+		return 0, newQueryError(qInputCommandOutputs, err)
+	}
+	cnt += t.RowsAffected()
+
 	return cnt, nil
 }
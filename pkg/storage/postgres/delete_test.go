@@ -81,11 +81,11 @@ func TestDelete(t *testing.T) {
 		assert.Equal(t, int64(2), result.DeletedUploads)
 	}
 
-	result, err := clt.TaskRunsDelete(ctx, startTime.Add(time.Minute), true)
+	result, err := clt.TaskRunsDelete(ctx, startTime.Add(time.Minute), storage.TaskRunsRetention{}, true)
 	require.NoError(t, err)
 	checkResultFn(result)
 
-	result, err = clt.TaskRunsDelete(ctx, startTime.Add(time.Minute), false)
+	result, err = clt.TaskRunsDelete(ctx, startTime.Add(time.Minute), storage.TaskRunsRetention{}, false)
 	require.NoError(t, err)
 	checkResultFn(result)
 
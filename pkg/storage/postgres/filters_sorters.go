@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/simplesurance/baur/v5/pkg/storage"
 )
@@ -30,26 +31,226 @@ func columnName(f storage.Field) (string, error) {
 		return "input_string_val", nil
 	case storage.FieldInputFilePath:
 		return "input_file_path", nil
+	case storage.FieldResult:
+		return "result", nil
+	case storage.FieldVCSRevision:
+		return "revision", nil
+	case storage.FieldVCSIsDirty:
+		return "dirty", nil
+	case storage.FieldTotalInputDigest:
+		return "total_input_digest", nil
+	case storage.FieldReleaseName:
+		return "name", nil
+	case storage.FieldReleaseCreatedAt:
+		return "created_at", nil
 
 	default:
 		return "", fmt.Errorf("no postgresql mapping for storage field %s exists", f)
 	}
 }
 
-func compileOp(a string, op storage.Op, b string) (string, error) {
+// multiValueSubquery returns the column and a subquery template for fields of
+// which a task run can have multiple values in other tables.
+// The template contains a %s verb for the condition on the column, the
+// subquery returns rows if the condition is true for one of the values.
+func multiValueSubquery(f storage.Field) (column, subquery string, ok bool) {
+	const outputSubquery = `
+		SELECT 1 FROM task_run_output
+		  JOIN output ON output.id = task_run_output.output_id
+		 WHERE task_run_output.task_run_id = tr.task_run_id AND %s`
+	const uploadSubquery = `
+		SELECT 1 FROM task_run_output
+		  JOIN upload ON upload.id = task_run_output.upload_id
+		 WHERE task_run_output.task_run_id = tr.task_run_id AND %s`
+
+	switch f { //nolint:exhaustive // other fields are columns of the task run query
+	case storage.FieldOutputDigest:
+		return "output.digest", outputSubquery, true
+	case storage.FieldOutputURI:
+		return "upload.uri", uploadSubquery, true
+	default:
+		return "", "", false
+	}
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// compileOp returns the SQL expression for comparing column a via op with
+// value.
+// argNr is the number of the query parameter that is used in the returned
+// expression. The argument of the expression is returned.
+func compileOp(a string, op storage.Op, value any, argNr int) (string, any, error) {
+	b := fmt.Sprintf("$%d", argNr)
+
 	switch op {
 	case storage.OpEQ:
-		return a + " = " + b, nil
+		return a + " = " + b, value, nil
+	case storage.OpNE:
+		return a + " <> " + b, value, nil
 	case storage.OpGT:
-		return a + " > " + b, nil
+		return a + " > " + b, value, nil
 	case storage.OpLT:
-		return a + " < " + b, nil
+		return a + " < " + b, value, nil
+	case storage.OpIN:
+		return fmt.Sprintf("%s = ANY (%s)", a, b), value, nil
+	case storage.OpNotIN:
+		return fmt.Sprintf("NOT (%s = ANY (%s))", a, b), value, nil
+	case storage.OpPrefix:
+		s, ok := value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("value of %s operator must be a string, got %T", op, value)
+		}
+
+		return fmt.Sprintf(`%s LIKE %s ESCAPE '\'`, a, b), escapeLike(s) + "%", nil
+
+	default:
+		return "", nil, fmt.Errorf("no postgresql mapping for storage operator %s exists", op)
+	}
+}
+
+// compileFilter returns the SQL expression for the filter f and its
+// arguments.
+func compileFilter(f *storage.Filter, argNr int) (string, []any, error) {
+	if f.Field == storage.FieldLabel {
+		return compileLabelFilter(f, argNr)
+	}
+
+	if column, subquery, ok := multiValueSubquery(f.Field); ok {
+		// negated operators match task runs that have no value that
+		// equals the filter value
+		switch f.Operator { //nolint:exhaustive // other operators are not negated
+		case storage.OpNE:
+			cond, arg, err := compileOp(column, storage.OpEQ, f.Value, argNr)
+			return "NOT EXISTS (" + fmt.Sprintf(subquery, cond) + ")", []any{arg}, err
+		case storage.OpNotIN:
+			cond, arg, err := compileOp(column, storage.OpIN, f.Value, argNr)
+			return "NOT EXISTS (" + fmt.Sprintf(subquery, cond) + ")", []any{arg}, err
+		default:
+			cond, arg, err := compileOp(column, f.Operator, f.Value, argNr)
+			return "EXISTS (" + fmt.Sprintf(subquery, cond) + ")", []any{arg}, err
+		}
+	}
+
+	column, err := columnName(f.Field)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if isInputField(f.Field) && (f.Operator == storage.OpNE || f.Operator == storage.OpNotIN) {
+		return "", nil, fmt.Errorf("storage operator %s is not supported for field %s", f.Operator, f.Field)
+	}
+
+	cond, arg, err := compileOp(column, f.Operator, f.Value, argNr)
+	return cond, []any{arg}, err
+}
+
+// compileLabelFilter returns the SQL expression for a filter on
+// [storage.FieldLabel] and its arguments.
+// The filter values are split into the key and value of the label, to be
+// able to use the index of the task_run_label table.
+func compileLabelFilter(f *storage.Filter, argNr int) (string, []any, error) {
+	const subquery = `
+		SELECT 1 FROM task_run_label
+		 WHERE task_run_label.task_run_id = tr.task_run_id AND %s`
+
+	// negated operators match task runs that have no label that
+	// equals the filter value
+	op := f.Operator
+	exists := "EXISTS"
+	switch op { //nolint:exhaustive // other operators are not negated
+	case storage.OpNE:
+		op = storage.OpEQ
+		exists = "NOT EXISTS"
+	case storage.OpNotIN:
+		op = storage.OpIN
+		exists = "NOT EXISTS"
+	}
+
+	cond, args, err := compileLabelOp(op, f.Value, argNr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return exists + " (" + fmt.Sprintf(subquery, cond) + ")", args, nil
+}
+
+// compileLabelOp returns the SQL expression for comparing the labels of
+// the task_run_label table via op with value.
+// argNr is the number of the first query parameter that is used in the
+// returned expression. The arguments of the expression are returned.
+func compileLabelOp(op storage.Op, value any, argNr int) (string, []any, error) {
+	switch op { //nolint:exhaustive // other operators are not supported
+	case storage.OpEQ:
+		key, val, err := splitLabelValue(value)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return fmt.Sprintf("task_run_label.key = $%d AND task_run_label.value = $%d", argNr, argNr+1),
+			[]any{key, val}, nil
 	case storage.OpIN:
-		return fmt.Sprintf("%s = ANY (%s)", a, b), nil
+		labels, ok := value.([]string)
+		if !ok {
+			return "", nil, fmt.Errorf("value of %s operator must be a []string, got %T", op, value)
+		}
+
+		keys := make([]string, 0, len(labels))
+		vals := make([]string, 0, len(labels))
+		for _, l := range labels {
+			key, val, err := splitLabelValue(l)
+			if err != nil {
+				return "", nil, err
+			}
+
+			keys = append(keys, key)
+			vals = append(vals, val)
+		}
+
+		return fmt.Sprintf(
+				"(task_run_label.key, task_run_label.value) IN (SELECT * FROM unnest($%d::text[], $%d::text[]))",
+				argNr, argNr+1,
+			),
+			[]any{keys, vals}, nil
+	case storage.OpPrefix:
+		s, ok := value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("value of %s operator must be a string, got %T", op, value)
+		}
 
+		key, valPrefix, found := storage.SplitLabelString(s)
+		if !found {
+			return fmt.Sprintf(`task_run_label.key LIKE $%d ESCAPE '\'`, argNr),
+				[]any{escapeLike(s) + "%"}, nil
+		}
+
+		return fmt.Sprintf(`task_run_label.key = $%d AND task_run_label.value LIKE $%d ESCAPE '\'`, argNr, argNr+1),
+			[]any{key, escapeLike(valPrefix) + "%"}, nil
 	default:
-		return "", fmt.Errorf("no postgresql mapping for storage operator %s exists", op)
+		return "", nil, fmt.Errorf("storage operator %s is not supported for field %s", op, storage.FieldLabel)
+	}
+}
+
+// splitLabelValue splits a label filter value into the key and value of the
+// label.
+func splitLabelValue(v any) (key, value string, err error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", "", fmt.Errorf("label filter value must be a string, got %T", v)
 	}
+
+	key, value, found := storage.SplitLabelString(s)
+	if !found {
+		return "", "", fmt.Errorf("label filter value %q is not in the format KEY=VALUE", s)
+	}
+
+	return key, value, nil
+}
+
+func isInputField(f storage.Field) bool {
+	return f == storage.FieldInputString || f == storage.FieldInputFilePath
 }
 
 func compileSortOrder(o storage.Order, column string) (string, error) {
@@ -70,18 +271,13 @@ func (q *query) compileFilterStr() (filterStr string, args []any, err error) {
 	}
 
 	for i, f := range q.Filters {
-		column, err := columnName(f.Field)
-		if err != nil {
-			return "", nil, err
-		}
-
-		opStr, err := compileOp(column, f.Operator, fmt.Sprintf("$%d", i+1))
+		opStr, opArgs, err := compileFilter(f, len(args)+1)
 		if err != nil {
 			return "", nil, err
 		}
 
 		filterStr += opStr
-		args = append(args, f.Value)
+		args = append(args, opArgs...)
 
 		if i+1 < len(q.Filters) {
 			filterStr += " AND "
@@ -128,10 +324,6 @@ func (q *query) compileLimitStr() string {
 
 // Compile creates the SQL query string and returns it with the arguments for the query
 func (q *query) Compile() (query string, args []any, err error) {
-	if len(q.Filters) == 0 && len(q.Sorters) == 0 {
-		return q.BaseQuery, nil, nil
-	}
-
 	filterStr, args, err := q.compileFilterStr()
 	if err != nil {
 		return "", nil, err
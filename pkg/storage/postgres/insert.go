@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"slices"
 	"sort"
 	"strings"
@@ -219,6 +220,17 @@ func clonedSortedInputTasks(result []*storage.InputTaskInfo) []*storage.InputTas
 	return result
 }
 
+func clonedSortedInputCommandOutputs(result []*storage.InputCommandOutput) []*storage.InputCommandOutput {
+	result = slices.Clone(result)
+	slices.SortFunc(result, func(a, b *storage.InputCommandOutput) int {
+		if res := cmp.Compare(a.Command, b.Command); res != 0 {
+			return res
+		}
+		return cmp.Compare(a.Digest, b.Digest)
+	})
+	return result
+}
+
 func insertInputFilesIfNotExist(ctx context.Context, db dbConn, inputs []*storage.InputFile) ([]int, error) {
 	const stmt1 = `
            INSERT INTO input_file (path, digest)
@@ -452,6 +464,87 @@ func insertTaskRunInputTasksIfNotExist(ctx context.Context, db dbConn, taskRunID
 	return nil
 }
 
+func insertInputCommandOutputsIfNotExist(ctx context.Context, db dbConn, inputs []*storage.InputCommandOutput) ([]int, error) {
+	const stmt1 = `
+           INSERT INTO input_command_output (command, digest)
+	   VALUES
+`
+	const stmt2 = `
+	       ON CONFLICT ON CONSTRAINT input_command_output_command_digest_uniq
+	       DO UPDATE SET id=input_command_output.id
+	RETURNING id
+	`
+
+	// inputs are sorted to prevent an deadlock when running multiple
+	// transaction in parallel doing inserts, see
+	// https://github.com/simplesurance/baur/issues/343
+	inputs = clonedSortedInputCommandOutputs(inputs)
+
+	stmtVals := queryValueStr(len(inputs), 2)
+
+	queryArgs := make([]any, 0, len(inputs)*2)
+	for _, in := range inputs {
+		queryArgs = append(queryArgs, in.Command, in.Digest)
+	}
+
+	query := stmt1 + stmtVals + " " + stmt2
+
+	rows, err := db.Query(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, newQueryError(query, err, queryArgs...)
+	}
+
+	ids := make([]int, 0, len(inputs))
+	if err := scanIDs(rows, &ids); err != nil {
+		return nil, newQueryError(query, err, queryArgs...)
+	}
+
+	return ids, nil
+}
+
+func insertTaskRunInputCommandOutputsIfNotExist(ctx context.Context, db dbConn, taskRunID int, inputs []*storage.InputCommandOutput) error {
+	const stmt1 = `
+	INSERT INTO task_run_command_output_input (task_run_id, input_command_output_id)
+	VALUES
+	`
+
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	inputIDs, err := insertInputCommandOutputsIfNotExist(ctx, db, inputs)
+	if err != nil {
+		return err
+	}
+
+	var stmtVals strings.Builder
+	argNr := 2
+	for i := 0; i < len(inputIDs); i++ {
+		fmt.Fprintf(&stmtVals, "($1, $%d)", argNr)
+		argNr++
+
+		if i < len(inputIDs)-1 {
+			stmtVals.WriteString(", ")
+		}
+	}
+
+	queryArgs := make([]any, 1, len(inputIDs)+1)
+	queryArgs[0] = taskRunID
+
+	for _, inputID := range inputIDs {
+		queryArgs = append(queryArgs, inputID)
+	}
+
+	query := stmt1 + stmtVals.String()
+
+	_, err = db.Exec(ctx, query, queryArgs...)
+	if err != nil {
+		return newQueryError(query, err, queryArgs...)
+	}
+
+	return nil
+}
+
 func clonedSortedInputEnvVars(inputs []*storage.InputEnvVar) []*storage.InputEnvVar {
 	inputs = append([]*storage.InputEnvVar{}, inputs...)
 
@@ -661,6 +754,58 @@ func insertTaskOutputsIfNotExist(ctx context.Context, db dbConn, taskRunID int,
 	return nil
 }
 
+func insertTaskRunLabels(ctx context.Context, db dbConn, taskRunID int, labels map[string]string) error {
+	const stmt1 = "INSERT INTO task_run_label (task_run_id, key, value) VALUES"
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	keys := slices.Sorted(maps.Keys(labels))
+
+	var stmtVals strings.Builder
+	argNr := 2
+	for i := range keys {
+		fmt.Fprintf(&stmtVals, "($1, $%d, $%d)", argNr, argNr+1)
+		argNr += 2
+
+		if i < len(keys)-1 {
+			stmtVals.WriteString(", ")
+		}
+	}
+
+	queryArgs := make([]any, 1, len(keys)*2+1)
+	queryArgs[0] = taskRunID
+
+	for _, k := range keys {
+		queryArgs = append(queryArgs, k, labels[k])
+	}
+
+	query := stmt1 + stmtVals.String()
+
+	_, err := db.Exec(ctx, query, queryArgs...)
+	if err != nil {
+		return newQueryError(query, err, queryArgs...)
+	}
+
+	return nil
+}
+
+func insertTaskRunLog(ctx context.Context, db dbConn, taskRunID int, log []byte) error {
+	const query = "INSERT INTO task_run_log (task_run_id, log) VALUES($1, $2)"
+
+	if log == nil {
+		return nil
+	}
+
+	_, err := db.Exec(ctx, query, taskRunID, log)
+	if err != nil {
+		return newQueryError(query, err, taskRunID, fmt.Sprintf("<%d bytes>", len(log)))
+	}
+
+	return nil
+}
+
 func (c *Client) saveTaskRun(ctx context.Context, tx pgx.Tx, taskRun *storage.TaskRunFull) (int, error) {
 	const query = `
 		   INSERT INTO task_run (vcs_id, task_id, total_input_digest, start_timestamp, stop_timestamp, result)
@@ -718,11 +863,26 @@ func (c *Client) saveTaskRun(ctx context.Context, tx pgx.Tx, taskRun *storage.Ta
 		return -1, err
 	}
 
+	err = insertTaskRunInputCommandOutputsIfNotExist(ctx, tx, taskRunID, taskRun.Inputs.CommandOutputs)
+	if err != nil {
+		return -1, err
+	}
+
 	err = insertTaskOutputsIfNotExist(ctx, tx, taskRunID, taskRun.Outputs)
 	if err != nil {
 		return -1, err
 	}
 
+	err = insertTaskRunLabels(ctx, tx, taskRunID, taskRun.Labels)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskRunLog(ctx, tx, taskRunID, taskRun.Log)
+	if err != nil {
+		return -1, err
+	}
+
 	return taskRunID, nil
 }
 
@@ -735,9 +895,9 @@ func (c *Client) SaveTaskRun(ctx context.Context, taskRun *storage.TaskRunFull)
 	})
 }
 
-func (c *Client) CreateRelease(ctx context.Context, releaseName string, createdAt time.Time, taskRunIDs []int, metadata io.Reader) error {
+func (c *Client) CreateRelease(ctx context.Context, releaseName, baseReleaseName string, createdAt time.Time, taskRunIDs []int, metadata io.Reader) error {
 	return c.db.BeginFunc(ctx, func(tx pgx.Tx) error {
-		releaseID, err := c.insertRelease(ctx, tx, releaseName, createdAt, metadata)
+		releaseID, err := c.insertRelease(ctx, tx, releaseName, baseReleaseName, createdAt, metadata)
 		if err != nil {
 			return err
 		}
@@ -746,16 +906,24 @@ func (c *Client) CreateRelease(ctx context.Context, releaseName string, createdA
 	})
 }
 
-func (*Client) insertRelease(ctx context.Context, tx pgx.Tx, name string, createdAt time.Time, metadata io.Reader) (int, error) {
+func (*Client) insertRelease(ctx context.Context, tx pgx.Tx, name, baseName string, createdAt time.Time, metadata io.Reader) (int, error) {
 	const query = `
-		INSERT INTO release (name, created_at, metadata)
-	        VALUES($1, $2, $3)
+		INSERT INTO release (name, created_at, metadata, base_release_id)
+	        VALUES($1, $2, $3, $4)
 	     RETURNING id
 	`
 
 	var data []byte
 	var err error
 	var releaseID int
+	var baseReleaseID any
+
+	if baseName != "" {
+		baseReleaseID, err = releaseIDByName(ctx, tx, baseName)
+		if err != nil {
+			return -1, err
+		}
+	}
 
 	if metadata != nil {
 		data, err = io.ReadAll(metadata)
@@ -764,7 +932,7 @@ func (*Client) insertRelease(ctx context.Context, tx pgx.Tx, name string, create
 		}
 	}
 
-	err = tx.QueryRow(ctx, query, name, createdAt, data).Scan(&releaseID)
+	err = tx.QueryRow(ctx, query, name, createdAt, data, baseReleaseID).Scan(&releaseID)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -783,6 +951,28 @@ func (*Client) insertRelease(ctx context.Context, tx pgx.Tx, name string, create
 	return releaseID, nil
 }
 
+// releaseIDByName returns the ID of the release called name.
+// If it does not exist, [storage.ErrNotExist] is returned.
+func releaseIDByName(ctx context.Context, tx pgx.Tx, name string) (int, error) {
+	const query = `
+		SELECT id
+		  FROM release
+		 WHERE name = $1
+	`
+
+	var id int
+	err := tx.QueryRow(ctx, query, name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return -1, storage.ErrNotExist
+		}
+
+		return -1, newQueryError(query, err, name)
+	}
+
+	return id, nil
+}
+
 func (*Client) insertReleaseTaskRun(ctx context.Context, tx pgx.Tx, releaseID int, taskRunIDs []int) error {
 	const stmt1 = `
 		INSERT INTO release_task_run (release_id, task_run_id)
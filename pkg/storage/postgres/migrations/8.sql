@@ -0,0 +1 @@
+ALTER TABLE release ADD COLUMN base_release_id integer REFERENCES release (id) ON DELETE SET NULL;
@@ -50,7 +50,10 @@ func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, t
 	       task_run.total_input_digest,
 	       task_run.start_timestamp,
 	       task_run.stop_timestamp,
-	       task_run.result
+	       task_run.result,
+	       (SELECT json_object_agg(task_run_label.key, task_run_label.value)
+	          FROM task_run_label
+	         WHERE task_run_label.task_run_id = task_run.id)
 	  FROM application
 	  JOIN task ON application.id = task.application_id
 	  JOIN task_run ON task.id = task_run.task_id
@@ -76,6 +79,7 @@ func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, t
 		&result.StartTimestamp,
 		&result.StopTimestamp,
 		&result.Result,
+		&result.Labels,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -88,6 +92,88 @@ func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, t
 	return &result, nil
 }
 
+func (c *Client) LatestTaskRunsByDigests(ctx context.Context, digests []storage.AppTaskDigest) (map[storage.AppTaskDigest]*storage.TaskRunWithID, error) {
+	const query = `
+	SELECT DISTINCT ON (task_run.task_id, task_run.total_input_digest)
+	       task_run.id,
+	       application.name,
+	       task.name,
+	       vcs.revision,
+	       vcs.dirty,
+	       task_run.total_input_digest,
+	       task_run.start_timestamp,
+	       task_run.stop_timestamp,
+	       task_run.result,
+	       (SELECT json_object_agg(task_run_label.key, task_run_label.value)
+	          FROM task_run_label
+	         WHERE task_run_label.task_run_id = task_run.id)
+	  FROM unnest($1::text[], $2::text[], $3::text[]) AS wanted(app_name, task_name, total_input_digest)
+	  JOIN application ON application.name = wanted.app_name
+	  JOIN task ON application.id = task.application_id
+	   AND task.name = wanted.task_name
+	  JOIN task_run ON task.id = task_run.task_id
+	   AND task_run.total_input_digest = wanted.total_input_digest
+	  LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
+	 ORDER BY task_run.task_id,
+	          task_run.total_input_digest,
+	          task_run.stop_timestamp DESC,
+	          task_run.id DESC
+	 `
+
+	result := make(map[storage.AppTaskDigest]*storage.TaskRunWithID, len(digests))
+	if len(digests) == 0 {
+		return result, nil
+	}
+
+	appNames := make([]string, 0, len(digests))
+	taskNames := make([]string, 0, len(digests))
+	totalInputDigests := make([]string, 0, len(digests))
+	for _, d := range digests {
+		appNames = append(appNames, d.AppName)
+		taskNames = append(taskNames, d.TaskName)
+		totalInputDigests = append(totalInputDigests, d.TotalInputDigest)
+	}
+
+	rows, err := c.db.Query(ctx, query, appNames, taskNames, totalInputDigests)
+	if err != nil {
+		return nil, newQueryError(query, err, appNames, taskNames, totalInputDigests)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tr storage.TaskRunWithID
+
+		err := rows.Scan(
+			&tr.ID,
+			&tr.ApplicationName,
+			&tr.TaskName,
+			&tr.VCSRevision,
+			&tr.VCSIsDirty,
+			&tr.TotalInputDigest,
+			&tr.StartTimestamp,
+			&tr.StopTimestamp,
+			&tr.Result,
+			&tr.Labels,
+		)
+		if err != nil {
+			return nil, newQueryError(query, err, appNames, taskNames, totalInputDigests)
+		}
+
+		key := storage.AppTaskDigest{
+			AppName:          tr.ApplicationName,
+			TaskName:         tr.TaskName,
+			TotalInputDigest: tr.TotalInputDigest,
+		}
+		result[key] = &tr
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, appNames, taskNames, totalInputDigests)
+	}
+
+	return result, nil
+}
+
 func (c *Client) inputStrings(ctx context.Context, taskRunID int) ([]*storage.InputString, error) {
 	const query = `
 	SELECT input_string.string,
@@ -239,6 +325,43 @@ func (c *Client) inputTasks(ctx context.Context, taskRunID int) ([]*storage.Inpu
 	return result, nil
 }
 
+func (c *Client) inputCommandOutputs(ctx context.Context, taskRunID int) ([]*storage.InputCommandOutput, error) {
+	const query = `
+	SELECT input_command_output.command,
+	       input_command_output.digest
+	 FROM  input_command_output
+	 JOIN  task_run_command_output_input ON input_command_output.id = task_run_command_output_input.input_command_output_id
+        WHERE  task_run_command_output_input.task_run_id = $1`
+
+	var result []*storage.InputCommandOutput
+
+	rows, err := c.db.Query(ctx, query, taskRunID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotExist
+		}
+
+		return nil, fmt.Errorf("query %s with arg: %d failed: %w", query, taskRunID, err)
+	}
+
+	for rows.Next() {
+		var input storage.InputCommandOutput
+
+		if err := rows.Scan(&input.Command, &input.Digest); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("query %s with arg: %d failed: %w", query, taskRunID, err)
+		}
+
+		result = append(result, &input)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query %s with arg: %d failed: %w", query, taskRunID, err)
+	}
+
+	return result, nil
+}
+
 func (c *Client) Inputs(ctx context.Context, taskRunID int) (*storage.Inputs, error) {
 	var result storage.Inputs
 	var err error
@@ -263,7 +386,16 @@ func (c *Client) Inputs(ctx context.Context, taskRunID int) (*storage.Inputs, er
 		return nil, err
 	}
 
-	if len(result.Files) == 0 && len(result.Strings) == 0 && len(result.EnvironmentVariables) == 0 {
+	result.CommandOutputs, err = c.inputCommandOutputs(ctx, taskRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Files) == 0 &&
+		len(result.Strings) == 0 &&
+		len(result.EnvironmentVariables) == 0 &&
+		len(result.TaskInfo) == 0 &&
+		len(result.CommandOutputs) == 0 {
 		return nil, storage.ErrNotExist
 	}
 
@@ -340,6 +472,25 @@ func (c *Client) Outputs(ctx context.Context, taskRunID int) ([]*storage.Output,
 	return result, nil
 }
 
+func (c *Client) TaskRunLog(ctx context.Context, taskRunID int) ([]byte, error) {
+	const query = `
+	SELECT log
+	  FROM task_run_log
+	 WHERE task_run_id = $1
+	 `
+	var log []byte
+
+	err := c.db.QueryRow(ctx, query, taskRunID).Scan(&log)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotExist
+		}
+		return nil, newQueryError(query, err, taskRunID)
+	}
+
+	return log, nil
+}
+
 func (c *Client) TaskRuns(
 	ctx context.Context,
 	filters []*storage.Filter,
@@ -348,14 +499,17 @@ func (c *Client) TaskRuns(
 	cb func(*storage.TaskRunWithID) error,
 ) error {
 	const queryTemplate = `
-	SELECT task_run_id, application_name, task_name, revision, dirty, total_input_digest, start_timestamp, stop_timestamp, result
+	SELECT task_run_id, application_name, task_name, revision, dirty, total_input_digest, start_timestamp, stop_timestamp, result,
+	       (SELECT json_object_agg(task_run_label.key, task_run_label.value)
+	          FROM task_run_label
+	         WHERE task_run_label.task_run_id = tr.task_run_id) AS labels
 	  FROM (
 	       SELECT DISTINCT ON ({distinct_on})
 		      task_run.id AS task_run_id,
 	              application.name AS application_name,
 	              task.name AS task_name,
-	              vcs.revision,
-	              vcs.dirty,
+	              COALESCE(vcs.revision, '') AS revision,
+	              COALESCE(vcs.dirty, false) AS dirty,
 	              task_run.total_input_digest,
 	              task_run.start_timestamp AS start_timestamp,
 	              task_run.stop_timestamp,
@@ -441,6 +595,7 @@ func (c *Client) TaskRuns(
 			&taskRun.StartTimestamp,
 			&taskRun.StopTimestamp,
 			&taskRun.Result,
+			&taskRun.Labels,
 		)
 		if err != nil {
 			rows.Close()
@@ -489,8 +644,9 @@ func (c *Client) ReleaseTaskRuns(ctx context.Context, releaseName string) ([]*st
 		)
 		SELECT application.name,
 		       task.name,
-		       task_run.id,
-		       output.id, output.name,
+		       task_run.id, task_run.total_input_digest,
+		       output.id, output.name, output.type,
+		       output.digest, output.size_bytes,
 		       upload.uri, upload.method
 		FROM fr
 		JOIN release_task_run ON release_task_run.release_id = fr.id
@@ -513,6 +669,9 @@ func (c *Client) ReleaseTaskRuns(ctx context.Context, releaseName string) ([]*st
 		var r storage.ReleaseTaskRunsResult
 		var outputID sql.NullInt32
 		var outputName sql.NullString
+		var outputType sql.NullString
+		var outputDigest sql.NullString
+		var outputSizeBytes sql.NullInt64
 		var uri sql.NullString
 		var uploadMethod sql.NullString
 
@@ -520,8 +679,12 @@ func (c *Client) ReleaseTaskRuns(ctx context.Context, releaseName string) ([]*st
 			&r.AppName,
 			&r.TaskName,
 			&r.RunID,
+			&r.TotalInputDigest,
 			&outputID,
 			&outputName,
+			&outputType,
+			&outputDigest,
+			&outputSizeBytes,
 			&uri,
 			&uploadMethod,
 		)
@@ -534,6 +697,9 @@ func (c *Client) ReleaseTaskRuns(ctx context.Context, releaseName string) ([]*st
 
 		r.OutputID = int(outputID.Int32)
 		r.OutputName = outputName.String
+		r.OutputType = storage.ArtifactType(outputType.String)
+		r.OutputDigest = outputDigest.String
+		r.OutputSizeBytes = uint64(outputSizeBytes.Int64)
 		r.URI = uri.String
 		r.UploadMethod = storage.UploadMethod(uploadMethod.String)
 		result = append(result, &r)
@@ -568,3 +734,59 @@ func (c *Client) ReleaseMetadata(ctx context.Context, releaseName string) ([]byt
 
 	return metadata, nil
 }
+
+func (c *Client) Releases(ctx context.Context, filters []*storage.Filter, cb func(*storage.Release) error) error {
+	const queryStr = `
+	SELECT name, created_at,
+	       (SELECT base.name FROM release base WHERE base.id = release.base_release_id)
+	  FROM release
+	 `
+	var queryReturnedRows bool
+
+	q := query{
+		BaseQuery: queryStr,
+		Filters:   filters,
+		Sorters: []*storage.Sorter{
+			{Field: storage.FieldReleaseCreatedAt, Order: storage.OrderAsc},
+			{Field: storage.FieldReleaseName, Order: storage.OrderAsc},
+		},
+	}
+
+	query, args, err := q.Compile()
+	if err != nil {
+		return fmt.Errorf("compiling query string failed: %w", err)
+	}
+
+	rows, err := c.db.Query(ctx, query, args...)
+	if err != nil {
+		return newQueryError(query, err, args...)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var release storage.Release
+		var baseRelease sql.NullString
+
+		queryReturnedRows = true
+
+		if err := rows.Scan(&release.Name, &release.CreatedAt, &baseRelease); err != nil {
+			return newQueryError(query, err, args...)
+		}
+
+		release.BaseRelease = baseRelease.String
+
+		if err := cb(&release); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return newQueryError(query, err, args...)
+	}
+
+	if !queryReturnedRows {
+		return storage.ErrNotExist
+	}
+
+	return nil
+}
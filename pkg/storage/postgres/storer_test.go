@@ -0,0 +1,19 @@
+//go:build dbtest
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+	"github.com/simplesurance/baur/v5/pkg/storage/storagetest"
+)
+
+func TestStorer(t *testing.T) {
+	storagetest.Run(t, func() storage.Storer {
+		client, cleanupFn := newTestClient(t)
+		t.Cleanup(cleanupFn)
+
+		return client
+	})
+}
@@ -0,0 +1,146 @@
+// Package sqlite is a baur-storage implementation storing data in a SQLite
+// database file.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// URLScheme is the scheme of URLs that refer to SQLite databases,
+// e.g. sqlite:///var/lib/baur/baur.db.
+const URLScheme = "sqlite"
+
+// busyTimeout is how long an operation waits for a lock on the database
+// file, held by another connection or process, before it fails.
+const busyTimeout = 30 * time.Second
+
+// Client is a SQLite storage client
+type Client struct {
+	db *sql.DB
+}
+
+// Logger is an interface for logging debug informations
+type Logger interface {
+	Debugln(v ...any)
+}
+
+// IsURL returns true if strURL is a SQLite database URL.
+func IsURL(strURL string) bool {
+	return strings.HasPrefix(strURL, URLScheme+"://")
+}
+
+// dbPathFromURL returns the path of the database file from a URL in the
+// format sqlite://PATH.
+// Absolute paths are specified with 3 slashes (sqlite:///tmp/baur.db),
+// relative paths with 2 (sqlite://baur.db).
+func dbPathFromURL(strURL string) (string, error) {
+	u, err := url.Parse(strURL)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != URLScheme {
+		return "", fmt.Errorf("unsupported URL scheme %q, expecting %q", u.Scheme, URLScheme)
+	}
+
+	path := u.Host + u.Path
+	if path == "" {
+		return "", errors.New("URL does not contain a database file path")
+	}
+
+	return path, nil
+}
+
+// dsn returns the database/sql data source name to open the database file
+// at path.
+// Foreign key support is enabled, to make the "ON DELETE CASCADE" clauses
+// effective, and the journal is kept in WAL mode to allow reads while
+// another connection writes.
+// Transactions acquire the write lock when they are started, this prevents
+// that they fail when they are upgraded from a read to a write transaction
+// while another connection is writing.
+func dsn(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Set("_txlock", "immediate")
+
+	return "file:" + path + "?" + q.Encode()
+}
+
+// New returns a new SQLite client for the database referenced by strURL.
+// The database file is created when it does not exist.
+// If logger is nil, logging is disabled.
+func New(ctx context.Context, strURL string, logger Logger) (*Client, error) {
+	path, err := dbPathFromURL(strURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if logger != nil {
+		logger.Debugln("sqlite: opening database", path)
+	}
+
+	db, err := sql.Open("sqlite", dsn(path))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening %s failed: %w", path, err)
+	}
+
+	return &Client{db: db}, nil
+}
+
+// Close closes the database.
+func (c *Client) Close() error {
+	return c.db.Close()
+}
+
+type dbConn interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+}
+
+// inTx runs fn in a transaction.
+// If fn returns nil, the transaction is committed, otherwise it is rolled
+// back.
+func (c *Client) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// timeToDB converts t to the representation that is stored in the
+// database.
+// Timestamps are stored as nanoseconds since the unix epoch, this keeps
+// comparisons and duration calculations in queries simple.
+func timeToDB(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+// timeFromDB converts a timestamp stored in the database to a time.Time.
+func timeFromDB(ns int64) time.Time {
+	return time.Unix(0, ns)
+}
@@ -0,0 +1,384 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// errPretend is returned by transaction functions to roll back the changes
+// of pretend delete operations.
+var errPretend = errors.New("pretend mode, rolling back transaction")
+
+// inPretendableTx runs fn in a transaction.
+// If pretend is true, the transaction is always rolled back.
+func (c *Client) inPretendableTx(ctx context.Context, pretend bool, fn func(*sql.Tx) error) error {
+	err := c.inTx(ctx, func(tx *sql.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		if pretend {
+			return errPretend
+		}
+
+		return nil
+	})
+	if errors.Is(err, errPretend) {
+		return nil
+	}
+
+	return err
+}
+
+func (c *Client) ReleasesDelete(ctx context.Context, before time.Time, pretend bool) (*storage.ReleasesDeleteResult, error) {
+	var result storage.ReleasesDeleteResult
+
+	err := c.inPretendableTx(ctx, pretend, func(tx *sql.Tx) (err error) {
+		result.DeletedReleases, err = c.deleteOldReleases(ctx, tx, before)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) ReleaseDelete(ctx context.Context, name string) error {
+	const query = `
+	      DELETE FROM release
+	       WHERE name = ?1
+	`
+
+	cnt, err := execRowsAffected(ctx, c.db, query, name)
+	if err != nil {
+		return err
+	}
+
+	if cnt == 0 {
+		return storage.ErrNotExist
+	}
+
+	return nil
+}
+
+func (*Client) deleteOldReleases(ctx context.Context, con dbConn, before time.Time) (int64, error) {
+	const query = `
+	      DELETE FROM release
+	       WHERE created_at < ?1
+	`
+
+	return execRowsAffected(ctx, con, query, timeToDB(before))
+}
+
+func (c *Client) TaskRunsDelete(ctx context.Context, before time.Time, retention storage.TaskRunsRetention, pretend bool) (*storage.TaskRunsDeleteResult, error) {
+	var result *storage.TaskRunsDeleteResult
+
+	err := c.inPretendableTx(ctx, pretend, func(tx *sql.Tx) (err error) {
+		result, err = c.taskRunsDelete(ctx, before, &retention, tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) taskRunsDelete(ctx context.Context, before time.Time, retention *storage.TaskRunsRetention, con dbConn) (*storage.TaskRunsDeleteResult, error) {
+	var err error
+	var result storage.TaskRunsDeleteResult
+
+	// the unreferenced uploads are determined before the task runs are
+	// deleted, the outputs of the deleted task runs are required to find
+	// uploads that share an image with the remaining ones
+	result.UnreferencedUploads, err = c.unreferencedUploads(ctx, con, before, retention)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DeletedTaskRuns, err = c.deleteOldTaskRuns(ctx, con, before, retention)
+	if err != nil {
+		return nil, err
+	}
+
+	result.DeletedTasks, err = c.deleteUnusedTasks(ctx, con)
+	if err != nil {
+		return &result, err
+	}
+
+	result.DeletedApps, err = c.deleteUnusedApps(ctx, con)
+	if err != nil {
+		return &result, err
+	}
+
+	result.DeletedOutputs, err = c.deleteUnusedOutputs(ctx, con)
+	if err != nil {
+		return &result, err
+	}
+
+	result.DeletedUploads, err = c.deleteUnusedUploads(ctx, con)
+	if err != nil {
+		return &result, err
+	}
+
+	result.DeletedInputs, err = c.deleteUnusedInputs(ctx, con)
+	if err != nil {
+		return &result, err
+	}
+
+	result.DeletedVCS, err = c.deleteUnusedVCS(ctx, con)
+	return &result, err
+}
+
+// execRowsAffected executes query and returns the number of affected rows.
+func execRowsAffected(ctx context.Context, con dbConn, query string, args ...any) (int64, error) {
+	res, err := con.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, newQueryError(query, err, args...)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return 0, newQueryError(query, err, args...)
+	}
+
+	return cnt, nil
+}
+
+// oldTaskRunsQuery selects the IDs of the task runs that are deleted by
+// [Client.TaskRunsDelete], its arguments are returned by oldTaskRunsArgs.
+// The task runs that are retained are determined by ranking the task
+// runs per task, per task and result and per task and input digest,
+// a rank of 1 is the newest run.
+const oldTaskRunsQuery = `
+	      SELECT id
+	        FROM task_run
+	       WHERE start_timestamp < ?1
+	         AND NOT EXISTS (
+			SELECT 1 FROM release_task_run
+			 WHERE task_run.id = release_task_run.task_run_id
+	       )
+	         AND id NOT IN (
+			SELECT ranked.id
+			  FROM (
+				SELECT id, result,
+				       row_number() OVER (
+					PARTITION BY task_id
+					ORDER BY start_timestamp DESC, id DESC
+				       ) AS task_rank,
+				       row_number() OVER (
+					PARTITION BY task_id, result
+					ORDER BY start_timestamp DESC, id DESC
+				       ) AS result_rank,
+				       row_number() OVER (
+					PARTITION BY task_id, total_input_digest
+					ORDER BY start_timestamp DESC, id DESC
+				       ) AS digest_rank
+				  FROM task_run
+			  ) AS ranked
+			 WHERE ranked.task_rank <= ?2
+			    OR (ranked.result = 'success' AND ranked.result_rank <= ?3)
+			    OR ranked.digest_rank <= ?4
+	       )
+`
+
+func oldTaskRunsArgs(before time.Time, retention *storage.TaskRunsRetention) []any {
+	var keepSuccessful int64
+	if retention.KeepLastSuccessful {
+		keepSuccessful = 1
+	}
+
+	return []any{
+		timeToDB(before),
+		int64(retention.KeepLast),
+		keepSuccessful,
+		int64(retention.KeepLastPerInputDigest),
+	}
+}
+
+func (*Client) deleteOldTaskRuns(ctx context.Context, con dbConn, before time.Time, retention *storage.TaskRunsRetention) (int64, error) {
+	const query = `DELETE FROM task_run WHERE id IN (` + oldTaskRunsQuery + `)`
+
+	return execRowsAffected(ctx, con, query, oldTaskRunsArgs(before, retention)...)
+}
+
+func (*Client) deleteUnusedTasks(ctx context.Context, con dbConn) (int64, error) {
+	const query = `
+		DELETE FROM task
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM task_run
+			 WHERE task.id = task_run.task_id
+		 )
+		`
+
+	return execRowsAffected(ctx, con, query)
+}
+
+func (*Client) deleteUnusedApps(ctx context.Context, con dbConn) (int64, error) {
+	const query = `
+		DELETE FROM application
+		 WHERE id NOT IN (
+			 SELECT task.application_id FROM task
+		 )
+		`
+
+	return execRowsAffected(ctx, con, query)
+}
+
+func (*Client) deleteUnusedOutputs(ctx context.Context, con dbConn) (int64, error) {
+	const query = `
+		DELETE FROM output
+		 WHERE id NOT IN (
+			 SELECT task_run_output.output_id
+			   FROM task_run_output
+		 )
+		`
+
+	return execRowsAffected(ctx, con, query)
+}
+
+// unreferencedUploads returns the uploads that are not referenced anymore
+// when the task runs selected by oldTaskRunsQuery are deleted.
+// Uploads whose URI is used by a remaining upload are omitted.
+// Uploads of docker images are also omitted when an image with the same
+// digest is referenced by a remaining upload, deleting the image manifest
+// would also delete the other tags that refer to it.
+func (*Client) unreferencedUploads(ctx context.Context, con dbConn, before time.Time, retention *storage.TaskRunsRetention) ([]*storage.UnreferencedUpload, error) {
+	const query = `
+		WITH remaining_task_run_output AS (
+			SELECT task_run_output.upload_id, task_run_output.output_id
+			  FROM task_run_output
+			 WHERE task_run_output.task_run_id NOT IN (` + oldTaskRunsQuery + `)
+		), remaining_upload AS (
+			SELECT upload.uri, output.type, output.digest
+			  FROM remaining_task_run_output
+			  JOIN upload ON upload.id = remaining_task_run_output.upload_id
+			  JOIN output ON output.id = remaining_task_run_output.output_id
+		)
+		SELECT DISTINCT upload.uri, upload.method
+		  FROM upload
+		 WHERE upload.id NOT IN (
+			 SELECT remaining_task_run_output.upload_id
+			   FROM remaining_task_run_output
+		 )
+		   AND upload.uri NOT IN (
+			 SELECT remaining_upload.uri
+			   FROM remaining_upload
+		 )
+		   AND NOT EXISTS (
+			 SELECT 1
+			   FROM task_run_output
+			   JOIN output ON output.id = task_run_output.output_id
+			   JOIN remaining_upload ON remaining_upload.digest = output.digest
+			  WHERE task_run_output.upload_id = upload.id
+			    AND output.type = 'docker'
+			    AND remaining_upload.type = 'docker'
+		 )
+		 ORDER BY upload.uri, upload.method
+		`
+	var result []*storage.UnreferencedUpload
+
+	args := oldTaskRunsArgs(before, retention)
+	rows, err := con.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, newQueryError(query, err, args...)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var upload storage.UnreferencedUpload
+
+		if err := rows.Scan(&upload.URI, &upload.Method); err != nil {
+			return nil, newQueryError(query, err, args...)
+		}
+
+		result = append(result, &upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, args...)
+	}
+
+	return result, nil
+}
+
+func (*Client) deleteUnusedUploads(ctx context.Context, con dbConn) (int64, error) {
+	const query = `
+		DELETE FROM upload
+		 WHERE id NOT IN (
+			 SELECT task_run_output.upload_id
+			   FROM task_run_output
+		 )
+		`
+
+	return execRowsAffected(ctx, con, query)
+}
+
+func (*Client) deleteUnusedVCS(ctx context.Context, con dbConn) (int64, error) {
+	const query = `
+		DELETE FROM vcs
+		 WHERE NOT EXISTS (
+			 SELECT 1 FROM task_run
+			  WHERE vcs.id = task_run.vcs_id
+		 )
+		`
+
+	return execRowsAffected(ctx, con, query)
+}
+
+func (*Client) deleteUnusedInputs(ctx context.Context, con dbConn) (int64, error) {
+	queries := []string{
+		`
+		DELETE FROM input_file
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM task_run_file_input
+			 WHERE input_file.id = task_run_file_input.input_file_id
+		 )
+		`,
+		`
+		DELETE FROM input_string
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM task_run_string_input
+			 WHERE input_string.id = task_run_string_input.input_string_id
+		 )
+		`,
+		`
+		DELETE FROM input_env_var
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM task_run_env_var_input
+			 WHERE input_env_var.id = task_run_env_var_input.input_env_var_id
+		 )
+		`,
+		`
+		DELETE FROM input_task
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM task_run_task_input
+			 WHERE input_task.id = task_run_task_input.input_task_id
+		 )
+		`,
+		`
+		DELETE FROM input_command_output
+		 WHERE NOT EXISTS (
+			SELECT 1 FROM task_run_command_output_input
+			 WHERE input_command_output.id = task_run_command_output_input.input_command_output_id
+		 )
+		`,
+	}
+
+	var cnt int64
+	for _, q := range queries {
+		n, err := execRowsAffected(ctx, con, q)
+		if err != nil {
+			return 0, err
+		}
+
+		cnt += n
+	}
+
+	return cnt, nil
+}
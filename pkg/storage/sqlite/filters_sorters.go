@@ -0,0 +1,373 @@
+package sqlite
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// query assembles an SQL-Query described by storage Filters and Sorters
+type query struct {
+	BaseQuery string
+	Filters   []*storage.Filter
+	Sorters   []*storage.Sorter
+	Limit     uint
+}
+
+func columnName(f storage.Field) (string, error) {
+	switch f {
+	case storage.FieldApplicationName:
+		return "application_name", nil
+	case storage.FieldTaskName:
+		return "task_name", nil
+	case storage.FieldDuration:
+		return "duration", nil
+	case storage.FieldStartTime:
+		return "start_timestamp", nil
+	case storage.FieldID:
+		return "task_run_id", nil
+	case storage.FieldInputString:
+		return "input_string_val", nil
+	case storage.FieldInputFilePath:
+		return "input_file_path", nil
+	case storage.FieldResult:
+		return "result", nil
+	case storage.FieldVCSRevision:
+		return "revision", nil
+	case storage.FieldVCSIsDirty:
+		return "dirty", nil
+	case storage.FieldTotalInputDigest:
+		return "total_input_digest", nil
+	case storage.FieldReleaseName:
+		return "name", nil
+	case storage.FieldReleaseCreatedAt:
+		return "created_at", nil
+
+	default:
+		return "", fmt.Errorf("no sqlite mapping for storage field %s exists", f)
+	}
+}
+
+// multiValueSubquery returns the column and a subquery template for fields of
+// which a task run can have multiple values in other tables.
+// The template contains a %s verb for the condition on the column, the
+// subquery returns rows if the condition is true for one of the values.
+func multiValueSubquery(f storage.Field) (column, subquery string, ok bool) {
+	const outputSubquery = `
+		SELECT 1 FROM task_run_output
+		  JOIN output ON output.id = task_run_output.output_id
+		 WHERE task_run_output.task_run_id = tr.task_run_id AND %s`
+	const uploadSubquery = `
+		SELECT 1 FROM task_run_output
+		  JOIN upload ON upload.id = task_run_output.upload_id
+		 WHERE task_run_output.task_run_id = tr.task_run_id AND %s`
+
+	switch f { //nolint:exhaustive // other fields are columns of the task run query
+	case storage.FieldOutputDigest:
+		return "output.digest", outputSubquery, true
+	case storage.FieldOutputURI:
+		return "upload.uri", uploadSubquery, true
+	default:
+		return "", "", false
+	}
+}
+
+// argValue converts a filter value to the representation that is stored in
+// the database.
+func argValue(v any) any {
+	switch val := v.(type) {
+	case time.Time:
+		return timeToDB(val)
+	case time.Duration:
+		return val.Nanoseconds()
+	default:
+		return v
+	}
+}
+
+// compileOp returns the SQL expression for comparing column a via op with
+// value.
+// argNr is the number of the first query parameter that is used in the
+// returned expression. The arguments of the expression are returned.
+func compileOp(a string, op storage.Op, value any, argNr int) (string, []any, error) {
+	switch op {
+	case storage.OpEQ:
+		return fmt.Sprintf("%s = ?%d", a, argNr), []any{argValue(value)}, nil
+	case storage.OpNE:
+		return fmt.Sprintf("%s <> ?%d", a, argNr), []any{argValue(value)}, nil
+	case storage.OpGT:
+		return fmt.Sprintf("%s > ?%d", a, argNr), []any{argValue(value)}, nil
+	case storage.OpLT:
+		return fmt.Sprintf("%s < ?%d", a, argNr), []any{argValue(value)}, nil
+	case storage.OpIN, storage.OpNotIN:
+		// SQLite has no array type, each element of the slice is
+		// passed as a separate parameter
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return "", nil, fmt.Errorf("value of %s operator must be a slice, got %T", op, value)
+		}
+
+		params := make([]string, 0, rv.Len())
+		args := make([]any, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			params = append(params, fmt.Sprintf("?%d", argNr+i))
+			args = append(args, argValue(rv.Index(i).Interface()))
+		}
+
+		if op == storage.OpNotIN {
+			return fmt.Sprintf("%s NOT IN (%s)", a, strings.Join(params, ", ")), args, nil
+		}
+
+		return fmt.Sprintf("%s IN (%s)", a, strings.Join(params, ", ")), args, nil
+	case storage.OpPrefix:
+		// LIKE is case-insensitive in SQLite, the prefix is compared
+		// instead
+		s, ok := value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("value of %s operator must be a string, got %T", op, value)
+		}
+
+		return fmt.Sprintf("substr(%s, 1, length(?%d)) = ?%d", a, argNr, argNr), []any{s}, nil
+
+	default:
+		return "", nil, fmt.Errorf("no sqlite mapping for storage operator %s exists", op)
+	}
+}
+
+// compileFilter returns the SQL expression for the filter f and its
+// arguments.
+func compileFilter(f *storage.Filter, argNr int) (string, []any, error) {
+	if f.Field == storage.FieldLabel {
+		return compileLabelFilter(f, argNr)
+	}
+
+	if column, subquery, ok := multiValueSubquery(f.Field); ok {
+		// negated operators match task runs that have no value that
+		// equals the filter value
+		switch f.Operator { //nolint:exhaustive // other operators are not negated
+		case storage.OpNE:
+			cond, args, err := compileOp(column, storage.OpEQ, f.Value, argNr)
+			return "NOT EXISTS (" + fmt.Sprintf(subquery, cond) + ")", args, err
+		case storage.OpNotIN:
+			cond, args, err := compileOp(column, storage.OpIN, f.Value, argNr)
+			return "NOT EXISTS (" + fmt.Sprintf(subquery, cond) + ")", args, err
+		default:
+			cond, args, err := compileOp(column, f.Operator, f.Value, argNr)
+			return "EXISTS (" + fmt.Sprintf(subquery, cond) + ")", args, err
+		}
+	}
+
+	column, err := columnName(f.Field)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if isInputField(f.Field) && (f.Operator == storage.OpNE || f.Operator == storage.OpNotIN) {
+		return "", nil, fmt.Errorf("storage operator %s is not supported for field %s", f.Operator, f.Field)
+	}
+
+	return compileOp(column, f.Operator, f.Value, argNr)
+}
+
+// compileLabelFilter returns the SQL expression for a filter on
+// [storage.FieldLabel] and its arguments.
+// The filter values are split into the key and value of the label, to be
+// able to use the index of the task_run_label table.
+func compileLabelFilter(f *storage.Filter, argNr int) (string, []any, error) {
+	const subquery = `
+		SELECT 1 FROM task_run_label
+		 WHERE task_run_label.task_run_id = tr.task_run_id AND %s`
+
+	// negated operators match task runs that have no label that
+	// equals the filter value
+	op := f.Operator
+	exists := "EXISTS"
+	switch op { //nolint:exhaustive // other operators are not negated
+	case storage.OpNE:
+		op = storage.OpEQ
+		exists = "NOT EXISTS"
+	case storage.OpNotIN:
+		op = storage.OpIN
+		exists = "NOT EXISTS"
+	}
+
+	cond, args, err := compileLabelOp(op, f.Value, argNr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return exists + " (" + fmt.Sprintf(subquery, cond) + ")", args, nil
+}
+
+// compileLabelOp returns the SQL expression for comparing the labels of
+// the task_run_label table via op with value.
+// argNr is the number of the first query parameter that is used in the
+// returned expression. The arguments of the expression are returned.
+func compileLabelOp(op storage.Op, value any, argNr int) (string, []any, error) {
+	switch op { //nolint:exhaustive // other operators are not supported
+	case storage.OpEQ:
+		key, val, err := splitLabelValue(value)
+		if err != nil {
+			return "", nil, err
+		}
+
+		return fmt.Sprintf("task_run_label.key = ?%d AND task_run_label.value = ?%d", argNr, argNr+1),
+			[]any{key, val}, nil
+	case storage.OpIN:
+		labels, ok := value.([]string)
+		if !ok {
+			return "", nil, fmt.Errorf("value of %s operator must be a []string, got %T", op, value)
+		}
+
+		if len(labels) == 0 {
+			return "FALSE", nil, nil
+		}
+
+		conds := make([]string, 0, len(labels))
+		args := make([]any, 0, 2*len(labels))
+		for _, l := range labels {
+			key, val, err := splitLabelValue(l)
+			if err != nil {
+				return "", nil, err
+			}
+
+			conds = append(conds, fmt.Sprintf(
+				"(task_run_label.key = ?%d AND task_run_label.value = ?%d)",
+				argNr+len(args), argNr+len(args)+1,
+			))
+			args = append(args, key, val)
+		}
+
+		return "(" + strings.Join(conds, " OR ") + ")", args, nil
+	case storage.OpPrefix:
+		// LIKE is case-insensitive in SQLite, the prefix is compared
+		// instead
+		s, ok := value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("value of %s operator must be a string, got %T", op, value)
+		}
+
+		key, valPrefix, found := storage.SplitLabelString(s)
+		if !found {
+			return fmt.Sprintf("substr(task_run_label.key, 1, length(?%d)) = ?%d", argNr, argNr),
+				[]any{s}, nil
+		}
+
+		return fmt.Sprintf(
+				"task_run_label.key = ?%d AND substr(task_run_label.value, 1, length(?%d)) = ?%d",
+				argNr, argNr+1, argNr+1,
+			),
+			[]any{key, valPrefix}, nil
+	default:
+		return "", nil, fmt.Errorf("storage operator %s is not supported for field %s", op, storage.FieldLabel)
+	}
+}
+
+// splitLabelValue splits a label filter value into the key and value of the
+// label.
+func splitLabelValue(v any) (key, value string, err error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", "", fmt.Errorf("label filter value must be a string, got %T", v)
+	}
+
+	key, value, found := storage.SplitLabelString(s)
+	if !found {
+		return "", "", fmt.Errorf("label filter value %q is not in the format KEY=VALUE", s)
+	}
+
+	return key, value, nil
+}
+
+func isInputField(f storage.Field) bool {
+	return f == storage.FieldInputString || f == storage.FieldInputFilePath
+}
+
+func compileSortOrder(o storage.Order, column string) (string, error) {
+	switch o {
+	case storage.OrderAsc:
+		return column + " ASC", nil
+	case storage.OrderDesc:
+		return column + " DESC", nil
+
+	default:
+		return "", fmt.Errorf("no sqlite mapping for storage order direction %s exists", o)
+	}
+}
+
+func (q *query) compileFilterStr() (filterStr string, args []any, err error) {
+	if len(q.Filters) == 0 {
+		return filterStr, args, err
+	}
+
+	for i, f := range q.Filters {
+		opStr, opArgs, err := compileFilter(f, len(args)+1)
+		if err != nil {
+			return "", nil, err
+		}
+
+		filterStr += opStr
+		args = append(args, opArgs...)
+
+		if i+1 < len(q.Filters) {
+			filterStr += " AND "
+		}
+	}
+
+	return "WHERE " + filterStr, args, err
+}
+
+func (q *query) compileSorterStr() (string, error) {
+	if len(q.Sorters) == 0 {
+		return "", nil
+	}
+
+	var sorterStr string
+	for i, f := range q.Sorters {
+		column, err := columnName(f.Field)
+		if err != nil {
+			return "", err
+		}
+
+		orderStr, err := compileSortOrder(f.Order, column)
+		if err != nil {
+			return "", err
+		}
+
+		sorterStr += orderStr
+
+		if i+1 < len(q.Sorters) {
+			sorterStr += ", "
+		}
+	}
+
+	return "ORDER BY " + sorterStr, nil
+}
+
+func (q *query) compileLimitStr() string {
+	if q.Limit == storage.NoLimit {
+		return ""
+	}
+
+	return fmt.Sprintf("LIMIT %d", q.Limit)
+}
+
+// Compile creates the SQL query string and returns it with the arguments for the query
+func (q *query) Compile() (query string, args []any, err error) {
+	filterStr, args, err := q.compileFilterStr()
+	if err != nil {
+		return "", nil, err
+	}
+
+	orderStr, err := q.compileSorterStr()
+	if err != nil {
+		return "", nil, err
+	}
+
+	limitStr := q.compileLimitStr()
+
+	return fmt.Sprintf("%s %s %s %s", q.BaseQuery, filterStr, orderStr, limitStr), args, nil
+}
@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+func newTestClient(t *testing.T) (*Client, func()) {
+	t.Helper()
+
+	dbURL := URLScheme + "://" + filepath.Join(t.TempDir(), "baur.db")
+
+	client, err := New(ctx, dbURL, nil)
+	require.NoError(t, err)
+
+	return client, func() {
+		require.NoError(t, client.Close())
+	}
+}
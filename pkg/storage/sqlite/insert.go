@@ -0,0 +1,600 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+// queryValuePairFirstConstStr returns the argument for an SQL VALUES statement
+// It creates pairsCount "(?1, ?n), (?1, ?n+1), (?1 ?n+...)" string pairs.
+// The first argument is constant and refers the first query argument.
+func queryValuePairFirstConstStr(pairsCount int) string {
+	var res []byte
+
+	argNr := 2
+	for i := 0; i < pairsCount; i++ {
+		res = fmt.Appendf(res, "(?1, ?%d)", argNr)
+		argNr++
+
+		if i < pairsCount-1 {
+			res = append(res, ", "...)
+		}
+	}
+
+	return string(res)
+}
+
+// insertIfNotExist runs query, that must be an INSERT statement with a
+// RETURNING id clause, once per element of args and returns the ids of the
+// records.
+// The statement is prepared once and executed for every element. Using
+// single-row statements prevents that the limit of parameters per query is
+// exceeded.
+func insertIfNotExist(ctx context.Context, db dbConn, query string, args [][]any) ([]int, error) {
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, newQueryError(query, err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int, 0, len(args))
+	for _, queryArgs := range args {
+		var id int
+
+		if err := stmt.QueryRowContext(ctx, queryArgs...).Scan(&id); err != nil {
+			return nil, newQueryError(query, err, queryArgs...)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// insertAssociations inserts one (taskRunID, id) record per element of ids
+// into the table via query.
+func insertAssociations(ctx context.Context, db dbConn, query string, taskRunID int, ids []int) error {
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return newQueryError(query, err)
+	}
+	defer stmt.Close()
+
+	for _, id := range ids {
+		if _, err := stmt.ExecContext(ctx, taskRunID, id); err != nil {
+			return newQueryError(query, err, taskRunID, id)
+		}
+	}
+
+	return nil
+}
+
+func insertAppIfNotExist(ctx context.Context, db dbConn, appName string) (int, error) {
+	const query = `
+	   INSERT INTO application (name)
+	   VALUES (?1)
+	       ON CONFLICT (name)
+	       DO UPDATE SET id=application.id
+	RETURNING id
+	`
+
+	var id int
+
+	if err := db.QueryRowContext(ctx, query, appName).Scan(&id); err != nil {
+		return -1, newQueryError(query, err, appName)
+	}
+
+	return id, nil
+}
+
+func insertTaskIfNotExist(ctx context.Context, db dbConn, appName, taskName string) (int, error) {
+	var id int
+
+	appID, err := insertAppIfNotExist(ctx, db, appName)
+	if err != nil {
+		return -1, err
+	}
+
+	const query = `
+	   INSERT INTO task (name, application_id)
+	   VALUES (?1, ?2)
+	       ON CONFLICT (name, application_id)
+	       DO UPDATE SET id=task.id
+	RETURNING id
+	`
+
+	if err := db.QueryRowContext(ctx, query, taskName, appID).Scan(&id); err != nil {
+		return -1, newQueryError(query, err, appName, taskName)
+	}
+
+	return id, nil
+}
+
+func insertVCSIfNotExist(ctx context.Context, db dbConn, revision string, isDirty bool) (int, error) {
+	const query = `
+	   INSERT INTO vcs (revision, dirty)
+	   VALUES (?1, ?2)
+	       ON CONFLICT (revision, dirty)
+	       DO UPDATE SET id=vcs.id
+	RETURNING id
+	`
+
+	var id int
+
+	if err := db.QueryRowContext(ctx, query, revision, isDirty).Scan(&id); err != nil {
+		return -1, newQueryError(query, err, revision, isDirty)
+	}
+
+	return id, nil
+}
+
+func insertTaskRunInputFilesIfNotExist(ctx context.Context, db dbConn, taskRunID int, inputs []*storage.InputFile) error {
+	const queryInput = `
+	   INSERT INTO input_file (path, digest)
+	   VALUES (?1, ?2)
+	       ON CONFLICT (path, digest)
+	       DO UPDATE SET id=input_file.id
+	RETURNING id
+	`
+	const queryAssoc = `
+	INSERT INTO task_run_file_input (task_run_id, input_file_id)
+	VALUES (?1, ?2)
+	`
+
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	args := make([][]any, 0, len(inputs))
+	for _, in := range inputs {
+		args = append(args, []any{in.Path, in.Digest})
+	}
+
+	ids, err := insertIfNotExist(ctx, db, queryInput, args)
+	if err != nil {
+		return err
+	}
+
+	return insertAssociations(ctx, db, queryAssoc, taskRunID, ids)
+}
+
+func insertTaskRunInputStringsIfNotExist(ctx context.Context, db dbConn, taskRunID int, inputs []*storage.InputString) error {
+	const queryInput = `
+	   INSERT INTO input_string (string, digest)
+	   VALUES (?1, ?2)
+	       ON CONFLICT (digest)
+	       DO UPDATE SET id=input_string.id
+	RETURNING id
+	`
+	const queryAssoc = `
+	INSERT INTO task_run_string_input (task_run_id, input_string_id)
+	VALUES (?1, ?2)
+	`
+
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	args := make([][]any, 0, len(inputs))
+	for _, in := range inputs {
+		args = append(args, []any{in.String, in.Digest})
+	}
+
+	ids, err := insertIfNotExist(ctx, db, queryInput, args)
+	if err != nil {
+		return err
+	}
+
+	return insertAssociations(ctx, db, queryAssoc, taskRunID, ids)
+}
+
+func insertTaskRunInputEnvVarsIfNotExist(ctx context.Context, db dbConn, taskRunID int, inputs []*storage.InputEnvVar) error {
+	const queryInput = `
+	   INSERT INTO input_env_var (name, digest)
+	   VALUES (?1, ?2)
+	       ON CONFLICT (digest)
+	       DO UPDATE SET id=input_env_var.id
+	RETURNING id
+	`
+	const queryAssoc = `
+	INSERT INTO task_run_env_var_input (task_run_id, input_env_var_id)
+	VALUES (?1, ?2)
+	`
+
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	args := make([][]any, 0, len(inputs))
+	for _, in := range inputs {
+		args = append(args, []any{in.Name, in.Digest})
+	}
+
+	ids, err := insertIfNotExist(ctx, db, queryInput, args)
+	if err != nil {
+		return err
+	}
+
+	return insertAssociations(ctx, db, queryAssoc, taskRunID, ids)
+}
+
+func insertTaskRunInputTasksIfNotExist(ctx context.Context, db dbConn, taskRunID int, inputs []*storage.InputTaskInfo) error {
+	const queryInput = `
+	   INSERT INTO input_task (name, digest)
+	   VALUES (?1, ?2)
+	       ON CONFLICT (name, digest)
+	       DO UPDATE SET id=input_task.id
+	RETURNING id
+	`
+	const queryAssoc = `
+	INSERT INTO task_run_task_input (task_run_id, input_task_id)
+	VALUES (?1, ?2)
+	`
+
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	args := make([][]any, 0, len(inputs))
+	for _, in := range inputs {
+		args = append(args, []any{in.Name, in.Digest})
+	}
+
+	ids, err := insertIfNotExist(ctx, db, queryInput, args)
+	if err != nil {
+		return err
+	}
+
+	return insertAssociations(ctx, db, queryAssoc, taskRunID, ids)
+}
+
+func insertTaskRunInputCommandOutputsIfNotExist(ctx context.Context, db dbConn, taskRunID int, inputs []*storage.InputCommandOutput) error {
+	const queryInput = `
+	   INSERT INTO input_command_output (command, digest)
+	   VALUES (?1, ?2)
+	       ON CONFLICT (command, digest)
+	       DO UPDATE SET id=input_command_output.id
+	RETURNING id
+	`
+	const queryAssoc = `
+	INSERT INTO task_run_command_output_input (task_run_id, input_command_output_id)
+	VALUES (?1, ?2)
+	`
+
+	if len(inputs) == 0 {
+		return nil
+	}
+
+	args := make([][]any, 0, len(inputs))
+	for _, in := range inputs {
+		args = append(args, []any{in.Command, in.Digest})
+	}
+
+	ids, err := insertIfNotExist(ctx, db, queryInput, args)
+	if err != nil {
+		return err
+	}
+
+	return insertAssociations(ctx, db, queryAssoc, taskRunID, ids)
+}
+
+func insertUpload(ctx context.Context, db dbConn, upload *storage.Upload) (int, error) {
+	const query = `
+	INSERT into upload (uri, method, start_timestamp, stop_timestamp)
+	VALUES (?1, ?2, ?3, ?4)
+	RETURNING id
+	`
+
+	var id int
+
+	queryArgs := []any{
+		upload.URI,
+		upload.Method,
+		timeToDB(upload.UploadStartTimestamp),
+		timeToDB(upload.UploadStopTimestamp),
+	}
+
+	if err := db.QueryRowContext(ctx, query, queryArgs...).Scan(&id); err != nil {
+		return -1, newQueryError(query, err, queryArgs...)
+	}
+
+	return id, nil
+}
+
+func insertOutputIfNotExist(ctx context.Context, db dbConn, output *storage.Output) (int, error) {
+	const query = `
+	   INSERT INTO output (name, type, digest, size_bytes)
+	   VALUES(?1, ?2, ?3, ?4)
+	       ON CONFLICT (name, type, digest, size_bytes)
+	       DO UPDATE SET id=output.id
+	RETURNING id
+	`
+
+	var id int
+
+	queryArgs := []any{
+		output.Name,
+		output.Type,
+		output.Digest,
+		output.SizeBytes,
+	}
+
+	err := db.QueryRowContext(ctx, query, queryArgs...).Scan(&id)
+	if err != nil {
+		return -1, newQueryError(query, err, queryArgs...)
+	}
+
+	return id, nil
+}
+
+func insertTaskOutputsIfNotExist(ctx context.Context, db dbConn, taskRunID int, outputs []*storage.Output) error {
+	const query = `
+	INSERT INTO task_run_output (task_run_id, output_id, upload_id)
+	VALUES (?1, ?2, ?3)
+	`
+
+	for _, output := range outputs {
+		outputID, err := insertOutputIfNotExist(ctx, db, output)
+		if err != nil {
+			return err
+		}
+
+		for _, upload := range output.Uploads {
+			uploadID, err := insertUpload(ctx, db, upload)
+			if err != nil {
+				return err
+			}
+
+			_, err = db.ExecContext(ctx, query, taskRunID, outputID, uploadID)
+			if err != nil {
+				return newQueryError(query, err, taskRunID, outputID, uploadID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func insertTaskRunLabels(ctx context.Context, db dbConn, taskRunID int, labels map[string]string) error {
+	const query = "INSERT INTO task_run_label (task_run_id, key, value) VALUES(?1, ?2, ?3)"
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return newQueryError(query, err)
+	}
+	defer stmt.Close()
+
+	for _, k := range slices.Sorted(maps.Keys(labels)) {
+		if _, err := stmt.ExecContext(ctx, taskRunID, k, labels[k]); err != nil {
+			return newQueryError(query, err, taskRunID, k, labels[k])
+		}
+	}
+
+	return nil
+}
+
+func insertTaskRunLog(ctx context.Context, db dbConn, taskRunID int, log []byte) error {
+	const query = "INSERT INTO task_run_log (task_run_id, log) VALUES(?1, ?2)"
+
+	if log == nil {
+		return nil
+	}
+
+	_, err := db.ExecContext(ctx, query, taskRunID, log)
+	if err != nil {
+		return newQueryError(query, err, taskRunID, fmt.Sprintf("<%d bytes>", len(log)))
+	}
+
+	return nil
+}
+
+func (c *Client) saveTaskRun(ctx context.Context, tx *sql.Tx, taskRun *storage.TaskRunFull) (int, error) {
+	const query = `
+		   INSERT INTO task_run (vcs_id, task_id, total_input_digest, start_timestamp, stop_timestamp, result)
+		   VALUES(?1, ?2, ?3, ?4, ?5, ?6)
+		RETURNING id
+		`
+
+	var taskRunID int
+
+	vcsID, err := insertVCSIfNotExist(ctx, tx, taskRun.VCSRevision, taskRun.VCSIsDirty)
+	if err != nil {
+		return -1, fmt.Errorf("storing vcs record failed: %w", err)
+	}
+
+	taskID, err := insertTaskIfNotExist(ctx, tx, taskRun.ApplicationName, taskRun.TaskName)
+	if err != nil {
+		return -1, fmt.Errorf("storing task record failed: %w", err)
+	}
+
+	queryArgs := []any{
+		vcsID,
+		taskID,
+		taskRun.TotalInputDigest,
+		timeToDB(taskRun.StartTimestamp),
+		timeToDB(taskRun.StopTimestamp),
+		taskRun.Result,
+	}
+
+	err = tx.QueryRowContext(ctx, query, queryArgs...).Scan(&taskRunID)
+	if err != nil {
+		return -1, newQueryError(query, err, queryArgs...)
+	}
+
+	err = insertTaskRunInputStringsIfNotExist(ctx, tx, taskRunID, taskRun.Inputs.Strings)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskRunInputFilesIfNotExist(ctx, tx, taskRunID, taskRun.Inputs.Files)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskRunInputEnvVarsIfNotExist(ctx, tx, taskRunID, taskRun.Inputs.EnvironmentVariables)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskRunInputTasksIfNotExist(ctx, tx, taskRunID, taskRun.Inputs.TaskInfo)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskRunInputCommandOutputsIfNotExist(ctx, tx, taskRunID, taskRun.Inputs.CommandOutputs)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskOutputsIfNotExist(ctx, tx, taskRunID, taskRun.Outputs)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskRunLabels(ctx, tx, taskRunID, taskRun.Labels)
+	if err != nil {
+		return -1, err
+	}
+
+	err = insertTaskRunLog(ctx, tx, taskRunID, taskRun.Log)
+	if err != nil {
+		return -1, err
+	}
+
+	return taskRunID, nil
+}
+
+func (c *Client) SaveTaskRun(ctx context.Context, taskRun *storage.TaskRunFull) (int, error) {
+	var id int
+
+	return id, c.inTx(ctx, func(tx *sql.Tx) (err error) {
+		id, err = c.saveTaskRun(ctx, tx, taskRun)
+		return err
+	})
+}
+
+func (c *Client) CreateRelease(ctx context.Context, releaseName, baseReleaseName string, createdAt time.Time, taskRunIDs []int, metadata io.Reader) error {
+	return c.inTx(ctx, func(tx *sql.Tx) error {
+		releaseID, err := c.insertRelease(ctx, tx, releaseName, baseReleaseName, createdAt, metadata)
+		if err != nil {
+			return err
+		}
+
+		return c.insertReleaseTaskRun(ctx, tx, releaseID, taskRunIDs)
+	})
+}
+
+func isUniqueConstraintErr(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+	}
+
+	return false
+}
+
+func (*Client) insertRelease(ctx context.Context, tx *sql.Tx, name, baseName string, createdAt time.Time, metadata io.Reader) (int, error) {
+	const query = `
+		INSERT INTO release (name, created_at, metadata, base_release_id)
+	        VALUES(?1, ?2, ?3, ?4)
+	     RETURNING id
+	`
+
+	var data []byte
+	var err error
+	var releaseID int
+	var baseReleaseID any
+
+	if baseName != "" {
+		baseReleaseID, err = releaseIDByName(ctx, tx, baseName)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	if metadata != nil {
+		data, err = io.ReadAll(metadata)
+		if err != nil {
+			return -1, fmt.Errorf("reading metadata failed: %w", err)
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, query, name, timeToDB(createdAt), data, baseReleaseID).Scan(&releaseID)
+	if err != nil {
+		// name is the only unique column of the release table
+		if isUniqueConstraintErr(err) {
+			return -1, storage.ErrExists
+		}
+
+		if metadata == nil {
+			return -1, newQueryError(query, err, name)
+		}
+
+		return -1, newQueryError(query, err, []any{name, "<OMITTED-RELEASE-METADATA>"}...)
+	}
+
+	return releaseID, nil
+}
+
+// releaseIDByName returns the ID of the release called name.
+// If it does not exist, [storage.ErrNotExist] is returned.
+func releaseIDByName(ctx context.Context, tx *sql.Tx, name string) (int, error) {
+	const query = `
+		SELECT id
+		  FROM release
+		 WHERE name = ?1
+	`
+
+	var id int
+	err := tx.QueryRowContext(ctx, query, name).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return -1, storage.ErrNotExist
+		}
+
+		return -1, newQueryError(query, err, name)
+	}
+
+	return id, nil
+}
+
+func (*Client) insertReleaseTaskRun(ctx context.Context, tx *sql.Tx, releaseID int, taskRunIDs []int) error {
+	const stmt1 = `
+		INSERT INTO release_task_run (release_id, task_run_id)
+		VALUES`
+
+	if len(taskRunIDs) == 0 {
+		return errors.New("no task run IDs were specified")
+	}
+
+	stmtVals := queryValuePairFirstConstStr(len(taskRunIDs))
+
+	queryArgs := make([]any, 1, len(taskRunIDs)+1)
+	queryArgs[0] = releaseID
+	for _, id := range taskRunIDs {
+		queryArgs = append(queryArgs, id)
+	}
+
+	query := stmt1 + stmtVals
+	_, err := tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return newQueryError(query, err, queryArgs...)
+	}
+
+	return nil
+}
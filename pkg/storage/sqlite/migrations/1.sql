@@ -0,0 +1,137 @@
+CREATE TABLE migrations (
+	schema_version integer NOT NULL
+);
+
+INSERT INTO migrations (schema_version) VALUES(1);
+
+CREATE TABLE application (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	CONSTRAINT application_name_uniq UNIQUE (name)
+);
+
+CREATE TABLE vcs (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	revision text NOT NULL,
+	dirty boolean NOT NULL,
+	CONSTRAINT vcs_revision_dirty_uniq UNIQUE (revision, dirty)
+);
+
+CREATE TABLE output (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	type text NOT NULL,
+	digest text NOT NULL,
+	size_bytes integer NOT NULL CHECK (size_bytes >= 0),
+	CONSTRAINT output_name_type_digest_size_bytes_uniq UNIQUE (name, type, digest, size_bytes)
+);
+
+/* timestamps are stored as nanoseconds since the unix epoch */
+CREATE TABLE upload (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	uri text NOT NULL,
+	method text NOT NULL,
+	start_timestamp integer NOT NULL,
+	stop_timestamp integer NOT NULL
+);
+
+CREATE TABLE task (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	application_id integer NOT NULL REFERENCES application(id) ON DELETE CASCADE,
+	CONSTRAINT task_name_application_id_uniq UNIQUE (name, application_id)
+);
+
+CREATE TABLE task_run (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	vcs_id integer REFERENCES vcs(id),
+	task_id integer NOT NULL REFERENCES task (id) ON DELETE CASCADE,
+	total_input_digest text NOT NULL,
+	start_timestamp integer NOT NULL,
+	stop_timestamp integer NOT NULL,
+	result text NOT NULL,
+	CONSTRAINT result_check CHECK (result in ('success', 'failure'))
+);
+CREATE INDEX idx_task_run_total_input_digest ON task_run(total_input_digest);
+CREATE INDEX idx_task_run_task_id ON task_run(task_id);
+
+CREATE TABLE input_file (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	path text NOT NULL,
+	digest text NOT NULL,
+	CONSTRAINT input_file_path_digest_uniq UNIQUE (path, digest)
+);
+
+CREATE TABLE input_string (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	string text NOT NULL,
+	digest text NOT NULL,
+	CONSTRAINT input_string_digest_uniq UNIQUE (digest)
+);
+
+CREATE TABLE input_env_var (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	digest text NOT NULL,
+	CONSTRAINT input_env_var_name_digest_uniq UNIQUE (digest)
+);
+CREATE INDEX idx_input_env_var_name ON input_env_var(name);
+
+CREATE TABLE input_task (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	digest text NOT NULL,
+	CONSTRAINT input_task_name_digest_uniq UNIQUE (name, digest)
+);
+
+CREATE TABLE task_run_file_input (
+	task_run_id integer NOT NULL REFERENCES task_run(id) ON DELETE CASCADE,
+	input_file_id integer NOT NULL REFERENCES input_file(id) ON DELETE CASCADE,
+	CONSTRAINT task_run_file_input_task_run_id_input_id_uniq UNIQUE (task_run_id, input_file_id)
+);
+CREATE INDEX idx_task_run_file_input_input_file_id ON task_run_file_input(input_file_id);
+
+CREATE TABLE task_run_string_input (
+	task_run_id integer NOT NULL REFERENCES task_run(id) ON DELETE CASCADE,
+	input_string_id integer NOT NULL REFERENCES input_string(id) ON DELETE CASCADE,
+	CONSTRAINT task_run_string_input_task_run_id_input_string_id_uniq UNIQUE (task_run_id, input_string_id)
+);
+CREATE INDEX idx_task_run_string_input_input_string_id ON task_run_string_input(input_string_id);
+
+CREATE TABLE task_run_env_var_input (
+	task_run_id integer NOT NULL REFERENCES task_run(id) ON DELETE CASCADE,
+	input_env_var_id integer NOT NULL REFERENCES input_env_var(id) ON DELETE CASCADE,
+	CONSTRAINT task_run_env_var_input_task_run_id_input_env_var_id_uniq UNIQUE (task_run_id, input_env_var_id)
+);
+CREATE INDEX idx_task_run_env_var_input_input_env_var_id ON task_run_env_var_input(input_env_var_id);
+
+CREATE TABLE task_run_task_input (
+	task_run_id integer NOT NULL REFERENCES task_run(id) ON DELETE CASCADE,
+	input_task_id integer NOT NULL REFERENCES input_task(id) ON DELETE CASCADE,
+	CONSTRAINT task_run_task_input_task_run_id_input_task_id_uniq UNIQUE (task_run_id, input_task_id)
+);
+CREATE INDEX idx_task_run_task_input_input_task_id ON task_run_task_input(input_task_id);
+
+CREATE TABLE task_run_output (
+	task_run_id integer NOT NULL REFERENCES task_run (id) ON DELETE CASCADE,
+	output_id integer NOT NULL REFERENCES output (id) ON DELETE CASCADE,
+	upload_id integer NOT NULL REFERENCES upload(id) ON DELETE CASCADE,
+	CONSTRAINT task_output_task_run_id_output_id_upload_id_uniq UNIQUE (task_run_id, output_id, upload_id)
+);
+CREATE INDEX idx_task_run_output_output_id ON task_run_output(output_id);
+CREATE INDEX idx_task_run_output_upload_id ON task_run_output(upload_id);
+
+CREATE TABLE release (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text NOT NULL,
+	metadata blob,
+	created_at integer NOT NULL,
+	CONSTRAINT release_name_uniq UNIQUE (name)
+);
+
+CREATE TABLE release_task_run (
+	release_id integer NOT NULL REFERENCES release (id) ON DELETE CASCADE,
+	task_run_id integer NOT NULL REFERENCES task_run (id) ON DELETE CASCADE,
+	PRIMARY KEY(release_id, task_run_id)
+);
+CREATE INDEX idx_release_task_run_task_run_id ON release_task_run(task_run_id);
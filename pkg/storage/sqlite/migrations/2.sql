@@ -0,0 +1,7 @@
+CREATE TABLE task_run_label (
+	task_run_id integer NOT NULL REFERENCES task_run(id) ON DELETE CASCADE,
+	key text NOT NULL,
+	value text NOT NULL,
+	CONSTRAINT task_run_label_task_run_id_key_uniq UNIQUE (task_run_id, key)
+);
+CREATE INDEX idx_task_run_label_key_value ON task_run_label(key, value);
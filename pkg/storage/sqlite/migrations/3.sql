@@ -0,0 +1,4 @@
+CREATE TABLE task_run_log (
+	task_run_id integer PRIMARY KEY REFERENCES task_run(id) ON DELETE CASCADE,
+	log blob NOT NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE input_command_output (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	command text NOT NULL,
+	digest text NOT NULL,
+	CONSTRAINT input_command_output_command_digest_uniq UNIQUE (command, digest)
+);
+
+CREATE TABLE task_run_command_output_input (
+	task_run_id integer NOT NULL REFERENCES task_run(id) ON DELETE CASCADE,
+	input_command_output_id integer NOT NULL REFERENCES input_command_output(id) ON DELETE CASCADE,
+	CONSTRAINT task_run_command_output_input_uniq UNIQUE (task_run_id, input_command_output_id)
+);
+CREATE INDEX idx_task_run_command_output_input_input_command_output_id ON task_run_command_output_input(input_command_output_id);
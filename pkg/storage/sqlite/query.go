@@ -0,0 +1,777 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func (c *Client) TaskRun(ctx context.Context, id int) (*storage.TaskRunWithID, error) {
+	var taskRun *storage.TaskRunWithID
+
+	idFilter := []*storage.Filter{
+		{
+			Field:    storage.FieldID,
+			Operator: storage.OpEQ,
+			Value:    id,
+		},
+	}
+
+	err := c.TaskRuns(ctx, idFilter, nil, storage.NoLimit, func(tr *storage.TaskRunWithID) error {
+		taskRun = tr
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if taskRun == nil {
+		panic("TaskRuns returned a nil TaskRunWithID and nil error")
+	}
+
+	return taskRun, nil
+}
+
+func (c *Client) LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string) (*storage.TaskRunWithID, error) {
+	const query = `
+	SELECT task_run.id,
+	       application.name,
+	       task.name,
+	       vcs.revision,
+	       vcs.dirty,
+	       task_run.total_input_digest,
+	       task_run.start_timestamp,
+	       task_run.stop_timestamp,
+	       task_run.result,
+	       (SELECT json_group_object(task_run_label.key, task_run_label.value)
+	          FROM task_run_label
+	         WHERE task_run_label.task_run_id = task_run.id
+	        HAVING COUNT(*) > 0)
+	  FROM application
+	  JOIN task ON application.id = task.application_id
+	  JOIN task_run ON task.id = task_run.task_id
+	  LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
+	 WHERE application.name = ?1
+	   AND task.name = ?2
+	   AND task_run.total_input_digest = ?3
+	 ORDER BY task_run.stop_timestamp DESC
+	 LIMIT 1
+	 `
+
+	var result storage.TaskRunWithID
+	var startTs, stopTs int64
+	var labels sql.NullString
+
+	row := c.db.QueryRowContext(ctx, query, appName, taskName, totalInputDigest)
+
+	err := row.Scan(
+		&result.ID,
+		&result.ApplicationName,
+		&result.TaskName,
+		&result.VCSRevision,
+		&result.VCSIsDirty,
+		&result.TotalInputDigest,
+		&startTs,
+		&stopTs,
+		&result.Result,
+		&labels,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotExist
+		}
+
+		return nil, newQueryError(query, err, appName, taskName, totalInputDigest)
+	}
+
+	result.Labels, err = labelsFromDB(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	result.StartTimestamp = timeFromDB(startTs)
+	result.StopTimestamp = timeFromDB(stopTs)
+
+	return &result, nil
+}
+
+func (c *Client) LatestTaskRunsByDigests(ctx context.Context, digests []storage.AppTaskDigest) (map[storage.AppTaskDigest]*storage.TaskRunWithID, error) {
+	// the AppTaskDigests are passed as JSON array of
+	// [app name, task name, total input digest] arrays
+	const query = `
+	SELECT id, app_name, task_name, revision, dirty, total_input_digest,
+	       start_timestamp, stop_timestamp, result, labels
+	  FROM (SELECT task_run.id,
+		       application.name AS app_name,
+		       task.name AS task_name,
+		       vcs.revision,
+		       vcs.dirty,
+		       task_run.total_input_digest,
+		       task_run.start_timestamp,
+		       task_run.stop_timestamp,
+		       task_run.result,
+		       (SELECT json_group_object(task_run_label.key, task_run_label.value)
+			  FROM task_run_label
+			 WHERE task_run_label.task_run_id = task_run.id
+			HAVING COUNT(*) > 0) AS labels,
+		       row_number() OVER (
+			       PARTITION BY task_run.task_id, task_run.total_input_digest
+			       ORDER BY task_run.stop_timestamp DESC, task_run.id DESC
+		       ) AS rn
+		  FROM json_each(?1) AS wanted
+		  JOIN application ON application.name = wanted.value ->> 0
+		  JOIN task ON application.id = task.application_id
+		   AND task.name = wanted.value ->> 1
+		  JOIN task_run ON task.id = task_run.task_id
+		   AND task_run.total_input_digest = wanted.value ->> 2
+		  LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id)
+	 WHERE rn = 1
+	 `
+
+	result := make(map[storage.AppTaskDigest]*storage.TaskRunWithID, len(digests))
+	if len(digests) == 0 {
+		return result, nil
+	}
+
+	wanted := make([][3]string, 0, len(digests))
+	for _, d := range digests {
+		wanted = append(wanted, [3]string{d.AppName, d.TaskName, d.TotalInputDigest})
+	}
+
+	wantedJSON, err := json.Marshal(wanted)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := c.db.QueryContext(ctx, query, string(wantedJSON))
+	if err != nil {
+		return nil, newQueryError(query, err, string(wantedJSON))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tr storage.TaskRunWithID
+		var startTs, stopTs int64
+		var labels sql.NullString
+
+		err := rows.Scan(
+			&tr.ID,
+			&tr.ApplicationName,
+			&tr.TaskName,
+			&tr.VCSRevision,
+			&tr.VCSIsDirty,
+			&tr.TotalInputDigest,
+			&startTs,
+			&stopTs,
+			&tr.Result,
+			&labels,
+		)
+		if err != nil {
+			return nil, newQueryError(query, err, string(wantedJSON))
+		}
+
+		tr.Labels, err = labelsFromDB(labels)
+		if err != nil {
+			return nil, err
+		}
+
+		tr.StartTimestamp = timeFromDB(startTs)
+		tr.StopTimestamp = timeFromDB(stopTs)
+
+		key := storage.AppTaskDigest{
+			AppName:          tr.ApplicationName,
+			TaskName:         tr.TaskName,
+			TotalInputDigest: tr.TotalInputDigest,
+		}
+		result[key] = &tr
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, string(wantedJSON))
+	}
+
+	return result, nil
+}
+
+// labelsFromDB converts the JSON object of labels that is returned by the
+// queries to a map. If the task run has no labels, nil is returned.
+func labelsFromDB(s sql.NullString) (map[string]string, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+
+	var result map[string]string
+	if err := json.Unmarshal([]byte(s.String), &result); err != nil {
+		return nil, fmt.Errorf("parsing labels %q failed: %w", s.String, err)
+	}
+
+	return result, nil
+}
+
+// queryStringPairs runs query with taskRunID as argument, the query must
+// return 2 string columns. For each row newRecord is called with the column
+// values.
+func queryStringPairs(ctx context.Context, db dbConn, query string, taskRunID int, newRecord func(a, b string)) error {
+	rows, err := db.QueryContext(ctx, query, taskRunID)
+	if err != nil {
+		return newQueryError(query, err, taskRunID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a, b string
+
+		if err := rows.Scan(&a, &b); err != nil {
+			return newQueryError(query, err, taskRunID)
+		}
+
+		newRecord(a, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return newQueryError(query, err, taskRunID)
+	}
+
+	return nil
+}
+
+func (c *Client) inputStrings(ctx context.Context, taskRunID int) ([]*storage.InputString, error) {
+	const query = `
+	SELECT input_string.string,
+	       input_string.digest
+	  FROM input_string
+	  JOIN task_run_string_input ON input_string.id = task_run_string_input.input_string_id
+	 WHERE task_run_string_input.task_run_id = ?1
+	 `
+
+	var result []*storage.InputString
+
+	err := queryStringPairs(ctx, c.db, query, taskRunID, func(str, digest string) {
+		result = append(result, &storage.InputString{String: str, Digest: digest})
+	})
+
+	return result, err
+}
+
+func (c *Client) inputFiles(ctx context.Context, taskRunID int) ([]*storage.InputFile, error) {
+	const query = `
+	SELECT input_file.path,
+	       input_file.digest
+	  FROM input_file
+	  JOIN task_run_file_input ON input_file.id = task_run_file_input.input_file_id
+	 WHERE task_run_file_input.task_run_id = ?1
+	 `
+
+	var result []*storage.InputFile
+
+	err := queryStringPairs(ctx, c.db, query, taskRunID, func(path, digest string) {
+		result = append(result, &storage.InputFile{Path: path, Digest: digest})
+	})
+
+	return result, err
+}
+
+func (c *Client) inputEnvVars(ctx context.Context, taskRunID int) ([]*storage.InputEnvVar, error) {
+	const query = `
+	SELECT input_env_var.name,
+	       input_env_var.digest
+	  FROM input_env_var
+	  JOIN task_run_env_var_input ON input_env_var.id = task_run_env_var_input.input_env_var_id
+	 WHERE task_run_env_var_input.task_run_id = ?1
+	 `
+
+	var result []*storage.InputEnvVar
+
+	err := queryStringPairs(ctx, c.db, query, taskRunID, func(name, digest string) {
+		result = append(result, &storage.InputEnvVar{Name: name, Digest: digest})
+	})
+
+	return result, err
+}
+
+func (c *Client) inputTasks(ctx context.Context, taskRunID int) ([]*storage.InputTaskInfo, error) {
+	const query = `
+	SELECT input_task.name,
+	       input_task.digest
+	  FROM input_task
+	  JOIN task_run_task_input ON input_task.id = task_run_task_input.input_task_id
+	 WHERE task_run_task_input.task_run_id = ?1
+	 `
+
+	var result []*storage.InputTaskInfo
+
+	err := queryStringPairs(ctx, c.db, query, taskRunID, func(name, digest string) {
+		result = append(result, &storage.InputTaskInfo{Name: name, Digest: digest})
+	})
+
+	return result, err
+}
+
+func (c *Client) inputCommandOutputs(ctx context.Context, taskRunID int) ([]*storage.InputCommandOutput, error) {
+	const query = `
+	SELECT input_command_output.command,
+	       input_command_output.digest
+	  FROM input_command_output
+	  JOIN task_run_command_output_input ON input_command_output.id = task_run_command_output_input.input_command_output_id
+	 WHERE task_run_command_output_input.task_run_id = ?1
+	 `
+
+	var result []*storage.InputCommandOutput
+
+	err := queryStringPairs(ctx, c.db, query, taskRunID, func(command, digest string) {
+		result = append(result, &storage.InputCommandOutput{Command: command, Digest: digest})
+	})
+
+	return result, err
+}
+
+func (c *Client) Inputs(ctx context.Context, taskRunID int) (*storage.Inputs, error) {
+	var result storage.Inputs
+	var err error
+
+	result.Files, err = c.inputFiles(ctx, taskRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Strings, err = c.inputStrings(ctx, taskRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.EnvironmentVariables, err = c.inputEnvVars(ctx, taskRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.TaskInfo, err = c.inputTasks(ctx, taskRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	result.CommandOutputs, err = c.inputCommandOutputs(ctx, taskRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Files) == 0 &&
+		len(result.Strings) == 0 &&
+		len(result.EnvironmentVariables) == 0 &&
+		len(result.TaskInfo) == 0 &&
+		len(result.CommandOutputs) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	return &result, nil
+}
+
+func (c *Client) Outputs(ctx context.Context, taskRunID int) ([]*storage.Output, error) {
+	const query = `
+	SELECT output.id,
+	       output.name,
+	       output.type,
+	       output.digest,
+	       output.size_bytes,
+	       upload.uri,
+	       upload.method,
+	       upload.start_timestamp,
+	       upload.stop_timestamp
+	  FROM output
+	  JOIN task_run_output ON task_run_output.output_id = output.id
+	  JOIN upload ON upload.id = task_run_output.upload_id
+	 WHERE task_run_output.task_run_id = ?1
+	 ORDER BY output.id, upload.id
+	 `
+
+	// result is a slice instead of a map, to return the outputs in a
+	// stable order
+	var result []*storage.Output
+	resMap := map[int]*storage.Output{}
+
+	rows, err := c.db.QueryContext(ctx, query, taskRunID)
+	if err != nil {
+		return nil, newQueryError(query, err, taskRunID)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var upload storage.Upload
+		var outputID int
+		var uploadStartTs, uploadStopTs int64
+		output := &storage.Output{}
+
+		err := rows.Scan(
+			&outputID,
+			&output.Name,
+			&output.Type,
+			&output.Digest,
+			&output.SizeBytes,
+			&upload.URI,
+			&upload.Method,
+			&uploadStartTs,
+			&uploadStopTs,
+		)
+		if err != nil {
+			return nil, newQueryError(query, err, taskRunID)
+		}
+
+		upload.UploadStartTimestamp = timeFromDB(uploadStartTs)
+		upload.UploadStopTimestamp = timeFromDB(uploadStopTs)
+
+		if rec := resMap[outputID]; rec == nil {
+			resMap[outputID] = output
+			result = append(result, output)
+		} else {
+			output = rec
+		}
+
+		output.Uploads = append(output.Uploads, &upload)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, taskRunID)
+	}
+
+	if len(result) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	return result, nil
+}
+
+func (c *Client) TaskRunLog(ctx context.Context, taskRunID int) ([]byte, error) {
+	const query = `
+	SELECT log
+	  FROM task_run_log
+	 WHERE task_run_id = ?1
+	 `
+	var log []byte
+
+	err := c.db.QueryRowContext(ctx, query, taskRunID).Scan(&log)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotExist
+		}
+		return nil, newQueryError(query, err, taskRunID)
+	}
+
+	return log, nil
+}
+
+func (c *Client) TaskRuns(
+	ctx context.Context,
+	filters []*storage.Filter,
+	sorters []*storage.Sorter,
+	limit uint,
+	cb func(*storage.TaskRunWithID) error,
+) error {
+	// SQLite does not support DISTINCT ON, SELECT DISTINCT over all
+	// columns of the subquery has the same effect because the additional
+	// columns are the same that postgresql uses for DISTINCT ON.
+	const queryTemplate = `
+	SELECT task_run_id, application_name, task_name, revision, dirty, total_input_digest, start_timestamp, stop_timestamp, result,
+	       (SELECT json_group_object(task_run_label.key, task_run_label.value)
+	          FROM task_run_label
+	         WHERE task_run_label.task_run_id = tr.task_run_id
+	        HAVING COUNT(*) > 0) AS labels
+	  FROM (
+	       SELECT DISTINCT
+		      task_run.id AS task_run_id,
+	              application.name AS application_name,
+	              task.name AS task_name,
+	              COALESCE(vcs.revision, '') AS revision,
+	              COALESCE(vcs.dirty, 0) AS dirty,
+	              task_run.total_input_digest,
+	              task_run.start_timestamp AS start_timestamp,
+	              task_run.stop_timestamp,
+	              task_run.result,
+	              {fields}
+	              (task_run.stop_timestamp - task_run.start_timestamp) AS duration
+	         FROM application
+	         JOIN task ON application.id = task.application_id
+	         JOIN task_run ON task.id = task_run.task_id
+		 {joins}
+	         LEFT OUTER JOIN vcs ON vcs.id = task_run.vcs_id
+	       ) tr
+	  `
+
+	containsInputStringFilter := false
+	containsInputFileFilter := false
+	for _, filter := range filters {
+		if filter.Field == storage.FieldInputString {
+			containsInputStringFilter = true
+		} else if filter.Field == storage.FieldInputFilePath {
+			containsInputFileFilter = true
+		}
+	}
+
+	if containsInputFileFilter && containsInputStringFilter {
+		return errors.New("either a FieldInputString or FieldInputFilePath filter can be specified, not both")
+	}
+
+	var replacer *strings.Replacer
+	if containsInputStringFilter { //nolint: gocritic // ifElseChain: rewrite if-else to switch statement
+		replacer = strings.NewReplacer(
+			"{fields}", "input_string.string AS input_string_val,",
+			"{joins}", "JOIN task_run_string_input ON task_run_string_input.task_run_id = task_run.id\n"+
+				"JOIN input_string ON input_string.id = task_run_string_input.input_string_id",
+		)
+	} else if containsInputFileFilter {
+		replacer = strings.NewReplacer(
+			"{fields}", "input_file.path AS input_file_path,",
+			"{joins}", "JOIN task_run_file_input ON task_run_file_input.task_run_id = task_run.id\n"+
+				"JOIN input_file ON input_file.id = task_run_file_input.input_file_id",
+		)
+	} else {
+		replacer = strings.NewReplacer(
+			"{fields}", "",
+			"{joins}", "")
+	}
+	queryStr := replacer.Replace(queryTemplate)
+
+	var queryReturnedRows bool
+
+	q := query{
+		BaseQuery: queryStr,
+		Filters:   filters,
+		Sorters:   sorters,
+		Limit:     limit,
+	}
+
+	query, args, err := q.Compile()
+	if err != nil {
+		return fmt.Errorf("compiling query string failed: %w", err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(query, err, args...)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskRun storage.TaskRunWithID
+		var revision sql.NullString
+		var dirty sql.NullBool
+		var labels sql.NullString
+		var startTs, stopTs int64
+
+		queryReturnedRows = true
+
+		err := rows.Scan(
+			&taskRun.ID,
+			&taskRun.ApplicationName,
+			&taskRun.TaskName,
+			&revision,
+			&dirty,
+			&taskRun.TotalInputDigest,
+			&startTs,
+			&stopTs,
+			&taskRun.Result,
+			&labels,
+		)
+		if err != nil {
+			return newQueryError(query, err, args...)
+		}
+
+		taskRun.Labels, err = labelsFromDB(labels)
+		if err != nil {
+			return err
+		}
+
+		taskRun.VCSRevision = revision.String
+		taskRun.VCSIsDirty = dirty.Bool
+		taskRun.StartTimestamp = timeFromDB(startTs)
+		taskRun.StopTimestamp = timeFromDB(stopTs)
+
+		if err := cb(&taskRun); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return newQueryError(query, err, args...)
+	}
+
+	if !queryReturnedRows {
+		return storage.ErrNotExist
+	}
+
+	return nil
+}
+
+func (c *Client) ReleaseExists(ctx context.Context, name string) (bool, error) {
+	const query = `
+	SELECT EXISTS (
+	       SELECT 1
+	         FROM release
+	        WHERE name = ?1
+	       )
+	 `
+	var exists bool
+
+	err := c.db.QueryRowContext(ctx, query, name).Scan(&exists)
+	if err != nil {
+		return false, newQueryError(query, err, name)
+	}
+
+	return exists, nil
+}
+
+func (c *Client) ReleaseTaskRuns(ctx context.Context, releaseName string) ([]*storage.ReleaseTaskRunsResult, error) {
+	const query = `
+		SELECT application.name,
+		       task.name,
+		       task_run.id, task_run.total_input_digest,
+		       output.id, output.name, output.type,
+		       output.digest, output.size_bytes,
+		       upload.uri, upload.method
+		  FROM release
+		  JOIN release_task_run ON release_task_run.release_id = release.id
+		  JOIN task_run ON task_run.id = release_task_run.task_run_id
+		  JOIN task ON task.id = task_run.task_id
+		  JOIN application ON application.id = task.application_id
+		  LEFT JOIN task_run_output ON task_run_output.task_run_id = release_task_run.task_run_id
+		  LEFT JOIN output ON output.id = task_run_output.output_id
+		  LEFT JOIN upload ON upload.id = task_run_output.upload_id
+		 WHERE release.name = ?1
+		 ORDER BY application.name, task.name, output.name, upload.uri
+		`
+	rows, err := c.db.QueryContext(ctx, query, releaseName)
+	if err != nil {
+		return nil, newQueryError(query, err, releaseName)
+	}
+	defer rows.Close()
+
+	var result []*storage.ReleaseTaskRunsResult
+
+	for rows.Next() {
+		var r storage.ReleaseTaskRunsResult
+		var outputID sql.NullInt32
+		var outputName sql.NullString
+		var outputType sql.NullString
+		var outputDigest sql.NullString
+		var outputSizeBytes sql.NullInt64
+		var uri sql.NullString
+		var uploadMethod sql.NullString
+
+		err := rows.Scan(
+			&r.AppName,
+			&r.TaskName,
+			&r.RunID,
+			&r.TotalInputDigest,
+			&outputID,
+			&outputName,
+			&outputType,
+			&outputDigest,
+			&outputSizeBytes,
+			&uri,
+			&uploadMethod,
+		)
+		if err != nil {
+			return nil, newQueryError(query, err, releaseName)
+		}
+
+		r.OutputID = int(outputID.Int32)
+		r.OutputName = outputName.String
+		r.OutputType = storage.ArtifactType(outputType.String)
+		r.OutputDigest = outputDigest.String
+		r.OutputSizeBytes = uint64(outputSizeBytes.Int64)
+		r.URI = uri.String
+		r.UploadMethod = storage.UploadMethod(uploadMethod.String)
+		result = append(result, &r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, newQueryError(query, err, releaseName)
+	}
+
+	if len(result) == 0 {
+		return nil, storage.ErrNotExist
+	}
+
+	return result, nil
+}
+
+func (c *Client) ReleaseMetadata(ctx context.Context, releaseName string) ([]byte, error) {
+	const query = `
+	SELECT metadata
+	  FROM release
+	 WHERE release.name = ?1
+	 `
+	var metadata []byte
+
+	err := c.db.QueryRowContext(ctx, query, releaseName).Scan(&metadata)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotExist
+		}
+		return nil, newQueryError(query, err, releaseName)
+	}
+
+	return metadata, nil
+}
+
+func (c *Client) Releases(ctx context.Context, filters []*storage.Filter, cb func(*storage.Release) error) error {
+	const queryStr = `
+	SELECT name, created_at,
+	       (SELECT base.name FROM release base WHERE base.id = release.base_release_id)
+	  FROM release
+	 `
+	var queryReturnedRows bool
+
+	q := query{
+		BaseQuery: queryStr,
+		Filters:   filters,
+		Sorters: []*storage.Sorter{
+			{Field: storage.FieldReleaseCreatedAt, Order: storage.OrderAsc},
+			{Field: storage.FieldReleaseName, Order: storage.OrderAsc},
+		},
+	}
+
+	query, args, err := q.Compile()
+	if err != nil {
+		return fmt.Errorf("compiling query string failed: %w", err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return newQueryError(query, err, args...)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var release storage.Release
+		var createdAt int64
+		var baseRelease sql.NullString
+
+		queryReturnedRows = true
+
+		if err := rows.Scan(&release.Name, &createdAt, &baseRelease); err != nil {
+			return newQueryError(query, err, args...)
+		}
+
+		release.CreatedAt = timeFromDB(createdAt)
+		release.BaseRelease = baseRelease.String
+
+		if err := cb(&release); err != nil {
+			return fmt.Errorf("callback failed: %w", err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return newQueryError(query, err, args...)
+	}
+
+	if !queryReturnedRows {
+		return storage.ErrNotExist
+	}
+
+	return nil
+}
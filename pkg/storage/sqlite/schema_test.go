@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func TestIsCompatible_AfterInit(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+	require.NoError(t, client.IsCompatible(ctx))
+}
+
+func TestInit_ReturnsErrExists(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+	require.ErrorIs(t, client.Init(ctx), storage.ErrExists)
+}
+
+func TestIsCompatible_SchemaNotExist(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	err := client.IsCompatible(ctx)
+	require.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+func TestIsCompatible_SchemaVersionDoesNotMatch(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	_, err := client.db.ExecContext(ctx, "UPDATE migrations set schema_version = 100")
+	require.NoError(t, err)
+
+	err = client.IsCompatible(ctx)
+	require.ErrorContains(t, err, "database schema version is not compatible")
+}
+
+func TestUpgrade_CreatesSchema(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Upgrade(ctx))
+	require.NoError(t, client.IsCompatible(ctx))
+
+	ver, err := client.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, client.MaxSchemaVersion(), ver)
+}
+
+func TestApplyMigrations(t *testing.T) {
+	client, cleanupFn := newTestClient(t)
+	defer cleanupFn()
+
+	require.NoError(t, client.Init(ctx))
+
+	err := client.applyMigrations(ctx, []*migration{
+		{
+			version: 2,
+			sql:     "CREATE table t1(id integer)",
+		},
+		{
+			version: 3,
+			sql:     "CREATE table t2(id integer)",
+		},
+	})
+	require.NoError(t, err)
+
+	exist, err := client.tableExists(ctx, "t1")
+	require.NoError(t, err)
+	require.True(t, exist, "t1 table does not exist")
+
+	exist, err = client.tableExists(ctx, "t2")
+	require.NoError(t, err)
+	require.True(t, exist, "t2 table does not exist")
+
+	ver, err := client.schemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), ver)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 	"time"
 )
 
@@ -34,11 +35,19 @@ type InputTaskInfo struct {
 	Digest string
 }
 
+// InputCommandOutput is the standard output of a command that is tracked as
+// input.
+type InputCommandOutput struct {
+	Command string
+	Digest  string
+}
+
 type Inputs struct {
 	Files                []*InputFile
 	Strings              []*InputString
 	EnvironmentVariables []*InputEnvVar
 	TaskInfo             []*InputTaskInfo
+	CommandOutputs       []*InputCommandOutput
 }
 
 // UploadMethod is the method that was used to upload the object
@@ -88,12 +97,30 @@ type TaskRun struct {
 	StopTimestamp    time.Time
 	TotalInputDigest string
 	Result           Result
+	// Labels are arbitrary key/value pairs that are attached to the
+	// task run, e.g. to record the CI context. Keys must not contain a
+	// "=" character.
+	Labels map[string]string
+}
+
+// LabelString returns the KEY=VALUE representation of a label.
+func LabelString(key, value string) string {
+	return key + "=" + value
+}
+
+// SplitLabelString splits the KEY=VALUE representation of a label into its
+// key and value. found is false if s does not contain a "=" character.
+func SplitLabelString(s string) (key, value string, found bool) {
+	return strings.Cut(s, "=")
 }
 
 type TaskRunFull struct {
 	TaskRun
 	Inputs  Inputs
 	Outputs []*Output
+	// Log is the gzip compressed combined stdout and stderr output of the
+	// task command. It is nil if no output was recorded.
+	Log []byte
 }
 
 type TaskRunWithID struct {
@@ -101,14 +128,36 @@ type TaskRunWithID struct {
 	TaskRun
 }
 
+// AppTaskDigest identifies the task runs of a task with a specific total
+// input digest.
+type AppTaskDigest struct {
+	AppName          string
+	TaskName         string
+	TotalInputDigest string
+}
+
 type ReleaseTaskRunsResult struct {
-	AppName      string
-	TaskName     string
-	RunID        int
-	OutputID     int
-	OutputName   string
-	URI          string
-	UploadMethod UploadMethod
+	AppName          string
+	TaskName         string
+	RunID            int
+	TotalInputDigest string
+	OutputID         int
+	OutputName       string
+	OutputType       ArtifactType
+	OutputDigest     string
+	OutputSizeBytes  uint64
+	URI              string
+	UploadMethod     UploadMethod
+}
+
+// Release describes a release.
+type Release struct {
+	Name      string
+	CreatedAt time.Time
+	// BaseRelease is the name of the release the release was derived
+	// from, it is empty if it was not derived from another release or the
+	// base release was deleted.
+	BaseRelease string
 }
 
 const (
@@ -123,7 +172,37 @@ type TaskRunsDeleteResult struct {
 	DeletedUploads  int64
 	DeletedInputs   int64
 	DeletedVCS      int64
+	// UnreferencedUploads are the uploads of the deleted task runs whose
+	// URIs are not referenced anymore by any remaining task run.
+	// Uploads of docker images are omitted when a remaining task run
+	// has a docker image output with the same digest.
+	// The artifacts they point to can be deleted.
+	// They are sorted by URI.
+	UnreferencedUploads []*UnreferencedUpload
+}
+
+// UnreferencedUpload is an upload destination that became unused by deleting
+// task runs.
+type UnreferencedUpload struct {
+	URI    string
+	Method UploadMethod
+}
+
+// TaskRunsRetention specifies task runs that are kept by
+// [Storer.TaskRunsDelete], independent of their age.
+// Task runs are ordered by their start timestamp to determine the newest.
+// The zero value does not keep any additional task runs.
+type TaskRunsRetention struct {
+	// KeepLast is the number of newest task runs per task that are kept.
+	KeepLast uint
+	// KeepLastSuccessful specifies if the newest successful task run of
+	// every task is kept.
+	KeepLastSuccessful bool
+	// KeepLastPerInputDigest is the number of newest task runs that are
+	// kept per task and total input digest.
+	KeepLastPerInputDigest uint
 }
+
 type ReleasesDeleteResult struct {
 	DeletedReleases int64
 }
@@ -150,8 +229,20 @@ type Storer interface {
 
 	SaveTaskRun(context.Context, *TaskRunFull) (id int, err error)
 	LatestTaskRunByDigest(ctx context.Context, appName, taskName, totalInputDigest string) (*TaskRunWithID, error)
+	// LatestTaskRunsByDigests returns the newest task run for each of the
+	// passed AppTaskDigests, it is the batched variant of
+	// LatestTaskRunByDigest.
+	// The returned map only contains elements for the AppTaskDigests
+	// for that a task run exists, if none exist an empty map is
+	// returned.
+	LatestTaskRunsByDigests(ctx context.Context, digests []AppTaskDigest) (map[AppTaskDigest]*TaskRunWithID, error)
 
 	TaskRun(ctx context.Context, id int) (*TaskRunWithID, error)
+	// TaskRunLog returns the log that was stored with the task run, see
+	// [TaskRunFull.Log].
+	// If the task run does not exist or has no log, ErrNotExist is
+	// returned.
+	TaskRunLog(ctx context.Context, taskRunID int) ([]byte, error)
 	// TaskRuns queries the storage for runs that match the filters.
 	// A limit value of 0 will return all results.
 	// The found results are passed in iterative manner to the callback
@@ -164,7 +255,13 @@ type Storer interface {
 		limit uint,
 		callback func(*TaskRunWithID) error,
 	) error
-	TaskRunsDelete(ctx context.Context, before time.Time, pretend bool) (*TaskRunsDeleteResult, error)
+	// TaskRunsDelete deletes task runs that started before the given time
+	// and records that are only referenced by them.
+	// Task runs that are referenced by a release or are retained by
+	// retention are not deleted.
+	// If pretend is true, nothing is deleted but the returned result
+	// contains the number of records that would have been deleted.
+	TaskRunsDelete(ctx context.Context, before time.Time, retention TaskRunsRetention, pretend bool) (*TaskRunsDeleteResult, error)
 
 	// Inputs returns the inputs of a task run. If no records were found,
 	// the method returns ErrNotExist.
@@ -174,12 +271,17 @@ type Storer interface {
 	// CreateRelease creates a new release called releaseName, that
 	// consists of the passed task runs. Metadata is arbitrary data stored
 	// together with the release, it is optional and can be nil.
-	CreateRelease(_ context.Context, releaseName string, createdAt time.Time, taskRunIDs []int, metadata io.Reader) error
+	// baseReleaseName is the name of the release the new release was
+	// derived from, it is optional and can be empty. If a base release is
+	// specified that does not exist, ErrNotExist is returned.
+	CreateRelease(_ context.Context, releaseName, baseReleaseName string, createdAt time.Time, taskRunIDs []int, metadata io.Reader) error
 	ReleaseExists(_ context.Context, name string) (bool, error)
 	// ReleaseTaskRuns the task runs and their outputs for the release
 	// named releaseName.
 	// If a task has no outputs the [ReleaseTaskRunsResult.OutputID]
-	// [ReleaseTaskRunsResult.OutputName], [ReleaseTaskRunsResult.URI],
+	// [ReleaseTaskRunsResult.OutputName], [ReleaseTaskRunsResult.OutputType],
+	// [ReleaseTaskRunsResult.OutputDigest],
+	// [ReleaseTaskRunsResult.OutputSizeBytes], [ReleaseTaskRunsResult.URI],
 	// [ReleaseTaskRunsResult.UploadMethod] fields are unset.
 	// If the release does not exist, ErrNotExist is returned.
 	ReleaseTaskRuns(ctx context.Context, releaseName string) ([]*ReleaseTaskRunsResult, error)
@@ -187,5 +289,16 @@ type Storer interface {
 	// If the release does not exist ErrNotExist is returned.
 	// If the release has no metadata the returned []byte is empty.
 	ReleaseMetadata(ctx context.Context, releaseName string) ([]byte, error)
+	// Releases passes the releases that match the filters, ordered
+	// ascending by their creation time, to the callback function.
+	// Only the [FieldReleaseName] and [FieldReleaseCreatedAt] fields are
+	// supported in filters.
+	// When the callback function returns an error, the iteration stops.
+	// If no matching releases exist, ErrNotExist is returned.
+	Releases(ctx context.Context, filters []*Filter, callback func(*Release) error) error
+	// ReleaseDelete deletes the release called name.
+	// The task runs of the release are not deleted.
+	// If the release does not exist, ErrNotExist is returned.
+	ReleaseDelete(ctx context.Context, name string) error
 	ReleasesDelete(ctx context.Context, before time.Time, pretend bool) (*ReleasesDeleteResult, error)
 }
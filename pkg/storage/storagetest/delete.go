@@ -0,0 +1,311 @@
+package storagetest
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func testTaskRunsDelete(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	oldID := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	newID := saveTaskRun(t, s, newTaskRun("app2", "build", "2", baseTime.Add(2*time.Hour), time.Minute))
+	before := baseTime.Add(time.Hour)
+
+	pretendResult, err := s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, true)
+	require.NoError(t, err)
+	require.NotNil(t, pretendResult)
+	assert.Equal(t, int64(1), pretendResult.DeletedTaskRuns)
+	assert.Equal(t, int64(1), pretendResult.DeletedApps)
+	assert.Equal(t, int64(1), pretendResult.DeletedOutputs)
+	assert.Equal(t, int64(1), pretendResult.DeletedUploads)
+
+	_, err = s.TaskRun(ctx, oldID)
+	require.NoError(t, err, "task run was deleted in pretend mode")
+
+	result, err := s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, pretendResult, result, "result of pretend and real delete operation differ")
+
+	_, err = s.TaskRun(ctx, oldID)
+	require.ErrorIs(t, err, storage.ErrNotExist)
+
+	_, err = s.TaskRun(ctx, newID)
+	require.NoError(t, err)
+
+	_, err = s.Outputs(ctx, newID)
+	require.NoError(t, err, "outputs of task run that was not deleted are missing")
+
+	_, err = s.Inputs(ctx, newID)
+	require.NoError(t, err, "inputs of task run that was not deleted are missing")
+
+	result, err = s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, false)
+	require.NoError(t, err)
+	assert.Zero(t, result.DeletedTaskRuns)
+}
+
+func testTaskRunsDeleteKeepsReleasedRuns(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	releasedID := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	unreleasedID := saveTaskRun(t, s, newTaskRun("app1", "check", "2", baseTime, time.Minute))
+
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{releasedID}, nil))
+
+	result, err := s.TaskRunsDelete(ctx, baseTime.Add(time.Hour), storage.TaskRunsRetention{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.DeletedTaskRuns)
+
+	_, err = s.TaskRun(ctx, releasedID)
+	require.NoError(t, err, "task run that is part of a release was deleted")
+
+	_, err = s.TaskRun(ctx, unreleasedID)
+	require.ErrorIs(t, err, storage.ErrNotExist)
+
+	runs, err := s.ReleaseTaskRuns(ctx, "v1")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, releasedID, runs[0].RunID)
+}
+
+func testTaskRunsDeleteUnreferencedUploads(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	// the upload URI is also referenced by the newer run that is kept
+	saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime.Add(2*time.Hour), time.Minute))
+
+	tr := newTaskRun("app1", "check", "2", baseTime, time.Minute)
+	tr.Outputs[0].Uploads = append(tr.Outputs[0].Uploads, &storage.Upload{
+		URI:                  "/artifacts/app1-check-2",
+		UploadStartTimestamp: baseTime,
+		UploadStopTimestamp:  baseTime,
+		Method:               storage.UploadMethodFileCopy,
+	})
+	saveTaskRun(t, s, tr)
+
+	releasedID := saveTaskRun(t, s, newTaskRun("app2", "build", "3", baseTime, time.Minute))
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{releasedID}, nil))
+
+	before := baseTime.Add(time.Hour)
+	expected := []*storage.UnreferencedUpload{
+		{URI: "/artifacts/app1-check-2", Method: storage.UploadMethodFileCopy},
+		{URI: "s3://bucket/app1-check-2", Method: storage.UploadMethodS3},
+	}
+
+	pretendResult, err := s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, expected, pretendResult.UnreferencedUploads)
+
+	result, err := s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result.UnreferencedUploads)
+	assert.Equal(t, int64(3), result.DeletedUploads)
+
+	result, err = s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, false)
+	require.NoError(t, err)
+	assert.Empty(t, result.UnreferencedUploads)
+}
+
+func newImageTaskRun(appName, totalInputDigest, imageDigest, uri string, start time.Time) *storage.TaskRunFull {
+	tr := newTaskRun(appName, "docker", totalInputDigest, start, time.Minute)
+	tr.Outputs = []*storage.Output{
+		{
+			Name:      "image",
+			Type:      storage.ArtifactTypeDocker,
+			Digest:    imageDigest,
+			SizeBytes: 1024,
+			Uploads: []*storage.Upload{
+				{
+					URI:                  uri,
+					UploadStartTimestamp: start,
+					UploadStopTimestamp:  start,
+					Method:               storage.UploadMethodDockerRegistry,
+				},
+			},
+		},
+	}
+
+	return tr
+}
+
+func testTaskRunsDeleteUnreferencedUploadsSharedImage(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	// the image of the deleted run is also tagged by the kept run
+	saveTaskRun(t, s, newImageTaskRun("app1", "1", "sha256:1", "registry/app1:1", baseTime))
+	saveTaskRun(t, s, newImageTaskRun("app1", "2", "sha256:1", "registry/app1:2", baseTime.Add(2*time.Hour)))
+	saveTaskRun(t, s, newImageTaskRun("app2", "3", "sha256:2", "registry/app2:3", baseTime))
+
+	before := baseTime.Add(time.Hour)
+	expected := []*storage.UnreferencedUpload{
+		{URI: "registry/app2:3", Method: storage.UploadMethodDockerRegistry},
+	}
+
+	pretendResult, err := s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, expected, pretendResult.UnreferencedUploads)
+
+	result, err := s.TaskRunsDelete(ctx, before, storage.TaskRunsRetention{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, expected, result.UnreferencedUploads)
+	assert.Equal(t, int64(2), result.DeletedTaskRuns)
+	assert.Equal(t, int64(2), result.DeletedUploads)
+}
+
+func testReleasesDelete(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	id := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+
+	require.NoError(t, s.CreateRelease(ctx, "old", "", baseTime, []int{id}, nil))
+	require.NoError(t, s.CreateRelease(ctx, "new", "", baseTime.Add(2*time.Hour), []int{id}, nil))
+	before := baseTime.Add(time.Hour)
+
+	result, err := s.ReleasesDelete(ctx, before, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.DeletedReleases)
+
+	exists, err := s.ReleaseExists(ctx, "old")
+	require.NoError(t, err)
+	assert.True(t, exists, "release was deleted in pretend mode")
+
+	result, err = s.ReleasesDelete(ctx, before, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.DeletedReleases)
+
+	exists, err = s.ReleaseExists(ctx, "old")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = s.ReleaseExists(ctx, "new")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = s.TaskRun(ctx, id)
+	require.NoError(t, err, "deleting a release deleted its task runs")
+}
+
+func testTaskRunsDeleteRetention(t *testing.T, newStorer func() storage.Storer) {
+	type run struct {
+		digest string
+		result storage.Result
+	}
+
+	// runs of app1.build, ordered from old to new
+	runs := []run{
+		{digest: "1", result: storage.ResultSuccess},
+		{digest: "1", result: storage.ResultSuccess},
+		{digest: "2", result: storage.ResultSuccess},
+		{digest: "2", result: storage.ResultFailure},
+		{digest: "3", result: storage.ResultFailure},
+	}
+
+	testcases := []struct {
+		name      string
+		retention storage.TaskRunsRetention
+		// kept contains the indexes in runs of the task runs that are
+		// expected to be kept
+		kept []int
+		// otherTaskKept is true if the single run of app1.check is
+		// expected to be kept
+		otherTaskKept bool
+	}{
+		{
+			name: "none",
+			kept: []int{},
+		},
+		{
+			name:          "keepLast",
+			retention:     storage.TaskRunsRetention{KeepLast: 2},
+			kept:          []int{3, 4},
+			otherTaskKept: true,
+		},
+		{
+			name:          "keepLastExceedsRuns",
+			retention:     storage.TaskRunsRetention{KeepLast: 100},
+			kept:          []int{0, 1, 2, 3, 4},
+			otherTaskKept: true,
+		},
+		{
+			name:          "keepLastSuccessful",
+			retention:     storage.TaskRunsRetention{KeepLastSuccessful: true},
+			kept:          []int{2},
+			otherTaskKept: true,
+		},
+		{
+			name:          "keepLastPerInputDigest",
+			retention:     storage.TaskRunsRetention{KeepLastPerInputDigest: 1},
+			kept:          []int{1, 3, 4},
+			otherTaskKept: true,
+		},
+		{
+			name: "combined",
+			retention: storage.TaskRunsRetention{
+				KeepLast:           1,
+				KeepLastSuccessful: true,
+			},
+			kept:          []int{2, 4},
+			otherTaskKept: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newInitializedStorer(t, newStorer)
+
+			ids := make([]int, 0, len(runs))
+			for i, r := range runs {
+				tr := newTaskRun("app1", "build", r.digest, baseTime.Add(time.Duration(i)*time.Minute), time.Second)
+				tr.Result = r.result
+				ids = append(ids, saveTaskRun(t, s, tr))
+			}
+
+			otherTaskID := saveTaskRun(t, s, newTaskRun("app1", "check", "1", baseTime, time.Second))
+
+			// a released run is kept even if it is not retained
+			releasedID := saveTaskRun(t, s, newTaskRun("app2", "build", "1", baseTime, time.Second))
+			require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{releasedID}, nil))
+
+			before := baseTime.Add(time.Hour)
+
+			pretendResult, err := s.TaskRunsDelete(ctx, before, tc.retention, true)
+			require.NoError(t, err)
+
+			result, err := s.TaskRunsDelete(ctx, before, tc.retention, false)
+			require.NoError(t, err)
+			assert.Equal(t, pretendResult, result, "result of pretend and real delete operation differ")
+
+			for i, id := range ids {
+				_, err := s.TaskRun(ctx, id)
+				if slices.Contains(tc.kept, i) {
+					require.NoError(t, err, "task run %d was deleted", i)
+				} else {
+					require.ErrorIs(t, err, storage.ErrNotExist, "task run %d was not deleted", i)
+				}
+			}
+
+			_, err = s.TaskRun(ctx, releasedID)
+			require.NoError(t, err, "task run that is part of a release was deleted")
+
+			_, err = s.TaskRun(ctx, otherTaskID)
+			if tc.otherTaskKept {
+				require.NoError(t, err, "task run of app1.check was deleted")
+			} else {
+				require.ErrorIs(t, err, storage.ErrNotExist, "task run of app1.check was not deleted")
+			}
+
+			expectedDeleted := len(runs) - len(tc.kept)
+			if !tc.otherTaskKept {
+				expectedDeleted++
+			}
+			assert.Equal(t, int64(expectedDeleted), result.DeletedTaskRuns)
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package storagetest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func testCreateRelease(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	id := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+
+	exists, err := s.ReleaseExists(ctx, "v1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{id}, nil))
+
+	exists, err = s.ReleaseExists(ctx, "v1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	err = s.CreateRelease(ctx, "v1", "", baseTime, []int{id}, nil)
+	require.ErrorIs(t, err, storage.ErrExists)
+}
+
+func testReleaseMetadata(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	id := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	metadata := []byte("hello\x00world")
+
+	require.NoError(t, s.CreateRelease(ctx, "withMetadata", "", baseTime, []int{id}, bytes.NewReader(metadata)))
+	require.NoError(t, s.CreateRelease(ctx, "withoutMetadata", "", baseTime, []int{id}, nil))
+
+	result, err := s.ReleaseMetadata(ctx, "withMetadata")
+	require.NoError(t, err)
+	assert.Equal(t, metadata, result)
+
+	result, err = s.ReleaseMetadata(ctx, "withoutMetadata")
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	_, err = s.ReleaseMetadata(ctx, "unknown")
+	require.ErrorIs(t, err, storage.ErrNotExist)
+}
+
+func testReleaseTaskRuns(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	noOutputs := newTaskRun("app1", "check", "1", baseTime, time.Minute)
+	noOutputs.Outputs = nil
+	noOutputsID := saveTaskRun(t, s, noOutputs)
+
+	withOutputs := newTaskRun("app1", "build", "2", baseTime, time.Minute)
+	withOutputs.Outputs[0].Uploads = append(withOutputs.Outputs[0].Uploads, &storage.Upload{
+		URI:                  "file:///tmp/binary",
+		UploadStartTimestamp: baseTime,
+		UploadStopTimestamp:  baseTime.Add(time.Second),
+		Method:               storage.UploadMethodFileCopy,
+	})
+	withOutputsID := saveTaskRun(t, s, withOutputs)
+
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{noOutputsID, withOutputsID}, nil))
+
+	result, err := s.ReleaseTaskRuns(ctx, "v1")
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+
+	// results are sorted by app name, task name, output name and URI
+	assert.Equal(t, "build", result[0].TaskName)
+	assert.Equal(t, "build", result[1].TaskName)
+	require.NotZero(t, result[0].OutputID)
+	assert.Equal(t, result[0].OutputID, result[1].OutputID, "uploads of the same output have different output IDs")
+
+	assert.Equal(t,
+		&storage.ReleaseTaskRunsResult{
+			AppName:          "app1",
+			TaskName:         "build",
+			RunID:            withOutputsID,
+			TotalInputDigest: withOutputs.TotalInputDigest,
+			OutputID:         result[0].OutputID,
+			OutputName:       "binary",
+			OutputType:       withOutputs.Outputs[0].Type,
+			OutputDigest:     withOutputs.Outputs[0].Digest,
+			OutputSizeBytes:  withOutputs.Outputs[0].SizeBytes,
+			URI:              "file:///tmp/binary",
+			UploadMethod:     storage.UploadMethodFileCopy,
+		},
+		result[0],
+	)
+	assert.Equal(t,
+		&storage.ReleaseTaskRunsResult{
+			AppName:          "app1",
+			TaskName:         "build",
+			RunID:            withOutputsID,
+			TotalInputDigest: withOutputs.TotalInputDigest,
+			OutputID:         result[0].OutputID,
+			OutputName:       "binary",
+			OutputType:       withOutputs.Outputs[0].Type,
+			OutputDigest:     withOutputs.Outputs[0].Digest,
+			OutputSizeBytes:  withOutputs.Outputs[0].SizeBytes,
+			URI:              withOutputs.Outputs[0].Uploads[0].URI,
+			UploadMethod:     storage.UploadMethodS3,
+		},
+		result[1],
+	)
+	assert.Equal(t,
+		&storage.ReleaseTaskRunsResult{
+			AppName:          "app1",
+			TaskName:         "check",
+			RunID:            noOutputsID,
+			TotalInputDigest: noOutputs.TotalInputDigest,
+		},
+		result[2],
+		"result for task without outputs has output fields set",
+	)
+}
+
+func testReleaseTaskRunsReturnsErrNotExist(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	result, err := s.ReleaseTaskRuns(ctx, "unknown")
+	require.ErrorIs(t, err, storage.ErrNotExist)
+	assert.Empty(t, result)
+}
+
+func testReleases(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	err := s.Releases(ctx, nil, func(*storage.Release) error { return nil })
+	require.ErrorIs(t, err, storage.ErrNotExist)
+
+	id := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	require.NoError(t, s.CreateRelease(ctx, "v2", "", baseTime.Add(time.Hour), []int{id}, nil))
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{id}, nil))
+
+	var result []*storage.Release
+	err = s.Releases(ctx, nil, func(r *storage.Release) error {
+		result = append(result, &storage.Release{Name: r.Name, CreatedAt: normalizeTime(r.CreatedAt)})
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]*storage.Release{
+			{Name: "v1", CreatedAt: baseTime},
+			{Name: "v2", CreatedAt: baseTime.Add(time.Hour)},
+		},
+		result,
+	)
+}
+
+func releaseNames(t *testing.T, s storage.Storer, filters []*storage.Filter) []string {
+	t.Helper()
+
+	var result []string
+	err := s.Releases(ctx, filters, func(r *storage.Release) error {
+		result = append(result, r.Name)
+		return nil
+	})
+	if errors.Is(err, storage.ErrNotExist) {
+		return nil
+	}
+	require.NoError(t, err)
+
+	return result
+}
+
+func testReleasesFilters(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	id := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	require.NoError(t, s.CreateRelease(ctx, "v1.0", "", baseTime, []int{id}, nil))
+	require.NoError(t, s.CreateRelease(ctx, "v1.1", "", baseTime.Add(time.Hour), []int{id}, nil))
+	require.NoError(t, s.CreateRelease(ctx, "v2.0", "", baseTime.Add(2*time.Hour), []int{id}, nil))
+
+	testcases := []struct {
+		name     string
+		filters  []*storage.Filter
+		expected []string
+	}{
+		{
+			name:     "eq",
+			filters:  []*storage.Filter{{Field: storage.FieldReleaseName, Operator: storage.OpEQ, Value: "v1.1"}},
+			expected: []string{"v1.1"},
+		},
+		{
+			name:     "ne",
+			filters:  []*storage.Filter{{Field: storage.FieldReleaseName, Operator: storage.OpNE, Value: "v1.1"}},
+			expected: []string{"v1.0", "v2.0"},
+		},
+		{
+			name:     "in",
+			filters:  []*storage.Filter{{Field: storage.FieldReleaseName, Operator: storage.OpIN, Value: []string{"v1.0", "v2.0"}}},
+			expected: []string{"v1.0", "v2.0"},
+		},
+		{
+			name:     "prefix",
+			filters:  []*storage.Filter{{Field: storage.FieldReleaseName, Operator: storage.OpPrefix, Value: "v1."}},
+			expected: []string{"v1.0", "v1.1"},
+		},
+		{
+			name: "created_at",
+			filters: []*storage.Filter{
+				{Field: storage.FieldReleaseCreatedAt, Operator: storage.OpGT, Value: baseTime},
+				{Field: storage.FieldReleaseCreatedAt, Operator: storage.OpLT, Value: baseTime.Add(2 * time.Hour)},
+			},
+			expected: []string{"v1.1"},
+		},
+		{
+			name:    "no_match",
+			filters: []*storage.Filter{{Field: storage.FieldReleaseName, Operator: storage.OpEQ, Value: "v3"}},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, releaseNames(t, s, tc.filters))
+		})
+	}
+}
+
+func testReleaseDelete(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	id := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{id}, nil))
+	require.NoError(t, s.CreateRelease(ctx, "v2", "", baseTime, []int{id}, nil))
+
+	require.NoError(t, s.ReleaseDelete(ctx, "v1"))
+	require.ErrorIs(t, s.ReleaseDelete(ctx, "v1"), storage.ErrNotExist)
+
+	exists, err := s.ReleaseExists(ctx, "v1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.Equal(t, []string{"v2"}, releaseNames(t, s, nil))
+
+	_, err = s.TaskRun(ctx, id)
+	require.NoError(t, err, "task run of the deleted release was deleted")
+}
+
+func testReleaseBase(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	id := saveTaskRun(t, s, newTaskRun("app1", "build", "1", baseTime, time.Minute))
+	require.NoError(t, s.CreateRelease(ctx, "v1", "", baseTime, []int{id}, nil))
+	require.NoError(t, s.CreateRelease(ctx, "v1.1", "v1", baseTime.Add(time.Hour), []int{id}, nil))
+
+	err := s.CreateRelease(ctx, "v2", "unknown", baseTime.Add(2*time.Hour), []int{id}, nil)
+	require.ErrorIs(t, err, storage.ErrNotExist)
+
+	exists, err := s.ReleaseExists(ctx, "v2")
+	require.NoError(t, err)
+	assert.False(t, exists, "release with non-existing base was created")
+
+	baseReleases := func() map[string]string {
+		result := map[string]string{}
+		err := s.Releases(ctx, nil, func(r *storage.Release) error {
+			result[r.Name] = r.BaseRelease
+			return nil
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	assert.Equal(t, map[string]string{"v1": "", "v1.1": "v1"}, baseReleases())
+
+	require.NoError(t, s.ReleaseDelete(ctx, "v1"))
+	assert.Equal(t, map[string]string{"v1.1": ""}, baseReleases())
+}
@@ -0,0 +1,188 @@
+// Package storagetest provides a conformance test suite for
+// [storage.Storer] implementations.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+var ctx = context.Background()
+
+// baseTime is the start timestamp of the task runs that are created by the
+// tests. It has no sub-second part to prevent that the comparison of
+// timestamps fail because of the precision in that they are stored.
+var baseTime = time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+type testFunc func(t *testing.T, newStorer func() storage.Storer)
+
+// Run runs the conformance tests against the storage implementation returned
+// by newStorer.
+// newStorer is called at least once per test and must return a Storer for an
+// empty storage that has not been initialized.
+// The tests do not close the returned Storers, releasing them is the
+// responsibility of the caller.
+func Run(t *testing.T, newStorer func() storage.Storer) {
+	tests := []struct {
+		name string
+		fn   testFunc
+	}{
+		{"Init", testInit},
+		{"SaveTaskRunAndTaskRun", testSaveTaskRunAndTaskRun},
+		{"SaveTaskRunWithoutVCSAndOutputs", testSaveTaskRunWithoutVCSAndOutputs},
+		{"TaskRunReturnsErrNotExist", testTaskRunReturnsErrNotExist},
+		{"LatestTaskRunByDigest", testLatestTaskRunByDigest},
+		{"LatestTaskRunsByDigests", testLatestTaskRunsByDigests},
+		{"Inputs", testInputs},
+		{"InputsReturnsErrNotExist", testInputsReturnsErrNotExist},
+		{"Outputs", testOutputs},
+		{"OutputsReturnsErrNotExist", testOutputsReturnsErrNotExist},
+		{"TaskRunLog", testTaskRunLog},
+		{"TaskRunsFilters", testTaskRunsFilters},
+		{"TaskRunsSorters", testTaskRunsSorters},
+		{"TaskRunsLimit", testTaskRunsLimit},
+		{"TaskRunsInputStringAndFileFilterFails", testTaskRunsInputStringAndFileFilterFails},
+		{"TaskRunsCallbackError", testTaskRunsCallbackError},
+		{"TaskRunsDelete", testTaskRunsDelete},
+		{"TaskRunsDeleteKeepsReleasedRuns", testTaskRunsDeleteKeepsReleasedRuns},
+		{"TaskRunsDeleteRetention", testTaskRunsDeleteRetention},
+		{"TaskRunsDeleteUnreferencedUploads", testTaskRunsDeleteUnreferencedUploads},
+		{"TaskRunsDeleteUnreferencedUploadsSharedImage", testTaskRunsDeleteUnreferencedUploadsSharedImage},
+		{"CreateRelease", testCreateRelease},
+		{"ReleaseMetadata", testReleaseMetadata},
+		{"ReleaseTaskRuns", testReleaseTaskRuns},
+		{"ReleaseTaskRunsReturnsErrNotExist", testReleaseTaskRunsReturnsErrNotExist},
+		{"Releases", testReleases},
+		{"ReleasesFilters", testReleasesFilters},
+		{"ReleaseDelete", testReleaseDelete},
+		{"ReleaseBase", testReleaseBase},
+		{"ReleasesDelete", testReleasesDelete},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.fn(t, newStorer)
+		})
+	}
+}
+
+// newInitializedStorer calls newStorer and initializes the returned storage.
+func newInitializedStorer(t *testing.T, newStorer func() storage.Storer) storage.Storer {
+	t.Helper()
+
+	s := newStorer()
+	require.NoError(t, s.Init(ctx), "initializing storage failed")
+
+	return s
+}
+
+// newTaskRun returns a TaskRunFull with a file input and an output that was
+// uploaded to 1 destination.
+func newTaskRun(appName, taskName, totalInputDigest string, start time.Time, duration time.Duration) *storage.TaskRunFull {
+	return &storage.TaskRunFull{
+		TaskRun: storage.TaskRun{
+			ApplicationName:  appName,
+			TaskName:         taskName,
+			VCSRevision:      "0c5ee0a4b4d1c5e7a3a9b6e9d2f0c3c1c9f1a2b3",
+			VCSIsDirty:       false,
+			StartTimestamp:   start,
+			StopTimestamp:    start.Add(duration),
+			Result:           storage.ResultSuccess,
+			TotalInputDigest: totalInputDigest,
+		},
+		Inputs: storage.Inputs{
+			Files: []*storage.InputFile{
+				{
+					Path:   "main.go",
+					Digest: "sha384:45",
+				},
+			},
+		},
+		Outputs: []*storage.Output{
+			{
+				Name:      "binary",
+				Type:      storage.ArtifactTypeFile,
+				Digest:    "sha384:" + totalInputDigest,
+				SizeBytes: 300,
+				Uploads: []*storage.Upload{
+					{
+						URI:                  "s3://bucket/" + appName + "-" + taskName + "-" + totalInputDigest,
+						UploadStartTimestamp: start.Add(duration),
+						UploadStopTimestamp:  start.Add(duration + time.Second),
+						Method:               storage.UploadMethodS3,
+					},
+				},
+			},
+		},
+	}
+}
+
+func saveTaskRun(t *testing.T, s storage.Storer, tr *storage.TaskRunFull) int {
+	t.Helper()
+
+	id, err := s.SaveTaskRun(ctx, tr)
+	require.NoError(t, err, "saving task run failed")
+	require.Positive(t, id, "SaveTaskRun returned an invalid id")
+
+	return id
+}
+
+// normalizeTime returns t in UTC, to make timestamps comparable
+// independent of the time zone in that they are returned by the storage.
+func normalizeTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func normalizeTaskRun(tr storage.TaskRun) storage.TaskRun {
+	tr.StartTimestamp = normalizeTime(tr.StartTimestamp)
+	tr.StopTimestamp = normalizeTime(tr.StopTimestamp)
+
+	return tr
+}
+
+func normalizeTaskRunWithID(tr *storage.TaskRunWithID) *storage.TaskRunWithID {
+	return &storage.TaskRunWithID{
+		ID:      tr.ID,
+		TaskRun: normalizeTaskRun(tr.TaskRun),
+	}
+}
+
+func normalizeOutputs(outputs []*storage.Output) []*storage.Output {
+	result := make([]*storage.Output, 0, len(outputs))
+
+	for _, o := range outputs {
+		out := *o
+		out.Uploads = make([]*storage.Upload, 0, len(o.Uploads))
+
+		for _, u := range o.Uploads {
+			upload := *u
+			upload.UploadStartTimestamp = normalizeTime(upload.UploadStartTimestamp)
+			upload.UploadStopTimestamp = normalizeTime(upload.UploadStopTimestamp)
+			out.Uploads = append(out.Uploads, &upload)
+		}
+
+		result = append(result, &out)
+	}
+
+	return result
+}
+
+func testInit(t *testing.T, newStorer func() storage.Storer) {
+	s := newStorer()
+
+	require.ErrorIs(t, s.IsCompatible(ctx), storage.ErrNotExist)
+
+	require.NoError(t, s.Init(ctx))
+	require.NoError(t, s.IsCompatible(ctx))
+
+	require.ErrorIs(t, s.Init(ctx), storage.ErrExists)
+
+	ver, err := s.SchemaVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, s.MaxSchemaVersion(), ver)
+}
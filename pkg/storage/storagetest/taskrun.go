@@ -0,0 +1,773 @@
+package storagetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplesurance/baur/v5/pkg/storage"
+)
+
+func testSaveTaskRunAndTaskRun(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+	tr.VCSIsDirty = true
+	tr.Result = storage.ResultFailure
+	tr.Labels = map[string]string{
+		"ci":      "true",
+		"job_url": "https://ci.example.com/job/1?a=b",
+		"user":    "",
+	}
+
+	id := saveTaskRun(t, s, tr)
+
+	stored, err := s.TaskRun(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+
+	assert.Equal(t, id, stored.ID)
+	assert.Equal(t, normalizeTaskRun(tr.TaskRun), normalizeTaskRun(stored.TaskRun))
+
+	id2 := saveTaskRun(t, s, tr)
+	assert.NotEqual(t, id, id2, "SaveTaskRun returned the same id for 2 runs")
+}
+
+func testSaveTaskRunWithoutVCSAndOutputs(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+	tr.VCSRevision = ""
+	tr.Outputs = nil
+
+	id := saveTaskRun(t, s, tr)
+
+	stored, err := s.TaskRun(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+
+	assert.Equal(t, id, stored.ID)
+	assert.Equal(t, normalizeTaskRun(tr.TaskRun), normalizeTaskRun(stored.TaskRun))
+}
+
+func testTaskRunReturnsErrNotExist(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	tr, err := s.TaskRun(ctx, 113124)
+	require.ErrorIs(t, err, storage.ErrNotExist)
+	assert.Nil(t, tr)
+}
+
+func testLatestTaskRunByDigest(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	run1 := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+	run2 := newTaskRun("baurHimself", "build", "1234567890", baseTime, 6*time.Minute)
+	run2.Labels = map[string]string{"branch": "main"}
+	otherDigest := newTaskRun("baurHimself", "build", "999", baseTime, 10*time.Minute)
+
+	saveTaskRun(t, s, run1)
+	id := saveTaskRun(t, s, run2)
+	saveTaskRun(t, s, otherDigest)
+
+	latest, err := s.LatestTaskRunByDigest(ctx, run2.ApplicationName, run2.TaskName, run2.TotalInputDigest)
+	require.NoError(t, err)
+
+	assert.Equal(t, id, latest.ID, "wrong record id")
+	assert.Equal(t, normalizeTaskRun(run2.TaskRun), normalizeTaskRun(latest.TaskRun))
+
+	t.Run("unknownDigest", func(t *testing.T) {
+		tr, err := s.LatestTaskRunByDigest(ctx, run2.ApplicationName, run2.TaskName, "241abc")
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, tr)
+	})
+
+	t.Run("unknownTask", func(t *testing.T) {
+		tr, err := s.LatestTaskRunByDigest(ctx, run2.ApplicationName, "check", run2.TotalInputDigest)
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, tr)
+	})
+}
+
+func testLatestTaskRunsByDigests(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	run1 := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+	run2 := newTaskRun("baurHimself", "build", "1234567890", baseTime, 6*time.Minute)
+	run2.Labels = map[string]string{"branch": "main"}
+	otherDigest := newTaskRun("baurHimself", "build", "999", baseTime, 10*time.Minute)
+	otherTask := newTaskRun("baurHimself", "check", "1234567890", baseTime, time.Minute)
+	otherApp := newTaskRun("shop", "build", "1234567890", baseTime, time.Minute)
+
+	saveTaskRun(t, s, run1)
+	run2ID := saveTaskRun(t, s, run2)
+	otherDigestID := saveTaskRun(t, s, otherDigest)
+	saveTaskRun(t, s, otherTask)
+	otherAppID := saveTaskRun(t, s, otherApp)
+
+	keyOf := func(tr *storage.TaskRunFull) storage.AppTaskDigest {
+		return storage.AppTaskDigest{
+			AppName:          tr.ApplicationName,
+			TaskName:         tr.TaskName,
+			TotalInputDigest: tr.TotalInputDigest,
+		}
+	}
+	unknown := storage.AppTaskDigest{AppName: "baurHimself", TaskName: "check", TotalInputDigest: "999"}
+
+	result, err := s.LatestTaskRunsByDigests(ctx, []storage.AppTaskDigest{
+		keyOf(run1),
+		keyOf(otherDigest),
+		keyOf(otherApp),
+		keyOf(run1),
+		unknown,
+	})
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+
+	require.Contains(t, result, keyOf(run2))
+	assert.Equal(t, run2ID, result[keyOf(run2)].ID, "wrong record id")
+	assert.Equal(t, normalizeTaskRun(run2.TaskRun), normalizeTaskRun(result[keyOf(run2)].TaskRun))
+
+	require.Contains(t, result, keyOf(otherDigest))
+	assert.Equal(t, otherDigestID, result[keyOf(otherDigest)].ID)
+
+	require.Contains(t, result, keyOf(otherApp))
+	assert.Equal(t, otherAppID, result[keyOf(otherApp)].ID)
+
+	t.Run("noDigests", func(t *testing.T) {
+		result, err := s.LatestTaskRunsByDigests(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("onlyUnknownDigests", func(t *testing.T) {
+		result, err := s.LatestTaskRunsByDigests(ctx, []storage.AppTaskDigest{unknown})
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+}
+
+func testInputs(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+	tr.Inputs = storage.Inputs{
+		Files: []*storage.InputFile{
+			{Path: "main.go", Digest: "45"},
+			{Path: "util.go", Digest: "46"},
+			{Path: "file://Makefile", Digest: "47"},
+		},
+		Strings: []*storage.InputString{
+			{String: "hello", Digest: "45"},
+			{String: "bye", Digest: "46"},
+		},
+		EnvironmentVariables: []*storage.InputEnvVar{
+			{Name: "VER", Digest: "45"},
+			{Name: "MYNUMBER", Digest: "9"},
+		},
+		TaskInfo: []*storage.InputTaskInfo{
+			{Name: "shop.build", Digest: "123"},
+		},
+		CommandOutputs: []*storage.InputCommandOutput{
+			{Command: "go version", Digest: "45"},
+			{Command: "node --version", Digest: "46"},
+		},
+	}
+
+	id := saveTaskRun(t, s, tr)
+
+	inputs, err := s.Inputs(ctx, id)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, tr.Inputs.Files, inputs.Files)
+	assert.ElementsMatch(t, tr.Inputs.Strings, inputs.Strings)
+	assert.ElementsMatch(t, tr.Inputs.EnvironmentVariables, inputs.EnvironmentVariables)
+	assert.ElementsMatch(t, tr.Inputs.TaskInfo, inputs.TaskInfo)
+	assert.ElementsMatch(t, tr.Inputs.CommandOutputs, inputs.CommandOutputs)
+}
+
+func testInputsReturnsErrNotExist(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	t.Run("runNotExist", func(t *testing.T) {
+		inputs, err := s.Inputs(ctx, 113124)
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, inputs)
+	})
+
+	t.Run("runWithoutInputs", func(t *testing.T) {
+		tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+		tr.Inputs = storage.Inputs{}
+		id := saveTaskRun(t, s, tr)
+
+		inputs, err := s.Inputs(ctx, id)
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, inputs)
+	})
+}
+
+func testOutputs(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+	tr.Outputs = append(tr.Outputs, &storage.Output{
+		Name:      "binary2",
+		Type:      storage.ArtifactTypeDocker,
+		Digest:    "sha256:4561",
+		SizeBytes: 2,
+		Uploads: []*storage.Upload{
+			{
+				URI:                  "registry.example.com/baur:latest",
+				UploadStartTimestamp: baseTime,
+				UploadStopTimestamp:  baseTime.Add(5 * time.Second),
+				Method:               storage.UploadMethodDockerRegistry,
+			},
+			{
+				URI:                  "file:///tmp/image.tar",
+				UploadStartTimestamp: baseTime,
+				UploadStopTimestamp:  baseTime.Add(5 * time.Second),
+				Method:               storage.UploadMethodFileCopy,
+			},
+		},
+	})
+
+	id := saveTaskRun(t, s, tr)
+
+	outputs, err := s.Outputs(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, outputs, len(tr.Outputs))
+
+	for _, o := range normalizeOutputs(outputs) {
+		var expected *storage.Output
+		for _, exp := range normalizeOutputs(tr.Outputs) {
+			if exp.Name == o.Name {
+				expected = exp
+				break
+			}
+		}
+		require.NotNilf(t, expected, "unexpected output %q returned", o.Name)
+
+		assert.Equal(t, expected.Type, o.Type)
+		assert.Equal(t, expected.Digest, o.Digest)
+		assert.Equal(t, expected.SizeBytes, o.SizeBytes)
+		assert.ElementsMatch(t, expected.Uploads, o.Uploads)
+	}
+}
+
+func testOutputsReturnsErrNotExist(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	t.Run("runNotExist", func(t *testing.T) {
+		outputs, err := s.Outputs(ctx, 113124)
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, outputs)
+	})
+
+	t.Run("runWithoutOutputs", func(t *testing.T) {
+		tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+		tr.Outputs = nil
+		id := saveTaskRun(t, s, tr)
+
+		outputs, err := s.Outputs(ctx, id)
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, outputs)
+	})
+}
+
+func testTaskRunLog(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+
+	tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+	tr.Log = []byte{0x1f, 0x8b, 0x08, 0x00, 'l', 'o', 'g', 0x00, 0xff}
+	id := saveTaskRun(t, s, tr)
+
+	log, err := s.TaskRunLog(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, tr.Log, log)
+
+	t.Run("runWithoutLog", func(t *testing.T) {
+		tr := newTaskRun("baurHimself", "build", "1234567890", baseTime, 5*time.Minute)
+		id := saveTaskRun(t, s, tr)
+
+		log, err := s.TaskRunLog(ctx, id)
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, log)
+	})
+
+	t.Run("runNotExist", func(t *testing.T) {
+		log, err := s.TaskRunLog(ctx, 113124)
+		require.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Nil(t, log)
+	})
+}
+
+// taskRunsFixture stores 3 task runs in s and returns them with their IDs.
+//
+//	app   | task  | start          | duration | input files | input strings | result  | vcs
+//	app1  | build | baseTime       | 1m       | main.go     | hello         | success | 0c5ee0a4…
+//	app1  | check | baseTime + 1h  | 3m       | util.go     |               | failure | 0c5ee0a4…
+//	app2  | build | baseTime + 2h  | 2m       | main.go     | bye           | success | f1e2d3c4…, dirty
+//
+// The total input digests are 1, 2 and 3, the output digests are sha384:1,
+// sha384:2 and sha384:3.
+// The first run has the labels ci=true and branch=main, the second ci=true and
+// branch=feature/x, the third has no labels.
+func taskRunsFixture(t *testing.T, s storage.Storer) (r1, r2, r3 *storage.TaskRunWithID) {
+	t.Helper()
+
+	tr1 := newTaskRun("app1", "build", "1", baseTime, time.Minute)
+	tr1.Inputs.Strings = []*storage.InputString{{String: "hello", Digest: "10"}}
+	tr1.Labels = map[string]string{"ci": "true", "branch": "main"}
+
+	tr2 := newTaskRun("app1", "check", "2", baseTime.Add(time.Hour), 3*time.Minute)
+	tr2.Inputs.Files = []*storage.InputFile{{Path: "util.go", Digest: "11"}}
+	tr2.Result = storage.ResultFailure
+	tr2.Labels = map[string]string{"ci": "true", "branch": "feature/x"}
+
+	tr3 := newTaskRun("app2", "build", "3", baseTime.Add(2*time.Hour), 2*time.Minute)
+	tr3.Inputs.Strings = []*storage.InputString{{String: "bye", Digest: "12"}}
+	tr3.VCSRevision = "f1e2d3c4b5a6f1e2d3c4b5a6f1e2d3c4b5a6f1e2"
+	tr3.VCSIsDirty = true
+
+	withID := func(tr *storage.TaskRunFull) *storage.TaskRunWithID {
+		return &storage.TaskRunWithID{
+			ID:      saveTaskRun(t, s, tr),
+			TaskRun: normalizeTaskRun(tr.TaskRun),
+		}
+	}
+
+	return withID(tr1), withID(tr2), withID(tr3)
+}
+
+// queryTaskRuns calls TaskRuns and returns the normalized results in the
+// order in that they were passed to the callback function.
+func queryTaskRuns(s storage.Storer, filters []*storage.Filter, sorters []*storage.Sorter, limit uint) ([]*storage.TaskRunWithID, error) {
+	var result []*storage.TaskRunWithID
+
+	err := s.TaskRuns(ctx, filters, sorters, limit, func(tr *storage.TaskRunWithID) error {
+		result = append(result, normalizeTaskRunWithID(tr))
+		return nil
+	})
+
+	return result, err
+}
+
+func testTaskRunsFilters(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+	r1, r2, r3 := taskRunsFixture(t, s)
+
+	testcases := []struct {
+		name    string
+		filters []*storage.Filter
+
+		expectedTaskRuns []*storage.TaskRunWithID
+		expectedError    error
+		// expectFailure is true if an error is expected that is not
+		// expectedError
+		expectFailure bool
+	}{
+		{
+			name:             "none",
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r2, r3},
+		},
+		{
+			name: "eqAppName",
+			filters: []*storage.Filter{
+				{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "app1"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r2},
+		},
+		{
+			name: "eqAppNameAndTaskName",
+			filters: []*storage.Filter{
+				{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "app1"},
+				{Field: storage.FieldTaskName, Operator: storage.OpEQ, Value: "build"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1},
+		},
+		{
+			name: "inAppNames",
+			filters: []*storage.Filter{
+				{Field: storage.FieldApplicationName, Operator: storage.OpIN, Value: []string{"app2", "unknown"}},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3},
+		},
+		{
+			name: "gtStartTime",
+			filters: []*storage.Filter{
+				{Field: storage.FieldStartTime, Operator: storage.OpGT, Value: baseTime.Add(30 * time.Minute)},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2, r3},
+		},
+		{
+			name: "ltStartTime",
+			filters: []*storage.Filter{
+				{Field: storage.FieldStartTime, Operator: storage.OpLT, Value: baseTime.Add(30 * time.Minute)},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1},
+		},
+		{
+			name: "gtAndLtStartTime",
+			filters: []*storage.Filter{
+				{Field: storage.FieldStartTime, Operator: storage.OpGT, Value: baseTime},
+				{Field: storage.FieldStartTime, Operator: storage.OpLT, Value: baseTime.Add(2 * time.Hour)},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "eqID",
+			filters: []*storage.Filter{
+				{Field: storage.FieldID, Operator: storage.OpEQ, Value: r2.ID},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "inIDs",
+			filters: []*storage.Filter{
+				{Field: storage.FieldID, Operator: storage.OpIN, Value: []int{r1.ID, r3.ID}},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r3},
+		},
+		{
+			name: "eqInputString",
+			filters: []*storage.Filter{
+				{Field: storage.FieldInputString, Operator: storage.OpEQ, Value: "hello"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1},
+		},
+		{
+			name: "eqInputFilePath",
+			filters: []*storage.Filter{
+				{Field: storage.FieldInputFilePath, Operator: storage.OpEQ, Value: "main.go"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r3},
+		},
+		{
+			name: "eqInputFilePathAndAppName",
+			filters: []*storage.Filter{
+				{Field: storage.FieldInputFilePath, Operator: storage.OpEQ, Value: "main.go"},
+				{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "app2"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3},
+		},
+		{
+			name: "neAppName",
+			filters: []*storage.Filter{
+				{Field: storage.FieldApplicationName, Operator: storage.OpNE, Value: "app1"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3},
+		},
+		{
+			name: "notInIDs",
+			filters: []*storage.Filter{
+				{Field: storage.FieldID, Operator: storage.OpNotIN, Value: []int{r1.ID, r3.ID}},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "prefixTaskName",
+			filters: []*storage.Filter{
+				{Field: storage.FieldTaskName, Operator: storage.OpPrefix, Value: "bu"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r3},
+		},
+		{
+			name: "prefixIsCaseSensitive",
+			filters: []*storage.Filter{
+				{Field: storage.FieldTaskName, Operator: storage.OpPrefix, Value: "BU"},
+			},
+			expectedError: storage.ErrNotExist,
+		},
+		{
+			name: "prefixWithWildcardChars",
+			filters: []*storage.Filter{
+				{Field: storage.FieldApplicationName, Operator: storage.OpPrefix, Value: "app_"},
+			},
+			expectedError: storage.ErrNotExist,
+		},
+		{
+			name: "eqResult",
+			filters: []*storage.Filter{
+				{Field: storage.FieldResult, Operator: storage.OpEQ, Value: storage.ResultFailure},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "neResult",
+			filters: []*storage.Filter{
+				{Field: storage.FieldResult, Operator: storage.OpNE, Value: string(storage.ResultFailure)},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r3},
+		},
+		{
+			name: "prefixVCSRevision",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSRevision, Operator: storage.OpPrefix, Value: "f1e2d3"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3},
+		},
+		{
+			name: "eqVCSIsDirty",
+			filters: []*storage.Filter{
+				{Field: storage.FieldVCSIsDirty, Operator: storage.OpEQ, Value: true},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3},
+		},
+		{
+			name: "notInTotalInputDigests",
+			filters: []*storage.Filter{
+				{Field: storage.FieldTotalInputDigest, Operator: storage.OpNotIN, Value: []string{"1", "3"}},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "eqOutputDigest",
+			filters: []*storage.Filter{
+				{Field: storage.FieldOutputDigest, Operator: storage.OpEQ, Value: "sha384:3"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3},
+		},
+		{
+			name: "neOutputDigest",
+			filters: []*storage.Filter{
+				{Field: storage.FieldOutputDigest, Operator: storage.OpNE, Value: "sha384:3"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r2},
+		},
+		{
+			name: "inOutputDigests",
+			filters: []*storage.Filter{
+				{Field: storage.FieldOutputDigest, Operator: storage.OpIN, Value: []string{"sha384:1", "sha384:2"}},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r2},
+		},
+		{
+			name: "prefixOutputURI",
+			filters: []*storage.Filter{
+				{Field: storage.FieldOutputURI, Operator: storage.OpPrefix, Value: "s3://bucket/app1-"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r2},
+		},
+		{
+			name: "notInOutputURIs",
+			filters: []*storage.Filter{
+				{Field: storage.FieldOutputURI, Operator: storage.OpNotIN, Value: []string{"s3://bucket/app1-build-1"}},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2, r3},
+		},
+		{
+			name: "eqLabel",
+			filters: []*storage.Filter{
+				{Field: storage.FieldLabel, Operator: storage.OpEQ, Value: "branch=main"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1},
+		},
+		{
+			name: "neLabel",
+			filters: []*storage.Filter{
+				{Field: storage.FieldLabel, Operator: storage.OpNE, Value: "ci=true"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3},
+		},
+		{
+			name: "inLabels",
+			filters: []*storage.Filter{
+				{Field: storage.FieldLabel, Operator: storage.OpIN, Value: []string{"branch=feature/x", "branch=other"}},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "prefixLabel",
+			filters: []*storage.Filter{
+				{Field: storage.FieldLabel, Operator: storage.OpPrefix, Value: "branch=feat"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "prefixLabelKey",
+			filters: []*storage.Filter{
+				{Field: storage.FieldLabel, Operator: storage.OpPrefix, Value: "bra"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r2},
+		},
+		{
+			name: "multipleLabels",
+			filters: []*storage.Filter{
+				{Field: storage.FieldLabel, Operator: storage.OpEQ, Value: "ci=true"},
+				{Field: storage.FieldLabel, Operator: storage.OpNE, Value: "branch=main"},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2},
+		},
+		{
+			name: "neInputStringFails",
+			filters: []*storage.Filter{
+				{Field: storage.FieldInputString, Operator: storage.OpNE, Value: "hello"},
+			},
+			expectFailure: true,
+		},
+		{
+			name: "noMatch",
+			filters: []*storage.Filter{
+				{Field: storage.FieldID, Operator: storage.OpEQ, Value: -500},
+			},
+			expectedError: storage.ErrNotExist,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := queryTaskRuns(s, tc.filters, nil, storage.NoLimit)
+			if tc.expectFailure {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, storage.ErrNotExist)
+				return
+			}
+
+			if tc.expectedError != nil {
+				require.ErrorIs(t, err, tc.expectedError)
+				assert.Empty(t, result)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expectedTaskRuns, result)
+		})
+	}
+}
+
+func testTaskRunsSorters(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+	r1, r2, r3 := taskRunsFixture(t, s)
+
+	testcases := []struct {
+		name    string
+		filters []*storage.Filter
+		sorters []*storage.Sorter
+
+		expectedTaskRuns []*storage.TaskRunWithID
+	}{
+		{
+			name:             "durationAsc",
+			sorters:          []*storage.Sorter{{Field: storage.FieldDuration, Order: storage.OrderAsc}},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r3, r2},
+		},
+		{
+			name:             "durationDesc",
+			sorters:          []*storage.Sorter{{Field: storage.FieldDuration, Order: storage.OrderDesc}},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2, r3, r1},
+		},
+		{
+			name:             "startTimeDesc",
+			sorters:          []*storage.Sorter{{Field: storage.FieldStartTime, Order: storage.OrderDesc}},
+			expectedTaskRuns: []*storage.TaskRunWithID{r3, r2, r1},
+		},
+		{
+			name:             "idAsc",
+			sorters:          []*storage.Sorter{{Field: storage.FieldID, Order: storage.OrderAsc}},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r2, r3},
+		},
+		{
+			name: "appNameAscStartTimeDesc",
+			sorters: []*storage.Sorter{
+				{Field: storage.FieldApplicationName, Order: storage.OrderAsc},
+				{Field: storage.FieldStartTime, Order: storage.OrderDesc},
+			},
+			expectedTaskRuns: []*storage.TaskRunWithID{r2, r1, r3},
+		},
+		{
+			name: "filteredTaskNameStartTimeAsc",
+			filters: []*storage.Filter{
+				{Field: storage.FieldTaskName, Operator: storage.OpEQ, Value: "build"},
+			},
+			sorters:          []*storage.Sorter{{Field: storage.FieldStartTime, Order: storage.OrderAsc}},
+			expectedTaskRuns: []*storage.TaskRunWithID{r1, r3},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := queryTaskRuns(s, tc.filters, tc.sorters, storage.NoLimit)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedTaskRuns, result)
+		})
+	}
+}
+
+func testTaskRunsLimit(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+	r1, r2, _ := taskRunsFixture(t, s)
+
+	t.Run("withoutFiltersAndSorters", func(t *testing.T) {
+		result, err := queryTaskRuns(s, nil, nil, 1)
+		require.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("withSorter", func(t *testing.T) {
+		result, err := queryTaskRuns(
+			s,
+			nil,
+			[]*storage.Sorter{{Field: storage.FieldStartTime, Order: storage.OrderAsc}},
+			2,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []*storage.TaskRunWithID{r1, r2}, result)
+	})
+
+	t.Run("withFilterAndSorter", func(t *testing.T) {
+		result, err := queryTaskRuns(
+			s,
+			[]*storage.Filter{{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "app1"}},
+			[]*storage.Sorter{{Field: storage.FieldStartTime, Order: storage.OrderDesc}},
+			1,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []*storage.TaskRunWithID{r2}, result)
+	})
+
+	t.Run("greaterThanMatches", func(t *testing.T) {
+		result, err := queryTaskRuns(
+			s,
+			[]*storage.Filter{{Field: storage.FieldApplicationName, Operator: storage.OpEQ, Value: "app1"}},
+			nil,
+			10,
+		)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []*storage.TaskRunWithID{r1, r2}, result)
+	})
+}
+
+func testTaskRunsInputStringAndFileFilterFails(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+	taskRunsFixture(t, s)
+
+	_, err := queryTaskRuns(
+		s,
+		[]*storage.Filter{
+			{Field: storage.FieldInputString, Operator: storage.OpEQ, Value: "hello"},
+			{Field: storage.FieldInputFilePath, Operator: storage.OpEQ, Value: "main.go"},
+		},
+		nil,
+		storage.NoLimit,
+	)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, storage.ErrNotExist)
+}
+
+func testTaskRunsCallbackError(t *testing.T, newStorer func() storage.Storer) {
+	s := newInitializedStorer(t, newStorer)
+	taskRunsFixture(t, s)
+
+	cbErr := errors.New("callback error")
+	var calls int
+
+	err := s.TaskRuns(ctx, nil, nil, storage.NoLimit, func(*storage.TaskRunWithID) error {
+		calls++
+		return cbErr
+	})
+	require.ErrorIs(t, err, cbErr)
+	assert.Equal(t, 1, calls, "iteration did not stop after the callback returned an error")
+}